@@ -0,0 +1,90 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ==========================
+// 提现目标地址校验
+// ==========================
+
+const (
+	ChainEthereum = "ethereum"
+	ChainBitcoin  = "bitcoin"
+	ChainTron     = "tron"
+	ChainSolana   = "solana"
+)
+
+var (
+	ErrInvalidFormat   = errors.New("地址格式错误")
+	ErrChecksum        = errors.New("地址校验和错误")
+	ErrWrongNetwork    = errors.New("地址不属于当前网络")
+	ErrZeroAddress     = errors.New("不允许提现到零地址")
+	ErrOwnAddress      = errors.New("不允许提现到平台自有地址")
+	ErrContractAddress = errors.New("该币种不允许提现到合约地址")
+	ErrUnknownCurrency = errors.New("不支持的币种")
+)
+
+// Validate 按链校验地址格式（含校验和与网络），不做业务规则检查
+func Validate(chain, addr string) error {
+	switch kindOf(chain) {
+	case ChainEthereum:
+		return ValidateEVM(addr)
+	case ChainBitcoin:
+		return ValidateBitcoin(addr, BitcoinParams)
+	case ChainTron:
+		return ValidateTron(addr)
+	case ChainSolana:
+		return ValidateSolana(addr)
+	default:
+		return fmt.Errorf("unsupported chain %q", chain)
+	}
+}
+
+// ==========================
+// 币种 -> 链
+// ==========================
+
+var (
+	currencyMu     sync.RWMutex
+	currencyChains = map[string]string{
+		"ETH":        ChainEthereum,
+		"USDT-ERC20": ChainEthereum,
+		"USDC-ERC20": ChainEthereum,
+		"BTC":        ChainBitcoin,
+		"TRX":        ChainTron,
+		"USDT-TRC20": ChainTron,
+		"SOL":        ChainSolana,
+		"USDC-SPL":   ChainSolana,
+	}
+)
+
+// RegisterCurrency 登记币种所在的链
+func RegisterCurrency(currency, chain string) {
+	currencyMu.Lock()
+	defer currencyMu.Unlock()
+	currencyChains[strings.ToUpper(currency)] = chain
+}
+
+// ChainOf 返回币种所在的链
+func ChainOf(currency string) (string, error) {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+	chain, ok := currencyChains[strings.ToUpper(currency)]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+	return chain, nil
+}
+
+// ValidateForCurrency 按币种所在链校验地址格式
+func ValidateForCurrency(currency, addr string) error {
+	chain, err := ChainOf(currency)
+	if err != nil {
+		return err
+	}
+	return Validate(chain, addr)
+}
@@ -0,0 +1,82 @@
+package address
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+const (
+	TEST_EVM_ADDRESS    = "0x52908400098527886E0F7030069857D2E4169EE7" // EIP-55 示例
+	TEST_TRON_ADDRESS   = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	TEST_SOLANA_ADDRESS = "So11111111111111111111111111111111111111112"
+)
+
+// 各链格式、校验和、网络、零地址规则；err 为 nil 表示通过
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		chain, addr string
+		err         error
+	}{
+		{ChainEthereum, TEST_EVM_ADDRESS, nil},
+		{ChainEthereum, "0x52908400098527886e0f7030069857d2e4169ee7", ErrChecksum},
+		{ChainEthereum, "0x52908400098527886E0F7030069857D2E4169Ee7", ErrChecksum},
+		{ChainEthereum, "52908400098527886E0F7030069857D2E4169EE7", ErrInvalidFormat},
+		{ChainEthereum, "0x52908400098527886E0F7030069857D2E4169E", ErrInvalidFormat},
+		{ChainEthereum, "0x0000000000000000000000000000000000000000", ErrZeroAddress},
+
+		{ChainBitcoin, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", nil},
+		{ChainBitcoin, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", nil},
+		{ChainBitcoin, "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", nil},
+		{ChainBitcoin, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", ErrWrongNetwork},
+		{ChainBitcoin, "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", ErrInvalidFormat}, // 测试网 base58 地址在主网无法解析
+		{ChainBitcoin, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdr", ErrInvalidFormat},
+
+		{ChainTron, TEST_TRON_ADDRESS, nil},
+		{ChainTron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", ErrChecksum},
+		{ChainTron, "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb", ErrZeroAddress},
+		{ChainTron, TEST_EVM_ADDRESS, ErrInvalidFormat},
+
+		{ChainSolana, TEST_SOLANA_ADDRESS, nil},
+		{ChainSolana, "11111111111111111111111111111111", ErrZeroAddress},
+		{ChainSolana, "So1111111111111111111111111111111111111111O", ErrInvalidFormat},
+		{ChainSolana, TEST_EVM_ADDRESS, ErrInvalidFormat},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.chain, tt.addr)
+		if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("Validate(%s, %s) = %v, want %v", tt.chain, tt.addr, err, tt.err)
+		}
+	}
+
+	if err := Validate("dogecoin", TEST_EVM_ADDRESS); err == nil {
+		t.Error("unsupported chain accepted")
+	}
+}
+
+// 比特币地址按 BitcoinParams 指定的网络校验
+func TestValidateBitcoinNetwork(t *testing.T) {
+	if err := ValidateBitcoin("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", &chaincfg.TestNet3Params); err != nil {
+		t.Fatalf("testnet address on testnet: %v", err)
+	}
+	if err := ValidateBitcoin("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", &chaincfg.TestNet3Params); !errors.Is(err, ErrWrongNetwork) {
+		t.Fatalf("mainnet address on testnet err = %v, want %v", err, ErrWrongNetwork)
+	}
+}
+
+// EVM 侧链按登记的地址规则校验，币种按所在链校验
+func TestValidateForCurrency(t *testing.T) {
+	RegisterChain("addresstest", ChainEthereum)
+	RegisterCurrency("atest", "addresstest")
+
+	if err := ValidateForCurrency("ATEST", TEST_EVM_ADDRESS); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateForCurrency("SOL", TEST_EVM_ADDRESS); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("EVM address for SOL err = %v, want %v", err, ErrInvalidFormat)
+	}
+	if err := ValidateForCurrency("NOPE", TEST_EVM_ADDRESS); !errors.Is(err, ErrUnknownCurrency) {
+		t.Fatalf("unknown currency err = %v, want %v", err, ErrUnknownCurrency)
+	}
+}
@@ -0,0 +1,27 @@
+package address
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// BitcoinParams 当前部署的比特币网络，测试环境改为 TestNet3Params
+var BitcoinParams = &chaincfg.MainNetParams
+
+// ValidateBitcoin 支持 base58 (P2PKH / P2SH) 与 bech32 / bech32m (SegWit / Taproot)，
+// 并校验地址属于 params 指定的网络；裸公钥地址不允许
+func ValidateBitcoin(addr string, params *chaincfg.Params) error {
+	decoded, err := btcutil.DecodeAddress(addr, params)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidFormat, addr, err)
+	}
+	if _, ok := decoded.(*btcutil.AddressPubKey); ok {
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, addr)
+	}
+	if !decoded.IsForNet(params) {
+		return fmt.Errorf("%w: %s 不是 %s 地址", ErrWrongNetwork, addr, params.Name)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package address
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// OwnAddressLookup 判断地址是否为平台自有地址（热/冷钱包、用户充值地址），addr 为规范形式
+type OwnAddressLookup interface {
+	IsOwnAddress(ctx context.Context, chain, addr string) (bool, error)
+}
+
+// ContractDetector 判断地址是否部署了合约
+type ContractDetector interface {
+	IsContract(ctx context.Context, addr string) (bool, error)
+}
+
+// Checker 提现目标地址的完整校验：格式 + 零地址 + 自有地址 + 合约地址
+type Checker struct {
+	own OwnAddressLookup
+
+	mu            sync.RWMutex
+	contracts     map[string]ContractDetector // chain -> detector
+	allowContract map[string]bool             // currency -> 是否允许提现到合约
+}
+
+func NewChecker(own OwnAddressLookup) *Checker {
+	return &Checker{
+		own:           own,
+		contracts:     map[string]ContractDetector{},
+		allowContract: map[string]bool{},
+	}
+}
+
+// SetContractDetector 为链配置合约检测，未配置的链跳过合约检查
+func (c *Checker) SetContractDetector(chain string, d ContractDetector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contracts[chain] = d
+}
+
+// AllowContract 允许该币种提现到合约地址（默认不允许）
+func (c *Checker) AllowContract(currency string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.allowContract[strings.ToUpper(currency)] = true
+}
+
+// Check 按币种校验提现地址
+func (c *Checker) Check(ctx context.Context, currency, addr string) error {
+	chain, err := ChainOf(currency)
+	if err != nil {
+		return err
+	}
+	c.mu.RLock()
+	allow := c.allowContract[strings.ToUpper(currency)]
+	c.mu.RUnlock()
+	return c.CheckChain(ctx, chain, addr, allow)
+}
+
+// CheckStored 校验已按 Check 通过并以规范形式保存的地址，只重新检查可能随时间变化的规则（自有地址、合约地址）；
+// 规范形式（如全小写 EVM 地址）不带校验和，不能再走 Check
+func (c *Checker) CheckStored(ctx context.Context, currency, addr string) error {
+	chain, err := ChainOf(currency)
+	if err != nil {
+		return err
+	}
+	canonical, err := Normalize(chain, addr)
+	if err != nil {
+		return err
+	}
+	if canonical != addr {
+		return fmt.Errorf("%w: %s 不是规范形式", ErrInvalidFormat, addr)
+	}
+	c.mu.RLock()
+	allow := c.allowContract[strings.ToUpper(currency)]
+	c.mu.RUnlock()
+	return c.checkTarget(ctx, chain, addr, allow)
+}
+
+// CheckChain 按链校验提现地址
+func (c *Checker) CheckChain(ctx context.Context, chain, addr string, allowContract bool) error {
+	if err := Validate(chain, addr); err != nil {
+		return err
+	}
+	addr, err := Normalize(chain, addr)
+	if err != nil {
+		return err
+	}
+	return c.checkTarget(ctx, chain, addr, allowContract)
+}
+
+// checkTarget 规范地址的自有地址、合约地址检查
+func (c *Checker) checkTarget(ctx context.Context, chain, addr string, allowContract bool) error {
+	if c.own != nil {
+		own, err := c.own.IsOwnAddress(ctx, chain, addr)
+		if err != nil {
+			return fmt.Errorf("check own address: %w", err)
+		}
+		if own {
+			return fmt.Errorf("%w: %s", ErrOwnAddress, addr)
+		}
+	}
+
+	if !allowContract {
+		c.mu.RLock()
+		detector := c.contracts[chain]
+		c.mu.RUnlock()
+		if detector != nil {
+			isContract, err := detector.IsContract(ctx, addr)
+			if err != nil {
+				return fmt.Errorf("check contract: %w", err)
+			}
+			if isContract {
+				return fmt.Errorf("%w: %s", ErrContractAddress, addr)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package address
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const TEST_CONTRACT_ADDRESS = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+// ownSet 规范地址集合；contractSet 同时实现合约检测
+type ownSet map[string]bool
+
+func (s ownSet) IsOwnAddress(ctx context.Context, chain, addr string) (bool, error) {
+	return s[addr], nil
+}
+
+func (s ownSet) IsContract(ctx context.Context, addr string) (bool, error) {
+	return s[addr], nil
+}
+
+func newTestChecker() *Checker {
+	c := NewChecker(ownSet{strings.ToLower(TEST_EVM_ADDRESS): true, TEST_SOLANA_ADDRESS: true})
+	c.SetContractDetector(ChainEthereum, ownSet{strings.ToLower(TEST_CONTRACT_ADDRESS): true})
+	return c
+}
+
+// Check 校验用户输入：格式规则之外拒绝自有地址和合约地址，允许提现到合约的币种跳过合约检查
+func TestCheckerCheck(t *testing.T) {
+	c := newTestChecker()
+	c.AllowContract("usdt-erc20")
+	const other = "0x000000000000000000000000000000000000dEaD"
+	tests := []struct {
+		currency, addr string
+		err            error
+	}{
+		{"ETH", other, nil},
+		{"ETH", strings.ToLower(other), ErrChecksum},
+		{"ETH", "0x0000000000000000000000000000000000000000", ErrZeroAddress},
+		{"ETH", TEST_EVM_ADDRESS, ErrOwnAddress},
+		{"ETH", TEST_CONTRACT_ADDRESS, ErrContractAddress},
+		{"USDT-ERC20", TEST_CONTRACT_ADDRESS, nil},
+		{"SOL", TEST_SOLANA_ADDRESS, ErrOwnAddress},
+		{"SOL", "11111111111111111111111111111111", ErrZeroAddress},
+		{"TRX", TEST_TRON_ADDRESS, nil}, // 未配置合约检测的链跳过合约检查
+		{"NOPE", other, ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		err := c.Check(context.Background(), tt.currency, tt.addr)
+		if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("Check(%s, %s) = %v, want %v", tt.currency, tt.addr, err, tt.err)
+		}
+	}
+}
+
+// CheckStored 只接受规范形式，并重新检查自有地址和合约地址；
+// 全小写的 EVM 规范地址不能再走 Check（没有校验和）
+func TestCheckerCheckStored(t *testing.T) {
+	c := newTestChecker()
+	ctx := context.Background()
+	const other = "0x000000000000000000000000000000000000dEaD"
+	canonical, err := NormalizeForCurrency("ETH", other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Check(ctx, "ETH", other); err != nil {
+		t.Fatalf("Check(raw) = %v", err)
+	}
+	if err := c.CheckStored(ctx, "ETH", canonical); err != nil {
+		t.Fatalf("CheckStored(canonical) = %v", err)
+	}
+	if err := c.Check(ctx, "ETH", canonical); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("Check(canonical) = %v, want %v", err, ErrChecksum)
+	}
+
+	tests := []struct {
+		currency, addr string
+		err            error
+	}{
+		{"ETH", other, ErrInvalidFormat}, // 校验和格式不是规范形式
+		{"ETH", strings.ToLower(TEST_EVM_ADDRESS), ErrOwnAddress},
+		{"ETH", strings.ToLower(TEST_CONTRACT_ADDRESS), ErrContractAddress},
+		{"SOL", TEST_SOLANA_ADDRESS, ErrOwnAddress},
+		{"TRX", TEST_TRON_ADDRESS, nil},
+	}
+	for _, tt := range tests {
+		err := c.CheckStored(ctx, tt.currency, tt.addr)
+		if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("CheckStored(%s, %s) = %v, want %v", tt.currency, tt.addr, err, tt.err)
+		}
+	}
+}
+
+type failingLookup struct{}
+
+func (failingLookup) IsOwnAddress(ctx context.Context, chain, addr string) (bool, error) {
+	return false, errors.New("db down")
+}
+
+// 自有地址查询失败时拒绝，不能放行
+func TestCheckerLookupError(t *testing.T) {
+	c := NewChecker(failingLookup{})
+	if err := c.Check(context.Background(), "ETH", TEST_EVM_ADDRESS); err == nil {
+		t.Fatal("lookup error ignored")
+	}
+}
@@ -0,0 +1,24 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ValidateEVM 要求 0x 前缀、20 字节，并且必须是 EIP-55 校验和格式；
+// 全小写 / 全大写地址无法校验输入错误，一律拒绝
+func ValidateEVM(addr string) error {
+	if !strings.HasPrefix(addr, "0x") || !common.IsHexAddress(addr) {
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, addr)
+	}
+	a := common.HexToAddress(addr)
+	if a == (common.Address{}) {
+		return ErrZeroAddress
+	}
+	if addr != a.Hex() {
+		return fmt.Errorf("%w: %s, 应为 %s", ErrChecksum, addr, a.Hex())
+	}
+	return nil
+}
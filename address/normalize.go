@@ -0,0 +1,115 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ==========================
+// 地址规范化
+// ==========================
+// 同一地址可能有多种写法（EVM 大小写、bech32 大小写），写库和查询前统一转换为规范形式，
+// 数据库中按等值比较：
+//
+//	EVM            全小写 0x 地址
+//	Bitcoin        解码后重新编码（bech32 为小写）
+//	Tron / Solana  base58 区分大小写，解码校验后原样返回
+//
+// 规范化只要求地址可以解析，校验和、零地址等规则由 Validate / Checker 负责。
+
+var (
+	chainKindMu sync.RWMutex
+	chainKinds  = map[string]string{}
+)
+
+// RegisterChain 登记链使用哪条链的地址规则，如 EVM 侧链按 ChainEthereum 处理
+func RegisterChain(name, kind string) {
+	chainKindMu.Lock()
+	defer chainKindMu.Unlock()
+	chainKinds[name] = kind
+}
+
+// kindOf 链的地址规则，未登记的链按名称本身
+func kindOf(chain string) string {
+	chainKindMu.RLock()
+	defer chainKindMu.RUnlock()
+	if kind, ok := chainKinds[chain]; ok {
+		return kind
+	}
+	return chain
+}
+
+// Normalize 按链返回地址的规范形式
+func Normalize(chain, addr string) (string, error) {
+	switch kindOf(chain) {
+	case ChainEthereum:
+		return NormalizeEVM(addr)
+	case ChainBitcoin:
+		return NormalizeBitcoin(addr, BitcoinParams)
+	case ChainTron:
+		return NormalizeTron(addr)
+	case ChainSolana:
+		return NormalizeSolana(addr)
+	default:
+		return "", fmt.Errorf("unsupported chain %q", chain)
+	}
+}
+
+// NormalizeForCurrency 按币种所在链返回地址的规范形式
+func NormalizeForCurrency(currency, addr string) (string, error) {
+	chain, err := ChainOf(currency)
+	if err != nil {
+		return "", err
+	}
+	return Normalize(chain, addr)
+}
+
+// NormalizeAny 不知道地址所属链时（如风控名单文件）按格式识别后规范化：
+// EVM、Bitcoin 地址转换为规范形式，其余（base58 等区分大小写的地址）只去除首尾空白
+func NormalizeAny(addr string) string {
+	if n, err := NormalizeEVM(addr); err == nil {
+		return n
+	}
+	if n, err := NormalizeBitcoin(addr, BitcoinParams); err == nil {
+		return n
+	}
+	return strings.TrimSpace(addr)
+}
+
+func NormalizeEVM(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if !strings.HasPrefix(strings.ToLower(addr), "0x") || !common.IsHexAddress(addr) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidFormat, addr)
+	}
+	return strings.ToLower(common.HexToAddress(addr).Hex()), nil
+}
+
+func NormalizeBitcoin(addr string, params *chaincfg.Params) (string, error) {
+	decoded, err := btcutil.DecodeAddress(strings.TrimSpace(addr), params)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidFormat, addr, err)
+	}
+	return decoded.EncodeAddress(), nil
+}
+
+func NormalizeTron(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if _, version, err := base58.CheckDecode(addr); err != nil || version != tronAddressVersion {
+		return "", fmt.Errorf("%w: %s", ErrInvalidFormat, addr)
+	}
+	return addr, nil
+}
+
+func NormalizeSolana(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if len(base58.Decode(addr)) != 32 {
+		return "", fmt.Errorf("%w: %s", ErrInvalidFormat, addr)
+	}
+	return addr, nil
+}
@@ -0,0 +1,101 @@
+package address
+
+import (
+	"strings"
+	"testing"
+)
+
+// 各链的不同写法规范化为同一形式，规范形式再次规范化不变
+func TestNormalizeRoundTrip(t *testing.T) {
+	lowerEVM := strings.ToLower(TEST_EVM_ADDRESS)
+	tests := []struct {
+		chain     string
+		inputs    []string
+		canonical string
+	}{
+		{ChainEthereum, []string{TEST_EVM_ADDRESS, lowerEVM, "0X" + strings.ToUpper(lowerEVM[2:]), " " + TEST_EVM_ADDRESS + "\n"}, lowerEVM},
+		{ChainBitcoin, []string{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ"}, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+		{ChainBitcoin, []string{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", " 1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"}, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},
+		{ChainTron, []string{TEST_TRON_ADDRESS, "\t" + TEST_TRON_ADDRESS}, TEST_TRON_ADDRESS},
+		{ChainSolana, []string{TEST_SOLANA_ADDRESS, TEST_SOLANA_ADDRESS + " "}, TEST_SOLANA_ADDRESS},
+	}
+	for _, tt := range tests {
+		for _, in := range tt.inputs {
+			got, err := Normalize(tt.chain, in)
+			if err != nil {
+				t.Fatalf("Normalize(%s, %q): %v", tt.chain, in, err)
+			}
+			if got != tt.canonical {
+				t.Fatalf("Normalize(%s, %q) = %s, want %s", tt.chain, in, got, tt.canonical)
+			}
+			again, err := Normalize(tt.chain, got)
+			if err != nil || again != got {
+				t.Fatalf("Normalize(%s, %s) = (%s, %v), want unchanged", tt.chain, got, again, err)
+			}
+		}
+	}
+}
+
+// base58 地址区分大小写，改变大小写后不再是同一地址；无法解析的地址报错
+func TestNormalizeRejects(t *testing.T) {
+	tests := []struct{ chain, addr string }{
+		{ChainEthereum, TEST_EVM_ADDRESS[2:]},
+		{ChainEthereum, TEST_EVM_ADDRESS + "00"},
+		{ChainBitcoin, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdr"},
+		{ChainTron, strings.ToLower(TEST_TRON_ADDRESS)},
+		{ChainTron, TEST_EVM_ADDRESS},
+		{ChainSolana, TEST_EVM_ADDRESS},
+		{"dogecoin", TEST_EVM_ADDRESS},
+	}
+	for _, tt := range tests {
+		if got, err := Normalize(tt.chain, tt.addr); err == nil {
+			t.Errorf("Normalize(%s, %s) = %s, want error", tt.chain, tt.addr, got)
+		}
+	}
+	if got, err := Normalize(ChainSolana, strings.ToLower(TEST_SOLANA_ADDRESS)); err == nil && got == TEST_SOLANA_ADDRESS {
+		t.Errorf("Solana address normalized case-insensitively: %s", got)
+	}
+}
+
+// 按币种所在链规范化，EVM 侧链按登记的地址规则
+func TestNormalizeForCurrency(t *testing.T) {
+	RegisterChain("normalizetest", ChainEthereum)
+	RegisterCurrency("NTEST", "normalizetest")
+	lowerEVM := strings.ToLower(TEST_EVM_ADDRESS)
+	tests := []struct{ currency, addr, want string }{
+		{"eth", TEST_EVM_ADDRESS, lowerEVM},
+		{"USDT-ERC20", lowerEVM, lowerEVM},
+		{"NTEST", TEST_EVM_ADDRESS, lowerEVM},
+		{"USDT-TRC20", TEST_TRON_ADDRESS, TEST_TRON_ADDRESS},
+		{"USDC-SPL", TEST_SOLANA_ADDRESS, TEST_SOLANA_ADDRESS},
+		{"BTC", "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeForCurrency(tt.currency, tt.addr)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeForCurrency(%s, %s) = (%s, %v), want %s", tt.currency, tt.addr, got, err, tt.want)
+		}
+	}
+	if _, err := NormalizeForCurrency("SOL", TEST_EVM_ADDRESS); err == nil {
+		t.Error("EVM address normalized for SOL")
+	}
+	if _, err := NormalizeForCurrency("NOPE", TEST_EVM_ADDRESS); err == nil {
+		t.Error("unknown currency accepted")
+	}
+}
+
+// 不知道所属链时：EVM、Bitcoin 转为规范形式，其余只去除首尾空白
+func TestNormalizeAny(t *testing.T) {
+	tests := []struct{ addr, want string }{
+		{TEST_EVM_ADDRESS, strings.ToLower(TEST_EVM_ADDRESS)},
+		{" BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+		{TEST_TRON_ADDRESS + " ", TEST_TRON_ADDRESS},
+		{" " + TEST_SOLANA_ADDRESS, TEST_SOLANA_ADDRESS},
+		{" not-an-address ", "not-an-address"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeAny(tt.addr); got != tt.want {
+			t.Errorf("NormalizeAny(%q) = %s, want %s", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package address
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// system program id, the all-zero public key
+const solanaZeroAddress = "11111111111111111111111111111111"
+
+// ValidateSolana base58 编码的 32 字节公钥
+func ValidateSolana(addr string) error {
+	if len(addr) < 32 || len(addr) > 44 {
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, addr)
+	}
+	if decoded := base58.Decode(addr); len(decoded) != 32 {
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, addr)
+	}
+	if addr == solanaZeroAddress {
+		return ErrZeroAddress
+	}
+	return nil
+}
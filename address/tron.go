@@ -0,0 +1,29 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+const (
+	tronAddressVersion = 0x41
+	// base58check of 0x41 + 20 zero bytes
+	tronZeroAddress = "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb"
+)
+
+// ValidateTron base58check，版本字节 0x41，负载 20 字节
+func ValidateTron(addr string) error {
+	payload, version, err := base58.CheckDecode(addr)
+	if errors.Is(err, base58.ErrChecksum) {
+		return fmt.Errorf("%w: %s", ErrChecksum, addr)
+	}
+	if err != nil || version != tronAddressVersion || len(payload) != 20 {
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, addr)
+	}
+	if addr == tronZeroAddress {
+		return ErrZeroAddress
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/crypto_custody/config"
+	"github.com/crypto_custody/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// ==========================
+// 服务进程公共逻辑
+// ==========================
+// 数据库连接、表结构版本检查、退出信号与 HTTP 优雅关闭，cmd 下各服务共用。
+
+// SECRETS_DIR_ENV 设置时，配置中的 secret: 引用从该目录按文件名读取
+const SECRETS_DIR_ENV = "SECRETS_DIR"
+
+// LoadConfig 读取配置文件（path 为空时取 CONFIG_FILE）与环境变量，并校验当前服务必需的配置
+func LoadConfig(ctx context.Context, path string, needs ...config.Need) (*config.Config, error) {
+	var store config.SecretStore
+	if dir := os.Getenv(SECRETS_DIR_ENV); dir != "" {
+		store = config.DirStore(dir)
+	}
+	return config.Load(ctx, path, store, needs...)
+}
+
+// OpenDB 连接数据库
+func OpenDB(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+// CloseDB 关闭底层连接池
+func CloseDB(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Printf("close db err: %v", err)
+	}
+}
+
+// CheckSchema 表结构由 migrate 命令维护，服务启动时只确认数据库已迁移到最新版本
+func CheckSchema(ctx context.Context, db *gorm.DB) error {
+	m, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	return m.RequireLatest(ctx)
+}
+
+// SignalContext 收到 SIGINT / SIGTERM 时取消
+func SignalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// Serve 启动 HTTP 服务，ctx 取消后停止接收新连接，并在 timeout 内等待在途请求完成
+func Serve(ctx context.Context, addr string, handler http.Handler, timeout time.Duration) error {
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	log.Printf("shutting down %s", addr)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Group 后台任务组，Wait 等待全部任务在 ctx 取消后退出
+type Group struct {
+	wg sync.WaitGroup
+}
+
+// Go 启动一个随 ctx 退出的后台任务
+func (g *Group) Go(fn func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn()
+	}()
+}
+
+func (g *Group) Wait() {
+	g.wg.Wait()
+}
@@ -0,0 +1,401 @@
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/approval"
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/blobstore"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/chain/evm"
+	"github.com/crypto_custody/chain/solana"
+	"github.com/crypto_custody/config"
+	"github.com/crypto_custody/kyc"
+	"github.com/crypto_custody/offline"
+	"github.com/crypto_custody/outbox"
+	"github.com/crypto_custody/por"
+	"github.com/crypto_custody/repository"
+	"github.com/crypto_custody/risk"
+	"github.com/crypto_custody/service"
+	"github.com/crypto_custody/webhook"
+	"gorm.io/gorm"
+)
+
+// ==========================
+// 组件装配
+// ==========================
+// 按配置组装各服务共用的组件；审批策略、风控金额、对账容差与等级限额取自链配置中的币种参数。
+
+// NewChain 连接链节点，不注册
+func NewChain(ch *config.ChainConfig) (chain.Chain, error) {
+	registerAddressRules(ch)
+	switch ch.Type {
+	case config.CHAIN_TYPE_SOLANA:
+		if ch.Name != solana.CHAIN {
+			return nil, fmt.Errorf("solana chain must be named %s", solana.CHAIN)
+		}
+		c := solana.New(ch.RPCURL.Value())
+		c.SetInternalAddresses(ch.OwnAddresses()...)
+		return c, nil
+	default:
+		c, err := evm.New(ch.RPCURL.Value(), evm.Config{Name: ch.Name, ChainID: ch.ChainID, Confirmations: ch.Confirmations})
+		if err != nil {
+			return nil, err
+		}
+		c.SetInternalAddresses(ch.OwnAddresses()...)
+		return c, nil
+	}
+}
+
+// NewOfflineChain 不连接节点的链适配器，只用于地址派生
+func NewOfflineChain(ch *config.ChainConfig) (chain.Chain, error) {
+	registerAddressRules(ch)
+	switch ch.Type {
+	case config.CHAIN_TYPE_SOLANA:
+		if ch.Name != solana.CHAIN {
+			return nil, fmt.Errorf("solana chain must be named %s", solana.CHAIN)
+		}
+		return solana.New(""), nil
+	default:
+		return evm.NewOffline(evm.Config{Name: ch.Name, ChainID: ch.ChainID, Confirmations: ch.Confirmations}), nil
+	}
+}
+
+// registerAddressRules 登记链及其币种的地址规则，供地址校验与规范化使用
+func registerAddressRules(ch *config.ChainConfig) {
+	kind := address.ChainEthereum
+	if ch.Type == config.CHAIN_TYPE_SOLANA {
+		kind = address.ChainSolana
+	}
+	address.RegisterChain(ch.Name, kind)
+	for _, cur := range ch.Currencies {
+		address.RegisterCurrency(cur.Currency, ch.Name)
+	}
+}
+
+// NewChains 连接配置的全部链并注册到 chain 包
+func NewChains(cfg *config.Config) (map[string]chain.Chain, error) {
+	chains := map[string]chain.Chain{}
+	for i := range cfg.Chains {
+		c, err := NewChain(&cfg.Chains[i])
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", cfg.Chains[i].Name, err)
+		}
+		chain.Register(c)
+		chains[c.Name()] = c
+	}
+	return chains, nil
+}
+
+// NewAuth 令牌与二次验证
+func NewAuth(cfg *config.Config, db *gorm.DB) (*auth.Tokens, *auth.TwoFactor, error) {
+	tokens, err := auth.NewTokens(db, []byte(cfg.Auth.JWTSecret.Value()), cfg.Auth.AccessTTL, cfg.Auth.RefreshTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := hex.DecodeString(cfg.Auth.TOTPKey.Value())
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid totp key: %w", err)
+	}
+	twoFactor, err := auth.NewTwoFactor(db, key, cfg.Auth.TOTPIssuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, twoFactor, nil
+}
+
+// NewKYC KYC 审核，未配置服务商时只支持人工审核
+func NewKYC(cfg *config.Config, db *gorm.DB) (*kyc.Service, error) {
+	blobs, err := blobstore.NewLocalStore(cfg.KYC.BlobDir)
+	if err != nil {
+		return nil, err
+	}
+	var provider kyc.Provider
+	if cfg.KYC.Provider != "" {
+		provider = kyc.NewHostedProvider(cfg.KYC.Provider, []byte(cfg.KYC.ProviderSecret.Value()))
+	}
+	return kyc.NewService(db, blobs, provider), nil
+}
+
+// amount 配置中的最小单位金额，启动时已校验
+func amount(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// NewApprovals 提现、调拨审批
+func NewApprovals(cfg *config.Config, db *gorm.DB) *approval.Service {
+	tiers := map[string][]approval.Tier{}
+	for _, ch := range cfg.Chains {
+		for _, cur := range ch.Currencies {
+			for _, t := range cur.ApprovalTiers {
+				tiers[cur.Currency] = append(tiers[cur.Currency], approval.Tier{MinAmount: amount(t.MinAmount), Required: t.Required, Roles: t.Roles})
+			}
+		}
+	}
+	return approval.NewService(db, approval.Policy{
+		Tiers:       tiers,
+		TTL:         cfg.Withdraw.ApprovalTTL,
+		ReviewRoles: cfg.Withdraw.ReviewRoles,
+	}, []byte(cfg.Withdraw.ApprovalTrailKey.Value()))
+}
+
+// NewReconciler 链上余额与账本对账，未配置容差的币种不对账；热、冷钱包地址取自链配置
+func NewReconciler(cfg *config.Config, db *gorm.DB) *service.Reconciler {
+	var rules []service.ReconcileRule
+	for _, ch := range cfg.Chains {
+		for _, cur := range ch.Currencies {
+			if cur.ReconcileTolerance == "" {
+				continue
+			}
+			rules = append(rules, service.ReconcileRule{
+				Currency:    cur.Currency,
+				Chain:       ch.Name,
+				Token:       cur.Token,
+				Tolerance:   amount(cur.ReconcileTolerance),
+				HotAddress:  ch.HotWallet.Address,
+				ColdAddress: ch.ColdWallet.Address,
+			})
+		}
+	}
+	return service.NewReconciler(db, rules)
+}
+
+// NewChecker 提现地址校验，支持合约检测的链拒绝向合约地址提现
+func NewChecker(db *gorm.DB, chains map[string]chain.Chain) *address.Checker {
+	checker := address.NewChecker(repository.NewAddressRepository(db))
+	for name, c := range chains {
+		if d, ok := c.(address.ContractDetector); ok {
+			checker.SetContractDetector(name, d)
+		}
+	}
+	return checker
+}
+
+// NewRiskEngine 风控规则，金额阈值按币种配置
+func NewRiskEngine(cfg *config.Config, db *gorm.DB) (*risk.Engine, error) {
+	userMax, globalMax, review := map[string]string{}, map[string]string{}, map[string]string{}
+	for _, ch := range cfg.Chains {
+		for _, cur := range ch.Currencies {
+			if cur.UserVelocityMaxAmount != "" {
+				userMax[cur.Currency] = cur.UserVelocityMaxAmount
+			}
+			if cur.GlobalVelocityMaxAmount != "" {
+				globalMax[cur.Currency] = cur.GlobalVelocityMaxAmount
+			}
+			if cur.ReviewThreshold != "" {
+				review[cur.Currency] = cur.ReviewThreshold
+			}
+		}
+	}
+	return risk.NewEngineFromConfig(risk.Config{
+		BlacklistFiles:    cfg.Risk.BlacklistFiles,
+		SanctionsFiles:    cfg.Risk.SanctionsFiles,
+		UserVelocity:      risk.LimitConfig{Window: cfg.Risk.UserVelocityWindow, MaxCount: cfg.Risk.UserVelocityMaxCount, MaxAmount: userMax},
+		GlobalVelocity:    risk.LimitConfig{Window: cfg.Risk.GlobalVelocityWindow, MaxAmount: globalMax},
+		NewAddressCooling: cfg.Risk.NewAddressCooling,
+		ReviewThresholds:  review,
+	}, service.NewWithdrawalHistory(db))
+}
+
+// NewWithdrawalService 各提现链热钱包签名、风控与审批，提现按币种路由到所在链
+func NewWithdrawalService(cfg *config.Config, db *gorm.DB, chains map[string]chain.Chain, approvals *approval.Service, reconciler *service.Reconciler) (*service.WithdrawalService, error) {
+	var signServices []*service.SignService
+	for _, ch := range cfg.WithdrawChains() {
+		c, ok := chains[ch.Name]
+		if !ok {
+			return nil, fmt.Errorf("withdraw chain %s is not connected", ch.Name)
+		}
+		tokens := map[string]*string{}
+		for _, cur := range ch.Currencies {
+			tokens[cur.Currency] = cur.Token
+		}
+		signService, err := service.NewSignService(db, c, ch.HotWallet.Address, ch.HotWallet.Key.Value(), approvals, tokens)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: init sign service: %w", ch.Name, err)
+		}
+		signServices = append(signServices, signService)
+	}
+	engine, err := NewRiskEngine(cfg, db)
+	if err != nil {
+		return nil, fmt.Errorf("init risk engine: %w", err)
+	}
+	return service.NewWithdrawalService(db, signServices, NewChecker(db, chains), engine, approvals, reconciler)
+}
+
+// NewRequestWorker 钱包接口提交的提现申请按币种精度换算后转入提现流程；所在链没有热钱包的币种由提现流程拒绝
+func NewRequestWorker(cfg *config.Config, db *gorm.DB, withdrawals *service.WithdrawalService) *service.RequestWorker {
+	decimals := map[string]int{}
+	for _, ch := range cfg.Chains {
+		for _, cur := range ch.Currencies {
+			decimals[cur.Currency] = cur.Decimals
+		}
+	}
+	return service.NewRequestWorker(db, withdrawals, decimals)
+}
+
+// NewRebalancer 冷热钱包调拨，水位与冷钱包取自链配置；热钱包私钥与提现签名共用，
+// Safe 多签冷钱包由所在链的热钱包发起执行
+func NewRebalancer(cfg *config.Config, db *gorm.DB, approvals *approval.Service) (*service.Rebalancer, error) {
+	hotKeys := map[string][]byte{}
+	var rules []service.TreasuryRule
+	var safes *service.SafeService
+	for i := range cfg.Chains {
+		ch := &cfg.Chains[i]
+		if ch.HotWallet.Address != "" {
+			key, err := hex.DecodeString(strings.TrimPrefix(ch.HotWallet.Key.Value(), "0x"))
+			if err != nil {
+				return nil, fmt.Errorf("chain %s: invalid hot wallet key: %w", ch.Name, err)
+			}
+			hotKeys[strings.ToLower(ch.HotWallet.Address)] = key
+		}
+		for _, cur := range ch.Currencies {
+			if cur.Treasury == nil {
+				continue
+			}
+			rules = append(rules, service.TreasuryRule{
+				Currency:      cur.Currency,
+				Chain:         ch.Name,
+				Token:         cur.Token,
+				Min:           amount(cur.Treasury.Min),
+				Target:        amount(cur.Treasury.Target),
+				Max:           amount(cur.Treasury.Max),
+				Confirmations: ch.Confirmations,
+				ColdAddress:   ch.ColdWallet.Address,
+				ColdSafe:      ch.ColdWallet.Safe,
+			})
+		}
+		if !ch.ColdWallet.Safe {
+			continue
+		}
+		if safes != nil {
+			return nil, fmt.Errorf("chain %s: only one chain may use a safe cold wallet", ch.Name)
+		}
+		if ch.HotWallet.Address == "" {
+			return nil, fmt.Errorf("chain %s: safe cold wallet requires hot_wallet to execute transactions", ch.Name)
+		}
+		var err error
+		if safes, err = service.NewSafeService(db, ch.HotWallet.Address, ch.HotWallet.Key.Value()); err != nil {
+			return nil, fmt.Errorf("chain %s: init safe service: %w", ch.Name, err)
+		}
+	}
+	return service.NewRebalancer(db, rules, hotKeys, approvals, safes), nil
+}
+
+// NewSweeper 充值地址归集到所在链的热钱包，未配置归集阈值的币种不归集；
+// 手续费按链上原生币记账
+func NewSweeper(cfg *config.Config, db *gorm.DB, keys service.KeySource) (*service.Sweeper, error) {
+	var rules []service.SweepRule
+	for _, ch := range cfg.Chains {
+		var native *config.CurrencyConfig
+		for i := range ch.Currencies {
+			if ch.Currencies[i].Token == nil {
+				native = &ch.Currencies[i]
+				break
+			}
+		}
+		for _, cur := range ch.Currencies {
+			if cur.SweepThreshold == "" {
+				continue
+			}
+			if ch.HotWallet.Address == "" {
+				return nil, fmt.Errorf("chain %s: sweeping %s requires hot_wallet.address", ch.Name, cur.Currency)
+			}
+			if native == nil {
+				return nil, fmt.Errorf("chain %s: sweeping %s requires the native currency to record fees", ch.Name, cur.Currency)
+			}
+			rule := service.SweepRule{
+				Chain:         ch.Name,
+				Token:         cur.Token,
+				Currency:      cur.Currency,
+				FeeCurrency:   native.Currency,
+				FeeDecimals:   native.Decimals,
+				HotAddress:    ch.HotWallet.Address,
+				Threshold:     amount(cur.SweepThreshold),
+				Confirmations: ch.Confirmations,
+			}
+			if cur.TokenPerNative != "" {
+				rule.TokenPerNative, _ = new(big.Rat).SetString(cur.TokenPerNative)
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return service.NewSweeper(db, keys, rules), nil
+}
+
+// NewGasStations 配置了 gas 钱包的链为代币充值地址补充归集手续费
+func NewGasStations(cfg *config.Config, db *gorm.DB, chains map[string]chain.Chain, sweeper *service.Sweeper) ([]*service.GasStation, error) {
+	var stations []*service.GasStation
+	for _, ch := range cfg.Chains {
+		if ch.GasWallet.Address == "" {
+			continue
+		}
+		c, ok := chains[ch.Name]
+		if !ok {
+			return nil, fmt.Errorf("chain %s is not connected", ch.Name)
+		}
+		g, err := service.NewGasStation(db, sweeper, c, ch.GasWallet.Address, ch.GasWallet.Key.Value(), ch.Confirmations)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", ch.Name, err)
+		}
+		stations = append(stations, g)
+	}
+	return stations, nil
+}
+
+// NewExporter 离线签名批次导入导出
+func NewExporter(cfg *config.Config, db *gorm.DB) (*offline.Exporter, error) {
+	key, err := offline.ParsePrivateKey(cfg.Offline.ExportKey.Value())
+	if err != nil {
+		return nil, fmt.Errorf("offline.export_key: %w", err)
+	}
+	pub, err := offline.ParsePublicKey(cfg.Offline.SignerPub)
+	if err != nil {
+		return nil, fmt.Errorf("offline.signer_pub: %w", err)
+	}
+	return offline.NewExporter(db, key, pub), nil
+}
+
+// TierLimits KYC 等级提现限额，未配置的等级不允许提现
+func TierLimits(cfg *config.Config) service.TierLimits {
+	limits := service.TierLimits{}
+	for _, ch := range cfg.Chains {
+		for _, cur := range ch.Currencies {
+			for tier, l := range cur.Limits {
+				if limits[tier] == nil {
+					limits[tier] = map[string]service.TierLimit{}
+				}
+				limits[tier][cur.Currency] = service.TierLimit{Daily: l.Daily, Monthly: l.Monthly}
+			}
+		}
+	}
+	return limits
+}
+
+// PorAssets 储备证明覆盖的资产
+func PorAssets(cfg *config.Config) []por.Asset {
+	var assets []por.Asset
+	for _, ch := range cfg.Chains {
+		for _, cur := range ch.Currencies {
+			assets = append(assets, por.Asset{Currency: cur.Currency, Chain: ch.Name, Token: cur.Token})
+		}
+	}
+	return assets
+}
+
+// NewBroker 进程内消息总线：记录事件并投递给 webhook
+func NewBroker(webhooks *webhook.Service) *outbox.MemoryBroker {
+	broker := outbox.NewMemoryBroker()
+	broker.Subscribe("*", func(ctx context.Context, msg outbox.Message) error {
+		log.Printf("event %s %s %s", msg.Topic, msg.AggregateID, msg.Payload)
+		return nil
+	})
+	broker.Subscribe("*", webhooks.HandleEvent)
+	return broker
+}
@@ -0,0 +1,61 @@
+package approval
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tier 金额达到 MinAmount 时需要 Required 个不同角色的审批，审批人角色必须在 Roles 中
+type Tier struct {
+	MinAmount *big.Int // 最小单位
+	Required  int
+	Roles     []string
+}
+
+// Policy 审批策略
+type Policy struct {
+	Tiers map[string][]Tier // currency -> tiers
+	TTL   time.Duration     // 审批单有效期，过期未完成自动失效
+
+	// 风控要求人工审核时，至少需要 ReviewRoles 中一个角色审批
+	ReviewRoles []string
+}
+
+// Requirement 一笔提现需要的审批：Roles 中 Required 个不同角色，
+// ReviewRoles 非空时另需其中至少一个角色审批（同一角色可同时满足两项）
+type Requirement struct {
+	Required    int
+	Roles       []string
+	ReviewRoles []string
+}
+
+// None 无需审批
+func (r Requirement) None() bool {
+	return r.Required == 0 && len(r.ReviewRoles) == 0
+}
+
+// Requirement 计算一笔提现需要的审批；风控要求人工审核时在金额档位之外总是要求审核角色
+func (p Policy) Requirement(currency string, amount *big.Int, riskReview bool) Requirement {
+	var matched *Tier
+	for i, t := range p.Tiers[strings.ToUpper(currency)] {
+		if amount.Cmp(t.MinAmount) < 0 {
+			continue
+		}
+		if matched == nil || t.MinAmount.Cmp(matched.MinAmount) > 0 {
+			matched = &p.Tiers[strings.ToUpper(currency)][i]
+		}
+	}
+
+	req := Requirement{Roles: []string{}}
+	if matched != nil {
+		req.Required, req.Roles = matched.Required, append(req.Roles, matched.Roles...)
+	}
+	if riskReview {
+		req.ReviewRoles = append([]string{}, p.ReviewRoles...)
+		sort.Strings(req.ReviewRoles)
+	}
+	sort.Strings(req.Roles)
+	return req
+}
@@ -0,0 +1,49 @@
+package approval
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func testPolicy() Policy {
+	return Policy{
+		Tiers: map[string][]Tier{
+			"ETH": {
+				{MinAmount: big.NewInt(100), Required: 1, Roles: []string{"finance"}},
+				{MinAmount: big.NewInt(1000), Required: 2, Roles: []string{"ops", "finance", "risk"}},
+			},
+		},
+		ReviewRoles: []string{"risk"},
+	}
+}
+
+func TestRequirement(t *testing.T) {
+	p := testPolicy()
+	tests := []struct {
+		name       string
+		currency   string
+		amount     int64
+		riskReview bool
+		want       Requirement
+	}{
+		{"below tiers", "ETH", 99, false, Requirement{Roles: []string{}}},
+		{"first tier", "eth", 100, false, Requirement{Required: 1, Roles: []string{"finance"}}},
+		{"highest matching tier", "ETH", 5000, false, Requirement{Required: 2, Roles: []string{"finance", "ops", "risk"}}},
+		{"review without tier", "ETH", 1, true, Requirement{Roles: []string{}, ReviewRoles: []string{"risk"}}},
+		// 命中档位时风控审核角色仍然必须审批，不能只由财务放行
+		{"review on top of tier", "ETH", 100, true, Requirement{Required: 1, Roles: []string{"finance"}, ReviewRoles: []string{"risk"}}},
+		{"unknown currency", "BTC", 1 << 40, false, Requirement{Roles: []string{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Requirement(tt.currency, big.NewInt(tt.amount), tt.riskReview)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Requirement = %+v, want %+v", got, tt.want)
+			}
+			if got.None() != (tt.want.Required == 0 && len(tt.want.ReviewRoles) == 0) {
+				t.Fatalf("None() = %v", got.None())
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package approval
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==========================
+// 提现审批（maker-checker）
+// ==========================
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+	StatusExpired  = "expired"
+
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+
+	KindWithdrawal = "withdrawal" // 用户提现
+	KindRebalance  = "rebalance"  // 冷钱包向热钱包调拨
+)
+
+var (
+	ErrNotFound        = errors.New("审批单不存在")
+	ErrNotPending      = errors.New("审批单已结束")
+	ErrExpired         = errors.New("审批单已过期")
+	ErrRoleNotAllowed  = errors.New("该角色无权审批此提现")
+	ErrDuplicateRole   = errors.New("该角色已审批过")
+	ErrDuplicateOp     = errors.New("同一操作员不能重复审批")
+	ErrTrailTampered   = errors.New("审批链校验失败")
+	ErrNotApproved     = errors.New("审批未完成")
+	ErrSubjectMismatch = errors.New("审批单与待发送的交易不符")
+)
+
+// Subject 审批对象的实际参数：提交审批时写入审批单，签名前按待发送的交易重新填写
+type Subject struct {
+	ID         uint // 提现或调拨单 ID
+	Currency   string
+	Address    string // 目标地址，规范形式
+	Amount     *big.Int
+	RiskReview bool // 风控要求人工审核
+}
+
+// Verifier 签名前校验提现的审批链
+type Verifier interface {
+	VerifyApproved(ctx context.Context, subject Subject) error
+}
+
+type Service struct {
+	db     *gorm.DB
+	kind   string
+	policy Policy
+	key    []byte // 审批链 HMAC 密钥，签名服务持有同一密钥
+	now    func() time.Time
+}
+
+var _ Verifier = (*Service)(nil)
+
+func NewService(db *gorm.DB, policy Policy, trailKey []byte) *Service {
+	return &Service{db: db, kind: KindWithdrawal, policy: policy, key: trailKey, now: time.Now}
+}
+
+// ForKind 返回审批其他对象的副本，审批单与审批链按 kind 隔离
+func (s *Service) ForKind(kind string) *Service {
+	cp := *s
+	cp.kind = kind
+	return &cp
+}
+
+// WithTx 返回使用给定事务的副本，便于与提现记录同事务创建审批单
+func (s *Service) WithTx(tx *gorm.DB) *Service {
+	cp := *s
+	cp.db = tx
+	return &cp
+}
+
+// Submit 为提现创建审批单；无需审批时返回 nil
+func (s *Service) Submit(ctx context.Context, sub Subject) (*model.WithdrawalApproval, error) {
+	req := s.policy.Requirement(sub.Currency, sub.Amount, sub.RiskReview)
+	if sub.RiskReview && len(req.ReviewRoles) == 0 {
+		return nil, fmt.Errorf("risk review for %s needs review roles but none configured", sub.Currency)
+	}
+	if req.None() {
+		return nil, nil
+	}
+	if req.Required > len(req.Roles) {
+		return nil, fmt.Errorf("approval policy for %s needs %d roles but only %d configured", sub.Currency, req.Required, len(req.Roles))
+	}
+	a := &model.WithdrawalApproval{
+		Kind:         s.kind,
+		WithdrawalID: sub.ID,
+		Currency:     sub.Currency,
+		Address:      sub.Address,
+		Amount:       sub.Amount.String(),
+		Required:     req.Required,
+		Roles:        strings.Join(req.Roles, ","),
+		ReviewRoles:  strings.Join(req.ReviewRoles, ","),
+		Status:       StatusPending,
+		ExpiresAt:    s.now().Add(s.policy.TTL).UTC().Truncate(time.Second),
+	}
+	a.Hash = s.approvalHash(a)
+	if err := s.db.WithContext(ctx).Create(a).Error; err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Approve 记录一次审批；达到所需人数时审批单变为 approved。
+// roles 为操作员持有的全部角色（取自访问令牌），以其中第一个可审批且尚未使用的角色记录
+func (s *Service) Approve(ctx context.Context, withdrawalID uint, operatorID uint64, roles []string, comment string) (*model.WithdrawalApproval, error) {
+	return s.act(ctx, withdrawalID, operatorID, roles, ActionApprove, comment)
+}
+
+// Reject 任一有权角色拒绝即终止审批
+func (s *Service) Reject(ctx context.Context, withdrawalID uint, operatorID uint64, roles []string, comment string) (*model.WithdrawalApproval, error) {
+	return s.act(ctx, withdrawalID, operatorID, roles, ActionReject, comment)
+}
+
+func (s *Service) act(ctx context.Context, withdrawalID uint, operatorID uint64, roles []string, action, comment string) (*model.WithdrawalApproval, error) {
+	var a model.WithdrawalApproval
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 行锁，避免并发审批越过人数判断
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kind = ? AND withdrawal_id = ?", s.kind, withdrawalID).First(&a).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if a.Status != StatusPending {
+			return ErrNotPending
+		}
+		if !s.now().Before(a.ExpiresAt) {
+			return ErrExpired
+		}
+
+		trail, err := s.trail(tx, a.ID)
+		if err != nil {
+			return err
+		}
+		used := map[string]bool{}
+		for _, act := range trail {
+			if act.OperatorID == operatorID {
+				return ErrDuplicateOp
+			}
+			used[act.Role] = true
+		}
+		// 审核角色尚未审批时优先以审核角色记录
+		reviewed := !needsReview(&a, trail)
+		role, allowed := "", false
+		for _, r := range roles {
+			if !actsOn(&a, r) {
+				continue
+			}
+			allowed = true
+			if used[r] {
+				continue
+			}
+			if role == "" || (!reviewed && containsRole(a.ReviewRoles, r) && !containsRole(a.ReviewRoles, role)) {
+				role = r
+			}
+		}
+		if !allowed {
+			return ErrRoleNotAllowed
+		}
+		if role == "" {
+			return ErrDuplicateRole
+		}
+
+		prev := a.Hash
+		if len(trail) > 0 {
+			prev = trail[len(trail)-1].Hash
+		}
+		entry := model.ApprovalAction{
+			ApprovalID: a.ID,
+			OperatorID: operatorID,
+			Role:       role,
+			Action:     action,
+			Comment:    comment,
+			PrevHash:   prev,
+			CreatedAt:  s.now().UTC().Truncate(time.Microsecond),
+		}
+		entry.Hash = s.actionHash(&entry)
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case action == ActionReject:
+			a.Status = StatusRejected
+		case satisfied(&a, append(trail, entry)):
+			a.Status = StatusApproved
+		default:
+			return nil
+		}
+		return tx.Model(&a).Update("status", a.Status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ExpireStale 将过期未完成的审批单置为 expired，返回对应的提现 ID
+func (s *Service) ExpireStale(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stale []model.WithdrawalApproval
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("kind = ? AND status = ? AND expires_at <= ?", s.kind, StatusPending, s.now()).
+			Find(&stale).Error; err != nil {
+			return err
+		}
+		for _, a := range stale {
+			ids = append(ids, a.WithdrawalID)
+		}
+		if len(stale) == 0 {
+			return nil
+		}
+		return tx.Model(&model.WithdrawalApproval{}).
+			Where("id IN ?", approvalIDs(stale)).
+			Update("status", StatusExpired).Error
+	})
+	return ids, err
+}
+
+// Trail 返回审批单及其审批记录
+func (s *Service) Trail(ctx context.Context, withdrawalID uint) (*model.WithdrawalApproval, []model.ApprovalAction, error) {
+	var a model.WithdrawalApproval
+	if err := s.db.WithContext(ctx).Where("kind = ? AND withdrawal_id = ?", s.kind, withdrawalID).First(&a).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	trail, err := s.trail(s.db.WithContext(ctx), a.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &a, trail, nil
+}
+
+// VerifyApproved 按待发送的参数重新计算审批要求，再校验审批链：审批单须与参数一致，
+// 每条记录的 HMAC、角色与人数均需满足审批单和当前策略。
+// 只有策略不要求审批的提现可以没有审批单，其他情况一律拒绝
+func (s *Service) VerifyApproved(ctx context.Context, sub Subject) error {
+	req := s.policy.Requirement(sub.Currency, sub.Amount, sub.RiskReview)
+	if sub.RiskReview && len(req.ReviewRoles) == 0 {
+		return fmt.Errorf("%w: risk review for %d needs review roles but none configured", ErrNotApproved, sub.ID)
+	}
+	a, trail, err := s.Trail(ctx, sub.ID)
+	if errors.Is(err, ErrNotFound) {
+		if s.kind == KindWithdrawal && req.None() {
+			return nil
+		}
+		return fmt.Errorf("%w: no %s approval for %d", ErrNotApproved, s.kind, sub.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(a.Hash), []byte(s.approvalHash(a))) {
+		return fmt.Errorf("%w: approval %d", ErrTrailTampered, a.ID)
+	}
+	if a.Currency != sub.Currency || a.Amount != sub.Amount.String() || a.Address != sub.Address {
+		return fmt.Errorf("%w: approval %d is for %s %s to %s", ErrSubjectMismatch, a.ID, a.Amount, a.Currency, a.Address)
+	}
+	// 审批单创建后策略可能收紧，以两者中较严格的为准；策略不要求审批时只按审批单校验角色
+	policyRoles, policyReview := strings.Join(req.Roles, ","), strings.Join(req.ReviewRoles, ",")
+	allowed := func(role string) bool {
+		return containsRole(a.Roles, role) && (req.Required == 0 || containsRole(policyRoles, role))
+	}
+	reviewAllowed := func(role string) bool {
+		if a.ReviewRoles != "" && !containsRole(a.ReviewRoles, role) {
+			return false
+		}
+		return policyReview == "" || containsRole(policyReview, role)
+	}
+	required := req.Required
+	if a.Required > required {
+		required = a.Required
+	}
+	needReview := a.ReviewRoles != "" || policyReview != ""
+
+	prev := a.Hash
+	roles := map[string]bool{}
+	operators := map[uint64]bool{}
+	reviewed := false
+	for _, act := range trail {
+		if act.PrevHash != prev || !hmac.Equal([]byte(act.Hash), []byte(s.actionHash(&act))) {
+			return fmt.Errorf("%w: action %d", ErrTrailTampered, act.ID)
+		}
+		if act.Action != ActionApprove {
+			return fmt.Errorf("%w: %s %d 已被拒绝", ErrNotApproved, s.kind, sub.ID)
+		}
+		if !actsOn(a, act.Role) || roles[act.Role] || operators[act.OperatorID] {
+			return fmt.Errorf("%w: action %d", ErrTrailTampered, act.ID)
+		}
+		if act.CreatedAt.After(a.ExpiresAt) {
+			return fmt.Errorf("%w: action %d after expiry", ErrTrailTampered, act.ID)
+		}
+		if allowed(act.Role) {
+			roles[act.Role] = true
+		}
+		if reviewAllowed(act.Role) {
+			reviewed = true
+		}
+		operators[act.OperatorID] = true
+		prev = act.Hash
+	}
+	if len(roles) < required {
+		return fmt.Errorf("%w: %d/%d", ErrNotApproved, len(roles), required)
+	}
+	if needReview && !reviewed {
+		return fmt.Errorf("%w: risk review approval missing", ErrNotApproved)
+	}
+	return nil
+}
+
+func (s *Service) trail(tx *gorm.DB, approvalID uint) ([]model.ApprovalAction, error) {
+	var trail []model.ApprovalAction
+	err := tx.Where("approval_id = ?", approvalID).Order("id asc").Find(&trail).Error
+	return trail, err
+}
+
+func (s *Service) mac(fields ...string) string {
+	m := hmac.New(sha256.New, s.key)
+	for _, f := range fields {
+		m.Write([]byte(f))
+		m.Write([]byte{0})
+	}
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func (s *Service) approvalHash(a *model.WithdrawalApproval) string {
+	fields := []string{
+		a.Kind,
+		strconv.FormatUint(uint64(a.WithdrawalID), 10),
+		a.Currency,
+		a.Address,
+		a.Amount,
+		strconv.Itoa(a.Required),
+		a.Roles,
+		strconv.FormatInt(a.ExpiresAt.Unix(), 10),
+	}
+	// 不需要风控审核的审批单保持原有哈希，升级前创建的审批单仍可校验
+	if a.ReviewRoles != "" {
+		fields = append(fields, a.ReviewRoles)
+	}
+	return s.mac(fields...)
+}
+
+func (s *Service) actionHash(act *model.ApprovalAction) string {
+	return s.mac(
+		act.PrevHash,
+		strconv.FormatUint(uint64(act.ApprovalID), 10),
+		strconv.FormatUint(act.OperatorID, 10),
+		act.Role,
+		act.Action,
+		act.Comment,
+		strconv.FormatInt(act.CreatedAt.UnixMicro(), 10),
+	)
+}
+
+func containsRole(roles, role string) bool {
+	for _, r := range strings.Split(roles, ",") {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// actsOn 角色可以审批该审批单：金额档位角色或风控审核角色
+func actsOn(a *model.WithdrawalApproval, role string) bool {
+	return containsRole(a.Roles, role) || (a.ReviewRoles != "" && containsRole(a.ReviewRoles, role))
+}
+
+// needsReview 审批单要求风控审核且尚无审核角色审批
+func needsReview(a *model.WithdrawalApproval, trail []model.ApprovalAction) bool {
+	if a.ReviewRoles == "" {
+		return false
+	}
+	for _, act := range trail {
+		if act.Action == ActionApprove && containsRole(a.ReviewRoles, act.Role) {
+			return false
+		}
+	}
+	return true
+}
+
+// satisfied 档位角色的不同审批人数达到要求，且需要时已有审核角色审批
+func satisfied(a *model.WithdrawalApproval, trail []model.ApprovalAction) bool {
+	roles := map[string]bool{}
+	for _, act := range trail {
+		if act.Action == ActionApprove && containsRole(a.Roles, act.Role) {
+			roles[act.Role] = true
+		}
+	}
+	return len(roles) >= a.Required && !needsReview(a, trail)
+}
+
+func approvalIDs(list []model.WithdrawalApproval) []uint {
+	ids := make([]uint, 0, len(list))
+	for _, a := range list {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
@@ -0,0 +1,111 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/internal/testdb"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db := testdb.Open(t, "withdrawal_approvals", "approval_actions")
+	p := testPolicy()
+	p.TTL = time.Hour
+	return NewService(db, p, []byte("trail-key"))
+}
+
+// 风控标记且命中金额档位的提现，财务审批后仍需风控角色审批
+func TestRiskReviewRequiresReviewRole(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	sub := Subject{ID: 1, Currency: "ETH", Address: "0xabc", Amount: big.NewInt(100), RiskReview: true}
+
+	a, err := s.Submit(ctx, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == nil || a.Required != 1 || a.Roles != "finance" || a.ReviewRoles != "risk" {
+		t.Fatalf("approval = %+v, want finance tier plus risk review", a)
+	}
+	a, err = s.Approve(ctx, sub.ID, 10, []string{"finance"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusPending {
+		t.Fatalf("status after finance approval = %s, want %s", a.Status, StatusPending)
+	}
+	if err := s.VerifyApproved(ctx, sub); !errors.Is(err, ErrNotApproved) {
+		t.Fatalf("VerifyApproved after finance only = %v, want %v", err, ErrNotApproved)
+	}
+	if _, err := s.Approve(ctx, sub.ID, 11, []string{"ops"}, ""); !errors.Is(err, ErrRoleNotAllowed) {
+		t.Fatalf("ops approval err = %v, want %v", err, ErrRoleNotAllowed)
+	}
+	a, err = s.Approve(ctx, sub.ID, 12, []string{"risk"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusApproved {
+		t.Fatalf("status after risk approval = %s, want %s", a.Status, StatusApproved)
+	}
+	if err := s.VerifyApproved(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	// 审批单与待发送交易的金额不符时拒绝
+	if err := s.VerifyApproved(ctx, Subject{ID: 1, Currency: "ETH", Address: "0xabc", Amount: big.NewInt(101), RiskReview: true}); !errors.Is(err, ErrSubjectMismatch) {
+		t.Fatalf("VerifyApproved with other amount = %v, want %v", err, ErrSubjectMismatch)
+	}
+}
+
+// 风控审核角色只审核、档位未要求审批时，一人风控审批即可
+func TestRiskReviewWithoutTier(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	sub := Subject{ID: 2, Currency: "ETH", Address: "0xabc", Amount: big.NewInt(1), RiskReview: true}
+	if _, err := s.Submit(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, sub.ID, 10, []string{"finance"}, ""); !errors.Is(err, ErrRoleNotAllowed) {
+		t.Fatalf("finance approval err = %v, want %v", err, ErrRoleNotAllowed)
+	}
+	a, err := s.Approve(ctx, sub.ID, 12, []string{"risk"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusApproved {
+		t.Fatalf("status = %s, want %s", a.Status, StatusApproved)
+	}
+	if err := s.VerifyApproved(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 一名同时持有财务与风控角色的操作员只能审批一次，优先以风控角色记录
+func TestReviewRolePreferredForDualRoleOperator(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	sub := Subject{ID: 3, Currency: "ETH", Address: "0xabc", Amount: big.NewInt(100), RiskReview: true}
+	if _, err := s.Submit(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, sub.ID, 10, []string{"finance", "risk"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	_, trail, err := s.Trail(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trail) != 1 || trail[0].Role != "risk" {
+		t.Fatalf("trail = %+v, want one risk approval", trail)
+	}
+	a, err := s.Approve(ctx, sub.ID, 11, []string{"finance"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusApproved {
+		t.Fatalf("status = %s, want %s", a.Status, StatusApproved)
+	}
+}
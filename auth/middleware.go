@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin.Context 中保存的认证信息
+const (
+	CTX_USER_ID    = "auth.userId"
+	CTX_SESSION_ID = "auth.sessionId"
+	CTX_ROLES      = "auth.roles"
+
+	STEP_UP_HEADER = "X-2FA-Code"
+
+	ROLE_KYC_REVIEWER = "kyc_reviewer" // KYC 审核，可查看证件
+	ROLE_FINANCE      = "finance"      // 财务，审批提现、查看对账
+	ROLE_RISK         = "risk"         // 风控，审批提现（含风控人工审核）、查看对账
+	ROLE_OPS          = "ops"          // 运维，冷热调拨、解除对账暂停、储备金证明
+)
+
+// Middleware 从 Authorization: Bearer <token> 校验访问令牌，用户 ID 只取自令牌
+func Middleware(t *Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+		claims, err := t.Authenticate(c, token)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if !errors.Is(err, ErrInvalidToken) && !errors.Is(err, ErrSessionRevoked) {
+				status = http.StatusInternalServerError
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(CTX_USER_ID, claims.UserID())
+		c.Set(CTX_SESSION_ID, claims.SessionID)
+		c.Set(CTX_ROLES, claims.Roles)
+		c.Next()
+	}
+}
+
+// UserID 当前请求的用户，仅在 Middleware 之后可用
+func UserID(c *gin.Context) uint64 {
+	return c.GetUint64(CTX_USER_ID)
+}
+
+// SessionID 当前请求的会话
+func SessionID(c *gin.Context) string {
+	return c.GetString(CTX_SESSION_ID)
+}
+
+// Roles 当前请求的管理后台角色
+func Roles(c *gin.Context) []string {
+	return c.GetStringSlice(CTX_ROLES)
+}
+
+// RequireRole 管理接口须在 Middleware 之后使用：令牌须带有 roles 中任一角色，roles 为空时拒绝全部请求
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, have := range Roles(c) {
+			for _, want := range roles {
+				if have == want {
+					c.Next()
+					return
+				}
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin role required"})
+	}
+}
+
+// CodeVerifier 二次验证码校验，由 TwoFactor 实现
+type CodeVerifier interface {
+	Verify(ctx context.Context, userID uint64, code string) error
+}
+
+// StepUp 敏感操作（提现、地址簿变更）要求在请求头中附带二次验证码，须在 Middleware 之后使用
+func StepUp(v CodeVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.GetHeader(STEP_UP_HEADER)
+		if code == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "2FA code required in " + STEP_UP_HEADER})
+			return
+		}
+		if err := v.Verify(c, UserID(c), code); err != nil {
+			status := http.StatusForbidden
+			switch {
+			case errors.Is(err, ErrTwoFactorLocked):
+				status = http.StatusTooManyRequests
+			case !errors.Is(err, ErrInvalidCode) && !errors.Is(err, ErrTwoFactorNotEnabled):
+				status = http.StatusInternalServerError
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+// Package auth 用户认证：密码哈希、JWT 访问/刷新令牌的签发、轮换与撤销，以及 gin 认证中间件。
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	BCRYPT_COST         = 12
+	MIN_PASSWORD_LENGTH = 8
+	MAX_PASSWORD_LENGTH = 72 // bcrypt 只使用前 72 字节
+)
+
+var (
+	ErrWeakPassword       = errors.New("密码长度须为 8-72 个字符")
+	ErrInvalidCredentials = errors.New("账号或密码错误")
+)
+
+// dummyHash 用户不存在时也做一次 bcrypt 比较，避免通过响应时间枚举账号
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("crypto_custody-dummy-password"), BCRYPT_COST)
+
+// ValidatePassword 注册、改密时的密码强度检查
+func ValidatePassword(password string) error {
+	if len(password) < MIN_PASSWORD_LENGTH || len(password) > MAX_PASSWORD_LENGTH {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), BCRYPT_COST)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// IsHashed 区分 bcrypt 哈希与历史遗留的明文密码
+func IsHashed(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// CheckPassword 校验密码。needRehash 为 true 表示存储的是明文或低成本哈希，调用方应在登录成功后重新哈希保存
+func CheckPassword(stored, password string) (needRehash bool, err error) {
+	if !IsHashed(stored) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		if stored == "" || subtle.ConstantTimeCompare([]byte(stored), []byte(password)) != 1 {
+			return false, ErrInvalidCredentials
+		}
+		return true, nil
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)); err != nil {
+		return false, ErrInvalidCredentials
+	}
+	cost, err := bcrypt.Cost([]byte(stored))
+	return err == nil && cost < BCRYPT_COST, nil
+}
+
+// CheckMissingUser 账号不存在时调用，耗时与正常校验一致
+func CheckMissingUser(password string) error {
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+	return ErrInvalidCredentials
+}
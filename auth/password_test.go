@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password string
+		ok       bool
+	}{
+		{strings.Repeat("a", MIN_PASSWORD_LENGTH-1), false},
+		{strings.Repeat("a", MIN_PASSWORD_LENGTH), true},
+		{strings.Repeat("a", MAX_PASSWORD_LENGTH), true},
+		{strings.Repeat("a", MAX_PASSWORD_LENGTH+1), false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePassword(tt.password); (err == nil) != tt.ok {
+			t.Fatalf("ValidatePassword(len %d) = %v, want ok=%v", len(tt.password), err, tt.ok)
+		}
+	}
+}
+
+// 哈希以 BCRYPT_COST 生成；明文和低成本哈希登录成功后需要重新哈希
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsHashed(hash) {
+		t.Fatalf("%s not recognized as a bcrypt hash", hash)
+	}
+	if cost, err := bcrypt.Cost([]byte(hash)); err != nil || cost != BCRYPT_COST {
+		t.Fatalf("cost = (%d, %v), want %d", cost, err, BCRYPT_COST)
+	}
+	weak, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		stored     string
+		password   string
+		needRehash bool
+		err        error
+	}{
+		{"hashed", hash, "correct horse", false, nil},
+		{"wrong password", hash, "correct horsE", false, ErrInvalidCredentials},
+		{"low cost", string(weak), "correct horse", true, nil},
+		{"legacy plaintext", "correct horse", "correct horse", true, nil},
+		{"legacy plaintext mismatch", "correct horse", "wrong", false, ErrInvalidCredentials},
+		{"empty stored", "", "", false, ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			needRehash, err := CheckPassword(tt.stored, tt.password)
+			if needRehash != tt.needRehash || !errors.Is(err, tt.err) {
+				t.Fatalf("CheckPassword = (%v, %v), want (%v, %v)", needRehash, err, tt.needRehash, tt.err)
+			}
+		})
+	}
+	if err := CheckMissingUser("x"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("CheckMissingUser err = %v", err)
+	}
+}
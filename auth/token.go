@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==========================
+// JWT 令牌
+// ==========================
+// 访问令牌短期有效，携带会话 ID，校验时确认会话未被撤销；刷新令牌每次使用后轮换，
+// 已使用的刷新令牌再次出现说明被窃取，整个会话随之撤销。
+
+const (
+	TOKEN_TYPE_ACCESS  = "access"
+	TOKEN_TYPE_REFRESH = "refresh"
+	TOKEN_TYPE_2FA     = "2fa" // 密码已通过、等待二次验证的登录挑战
+
+	DEFAULT_ACCESS_TTL  = 15 * time.Minute
+	DEFAULT_REFRESH_TTL = 30 * 24 * time.Hour
+	CHALLENGE_TTL       = 5 * time.Minute
+	MIN_SECRET_LENGTH   = 32
+	TOKEN_ISSUER        = "crypto_custody"
+)
+
+var (
+	ErrInvalidToken   = errors.New("令牌无效或已过期")
+	ErrSessionRevoked = errors.New("会话已注销")
+	ErrTokenReused    = errors.New("刷新令牌已被使用，会话已撤销")
+)
+
+// Claims 令牌内容，Subject 为用户 ID；Roles 为管理后台角色，只写入访问令牌
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID string   `json:"sid"`
+	Type      string   `json:"typ"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+// UserID 令牌所属用户
+func (c *Claims) UserID() uint64 {
+	id, _ := strconv.ParseUint(c.Subject, 10, 64)
+	return id
+}
+
+// Pair 登录或刷新时返回给客户端的令牌
+type Pair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int64  `json:"expiresIn"` // 访问令牌有效秒数
+}
+
+type Tokens struct {
+	db         *gorm.DB
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokens HS256 签名，secret 至少 32 字节；ttl 为 0 时使用默认值
+func NewTokens(db *gorm.DB, secret []byte, accessTTL, refreshTTL time.Duration) (*Tokens, error) {
+	if len(secret) < MIN_SECRET_LENGTH {
+		return nil, fmt.Errorf("jwt secret must be at least %d bytes", MIN_SECRET_LENGTH)
+	}
+	if accessTTL == 0 {
+		accessTTL = DEFAULT_ACCESS_TTL
+	}
+	if refreshTTL == 0 {
+		refreshTTL = DEFAULT_REFRESH_TTL
+	}
+	return &Tokens{db: db, secret: secret, accessTTL: accessTTL, refreshTTL: refreshTTL}, nil
+}
+
+func (t *Tokens) sign(userID uint64, sessionID, typ, jti string, roles []string, now time.Time, ttl time.Duration) (string, error) {
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    TOKEN_ISSUER,
+			Subject:   strconv.FormatUint(userID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		SessionID: sessionID,
+		Type:      typ,
+		Roles:     roles,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.secret)
+}
+
+func (t *Tokens) parse(token, typ string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(TOKEN_ISSUER), jwt.WithExpirationRequired())
+	if err != nil || claims.Type != typ || claims.SessionID == "" || claims.UserID() == 0 {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// issue 在会话内签发一对新令牌并登记刷新令牌
+func (t *Tokens) issue(tx *gorm.DB, userID uint64, sessionID string) (*Pair, error) {
+	now := time.Now()
+	jti := uuid.NewString()
+	if err := tx.Create(&model.RefreshToken{
+		JTI:       jti,
+		SessionID: sessionID,
+		UserID:    userID,
+		ExpiresAt: now.Add(t.refreshTTL),
+	}).Error; err != nil {
+		return nil, err
+	}
+	refresh, err := t.sign(userID, sessionID, TOKEN_TYPE_REFRESH, jti, nil, now, t.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	// 角色每次签发时重新读取，撤销角色后刷新令牌即不再携带
+	var roles []string
+	if err := tx.Model(&model.AdminRole{}).Where("user_id = ?", userID).Order("role").Pluck("role", &roles).Error; err != nil {
+		return nil, err
+	}
+	access, err := t.sign(userID, sessionID, TOKEN_TYPE_ACCESS, uuid.NewString(), roles, now, t.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &Pair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(t.accessTTL / time.Second),
+	}, nil
+}
+
+// Issue 登录成功后创建会话并签发令牌
+func (t *Tokens) Issue(ctx context.Context, userID uint64, userAgent, ip string) (*Pair, error) {
+	var pair *Pair
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		session := model.AuthSession{ID: uuid.NewString(), UserID: userID, UserAgent: userAgent, IP: ip}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		pair, err = t.issue(tx, userID, session.ID)
+		return err
+	})
+	return pair, err
+}
+
+// Refresh 用刷新令牌换取新令牌，旧刷新令牌作废
+func (t *Tokens) Refresh(ctx context.Context, refreshToken string) (*Pair, error) {
+	claims, err := t.parse(refreshToken, TOKEN_TYPE_REFRESH)
+	if err != nil {
+		return nil, err
+	}
+	var pair *Pair
+	reused := false
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rt model.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("jti = ? AND session_id = ?", claims.ID, claims.SessionID).First(&rt).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if rt.UsedAt != nil {
+			reused = true
+			return ErrTokenReused
+		}
+		var session model.AuthSession
+		if err := tx.First(&session, "id = ?", claims.SessionID).Error; err != nil {
+			return err
+		}
+		if session.RevokedAt != nil {
+			return ErrSessionRevoked
+		}
+		now := time.Now()
+		if err := tx.Model(&rt).Update("used_at", &now).Error; err != nil {
+			return err
+		}
+		pair, err = t.issue(tx, rt.UserID, rt.SessionID)
+		return err
+	})
+	if reused {
+		// 事务外撤销，避免随回滚一起丢失
+		if rerr := t.Revoke(ctx, claims.UserID(), claims.SessionID); rerr != nil {
+			return nil, rerr
+		}
+	}
+	return pair, err
+}
+
+// Authenticate 校验访问令牌及其会话
+func (t *Tokens) Authenticate(ctx context.Context, accessToken string) (*Claims, error) {
+	claims, err := t.parse(accessToken, TOKEN_TYPE_ACCESS)
+	if err != nil {
+		return nil, err
+	}
+	var session model.AuthSession
+	if err := t.db.WithContext(ctx).First(&session, "id = ?", claims.SessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if session.RevokedAt != nil || session.UserID != claims.UserID() {
+		return nil, ErrSessionRevoked
+	}
+	return claims, nil
+}
+
+// Challenge 开启二次验证的用户密码校验通过后签发登录挑战，凭挑战与验证码换取令牌
+func (t *Tokens) Challenge(userID uint64) (string, error) {
+	return t.sign(userID, uuid.NewString(), TOKEN_TYPE_2FA, uuid.NewString(), nil, time.Now(), CHALLENGE_TTL)
+}
+
+// ParseChallenge 校验登录挑战，返回用户 ID
+func (t *Tokens) ParseChallenge(token string) (uint64, error) {
+	claims, err := t.parse(token, TOKEN_TYPE_2FA)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID(), nil
+}
+
+// Revoke 注销单个会话
+func (t *Tokens) Revoke(ctx context.Context, userID uint64, sessionID string) error {
+	return t.db.WithContext(ctx).Model(&model.AuthSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAll 注销用户的全部会话，用于改密、账号冻结等场景
+func (t *Tokens) RevokeAll(ctx context.Context, userID uint64) error {
+	return t.db.WithContext(ctx).Model(&model.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestNewTokensSecretLength(t *testing.T) {
+	if _, err := NewTokens(nil, testSecret[:MIN_SECRET_LENGTH-1], 0, 0); err == nil {
+		t.Fatal("short secret accepted")
+	}
+}
+
+// 过期、类型不符、签名密钥不符的令牌在查询会话前即被拒绝
+func TestParseRejects(t *testing.T) {
+	tokens, err := NewTokens(nil, testSecret, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewTokens(nil, []byte("fedcba9876543210fedcba9876543210"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	sign := func(tk *Tokens, typ string, issued time.Time, ttl time.Duration) string {
+		t.Helper()
+		token, err := tk.sign(1, "session", typ, "jti", nil, issued, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired access", sign(tokens, TOKEN_TYPE_ACCESS, now.Add(-time.Hour), DEFAULT_ACCESS_TTL)},
+		{"refresh as access", sign(tokens, TOKEN_TYPE_REFRESH, now, DEFAULT_REFRESH_TTL)},
+		{"challenge as access", sign(tokens, TOKEN_TYPE_2FA, now, CHALLENGE_TTL)},
+		{"other secret", sign(other, TOKEN_TYPE_ACCESS, now, DEFAULT_ACCESS_TTL)},
+		{"garbage", "not.a.jwt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tokens.Authenticate(context.Background(), tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Authenticate err = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+
+	if _, err := tokens.Refresh(context.Background(), sign(tokens, TOKEN_TYPE_REFRESH, now.Add(-2*DEFAULT_REFRESH_TTL), DEFAULT_REFRESH_TTL)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expired refresh err = %v, want %v", err, ErrInvalidToken)
+	}
+	challenge, err := tokens.Challenge(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID, err := tokens.ParseChallenge(challenge); err != nil || userID != 7 {
+		t.Fatalf("ParseChallenge = (%d, %v), want 7", userID, err)
+	}
+}
+
+func newTestTokens(t *testing.T) (*Tokens, uint64) {
+	t.Helper()
+	db := testdb.Open(t, "users", "auth_sessions", "refresh_tokens", "admin_roles")
+	user := model.User{Email: "token@example.com", Password: "x"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.AdminRole{UserID: user.ID, Role: "finance"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := NewTokens(db, testSecret, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens, user.ID
+}
+
+// 刷新令牌每次使用后轮换，新的访问令牌携带当前角色
+func TestRefreshRotation(t *testing.T) {
+	tokens, userID := newTestTokens(t)
+	ctx := context.Background()
+
+	first, err := tokens.Issue(ctx, userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := tokens.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token not rotated")
+	}
+	claims, err := tokens.Authenticate(ctx, second.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID() != userID || len(claims.Roles) != 1 || claims.Roles[0] != "finance" {
+		t.Fatalf("claims user = %d roles = %v, want %d with finance", claims.UserID(), claims.Roles, userID)
+	}
+	third, err := tokens.Refresh(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.Authenticate(ctx, third.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 已轮换的刷新令牌再次使用：撤销整个会话，此前签发的全部令牌失效，其他会话不受影响
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	tokens, userID := newTestTokens(t)
+	ctx := context.Background()
+
+	stolen, err := tokens.Issue(ctx, userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := tokens.Issue(ctx, userID, "other", "127.0.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := tokens.Refresh(ctx, stolen.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokens.Refresh(ctx, stolen.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("reused refresh err = %v, want %v", err, ErrTokenReused)
+	}
+	if _, err := tokens.Refresh(ctx, rotated.RefreshToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("rotated refresh after reuse err = %v, want %v", err, ErrSessionRevoked)
+	}
+	for _, access := range []string{stolen.AccessToken, rotated.AccessToken} {
+		if _, err := tokens.Authenticate(ctx, access); !errors.Is(err, ErrSessionRevoked) {
+			t.Fatalf("access token after reuse err = %v, want %v", err, ErrSessionRevoked)
+		}
+	}
+	if _, err := tokens.Authenticate(ctx, other.AccessToken); err != nil {
+		t.Fatalf("other session: %v", err)
+	}
+	if _, err := tokens.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Fatalf("other session refresh: %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==========================
+// TOTP 二次验证 (RFC 6238)
+// ==========================
+// HMAC-SHA1、6 位、30 秒步长，兼容常见验证器 App；允许前后各一个步长的时钟偏差，
+// 已使用的时间步不能再次通过。连续失败达到上限后锁定一段时间。
+
+const (
+	TOTP_DIGITS         = 6
+	TOTP_PERIOD         = 30
+	TOTP_SKEW           = 1
+	TOTP_SECRET_SIZE    = 20
+	RECOVERY_CODE_COUNT = 10
+	MAX_2FA_FAILURES    = 5
+	LOCKOUT_DURATION    = 15 * time.Minute
+)
+
+var (
+	ErrTwoFactorNotEnabled = errors.New("未开启二次验证")
+	ErrTwoFactorEnabled    = errors.New("二次验证已开启")
+	ErrTwoFactorNotPending = errors.New("请先生成二次验证密钥")
+	ErrInvalidCode         = errors.New("验证码错误")
+	ErrTwoFactorLocked     = errors.New("验证失败次数过多，请稍后再试")
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode 第 step 个时间步的验证码
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	v := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTP_DIGITS, v%1000000)
+}
+
+// matchStep 返回与 code 匹配且晚于 lastStep 的时间步
+func matchStep(secret []byte, code string, now time.Time, lastStep int64) (int64, bool) {
+	current := now.Unix() / TOTP_PERIOD
+	for step := current - TOTP_SKEW; step <= current+TOTP_SKEW; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if hmac.Equal([]byte(totpCode(secret, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))))
+	return hex.EncodeToString(sum[:])
+}
+
+// TwoFactor TOTP 注册、确认与校验，实现 service.TwoFactorVerifier
+type TwoFactor struct {
+	db     *gorm.DB
+	aead   cipher.AEAD
+	issuer string
+}
+
+// NewTwoFactor key 为 32 字节 AES-256 密钥，用于加密存储 TOTP 密钥
+func NewTwoFactor(db *gorm.DB, key []byte, issuer string) (*TwoFactor, error) {
+	if len(key) != 32 {
+		return nil, errors.New("2fa encryption key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactor{db: db, aead: aead, issuer: issuer}, nil
+}
+
+func (t *TwoFactor) seal(secret []byte, userID uint64) (string, error) {
+	nonce := make([]byte, t.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := t.aead.Seal(nonce, nonce, secret, []byte(fmt.Sprint(userID)))
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+func (t *TwoFactor) open(sealed string, userID uint64) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < t.aead.NonceSize() {
+		return nil, errors.New("corrupted 2fa secret")
+	}
+	n := t.aead.NonceSize()
+	return t.aead.Open(nil, raw[:n], raw[n:], []byte(fmt.Sprint(userID)))
+}
+
+// Enroll 生成新的 TOTP 密钥，返回 base32 密钥与 otpauth URI（用于二维码）；确认前不生效
+func (t *TwoFactor) Enroll(ctx context.Context, userID uint64, account string) (string, string, error) {
+	secret := make([]byte, TOTP_SECRET_SIZE)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	sealed, err := t.seal(secret, userID)
+	if err != nil {
+		return "", "", err
+	}
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tf model.TwoFactor
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tf, "user_id = ?", userID).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(&model.TwoFactor{UserID: userID, Secret: sealed}).Error
+		case err != nil:
+			return err
+		case tf.Enabled:
+			return ErrTwoFactorEnabled
+		}
+		return tx.Model(&tf).Updates(map[string]interface{}{"secret": sealed, "last_step": 0, "failed_attempts": 0}).Error
+	})
+	if err != nil {
+		return "", "", err
+	}
+	encoded := b32.EncodeToString(secret)
+	label := url.PathEscape(t.issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", encoded)
+	q.Set("issuer", t.issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(TOTP_DIGITS))
+	q.Set("period", fmt.Sprint(TOTP_PERIOD))
+	return encoded, "otpauth://totp/" + label + "?" + q.Encode(), nil
+}
+
+// Confirm 用第一个验证码确认开启，返回一次性恢复码（只显示这一次）
+func (t *TwoFactor) Confirm(ctx context.Context, userID uint64, code string) ([]string, error) {
+	var codes []string
+	var verr error
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tf, err := t.lock(tx, userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotPending
+		}
+		if err != nil {
+			return err
+		}
+		if tf.Enabled {
+			return ErrTwoFactorEnabled
+		}
+		if verr = t.check(tx, tf, code, false); verr != nil {
+			return nil
+		}
+		now := time.Now()
+		if err := tx.Model(tf).Updates(map[string]interface{}{"enabled": true, "confirmed_at": &now}).Error; err != nil {
+			return err
+		}
+		codes, err = t.resetRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, verr
+}
+
+// Verify 校验验证码或恢复码，用于登录第二步及提现、地址簿等敏感操作
+func (t *TwoFactor) Verify(ctx context.Context, userID uint64, code string) error {
+	var verr error
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tf, err := t.lock(tx, userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnabled
+		}
+		if err != nil {
+			return err
+		}
+		if !tf.Enabled {
+			return ErrTwoFactorNotEnabled
+		}
+		// 校验失败也要提交事务，记录失败次数
+		verr = t.check(tx, tf, code, true)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return verr
+}
+
+// Disable 关闭二次验证，需要当前验证码或恢复码
+func (t *TwoFactor) Disable(ctx context.Context, userID uint64, code string) error {
+	if err := t.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.TwoFactor{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes 作废旧恢复码并生成新的一组
+func (t *TwoFactor) RegenerateRecoveryCodes(ctx context.Context, userID uint64, code string) ([]string, error) {
+	if err := t.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = t.resetRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// Enabled 用户是否已开启二次验证
+func (t *TwoFactor) Enabled(ctx context.Context, userID uint64) (bool, error) {
+	var n int64
+	err := t.db.WithContext(ctx).Model(&model.TwoFactor{}).Where("user_id = ? AND enabled = ?", userID, true).Count(&n).Error
+	return n > 0, err
+}
+
+func (t *TwoFactor) lock(tx *gorm.DB, userID uint64) (*model.TwoFactor, error) {
+	var tf model.TwoFactor
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tf, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// check 在已加锁的记录上校验验证码，并更新失败计数与锁定状态
+func (t *TwoFactor) check(tx *gorm.DB, tf *model.TwoFactor, code string, allowRecovery bool) error {
+	now := time.Now()
+	if tf.LockedUntil != nil && now.Before(*tf.LockedUntil) {
+		return ErrTwoFactorLocked
+	}
+	secret, err := t.open(tf.Secret, tf.UserID)
+	if err != nil {
+		return err
+	}
+	code = strings.TrimSpace(code)
+	if step, ok := matchStep(secret, code, now, tf.LastStep); ok {
+		return tx.Model(tf).Updates(map[string]interface{}{"last_step": step, "failed_attempts": 0, "locked_until": nil}).Error
+	}
+	if allowRecovery && len(code) > TOTP_DIGITS {
+		res := tx.Model(&model.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", tf.UserID, hashRecoveryCode(code)).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			return tx.Model(tf).Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": nil}).Error
+		}
+	}
+
+	updates := map[string]interface{}{"failed_attempts": tf.FailedAttempts + 1}
+	if tf.FailedAttempts+1 >= MAX_2FA_FAILURES {
+		updates["failed_attempts"] = 0
+		updates["locked_until"] = now.Add(LOCKOUT_DURATION)
+	}
+	if err := tx.Model(tf).Updates(updates).Error; err != nil {
+		return err
+	}
+	return ErrInvalidCode
+}
+
+func (t *TwoFactor) resetRecoveryCodes(tx *gorm.DB, userID uint64) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, RECOVERY_CODE_COUNT)
+	rows := make([]model.RecoveryCode, RECOVERY_CODE_COUNT)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(buf)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		rows[i] = model.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(raw)}
+	}
+	return codes, tx.Create(&rows).Error
+}
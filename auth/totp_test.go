@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量，取低 6 位
+func TestTotpCodeRFC6238(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(secret, tt.unix/TOTP_PERIOD); got != tt.code {
+			t.Fatalf("totpCode(%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestMatchStep(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / TOTP_PERIOD
+	tests := []struct {
+		name     string
+		step     int64
+		lastStep int64
+		ok       bool
+	}{
+		{"current", current, 0, true},
+		{"previous step", current - 1, 0, true},
+		{"next step", current + 1, 0, true},
+		{"outside skew", current - 2, 0, false},
+		{"outside skew ahead", current + 2, 0, false},
+		{"already used", current, current, false},
+		{"older than used", current - 1, current, false},
+		{"after used", current + 1, current, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := matchStep(secret, totpCode(secret, tt.step), now, tt.lastStep)
+			if ok != tt.ok || (ok && step != tt.step) {
+				t.Fatalf("matchStep = (%d, %v), want (%d, %v)", step, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	want := hashRecoveryCode("abcde12345")
+	for _, code := range []string{"abcde-12345", " ABCDE-12345 ", "ABCDE12345"} {
+		if hashRecoveryCode(code) != want {
+			t.Fatalf("hashRecoveryCode(%q) differs from the displayed form", code)
+		}
+	}
+}
+
+// enrolledUser 创建用户并完成二次验证开启，返回 TOTP 密钥、确认时用掉的时间步和恢复码
+func enrolledUser(t *testing.T, tf *TwoFactor, db *gorm.DB) (uint64, []byte, int64, []string) {
+	t.Helper()
+	ctx := context.Background()
+	user := model.User{Email: "totp@example.com", Password: "x"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	encoded, uri, err := tf.Enroll(ctx, user.ID, user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri == "" {
+		t.Fatal("empty otpauth uri")
+	}
+	secret, err := b32.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := time.Now().Unix() / TOTP_PERIOD
+	codes, err := tf.Confirm(ctx, user.ID, totpCode(secret, step))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RECOVERY_CODE_COUNT {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), RECOVERY_CODE_COUNT)
+	}
+	return user.ID, secret, step, codes
+}
+
+func TestTwoFactorReplayAndRecovery(t *testing.T) {
+	db := testdb.Open(t, "users")
+	tf, err := NewTwoFactor(db, make([]byte, 32), "custody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	userID, secret, step, codes := enrolledUser(t, tf, db)
+
+	// 确认时用过的验证码不能再次通过
+	if err := tf.Verify(ctx, userID, totpCode(secret, step)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("replayed code err = %v, want %v", err, ErrInvalidCode)
+	}
+	if err := tf.Verify(ctx, userID, totpCode(secret, step+1)); err != nil {
+		t.Fatalf("next step: %v", err)
+	}
+	if err := tf.Verify(ctx, userID, codes[0]); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if err := tf.Verify(ctx, userID, codes[0]); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("reused recovery code err = %v, want %v", err, ErrInvalidCode)
+	}
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	db := testdb.Open(t, "users")
+	tf, err := NewTwoFactor(db, make([]byte, 32), "custody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	userID, secret, step, _ := enrolledUser(t, tf, db)
+
+	wrong := "000000"
+	if wrong == totpCode(secret, step+1) {
+		wrong = "111111"
+	}
+	for i := 0; i < MAX_2FA_FAILURES; i++ {
+		if err := tf.Verify(ctx, userID, wrong); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("attempt %d err = %v, want %v", i+1, err, ErrInvalidCode)
+		}
+	}
+	// 锁定期间正确的验证码也被拒绝
+	if err := tf.Verify(ctx, userID, totpCode(secret, step+1)); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Fatalf("locked err = %v, want %v", err, ErrTwoFactorLocked)
+	}
+
+	var rec model.TwoFactor
+	if err := db.First(&rec, "user_id = ?", userID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if rec.LockedUntil == nil || time.Until(*rec.LockedUntil) <= LOCKOUT_DURATION-time.Minute {
+		t.Fatalf("locked_until = %v, want about %s from now", rec.LockedUntil, LOCKOUT_DURATION)
+	}
+	// 锁定到期后恢复，成功校验清零失败计数
+	if err := db.Model(&rec).Update("locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := tf.Verify(ctx, userID, totpCode(secret, step+1)); err != nil {
+		t.Fatalf("after lockout: %v", err)
+	}
+	if err := db.First(&rec, "user_id = ?", userID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if rec.FailedAttempts != 0 || rec.LockedUntil != nil {
+		t.Fatalf("failed_attempts = %d, locked_until = %v after success", rec.FailedAttempts, rec.LockedUntil)
+	}
+}
@@ -0,0 +1,193 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// ==========================
+// 链适配器接口
+// ==========================
+// 所有链相关逻辑（地址派生、校验、扫块、解析转账、构造/广播交易、确认数）
+// 都通过 Chain 接口实现，扫块、入账、提现等服务只依赖该接口
+
+// ErrTxFailed 交易不会再成功，资金未转移：已上链但执行失败（EVM revert、Solana 指令错误），
+// 或有效期已过仍未上链（同时匹配 ErrTxExpired）
+var ErrTxFailed = errors.New("transaction failed on chain")
+
+// ErrTxExpired 交易有效期已过（Solana blockhash 失效）仍未上链，不会再上链，可用新的交易重发
+var ErrTxExpired = errors.New("transaction expired before landing")
+
+// ErrFeeExceedsAmount 余额不足以支付转出全部余额的手续费
+var ErrFeeExceedsAmount = errors.New("fee exceeds transfer amount")
+
+// Event 扫块得到的原始链上事件，对应 onchain_events 表的一行
+// Topics / Data 的内容由各链自行定义，只需 ParseTransfers 能解析
+type Event struct {
+	BlockNumber int64
+	BlockHash   string
+	TxHash      string
+	LogIndex    int
+	Address     string // 发出事件的合约 / 程序地址
+	Topics      string
+	Data        []byte
+}
+
+// Transfer 解析出的一笔入账转账
+type Transfer struct {
+	TxHash      string
+	LogIndex    int
+	BlockNumber int64
+	From        string
+	To          string
+	Token       *string // 代币合约 / mint 地址，原生币为 nil
+	Amount      *big.Int
+}
+
+// TransferRequest 提现转账请求
+type TransferRequest struct {
+	From   string
+	To     string
+	Token  *string
+	Amount *big.Int
+}
+
+// UnsignedTx 未签名交易，Payload 由各链自行编码
+type UnsignedTx struct {
+	Chain      string
+	From       string
+	Payload    []byte
+	ValidUntil uint64 // 交易失效高度（如 Solana blockhash 有效期），0 表示不会过期
+}
+
+// AddressFilter 判断地址是否属于我方地址池
+type AddressFilter func(addr string) bool
+
+type Chain interface {
+	// Name 链标识，如 "ethereum"、"solana"
+	Name() string
+	// CoinType BIP44 coin type
+	CoinType() uint32
+
+	// DeriveAddress 从 BIP39 种子派生第 index 个地址，返回地址与派生路径
+	DeriveAddress(seed []byte, index uint32) (address string, path string, err error)
+	// ValidateAddress 校验地址格式
+	ValidateAddress(addr string) error
+	// NormalizeAddress 地址的规范形式，写库与查询前统一转换，见 address.Normalize
+	NormalizeAddress(addr string) (string, error)
+
+	// SafeHeight 已达到确认要求、可以扫描的最高区块
+	SafeHeight(ctx context.Context) (uint64, error)
+	// BlockHash 返回指定高度的区块哈希，用于回滚检测
+	BlockHash(ctx context.Context, height uint64) (string, error)
+	// ScanRange 扫描 [from, to] 区间，返回需要落库的事件
+	ScanRange(ctx context.Context, from, to uint64, watched AddressFilter) ([]Event, error)
+	// ParseTransfers 从事件中解析入账转账；非转账事件返回空切片
+	ParseTransfers(ev Event) ([]Transfer, error)
+
+	// Balance 查询地址余额（最小单位），token 为 nil 时查询原生币
+	Balance(ctx context.Context, addr string, token *string) (*big.Int, error)
+	// EstimateFee 估算转账手续费，以原生币最小单位计
+	EstimateFee(ctx context.Context, req TransferRequest) (*big.Int, error)
+
+	// BuildTx 构造未签名提现交易
+	BuildTx(ctx context.Context, req TransferRequest) (*UnsignedTx, error)
+	// SignTx 用原始私钥字节签名，返回可广播的序列化交易
+	SignTx(tx *UnsignedTx, privateKey []byte) ([]byte, error)
+	// Broadcast 广播已签名交易，返回交易哈希；交易已过期未上链时返回 ErrTxExpired
+	Broadcast(ctx context.Context, signed []byte) (string, error)
+	// Confirmations 返回交易当前确认数，未上链返回 0；已上链但执行失败或已过期未上链返回 ErrTxFailed
+	Confirmations(ctx context.Context, txHash string) (uint64, error)
+}
+
+// SignedVerifier 可选能力：校验签名交易与未签名交易内容一致且由 From 签名，
+// 用于离线签名结果导入时的校验
+type SignedVerifier interface {
+	VerifySigned(tx *UnsignedTx, signed []byte) error
+}
+
+// SweepBuilder 可选能力：构造转出地址全部余额的归集交易，req.Amount 为地址余额。
+// 转账金额与手续费取自同一笔交易的 gas 价格：原生币转出余额减去手续费，代币转出全部余额；
+// 余额不足以支付手续费时返回 ErrFeeExceedsAmount
+type SweepBuilder interface {
+	BuildSweepTx(ctx context.Context, req TransferRequest) (tx *UnsignedTx, amount *big.Int, fee *big.Int, err error)
+}
+
+// NonceReplacer 可选能力：按账户 nonce 排序交易的链（EVM）。交易不会过期，广播后长时间未上链仍可能上链，
+// 不能另发新交易重试；应以相同 nonce、更高 gas 价格构造替换交易，同一 nonce 的交易最多一笔上链
+type NonceReplacer interface {
+	// TxNonce 未签名交易的 nonce
+	TxNonce(tx *UnsignedTx) (uint64, error)
+	// MinedNonce 地址在最新区块的 nonce，小于该值的 nonce 已被上链交易占用
+	MinedNonce(ctx context.Context, addr string) (uint64, error)
+	// ReplaceSweepTx 以指定 nonce 构造归集交易（同 SweepBuilder），手续费足以替换 prevFee 的原交易
+	ReplaceSweepTx(ctx context.Context, req TransferRequest, nonce uint64, prevFee *big.Int) (tx *UnsignedTx, amount *big.Int, fee *big.Int, err error)
+}
+
+// TxHasher 可选能力：由已签名交易计算交易哈希，广播前先记录哈希，
+// 广播结果不确定时按哈希查询交易是否上链
+type TxHasher interface {
+	SignedTxHash(signed []byte) (string, error)
+}
+
+// FeeReader 可选能力：查询已上链交易实际支付的手续费（原生币最小单位）
+type FeeReader interface {
+	TxFee(ctx context.Context, txHash string) (*big.Int, error)
+}
+
+// UTXO 一个未花费输出
+type UTXO struct {
+	TxHash        string
+	Vout          uint32
+	Amount        *big.Int
+	Confirmations uint64
+}
+
+// UTXOLister 可选能力：UTXO 模型的链（如 BTC）按地址列出未花费输出，
+// 对账时以 UTXO 之和作为地址余额
+type UTXOLister interface {
+	ListUnspent(ctx context.Context, addr string) ([]UTXO, error)
+}
+
+// ==========================
+// 注册表
+// ==========================
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Chain{}
+)
+
+// Register 注册链适配器，重复注册会覆盖
+func Register(c Chain) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[c.Name()] = c
+}
+
+// Get 按名称获取链适配器
+func Get(name string) (Chain, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("chain %q not registered", name)
+	}
+	return c, nil
+}
+
+// Names 已注册的链名称（有序）
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,635 @@
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/chain"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	COIN_TYPE           = 60
+	NATIVE_TRANSFER_GAS = uint64(21000)
+	// NATIVE_TRANSFER_INDEX 原生币转账事件的 LogIndex：原生币转账不产生日志，取真实日志序号达不到的值，
+	// 与同一交易中的 ERC20 日志区分
+	NATIVE_TRANSFER_INDEX = math.MaxInt32
+)
+
+// Transfer event signature: Transfer(address,address,uint256)
+var transferEventSig = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// minimal ERC20 ABI: Transfer event for decoding, transfer() for withdrawals, balanceOf() for sweeping
+const erc20ABIJSON = `[{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"from","type":"address"},{"indexed":true,"internalType":"address","name":"to","type":"address"},{"indexed":false,"internalType":"uint256","name":"value","type":"uint256"}],"name":"Transfer","type":"event"},{"inputs":[{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"value","type":"uint256"}],"name":"transfer","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"address","name":"account","type":"address"}],"name":"balanceOf","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+// Config EVM 链参数，同一实现可用于以太坊及其兼容链
+type Config struct {
+	Name          string // 链标识，如 "ethereum"
+	ChainID       int64  // 0 表示启动时从节点查询
+	Confirmations uint64
+}
+
+// Chain EVM 链适配器
+type Chain struct {
+	cfg     Config
+	client  *ethclient.Client
+	erc     abi.ABI
+	chainID *big.Int
+	// 平台自有钱包（小写），扫块时跳过由它们发起的原生币转账
+	internal map[string]bool
+}
+
+var _ chain.Chain = (*Chain)(nil)
+
+func New(rpcURL string, cfg Config) (*Chain, error) {
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, err
+	}
+	erc, err := abi.JSON(strings.NewReader(erc20ABIJSON))
+	if err != nil {
+		return nil, err
+	}
+	c := &Chain{cfg: cfg, client: client, erc: erc}
+	if cfg.ChainID != 0 {
+		c.chainID = big.NewInt(cfg.ChainID)
+	}
+	return c, nil
+}
+
+// NewOffline 不连接节点的实例，用于地址派生、校验，以及配置了 ChainID 时的离线签名
+func NewOffline(cfg Config) *Chain {
+	erc, _ := abi.JSON(strings.NewReader(erc20ABIJSON))
+	c := &Chain{cfg: cfg, erc: erc}
+	if cfg.ChainID != 0 {
+		c.chainID = big.NewInt(cfg.ChainID)
+	}
+	return c
+}
+
+// SetInternalAddresses 登记平台自有的热/冷/gas 钱包，扫块时跳过由它们发起的原生币转账（补 gas、调拨）
+func (c *Chain) SetInternalAddresses(addrs ...string) {
+	c.internal = make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		if a != "" {
+			c.internal[strings.ToLower(a)] = true
+		}
+	}
+}
+
+func (c *Chain) Name() string     { return c.cfg.Name }
+func (c *Chain) CoinType() uint32 { return COIN_TYPE }
+
+// Client 底层 RPC 客户端，供需要 EVM 特有能力的组件使用
+func (c *Chain) Client() *ethclient.Client { return c.client }
+
+func (c *Chain) networkID(ctx context.Context) (*big.Int, error) {
+	if c.chainID != nil {
+		return c.chainID, nil
+	}
+	if c.client == nil {
+		return nil, errors.New("offline chain requires Config.ChainID")
+	}
+	id, err := c.client.NetworkID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.chainID = id
+	return id, nil
+}
+
+// ==========================
+// 地址
+// ==========================
+
+// DeriveKey 按 BIP44 路径 m/44'/60'/0'/0/index 派生充值地址私钥
+func DeriveKey(seed []byte, index uint32) (*ecdsa.PrivateKey, error) {
+	return DeriveAccountKey(seed, 0, index)
+}
+
+// AccountPath BIP44 路径 m/44'/60'/account'/0/index
+func AccountPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/0/%d", COIN_TYPE, account, index)
+}
+
+// DeriveAccountKey 按 AccountPath 派生私钥，不同用途的密钥使用不同 account 隔离
+func DeriveAccountKey(seed []byte, account, index uint32) (*ecdsa.PrivateKey, error) {
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range []uint32{
+		hdkeychain.HardenedKeyStart + 44,
+		hdkeychain.HardenedKeyStart + COIN_TYPE,
+		hdkeychain.HardenedKeyStart + account,
+		0,
+		index,
+	} {
+		if key, err = key.Derive(i); err != nil {
+			return nil, err
+		}
+	}
+	priv, err := key.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	return priv.ToECDSA(), nil
+}
+
+func (c *Chain) DeriveAddress(seed []byte, index uint32) (string, string, error) {
+	priv, err := DeriveKey(seed, index)
+	if err != nil {
+		return "", "", err
+	}
+	addr := crypto.PubkeyToAddress(priv.PublicKey)
+	return addr.Hex(), AccountPath(0, index), nil
+}
+
+func (c *Chain) ValidateAddress(addr string) error {
+	return address.ValidateEVM(addr)
+}
+
+func (c *Chain) NormalizeAddress(addr string) (string, error) {
+	return address.NormalizeEVM(addr)
+}
+
+// IsContract 地址上是否部署了合约代码
+func (c *Chain) IsContract(ctx context.Context, addr string) (bool, error) {
+	code, err := c.client.CodeAt(ctx, common.HexToAddress(addr), nil)
+	if err != nil {
+		return false, err
+	}
+	return len(code) > 0, nil
+}
+
+// ==========================
+// 扫块
+// ==========================
+
+func (c *Chain) SafeHeight(ctx context.Context) (uint64, error) {
+	header, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	latest := header.Number.Uint64()
+	if latest <= c.cfg.Confirmations {
+		return 0, nil
+	}
+	return latest - c.cfg.Confirmations, nil
+}
+
+func (c *Chain) BlockHash(ctx context.Context, height uint64) (string, error) {
+	header, err := c.client.HeaderByNumber(ctx, new(big.Int).SetUint64(height))
+	if err != nil {
+		return "", err
+	}
+	return header.Hash().Hex(), nil
+}
+
+// ScanRange 拉取区间内全部日志，由 ParseTransfers 过滤；watched 暂未用于日志的服务端过滤。
+// 原生币转账不产生日志，另由 nativeEvents 逐块检查交易的收款地址
+func (c *Chain) ScanRange(ctx context.Context, from, to uint64, watched chain.AddressFilter) ([]chain.Event, error) {
+	q := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		// Addresses: []common.Address{...}, // optional: restrict to tokens/contracts you care about
+	}
+	logs, err := c.client.FilterLogs(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]chain.Event, 0, len(logs))
+	for _, l := range logs {
+		topicsJSON, _ := json.Marshal(l.Topics)
+		events = append(events, chain.Event{
+			BlockNumber: int64(l.BlockNumber),
+			BlockHash:   l.BlockHash.Hex(),
+			TxHash:      l.TxHash.Hex(),
+			LogIndex:    int(l.Index),
+			Address:     l.Address.Hex(),
+			Topics:      string(topicsJSON),
+			Data:        l.Data,
+		})
+	}
+	native, err := c.nativeEvents(ctx, from, to, watched)
+	if err != nil {
+		return nil, err
+	}
+	return append(events, native...), nil
+}
+
+// nativeTransfer 原生币转账事件的 Data
+type nativeTransfer struct {
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+// nativeEvents 区间内直接转入地址池的原生币交易，跳过平台自有钱包发起的和执行失败的交易。
+// 合约内部调用转出的原生币（internal transaction）不体现在交易字段中，需要节点的 trace 接口，暂不入账
+func (c *Chain) nativeEvents(ctx context.Context, from, to uint64, watched chain.AddressFilter) ([]chain.Event, error) {
+	var events []chain.Event
+	for height := from; height <= to; height++ {
+		block, err := c.client.BlockByNumber(ctx, new(big.Int).SetUint64(height))
+		if err != nil {
+			return nil, fmt.Errorf("get block %d: %w", height, err)
+		}
+		for i, tx := range block.Transactions() {
+			if tx.To() == nil || tx.Value().Sign() <= 0 || !watched(tx.To().Hex()) {
+				continue
+			}
+			sender, err := c.client.TransactionSender(ctx, tx, block.Hash(), uint(i))
+			if err != nil {
+				return nil, fmt.Errorf("sender of %s: %w", tx.Hash().Hex(), err)
+			}
+			if c.internal[strings.ToLower(sender.Hex())] {
+				continue
+			}
+			// 执行失败的交易不转移 value
+			receipt, err := c.client.TransactionReceipt(ctx, tx.Hash())
+			if err != nil {
+				return nil, fmt.Errorf("receipt of %s: %w", tx.Hash().Hex(), err)
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				continue
+			}
+			data, err := json.Marshal(nativeTransfer{From: sender, To: *tx.To(), Value: (*hexutil.Big)(tx.Value())})
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, chain.Event{
+				BlockNumber: int64(height),
+				BlockHash:   block.Hash().Hex(),
+				TxHash:      tx.Hash().Hex(),
+				LogIndex:    NATIVE_TRANSFER_INDEX,
+				Data:        data,
+			})
+		}
+	}
+	return events, nil
+}
+
+// ParseTransfers decodes an ERC20 Transfer log or a native transfer found by nativeEvents; other logs yield no transfers
+func (c *Chain) ParseTransfers(ev chain.Event) ([]chain.Transfer, error) {
+	if ev.LogIndex == NATIVE_TRANSFER_INDEX {
+		var t nativeTransfer
+		if err := json.Unmarshal(ev.Data, &t); err != nil {
+			return nil, fmt.Errorf("unmarshal native transfer: %w", err)
+		}
+		if t.Value == nil {
+			return nil, nil
+		}
+		return []chain.Transfer{{
+			TxHash:      ev.TxHash,
+			LogIndex:    ev.LogIndex,
+			BlockNumber: ev.BlockNumber,
+			From:        strings.ToLower(t.From.Hex()),
+			To:          strings.ToLower(t.To.Hex()),
+			Amount:      t.Value.ToInt(),
+		}}, nil
+	}
+	var topics []common.Hash
+	if err := json.Unmarshal([]byte(ev.Topics), &topics); err != nil {
+		return nil, fmt.Errorf("unmarshal topics: %w", err)
+	}
+	// topic[0] must be transferEventSig; topics[1] = from, topics[2] = to, data = value
+	if len(topics) < 3 || topics[0] != transferEventSig {
+		return nil, nil
+	}
+	from := common.BytesToAddress(topics[1].Bytes()[12:])
+	to := common.BytesToAddress(topics[2].Bytes()[12:])
+	var out struct{ Value *big.Int }
+	if err := c.erc.UnpackIntoInterface(&out, "Transfer", ev.Data); err != nil {
+		return nil, fmt.Errorf("abi unpack err: %w", err)
+	}
+	token := ev.Address
+	return []chain.Transfer{{
+		TxHash:      ev.TxHash,
+		LogIndex:    ev.LogIndex,
+		BlockNumber: ev.BlockNumber,
+		From:        strings.ToLower(from.Hex()),
+		To:          strings.ToLower(to.Hex()),
+		Token:       &token,
+		Amount:      out.Value,
+	}}, nil
+}
+
+// ==========================
+// 余额
+// ==========================
+
+func (c *Chain) Balance(ctx context.Context, addr string, token *string) (*big.Int, error) {
+	account := common.HexToAddress(addr)
+	if token == nil {
+		return c.client.BalanceAt(ctx, account, nil)
+	}
+	contract := common.HexToAddress(*token)
+	data, err := c.erc.Pack("balanceOf", account)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("balanceOf: %w", err)
+	}
+	values, err := c.erc.Unpack("balanceOf", out)
+	if err != nil {
+		return nil, fmt.Errorf("abi unpack err: %w", err)
+	}
+	return values[0].(*big.Int), nil
+}
+
+// EstimateFee gasPrice * gas；ERC20 转账的 gas 由节点估算
+func (c *Chain) EstimateFee(ctx context.Context, req chain.TransferRequest) (*big.Int, error) {
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := c.transferGas(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas)), nil
+}
+
+func (c *Chain) estimateTokenGas(ctx context.Context, req chain.TransferRequest) (uint64, error) {
+	from := common.HexToAddress(req.From)
+	token := common.HexToAddress(*req.Token)
+	data, err := c.erc.Pack("transfer", common.HexToAddress(req.To), req.Amount)
+	if err != nil {
+		return 0, err
+	}
+	gas, err := c.client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &token, Data: data})
+	if err != nil {
+		return 0, fmt.Errorf("estimate gas: %w", err)
+	}
+	return gas, nil
+}
+
+// ==========================
+// 交易
+// ==========================
+
+// BuildTx 构造 legacy 交易；Token 为空时转原生币，否则调用 ERC20 transfer
+func (c *Chain) BuildTx(ctx context.Context, req chain.TransferRequest) (*chain.UnsignedTx, error) {
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := c.transferGas(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.transferTx(ctx, req, nil, gas, gasPrice)
+}
+
+var _ chain.SweepBuilder = (*Chain)(nil)
+
+// BuildSweepTx 归集交易：手续费按本笔交易的 gas 上限与 gas 价格计算，原生币归集转出余额减去手续费
+func (c *Chain) BuildSweepTx(ctx context.Context, req chain.TransferRequest) (*chain.UnsignedTx, *big.Int, *big.Int, error) {
+	return c.sweepTx(ctx, req, nil, nil)
+}
+
+var _ chain.NonceReplacer = (*Chain)(nil)
+
+// REPLACEMENT_PRICE_BUMP 替换交易的 gas 价格至少比原交易高出的百分比（geth 交易池要求不低于 10%）
+const REPLACEMENT_PRICE_BUMP = 20
+
+// TxNonce 未签名交易的 nonce
+func (c *Chain) TxNonce(utx *chain.UnsignedTx) (uint64, error) {
+	var tx types.Transaction
+	if err := tx.UnmarshalJSON(utx.Payload); err != nil {
+		return 0, fmt.Errorf("unmarshal unsigned tx: %w", err)
+	}
+	return tx.Nonce(), nil
+}
+
+// MinedNonce 地址在最新区块的 nonce
+func (c *Chain) MinedNonce(ctx context.Context, addr string) (uint64, error) {
+	return c.client.NonceAt(ctx, common.HexToAddress(addr), nil)
+}
+
+// ReplaceSweepTx 以指定 nonce 构造归集交易，gas 价格取当前建议价格与原交易价格上浮 REPLACEMENT_PRICE_BUMP% 的较高者；
+// 原交易价格按 prevFee 除以本笔 gas 上限折算
+func (c *Chain) ReplaceSweepTx(ctx context.Context, req chain.TransferRequest, nonce uint64, prevFee *big.Int) (*chain.UnsignedTx, *big.Int, *big.Int, error) {
+	gas, err := c.transferGas(ctx, req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	minPrice := new(big.Int).Mul(prevFee, big.NewInt(100+REPLACEMENT_PRICE_BUMP))
+	divisor := new(big.Int).Mul(new(big.Int).SetUint64(gas), big.NewInt(100))
+	minPrice.Add(minPrice, new(big.Int).Sub(divisor, big.NewInt(1))).Div(minPrice, divisor)
+	return c.sweepTx(ctx, req, &nonce, minPrice)
+}
+
+// sweepTx nonce 为 nil 时取 pending nonce；gas 价格不低于 minPrice（可为 nil）
+func (c *Chain) sweepTx(ctx context.Context, req chain.TransferRequest, nonce *uint64, minPrice *big.Int) (*chain.UnsignedTx, *big.Int, *big.Int, error) {
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if minPrice != nil && gasPrice.Cmp(minPrice) < 0 {
+		gasPrice = minPrice
+	}
+	gas, err := c.transferGas(ctx, req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas))
+	amount := new(big.Int).Set(req.Amount)
+	if req.Token == nil {
+		if amount.Sub(amount, fee).Sign() <= 0 {
+			return nil, nil, fee, fmt.Errorf("%w: balance %s, fee %s", chain.ErrFeeExceedsAmount, req.Amount, fee)
+		}
+	}
+	req.Amount = amount
+	utx, err := c.transferTx(ctx, req, nonce, gas, gasPrice)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return utx, amount, fee, nil
+}
+
+func (c *Chain) transferGas(ctx context.Context, req chain.TransferRequest) (uint64, error) {
+	if req.Token == nil {
+		return NATIVE_TRANSFER_GAS, nil
+	}
+	return c.estimateTokenGas(ctx, req)
+}
+
+// transferTx fixedNonce 为 nil 时取 pending nonce
+func (c *Chain) transferTx(ctx context.Context, req chain.TransferRequest, fixedNonce *uint64, gas uint64, gasPrice *big.Int) (*chain.UnsignedTx, error) {
+	from := common.HexToAddress(req.From)
+	to := common.HexToAddress(req.To)
+
+	var nonce uint64
+	if fixedNonce != nil {
+		nonce = *fixedNonce
+	} else {
+		pending, err := c.client.PendingNonceAt(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		nonce = pending
+	}
+
+	var tx *types.Transaction
+	if req.Token == nil {
+		tx = types.NewTransaction(nonce, to, req.Amount, gas, gasPrice, nil)
+	} else {
+		token := common.HexToAddress(*req.Token)
+		data, err := c.erc.Pack("transfer", to, req.Amount)
+		if err != nil {
+			return nil, err
+		}
+		tx = types.NewTransaction(nonce, token, big.NewInt(0), gas, gasPrice, data)
+	}
+
+	payload, err := tx.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return &chain.UnsignedTx{Chain: c.Name(), From: from.Hex(), Payload: payload}, nil
+}
+
+// SignTx 签名普通交易；Payload 为 Safe 交易时返回 owner 对 SafeTxHash 的签名
+func (c *Chain) SignTx(utx *chain.UnsignedTx, privateKey []byte) ([]byte, error) {
+	key, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	if safeTx, ok := DecodeSafeTx(utx.Payload); ok {
+		return SignSafeTx(safeTx, key)
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalJSON(utx.Payload); err != nil {
+		return nil, fmt.Errorf("unmarshal unsigned tx: %w", err)
+	}
+	chainID, err := c.networkID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	signed, err := types.SignTx(&tx, types.LatestSignerForChainID(chainID), key)
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+var _ chain.SignedVerifier = (*Chain)(nil)
+
+// VerifySigned 签名交易的签名哈希须与未签名交易一致，且签名者为 From；
+// Safe 交易则校验 owner 签名恢复出的地址为 From
+func (c *Chain) VerifySigned(utx *chain.UnsignedTx, signed []byte) error {
+	if safeTx, ok := DecodeSafeTx(utx.Payload); ok {
+		signer, err := RecoverSafeSigner(safeTx, signed)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(signer.Hex(), utx.From) {
+			return fmt.Errorf("safe tx signed by %s, expected %s", signer.Hex(), utx.From)
+		}
+		return nil
+	}
+	var unsigned, tx types.Transaction
+	if err := unsigned.UnmarshalJSON(utx.Payload); err != nil {
+		return fmt.Errorf("unmarshal unsigned tx: %w", err)
+	}
+	if err := tx.UnmarshalBinary(signed); err != nil {
+		return fmt.Errorf("decode signed tx: %w", err)
+	}
+	chainID, err := c.networkID(context.Background())
+	if err != nil {
+		return err
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(&unsigned) != signer.Hash(&tx) {
+		return errors.New("signed tx does not match unsigned tx")
+	}
+	sender, err := types.Sender(signer, &tx)
+	if err != nil {
+		return fmt.Errorf("recover sender: %w", err)
+	}
+	if !strings.EqualFold(sender.Hex(), utx.From) {
+		return fmt.Errorf("signed by %s, expected %s", sender.Hex(), utx.From)
+	}
+	return nil
+}
+
+var _ chain.TxHasher = (*Chain)(nil)
+
+func (c *Chain) SignedTxHash(signed []byte) (string, error) {
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(signed); err != nil {
+		return "", fmt.Errorf("decode signed tx: %w", err)
+	}
+	return tx.Hash().Hex(), nil
+}
+
+func (c *Chain) Broadcast(ctx context.Context, signed []byte) (string, error) {
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(signed); err != nil {
+		return "", fmt.Errorf("decode signed tx: %w", err)
+	}
+	if err := c.client.SendTransaction(ctx, &tx); err != nil {
+		return "", err
+	}
+	return tx.Hash().Hex(), nil
+}
+
+func (c *Chain) Confirmations(ctx context.Context, txHash string) (uint64, error) {
+	receipt, err := c.client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return 0, fmt.Errorf("%w: %s reverted", chain.ErrTxFailed, txHash)
+	}
+	header, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if header.Number.Cmp(receipt.BlockNumber) < 0 {
+		return 0, nil
+	}
+	return new(big.Int).Sub(header.Number, receipt.BlockNumber).Uint64() + 1, nil
+}
+
+var _ chain.FeeReader = (*Chain)(nil)
+
+// TxFee 交易实际手续费：gasUsed * effectiveGasPrice
+func (c *Chain) TxFee(ctx context.Context, txHash string) (*big.Int, error) {
+	hash := common.HexToHash(txHash)
+	receipt, err := c.client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	price := receipt.EffectiveGasPrice
+	if price == nil {
+		// 不返回 effectiveGasPrice 的旧节点，legacy 交易按交易自身的 gas 价格
+		tx, _, err := c.client.TransactionByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		price = tx.GasPrice()
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), price), nil
+}
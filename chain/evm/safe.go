@@ -0,0 +1,233 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/crypto_custody/chain"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ==========================
+// Safe 多签冷钱包
+// ==========================
+// 冷钱包为 Safe (Gnosis Safe v1.3+) 合约时，每个 owner 对 EIP-712 SafeTxHash 签名，
+// 签名数达到阈值后由热钱包账户调用 execTransaction 上链，gas 由热钱包支付。
+
+// Safe 操作类型
+const (
+	SAFE_OPERATION_CALL         = uint8(0)
+	SAFE_OPERATION_DELEGATECALL = uint8(1)
+)
+
+var (
+	// keccak256("EIP712Domain(uint256 chainId,address verifyingContract)")
+	safeDomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	// keccak256("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)")
+	safeTxTypeHash = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+)
+
+// minimal Safe ABI: nonce() / getThreshold() / getOwners() for proposing, execTransaction() for execution
+const safeABIJSON = `[{"inputs":[],"name":"nonce","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"getThreshold","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"getOwners","outputs":[{"internalType":"address[]","name":"","type":"address[]"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"value","type":"uint256"},{"internalType":"bytes","name":"data","type":"bytes"},{"internalType":"uint8","name":"operation","type":"uint8"},{"internalType":"uint256","name":"safeTxGas","type":"uint256"},{"internalType":"uint256","name":"baseGas","type":"uint256"},{"internalType":"uint256","name":"gasPrice","type":"uint256"},{"internalType":"address","name":"gasToken","type":"address"},{"internalType":"address payable","name":"refundReceiver","type":"address"},{"internalType":"bytes","name":"signatures","type":"bytes"}],"name":"execTransaction","outputs":[{"internalType":"bool","name":"success","type":"bool"}],"stateMutability":"payable","type":"function"}]`
+
+var safeABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(safeABIJSON))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// SafeTx 一笔待多签的 Safe 交易，字段与合约 SafeTx 结构一致；
+// ChainID 与 Safe 参与 EIP-712 domain，签名只对该链上的该合约有效
+type SafeTx struct {
+	ChainID        *big.Int       `json:"chainId"`
+	Safe           common.Address `json:"safe"`
+	To             common.Address `json:"to"`
+	Value          *big.Int       `json:"value"`
+	Data           hexutil.Bytes  `json:"data"`
+	Operation      uint8          `json:"operation"`
+	SafeTxGas      *big.Int       `json:"safeTxGas"`
+	BaseGas        *big.Int       `json:"baseGas"`
+	GasPrice       *big.Int       `json:"gasPrice"`
+	GasToken       common.Address `json:"gasToken"`
+	RefundReceiver common.Address `json:"refundReceiver"`
+	Nonce          *big.Int       `json:"nonce"`
+}
+
+// Hash EIP-712 SafeTxHash，即各 owner 签名的摘要
+func (tx *SafeTx) Hash() common.Hash {
+	domain := crypto.Keccak256(
+		safeDomainTypeHash.Bytes(),
+		common.LeftPadBytes(tx.ChainID.Bytes(), 32),
+		common.LeftPadBytes(tx.Safe.Bytes(), 32),
+	)
+	structHash := crypto.Keccak256(
+		safeTxTypeHash.Bytes(),
+		common.LeftPadBytes(tx.To.Bytes(), 32),
+		common.LeftPadBytes(tx.Value.Bytes(), 32),
+		crypto.Keccak256(tx.Data),
+		common.LeftPadBytes([]byte{tx.Operation}, 32),
+		common.LeftPadBytes(tx.SafeTxGas.Bytes(), 32),
+		common.LeftPadBytes(tx.BaseGas.Bytes(), 32),
+		common.LeftPadBytes(tx.GasPrice.Bytes(), 32),
+		common.LeftPadBytes(tx.GasToken.Bytes(), 32),
+		common.LeftPadBytes(tx.RefundReceiver.Bytes(), 32),
+		common.LeftPadBytes(tx.Nonce.Bytes(), 32),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain, structHash)
+}
+
+// DecodeSafeTx 解析 SignRequest 中的 Safe 交易；普通交易返回 ok=false
+func DecodeSafeTx(payload []byte) (*SafeTx, bool) {
+	var tx SafeTx
+	if err := json.Unmarshal(payload, &tx); err != nil || tx.Safe == (common.Address{}) || tx.ChainID == nil {
+		return nil, false
+	}
+	for _, v := range []**big.Int{&tx.Value, &tx.SafeTxGas, &tx.BaseGas, &tx.GasPrice, &tx.Nonce} {
+		if *v == nil {
+			*v = new(big.Int)
+		}
+	}
+	return &tx, true
+}
+
+// SignSafeTx owner 对 SafeTxHash 直接签名（Safe 签名类型 v = 27/28）
+func SignSafeTx(tx *SafeTx, key *ecdsa.PrivateKey) ([]byte, error) {
+	sig, err := crypto.Sign(tx.Hash().Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// RecoverSafeSigner 从 owner 签名恢复签名地址
+func RecoverSafeSigner(tx *SafeTx, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength || (sig[64] != 27 && sig[64] != 28) {
+		return common.Address{}, errors.New("invalid safe signature")
+	}
+	raw := make([]byte, len(sig))
+	copy(raw, sig)
+	raw[64] -= 27
+	pub, err := crypto.SigToPub(tx.Hash().Bytes(), raw)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// PackSafeSignatures 按 owner 地址升序拼接签名，这是 Safe 合约校验要求的顺序
+func PackSafeSignatures(sigs map[common.Address][]byte) []byte {
+	owners := make([]common.Address, 0, len(sigs))
+	for owner := range sigs {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool { return bytes.Compare(owners[i].Bytes(), owners[j].Bytes()) < 0 })
+	var packed []byte
+	for _, owner := range owners {
+		packed = append(packed, sigs[owner]...)
+	}
+	return packed
+}
+
+// SafeInfo 链上读取 Safe 的 owner 列表、阈值和当前 nonce
+func (c *Chain) SafeInfo(ctx context.Context, safe string) ([]common.Address, uint64, *big.Int, error) {
+	addr := common.HexToAddress(safe)
+	call := func(method string) ([]interface{}, error) {
+		data, err := safeABI.Pack(method)
+		if err != nil {
+			return nil, err
+		}
+		out, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &addr, Data: data}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", method, err)
+		}
+		return safeABI.Unpack(method, out)
+	}
+	owners, err := call("getOwners")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	threshold, err := call("getThreshold")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	nonce, err := call("nonce")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return owners[0].([]common.Address), threshold[0].(*big.Int).Uint64(), nonce[0].(*big.Int), nil
+}
+
+// BuildSafeTx 构造从 Safe 转出的交易：原生币直接转账，ERC20 调用 transfer；
+// 不使用 Safe 内置的 gas 退款，safeTxGas/baseGas/gasPrice 均为 0
+func (c *Chain) BuildSafeTx(ctx context.Context, req chain.TransferRequest, nonce *big.Int) (*SafeTx, error) {
+	chainID, err := c.networkID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx := &SafeTx{
+		ChainID:   chainID,
+		Safe:      common.HexToAddress(req.From),
+		To:        common.HexToAddress(req.To),
+		Value:     req.Amount,
+		Operation: SAFE_OPERATION_CALL,
+		SafeTxGas: new(big.Int),
+		BaseGas:   new(big.Int),
+		GasPrice:  new(big.Int),
+		Nonce:     nonce,
+	}
+	if req.Token != nil {
+		data, err := c.erc.Pack("transfer", common.HexToAddress(req.To), req.Amount)
+		if err != nil {
+			return nil, err
+		}
+		tx.To = common.HexToAddress(*req.Token)
+		tx.Value = new(big.Int)
+		tx.Data = data
+	}
+	return tx, nil
+}
+
+// BuildExecTx 构造由 executor 发起的 execTransaction 调用，signatures 须已达到阈值
+func (c *Chain) BuildExecTx(ctx context.Context, tx *SafeTx, signatures []byte, executor string) (*chain.UnsignedTx, error) {
+	data, err := safeABI.Pack("execTransaction",
+		tx.To, tx.Value, []byte(tx.Data), tx.Operation,
+		tx.SafeTxGas, tx.BaseGas, tx.GasPrice, tx.GasToken, tx.RefundReceiver, signatures)
+	if err != nil {
+		return nil, err
+	}
+	from := common.HexToAddress(executor)
+	nonce, err := c.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 估算会执行签名校验，签名不足或错误时在此失败而不是上链后 revert
+	gas, err := c.client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &tx.Safe, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("estimate execTransaction: %w", err)
+	}
+	ethTx := types.NewTransaction(nonce, tx.Safe, big.NewInt(0), gas, gasPrice, data)
+	payload, err := ethTx.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return &chain.UnsignedTx{Chain: c.Name(), From: from.Hex(), Payload: payload}, nil
+}
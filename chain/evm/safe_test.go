@@ -0,0 +1,369 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/crypto_custody/chain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+const TEST_CHAIN_ID = 11155111
+
+var (
+	testSafe  = common.HexToAddress("0x5afe5afe5afe5afe5afe5afe5afe5afe5afe5afe")
+	testHot   = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	testToken = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+)
+
+func testKeys(t *testing.T, n int) []*ecdsa.PrivateKey {
+	t.Helper()
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		key, err := crypto.ToECDSA(crypto.Keccak256([]byte{byte(i + 1)}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func testSafeTx(t *testing.T, token *string) *SafeTx {
+	t.Helper()
+	c := NewOffline(Config{Name: "ethereum", ChainID: TEST_CHAIN_ID})
+	tx, err := c.BuildSafeTx(context.Background(), chain.TransferRequest{
+		From:   testSafe.Hex(),
+		To:     testHot.Hex(),
+		Token:  token,
+		Amount: big.NewInt(1_500_000),
+	}, big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// SafeTxHash 与 go-ethereum 的通用 EIP-712 实现逐字段对照
+func TestSafeTxHashMatchesEIP712(t *testing.T) {
+	tests := []struct {
+		name  string
+		token *string
+	}{
+		{"native", nil},
+		{"erc20", &testToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := testSafeTx(t, tt.token)
+			typed := apitypes.TypedData{
+				Types: apitypes.Types{
+					"EIP712Domain": {
+						{Name: "chainId", Type: "uint256"},
+						{Name: "verifyingContract", Type: "address"},
+					},
+					"SafeTx": {
+						{Name: "to", Type: "address"},
+						{Name: "value", Type: "uint256"},
+						{Name: "data", Type: "bytes"},
+						{Name: "operation", Type: "uint8"},
+						{Name: "safeTxGas", Type: "uint256"},
+						{Name: "baseGas", Type: "uint256"},
+						{Name: "gasPrice", Type: "uint256"},
+						{Name: "gasToken", Type: "address"},
+						{Name: "refundReceiver", Type: "address"},
+						{Name: "nonce", Type: "uint256"},
+					},
+				},
+				PrimaryType: "SafeTx",
+				Domain: apitypes.TypedDataDomain{
+					ChainId:           math.NewHexOrDecimal256(TEST_CHAIN_ID),
+					VerifyingContract: testSafe.Hex(),
+				},
+				Message: apitypes.TypedDataMessage{
+					"to":             tx.To.Hex(),
+					"value":          tx.Value.String(),
+					"data":           hexutil.Encode(tx.Data),
+					"operation":      "0",
+					"safeTxGas":      "0",
+					"baseGas":        "0",
+					"gasPrice":       "0",
+					"gasToken":       common.Address{}.Hex(),
+					"refundReceiver": common.Address{}.Hex(),
+					"nonce":          "7",
+				},
+			}
+			want, _, err := apitypes.TypedDataAndHash(typed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tx.Hash(); !bytes.Equal(got.Bytes(), want) {
+				t.Fatalf("SafeTxHash = %s, want %x", got.Hex(), want)
+			}
+		})
+	}
+}
+
+func TestSafeTxHashBindsDomainAndNonce(t *testing.T) {
+	base := testSafeTx(t, nil)
+	otherChain := *base
+	otherChain.ChainID = big.NewInt(1)
+	otherSafe := *base
+	otherSafe.Safe = common.HexToAddress("0x5afe000000000000000000000000000000000001")
+	otherNonce := *base
+	otherNonce.Nonce = big.NewInt(8)
+	for name, tx := range map[string]*SafeTx{"chain id": &otherChain, "safe": &otherSafe, "nonce": &otherNonce} {
+		if tx.Hash() == base.Hash() {
+			t.Fatalf("changing %s kept the same SafeTxHash", name)
+		}
+	}
+}
+
+func TestPackSafeSignaturesOrdersByOwner(t *testing.T) {
+	tx := testSafeTx(t, nil)
+	keys := testKeys(t, 3)
+	sigs := map[common.Address][]byte{}
+	for _, key := range keys {
+		sig, err := SignSafeTx(tx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := sig[64]; v != 27 && v != 28 {
+			t.Fatalf("v = %d, want 27 or 28", v)
+		}
+		sigs[crypto.PubkeyToAddress(key.PublicKey)] = sig
+	}
+
+	packed := PackSafeSignatures(sigs)
+	if len(packed) != len(keys)*crypto.SignatureLength {
+		t.Fatalf("packed %d bytes, want %d", len(packed), len(keys)*crypto.SignatureLength)
+	}
+	var last common.Address
+	for i := 0; i < len(keys); i++ {
+		sig := packed[i*crypto.SignatureLength : (i+1)*crypto.SignatureLength]
+		owner, err := RecoverSafeSigner(tx, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := sigs[owner]; !ok {
+			t.Fatalf("signature %d recovers unknown owner %s", i, owner.Hex())
+		}
+		if bytes.Compare(owner.Bytes(), last.Bytes()) <= 0 {
+			t.Fatalf("signature %d from %s is not above %s", i, owner.Hex(), last.Hex())
+		}
+		last = owner
+	}
+
+	// 签名只对原交易有效
+	other := *tx
+	other.Nonce = big.NewInt(8)
+	owner, err := RecoverSafeSigner(&other, packed[:crypto.SignatureLength])
+	if err == nil {
+		if _, ok := sigs[owner]; ok {
+			t.Fatal("signature recovered an owner for a different SafeTx")
+		}
+	}
+}
+
+// fakeSafeNode 进程内 JSON-RPC 节点，按 Safe 合约 checkNSignatures 的规则校验 execTransaction
+type fakeSafeNode struct {
+	chainID   *big.Int
+	owners    []common.Address
+	threshold uint64
+	nonce     *big.Int
+	txCount   uint64
+}
+
+type fakeCallArgs struct {
+	From  *common.Address `json:"from"`
+	To    *common.Address `json:"to"`
+	Input hexutil.Bytes   `json:"input"`
+}
+
+func (n *fakeSafeNode) ChainId() *hexutil.Big  { return (*hexutil.Big)(n.chainID) }
+func (n *fakeSafeNode) GasPrice() *hexutil.Big { return (*hexutil.Big)(big.NewInt(2_000_000_000)) }
+
+func (n *fakeSafeNode) GetTransactionCount(addr common.Address, block string) hexutil.Uint64 {
+	return hexutil.Uint64(n.txCount)
+}
+
+func (n *fakeSafeNode) Call(args fakeCallArgs, block string) (hexutil.Bytes, error) {
+	if args.To == nil || *args.To != testSafe || len(args.Input) < 4 {
+		return nil, errors.New("unexpected call")
+	}
+	method, err := safeABI.MethodById(args.Input[:4])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "getOwners":
+		return method.Outputs.Pack(n.owners)
+	case "getThreshold":
+		return method.Outputs.Pack(new(big.Int).SetUint64(n.threshold))
+	case "nonce":
+		return method.Outputs.Pack(n.nonce)
+	}
+	return nil, errors.New("unexpected method " + method.Name)
+}
+
+func (n *fakeSafeNode) EstimateGas(args fakeCallArgs, block *string) (hexutil.Uint64, error) {
+	if args.To == nil || *args.To != testSafe || len(args.Input) < 4 {
+		return 0, errors.New("unexpected call")
+	}
+	method, err := safeABI.MethodById(args.Input[:4])
+	if err != nil || method.Name != "execTransaction" {
+		return 0, errors.New("unexpected method")
+	}
+	v, err := method.Inputs.Unpack(args.Input[4:])
+	if err != nil {
+		return 0, err
+	}
+	tx := &SafeTx{
+		ChainID:        n.chainID,
+		Safe:           testSafe,
+		To:             v[0].(common.Address),
+		Value:          v[1].(*big.Int),
+		Data:           v[2].([]byte),
+		Operation:      v[3].(uint8),
+		SafeTxGas:      v[4].(*big.Int),
+		BaseGas:        v[5].(*big.Int),
+		GasPrice:       v[6].(*big.Int),
+		GasToken:       v[7].(common.Address),
+		RefundReceiver: v[8].(common.Address),
+		Nonce:          n.nonce,
+	}
+	sigs := v[9].([]byte)
+	if uint64(len(sigs)) < n.threshold*crypto.SignatureLength {
+		return 0, errors.New("execution reverted: GS020")
+	}
+	var last common.Address
+	for i := uint64(0); i < n.threshold; i++ {
+		owner, err := RecoverSafeSigner(tx, sigs[i*crypto.SignatureLength:(i+1)*crypto.SignatureLength])
+		if err != nil {
+			return 0, errors.New("execution reverted: GS026")
+		}
+		known := false
+		for _, o := range n.owners {
+			known = known || o == owner
+		}
+		if !known || bytes.Compare(owner.Bytes(), last.Bytes()) <= 0 {
+			return 0, errors.New("execution reverted: GS026")
+		}
+		last = owner
+	}
+	return 90_000, nil
+}
+
+func newFakeSafeChain(t *testing.T, node *fakeSafeNode) *Chain {
+	t.Helper()
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	client := ethclient.NewClient(rpc.DialInProc(srv))
+	t.Cleanup(func() {
+		client.Close()
+		srv.Stop()
+	})
+	c := NewOffline(Config{Name: "ethereum", ChainID: node.chainID.Int64()})
+	c.client = client
+	return c
+}
+
+func TestBuildExecTx(t *testing.T) {
+	keys := testKeys(t, 3)
+	owners := make([]common.Address, len(keys))
+	for i, key := range keys {
+		owners[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	node := &fakeSafeNode{chainID: big.NewInt(TEST_CHAIN_ID), owners: owners, threshold: 2, nonce: big.NewInt(7), txCount: 3}
+	c := newFakeSafeChain(t, node)
+	ctx := context.Background()
+
+	gotOwners, threshold, nonce, err := c.SafeInfo(ctx, testSafe.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotOwners) != 3 || threshold != 2 || nonce.Cmp(node.nonce) != 0 {
+		t.Fatalf("SafeInfo = %d owners, threshold %d, nonce %s", len(gotOwners), threshold, nonce)
+	}
+	tx, err := c.BuildSafeTx(ctx, chain.TransferRequest{From: testSafe.Hex(), To: testHot.Hex(), Token: &testToken, Amount: big.NewInt(1_500_000)}, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(key *ecdsa.PrivateKey) []byte {
+		sig, err := SignSafeTx(tx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	// 签名按 owner 地址降序拼接，合约会拒绝
+	descending := func(keys ...*ecdsa.PrivateKey) []byte {
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) > 0
+		})
+		var out []byte
+		for _, key := range keys {
+			out = append(out, sign(key)...)
+		}
+		return out
+	}
+	stranger := testKeys(t, 4)[3]
+
+	tests := []struct {
+		name       string
+		signatures []byte
+		ok         bool
+	}{
+		{"threshold met", PackSafeSignatures(map[common.Address][]byte{owners[0]: sign(keys[0]), owners[2]: sign(keys[2])}), true},
+		{"all owners", PackSafeSignatures(map[common.Address][]byte{owners[0]: sign(keys[0]), owners[1]: sign(keys[1]), owners[2]: sign(keys[2])}), true},
+		{"below threshold", PackSafeSignatures(map[common.Address][]byte{owners[1]: sign(keys[1])}), false},
+		{"descending order", descending(keys[0], keys[1]), false},
+		{"non-owner", PackSafeSignatures(map[common.Address][]byte{owners[0]: sign(keys[0]), crypto.PubkeyToAddress(stranger.PublicKey): sign(stranger)}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utx, err := c.BuildExecTx(ctx, tx, tt.signatures, testHot.Hex())
+			if !tt.ok {
+				if err == nil || !strings.Contains(err.Error(), "execution reverted") {
+					t.Fatalf("err = %v, want execTransaction estimate to revert", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ethTx types.Transaction
+			if err := ethTx.UnmarshalJSON(utx.Payload); err != nil {
+				t.Fatal(err)
+			}
+			if *ethTx.To() != testSafe || ethTx.Nonce() != node.txCount || ethTx.Gas() != 90_000 || ethTx.Value().Sign() != 0 {
+				t.Fatalf("exec tx to=%s nonce=%d gas=%d value=%s", ethTx.To().Hex(), ethTx.Nonce(), ethTx.Gas(), ethTx.Value())
+			}
+			if utx.From != testHot.Hex() {
+				t.Fatalf("from = %s, want executor %s", utx.From, testHot.Hex())
+			}
+			v, err := safeABI.Methods["execTransaction"].Inputs.Unpack(ethTx.Data()[4:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v[0].(common.Address) != common.HexToAddress(testToken) || !bytes.Equal(v[2].([]byte), tx.Data) || !bytes.Equal(v[9].([]byte), tt.signatures) {
+				t.Fatal("execTransaction arguments do not match the SafeTx")
+			}
+		})
+	}
+}
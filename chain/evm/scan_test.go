@@ -0,0 +1,180 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/crypto_custody/chain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeScanNode 进程内 JSON-RPC 节点：blocks 为各高度的交易及发送方，reverted 为执行失败的交易
+type fakeScanNode struct {
+	blocks   map[uint64][]scanTx
+	reverted map[common.Hash]bool
+}
+
+type scanTx struct {
+	tx   *types.Transaction
+	from common.Address
+}
+
+func (n *fakeScanNode) GetLogs(crit map[string]interface{}) ([]types.Log, error) {
+	return []types.Log{}, nil
+}
+
+func (n *fakeScanNode) GetBlockByNumber(number hexutil.Uint64, full bool) (json.RawMessage, error) {
+	txs := n.blocks[uint64(number)]
+	header := &types.Header{
+		Number:     new(big.Int).SetUint64(uint64(number)),
+		Difficulty: new(big.Int),
+		UncleHash:  types.EmptyUncleHash,
+		TxHash:     types.EmptyTxsHash,
+	}
+	if len(txs) > 0 {
+		header.TxHash = common.Hash{byte(number)}
+	}
+	block, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(block, &fields); err != nil {
+		return nil, err
+	}
+	list := make([]map[string]interface{}, len(txs))
+	for i, st := range txs {
+		raw, err := st.tx.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &list[i]); err != nil {
+			return nil, err
+		}
+		list[i]["from"] = st.from
+		list[i]["blockHash"] = header.Hash()
+		list[i]["blockNumber"] = hexutil.Uint64(number)
+		list[i]["transactionIndex"] = hexutil.Uint64(i)
+	}
+	fields["transactions"] = list
+	return json.Marshal(fields)
+}
+
+func (n *fakeScanNode) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: hash, Logs: []*types.Log{}, GasUsed: NATIVE_TRANSFER_GAS}
+	if n.reverted[hash] {
+		receipt.Status = types.ReceiptStatusFailed
+	}
+	return receipt, nil
+}
+
+func newFakeScanChain(t *testing.T, node *fakeScanNode) *Chain {
+	t.Helper()
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	client := ethclient.NewClient(rpc.DialInProc(srv))
+	t.Cleanup(func() {
+		client.Close()
+		srv.Stop()
+	})
+	c := NewOffline(Config{Name: "ethereum", ChainID: TEST_CHAIN_ID})
+	c.client = client
+	return c
+}
+
+// 原生币充值：只记录外部地址直接转入地址池且执行成功的交易
+func TestScanRangeNativeTransfers(t *testing.T) {
+	keys := testKeys(t, 2)
+	user := crypto.PubkeyToAddress(keys[0].PublicKey)
+	gasWallet := crypto.PubkeyToAddress(keys[1].PublicKey)
+	deposit := common.HexToAddress("0x00000000000000000000000000000000000000d1")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000e1")
+	signer := types.LatestSignerForChainID(big.NewInt(TEST_CHAIN_ID))
+	transfer := func(key int, nonce uint64, to common.Address, value int64) scanTx {
+		tx, err := types.SignNewTx(keys[key], signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Value:    big.NewInt(value),
+			Gas:      NATIVE_TRANSFER_GAS,
+			GasPrice: big.NewInt(1),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return scanTx{tx: tx, from: crypto.PubkeyToAddress(keys[key].PublicKey)}
+	}
+
+	credited := transfer(0, 0, deposit, 5_000)
+	reverted := transfer(0, 1, deposit, 6_000)
+	node := &fakeScanNode{
+		blocks: map[uint64][]scanTx{
+			10: {
+				credited,
+				transfer(1, 0, deposit, 7_000), // gas 钱包补 gas
+				reverted,
+				transfer(0, 2, other, 8_000), // 非地址池地址
+				transfer(0, 3, deposit, 0),
+			},
+		},
+		reverted: map[common.Hash]bool{reverted.tx.Hash(): true},
+	}
+	c := newFakeScanChain(t, node)
+	c.SetInternalAddresses(gasWallet.Hex())
+	watched := func(addr string) bool { return strings.EqualFold(addr, deposit.Hex()) }
+
+	events, err := c.ScanRange(context.Background(), 9, 11, watched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].TxHash != credited.tx.Hash().Hex() || events[0].BlockNumber != 10 || events[0].LogIndex != NATIVE_TRANSFER_INDEX {
+		t.Fatalf("events = %+v, want only the external transfer %s", events, credited.tx.Hash().Hex())
+	}
+	transfers, err := c.ParseTransfers(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 1 {
+		t.Fatalf("transfers = %+v, want one", transfers)
+	}
+	got := transfers[0]
+	if got.Token != nil || got.Amount.Int64() != 5_000 || got.To != strings.ToLower(deposit.Hex()) || got.From != strings.ToLower(user.Hex()) {
+		t.Fatalf("transfer = %+v, want 5000 native from %s to %s", got, user.Hex(), deposit.Hex())
+	}
+}
+
+// ERC20 日志仍按 Transfer 事件解析，其他日志忽略
+func TestParseTransfersERC20(t *testing.T) {
+	c := NewOffline(Config{Name: "ethereum", ChainID: TEST_CHAIN_ID})
+	from := common.HexToAddress("0x00000000000000000000000000000000000000f1")
+	to := common.HexToAddress("0x00000000000000000000000000000000000000d1")
+	data, err := c.erc.Events["Transfer"].Inputs.NonIndexed().Pack(big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	topics, _ := json.Marshal([]common.Hash{transferEventSig, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())})
+	transfers, err := c.ParseTransfers(chain.Event{TxHash: "0x01", LogIndex: 3, Address: testToken, Topics: string(topics), Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 1 || transfers[0].Token == nil || *transfers[0].Token != testToken || transfers[0].Amount.Int64() != 42 || transfers[0].LogIndex != 3 {
+		t.Fatalf("transfers = %+v, want 42 of %s", transfers, testToken)
+	}
+
+	approval, _ := json.Marshal([]common.Hash{crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))})
+	if transfers, err := c.ParseTransfers(chain.Event{Topics: string(approval)}); err != nil || len(transfers) != 0 {
+		t.Fatalf("approval log = (%v, %v), want no transfers", transfers, err)
+	}
+	if _, err := c.ParseTransfers(chain.Event{LogIndex: NATIVE_TRANSFER_INDEX, Data: []byte("x")}); err == nil {
+		t.Fatal("malformed native transfer accepted")
+	}
+}
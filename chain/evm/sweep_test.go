@@ -0,0 +1,100 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/crypto_custody/chain"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 原生币归集：转出金额为余额减去按同一 gas 价格计算的手续费，交易花费恰好等于余额
+func TestBuildSweepTxNative(t *testing.T) {
+	node := &fakeSafeNode{chainID: big.NewInt(TEST_CHAIN_ID), txCount: 5}
+	c := newFakeSafeChain(t, node)
+	gasPrice := node.GasPrice().ToInt()
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(NATIVE_TRANSFER_GAS))
+
+	tests := []struct {
+		name    string
+		balance *big.Int
+		amount  *big.Int
+		err     error
+	}{
+		{"above fee", new(big.Int).Add(fee, big.NewInt(1_000)), big.NewInt(1_000), nil},
+		{"equal to fee", new(big.Int).Set(fee), nil, chain.ErrFeeExceedsAmount},
+		{"below fee", big.NewInt(1), nil, chain.ErrFeeExceedsAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utx, amount, gotFee, err := c.BuildSweepTx(context.Background(), chain.TransferRequest{
+				From:   testHot.Hex(),
+				To:     testSafe.Hex(),
+				Amount: new(big.Int).Set(tt.balance),
+			})
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if amount.Cmp(tt.amount) != 0 || gotFee.Cmp(fee) != 0 {
+				t.Fatalf("amount = %s fee = %s, want %s and %s", amount, gotFee, tt.amount, fee)
+			}
+			var tx types.Transaction
+			if err := tx.UnmarshalJSON(utx.Payload); err != nil {
+				t.Fatal(err)
+			}
+			if tx.Cost().Cmp(tt.balance) != 0 || tx.GasPrice().Cmp(gasPrice) != 0 || tx.Nonce() != node.txCount {
+				t.Fatalf("tx cost = %s gas price = %s nonce = %d, want cost %s", tx.Cost(), tx.GasPrice(), tx.Nonce(), tt.balance)
+			}
+		})
+	}
+}
+
+// 替换交易沿用原 nonce，gas 价格取建议价格与原交易价格上浮 REPLACEMENT_PRICE_BUMP% 的较高者
+func TestReplaceSweepTx(t *testing.T) {
+	node := &fakeSafeNode{chainID: big.NewInt(TEST_CHAIN_ID), txCount: 5}
+	c := newFakeSafeChain(t, node)
+	suggested := node.GasPrice().ToInt()
+	gas := new(big.Int).SetUint64(NATIVE_TRANSFER_GAS)
+	balance := big.NewInt(1_000_000_000_000_000)
+
+	tests := []struct {
+		name     string
+		prevFee  *big.Int
+		gasPrice *big.Int
+	}{
+		{"bumped", new(big.Int).Mul(suggested, gas), new(big.Int).Div(new(big.Int).Mul(suggested, big.NewInt(100+REPLACEMENT_PRICE_BUMP)), big.NewInt(100))},
+		{"suggested above bump", gas, suggested},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utx, amount, fee, err := c.ReplaceSweepTx(context.Background(), chain.TransferRequest{
+				From:   testHot.Hex(),
+				To:     testSafe.Hex(),
+				Amount: new(big.Int).Set(balance),
+			}, 3, tt.prevFee)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce, err := c.TxNonce(utx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var tx types.Transaction
+			if err := tx.UnmarshalJSON(utx.Payload); err != nil {
+				t.Fatal(err)
+			}
+			wantFee := new(big.Int).Mul(tt.gasPrice, gas)
+			if nonce != 3 || tx.GasPrice().Cmp(tt.gasPrice) != 0 || fee.Cmp(wantFee) != 0 || new(big.Int).Add(amount, fee).Cmp(balance) != 0 {
+				t.Fatalf("nonce = %d gas price = %s fee = %s amount = %s, want nonce 3 at %s", nonce, tx.GasPrice(), fee, amount, tt.gasPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/chain"
+	bin "github.com/gagliardetto/binary"
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Chain Solana 链适配器；只扫描 finalized 区块，因此不会发生回滚
+type Chain struct {
+	client   *rpc.Client
+	internal map[string]bool // 平台自有钱包，从这些地址转入的不算充值
+
+	mu          sync.Mutex
+	blockhashes map[sol.Signature]sol.Hash // 本进程广播过、尚未确认的交易所用 blockhash，用于判断是否过期
+}
+
+var _ chain.Chain = (*Chain)(nil)
+
+func New(rpcURL string) *Chain {
+	return &Chain{client: rpc.New(rpcURL), blockhashes: map[sol.Signature]sol.Hash{}}
+}
+
+// SetInternalAddresses 登记平台自有的热/冷/gas 钱包，扫块时跳过由它们发起的转账
+func (c *Chain) SetInternalAddresses(addrs ...string) {
+	c.internal = make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		if a != "" {
+			c.internal[a] = true
+		}
+	}
+}
+
+func (c *Chain) isInternal(addr string) bool {
+	return c.internal[addr]
+}
+
+func (c *Chain) Name() string     { return CHAIN }
+func (c *Chain) CoinType() uint32 { return COIN_TYPE }
+
+func (c *Chain) DeriveAddress(seed []byte, index uint32) (string, string, error) {
+	addr, err := DeriveAddress(seed, index)
+	if err != nil {
+		return "", "", err
+	}
+	return addr, DerivationPath(index), nil
+}
+
+func (c *Chain) ValidateAddress(addr string) error {
+	return address.ValidateSolana(addr)
+}
+
+func (c *Chain) NormalizeAddress(addr string) (string, error) {
+	return address.NormalizeSolana(addr)
+}
+
+func (c *Chain) SafeHeight(ctx context.Context) (uint64, error) {
+	return c.client.GetSlot(ctx, rpc.CommitmentFinalized)
+}
+
+// BlockHash 返回 slot 对应的 blockhash，跳过的 slot 返回空串
+func (c *Chain) BlockHash(ctx context.Context, height uint64) (string, error) {
+	slots, err := c.client.GetBlocks(ctx, height, &height, rpc.CommitmentFinalized)
+	if err != nil {
+		return "", err
+	}
+	if len(slots) == 0 {
+		return "", nil
+	}
+	rewards := false
+	maxVersion := rpc.MaxSupportedTransactionVersion0
+	block, err := c.client.GetBlockWithOpts(ctx, height, &rpc.GetBlockOpts{
+		TransactionDetails:             rpc.TransactionDetailsNone,
+		Rewards:                        &rewards,
+		Commitment:                     rpc.CommitmentFinalized,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return "", err
+	}
+	return block.Blockhash.String(), nil
+}
+
+// ScanRange 逐个拉取区间内已出块的 slot，只为我方地址的余额增加生成事件
+func (c *Chain) ScanRange(ctx context.Context, from, to uint64, watched chain.AddressFilter) ([]chain.Event, error) {
+	// skipped slots have no block, GetBlocks only returns produced ones
+	slots, err := c.client.GetBlocks(ctx, from, &to, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, err
+	}
+
+	maxVersion := rpc.MaxSupportedTransactionVersion0
+	rewards := false
+	var events []chain.Event
+	for _, slot := range slots {
+		block, err := c.client.GetBlockWithOpts(ctx, slot, &rpc.GetBlockOpts{
+			Encoding:                       sol.EncodingBase64,
+			TransactionDetails:             rpc.TransactionDetailsFull,
+			Rewards:                        &rewards,
+			Commitment:                     rpc.CommitmentFinalized,
+			MaxSupportedTransactionVersion: &maxVersion,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get block %d: %w", slot, err)
+		}
+		transfers, err := ExtractTransfers(slot, block, watched, c.isInternal)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range transfers {
+			data, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			program := sol.SystemProgramID.String()
+			if t.Mint != nil {
+				program = sol.TokenProgramID.String()
+			}
+			events = append(events, chain.Event{
+				BlockNumber: int64(slot),
+				BlockHash:   t.BlockHash,
+				TxHash:      t.Signature,
+				LogIndex:    t.Index,
+				Address:     program,
+				Data:        data,
+			})
+		}
+	}
+	return events, nil
+}
+
+func (c *Chain) ParseTransfers(ev chain.Event) ([]chain.Transfer, error) {
+	var t Transfer
+	if err := json.Unmarshal(ev.Data, &t); err != nil {
+		return nil, fmt.Errorf("unmarshal solana transfer: %w", err)
+	}
+	if t.Amount == nil {
+		return nil, nil
+	}
+	return []chain.Transfer{{
+		TxHash:      t.Signature,
+		LogIndex:    t.Index,
+		BlockNumber: int64(t.Slot),
+		From:        t.From,
+		To:          t.To,
+		Token:       t.Mint,
+		Amount:      t.Amount,
+	}}, nil
+}
+
+func (c *Chain) Balance(ctx context.Context, addr string, token *string) (*big.Int, error) {
+	owner, err := sol.PublicKeyFromBase58(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %s", addr)
+	}
+	if token == nil {
+		res, err := c.client.GetBalance(ctx, owner, rpc.CommitmentFinalized)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetUint64(res.Value), nil
+	}
+	mint, err := sol.PublicKeyFromBase58(*token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mint: %s", *token)
+	}
+	ata, _, err := sol.FindAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.client.GetTokenAccountBalance(ctx, ata, rpc.CommitmentFinalized)
+	if errors.Is(err, rpc.ErrNotFound) {
+		return big.NewInt(0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	amount, ok := new(big.Int).SetString(res.Value.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid token amount: %s", res.Value.Amount)
+	}
+	return amount, nil
+}
+
+// EstimateFee 按实际构造的 message 查询签名费；不包含为目标创建 ATA 的租金
+func (c *Chain) EstimateFee(ctx context.Context, req chain.TransferRequest) (*big.Int, error) {
+	utx, err := c.BuildTx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.messageFee(ctx, utx.Payload)
+}
+
+func (c *Chain) messageFee(ctx context.Context, message []byte) (*big.Int, error) {
+	res, err := c.client.GetFeeForMessage(ctx, base64.StdEncoding.EncodeToString(message), rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, err
+	}
+	if res.Value == nil {
+		return nil, errors.New("blockhash expired while estimating fee")
+	}
+	return new(big.Int).SetUint64(*res.Value), nil
+}
+
+// BuildTx 构造 SOL 或 SPL 转账，Payload 为待签名的 message，ValidUntil 为 blockhash 失效高度
+func (c *Chain) BuildTx(ctx context.Context, req chain.TransferRequest) (*chain.UnsignedTx, error) {
+	from, ixs, err := c.transferInstructions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	utx, err := c.build(ctx, from, ixs)
+	if err != nil {
+		return nil, err
+	}
+	return unsignedTx(from, utx)
+}
+
+var _ chain.SweepBuilder = (*Chain)(nil)
+
+// BuildSweepTx 归集交易：按余额组装后查询签名费，原生币归集用同一 blockhash 扣除签名费重新组装；
+// 签名费只取决于签名数，与转账金额无关
+func (c *Chain) BuildSweepTx(ctx context.Context, req chain.TransferRequest) (*chain.UnsignedTx, *big.Int, *big.Int, error) {
+	from, ixs, err := c.transferInstructions(ctx, req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	utx, err := c.build(ctx, from, ixs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	payload, err := utx.Tx.Message.MarshalBinary()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fee, err := c.messageFee(ctx, payload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	amount := new(big.Int).Set(req.Amount)
+	if req.Token == nil {
+		if amount.Sub(amount, fee).Sign() <= 0 {
+			return nil, nil, fee, fmt.Errorf("%w: balance %s, fee %s", chain.ErrFeeExceedsAmount, req.Amount, fee)
+		}
+		to, _ := sol.PublicKeyFromBase58(req.To)
+		tx, err := sol.NewTransaction(c.solTransferInstructions(from, to, amount.Uint64()), utx.Tx.Message.RecentBlockhash, sol.TransactionPayer(from))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		utx.Tx = tx
+	}
+	out, err := unsignedTx(from, utx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return out, amount, fee, nil
+}
+
+// transferInstructions SOL 或 SPL 转账指令
+func (c *Chain) transferInstructions(ctx context.Context, req chain.TransferRequest) (sol.PublicKey, []sol.Instruction, error) {
+	from, err := sol.PublicKeyFromBase58(req.From)
+	if err != nil {
+		return from, nil, fmt.Errorf("invalid from address: %s", req.From)
+	}
+	to, err := sol.PublicKeyFromBase58(req.To)
+	if err != nil {
+		return from, nil, fmt.Errorf("invalid to address: %s", req.To)
+	}
+	if !req.Amount.IsUint64() {
+		return from, nil, fmt.Errorf("amount out of range: %s", req.Amount)
+	}
+	if req.Token == nil {
+		return from, c.solTransferInstructions(from, to, req.Amount.Uint64()), nil
+	}
+	mint, err := sol.PublicKeyFromBase58(*req.Token)
+	if err != nil {
+		return from, nil, fmt.Errorf("invalid mint: %s", *req.Token)
+	}
+	supply, err := c.client.GetTokenSupply(ctx, mint, rpc.CommitmentFinalized)
+	if err != nil {
+		return from, nil, fmt.Errorf("get mint decimals: %w", err)
+	}
+	ixs, err := c.splTransferInstructions(ctx, from, to, mint, req.Amount.Uint64(), supply.Value.Decimals)
+	return from, ixs, err
+}
+
+func unsignedTx(from sol.PublicKey, utx *builtTx) (*chain.UnsignedTx, error) {
+	payload, err := utx.Tx.Message.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &chain.UnsignedTx{
+		Chain:      CHAIN,
+		From:       from.String(),
+		Payload:    payload,
+		ValidUntil: utx.LastValidBlockHeight,
+	}, nil
+}
+
+// SignTx privateKey 为 64 字节 ed25519 私钥
+func (c *Chain) SignTx(utx *chain.UnsignedTx, privateKey []byte) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid private key length")
+	}
+	var msg sol.Message
+	if err := msg.UnmarshalWithDecoder(bin.NewBinDecoder(utx.Payload)); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	sig := ed25519.Sign(ed25519.PrivateKey(privateKey), utx.Payload)
+	tx := sol.Transaction{
+		Signatures: []sol.Signature{sol.SignatureFromBytes(sig)},
+		Message:    msg,
+	}
+	return tx.MarshalBinary()
+}
+
+var _ chain.SignedVerifier = (*Chain)(nil)
+
+// VerifySigned 签名交易的 message 须与未签名 message 一致，且 From 的签名有效
+func (c *Chain) VerifySigned(utx *chain.UnsignedTx, signed []byte) error {
+	tx, err := sol.TransactionFromDecoder(bin.NewBinDecoder(signed))
+	if err != nil {
+		return fmt.Errorf("decode signed tx: %w", err)
+	}
+	msg, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(msg, utx.Payload) {
+		return errors.New("signed tx does not match unsigned message")
+	}
+	from, err := sol.PublicKeyFromBase58(utx.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %s", utx.From)
+	}
+	if len(tx.Signatures) == 0 || !ed25519.Verify(ed25519.PublicKey(from[:]), msg, tx.Signatures[0][:]) {
+		return fmt.Errorf("invalid signature for %s", utx.From)
+	}
+	return nil
+}
+
+var _ chain.TxHasher = (*Chain)(nil)
+
+// SignedTxHash 交易签名即交易哈希，取手续费支付方（第一个签名者）的签名
+func (c *Chain) SignedTxHash(signed []byte) (string, error) {
+	tx, err := sol.TransactionFromDecoder(bin.NewBinDecoder(signed))
+	if err != nil {
+		return "", fmt.Errorf("decode signed tx: %w", err)
+	}
+	if len(tx.Signatures) == 0 {
+		return "", errors.New("signed tx has no signatures")
+	}
+	return tx.Signatures[0].String(), nil
+}
+
+// Broadcast 发送前查询签名状态，交易已被节点接收（含 processed）时不重复发送；
+// blockhash 已失效且交易未上链时返回 ErrTxExpired，需用新的 blockhash 重新构造
+func (c *Chain) Broadcast(ctx context.Context, signed []byte) (string, error) {
+	tx, err := sol.TransactionFromDecoder(bin.NewBinDecoder(signed))
+	if err != nil {
+		return "", fmt.Errorf("decode signed tx: %w", err)
+	}
+	if len(tx.Signatures) == 0 {
+		return "", errors.New("signed tx has no signatures")
+	}
+	sig, blockhash := tx.Signatures[0], tx.Message.RecentBlockhash
+	st, err := c.landed(ctx, sig, blockhash)
+	if err != nil {
+		return "", err
+	}
+	if st != nil {
+		return sig.String(), nil
+	}
+	c.mu.Lock()
+	c.blockhashes[sig] = blockhash
+	c.mu.Unlock()
+	if _, err := c.client.SendRawTransactionWithOpts(ctx, signed, rpc.TransactionOpts{
+		PreflightCommitment: rpc.CommitmentFinalized,
+	}); err != nil {
+		return "", err
+	}
+	return sig.String(), nil
+}
+
+// signatureStatus 查询签名状态（含 processed），节点未见过该交易时返回 nil
+func (c *Chain) signatureStatus(ctx context.Context, sig sol.Signature) (*rpc.SignatureStatusesResult, error) {
+	res, err := c.client.GetSignatureStatuses(ctx, true, sig)
+	if errors.Is(err, rpc.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Value) == 0 {
+		return nil, nil
+	}
+	return res.Value[0], nil
+}
+
+// landed 返回交易的签名状态，未上链返回 nil；先确认 blockhash 在 finalized 上已失效、再查询签名状态仍没有时，
+// 交易不会再上链，返回 ErrTxExpired（同时匹配 ErrTxFailed）
+func (c *Chain) landed(ctx context.Context, sig sol.Signature, blockhash sol.Hash) (*rpc.SignatureStatusesResult, error) {
+	valid, err := c.client.IsBlockhashValid(ctx, blockhash, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("check blockhash: %w", err)
+	}
+	st, err := c.signatureStatus(ctx, sig)
+	if err != nil || st != nil {
+		return st, err
+	}
+	if !valid.Value {
+		return nil, fmt.Errorf("%w: %w: %s (blockhash %s)", chain.ErrTxFailed, chain.ErrTxExpired, sig, blockhash)
+	}
+	return nil, nil
+}
+
+// Confirmations 已 root 的交易按 finalized slot 与所在 slot 之差计算；
+// 本进程广播过的交易未上链时检查 blockhash 是否过期，过期返回 ErrTxExpired，由调用方重新构造交易
+func (c *Chain) Confirmations(ctx context.Context, txHash string) (uint64, error) {
+	sig, err := sol.SignatureFromBase58(txHash)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	blockhash, pending := c.blockhashes[sig]
+	c.mu.Unlock()
+	var st *rpc.SignatureStatusesResult
+	if pending {
+		st, err = c.landed(ctx, sig, blockhash)
+	} else {
+		st, err = c.signatureStatus(ctx, sig)
+	}
+	if err != nil {
+		if errors.Is(err, chain.ErrTxExpired) {
+			c.forget(sig)
+		}
+		return 0, err
+	}
+	if st == nil {
+		return 0, nil
+	}
+	// processed / confirmed 的交易所在分叉仍可能被丢弃，finalized 或执行失败后才不再检查过期
+	if st.Err != nil || st.ConfirmationStatus == rpc.ConfirmationStatusFinalized {
+		c.forget(sig)
+	}
+	if st.Err != nil {
+		return 0, fmt.Errorf("%w: %s: %v", chain.ErrTxFailed, txHash, st.Err)
+	}
+	if st.Confirmations != nil {
+		return *st.Confirmations, nil
+	}
+	finalized, err := c.client.GetSlot(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return 0, err
+	}
+	if finalized < st.Slot {
+		return 0, nil
+	}
+	return finalized - st.Slot + 1, nil
+}
+
+// forget 交易已 finalized、执行失败或已过期，不再需要 blockhash
+func (c *Chain) forget(sig sol.Signature) {
+	c.mu.Lock()
+	delete(c.blockhashes, sig)
+	c.mu.Unlock()
+}
+
+var _ chain.FeeReader = (*Chain)(nil)
+
+// TxFee 交易实际扣除的签名费
+func (c *Chain) TxFee(ctx context.Context, txHash string) (*big.Int, error) {
+	sig, err := sol.SignatureFromBase58(txHash)
+	if err != nil {
+		return nil, err
+	}
+	maxVersion := rpc.MaxSupportedTransactionVersion0
+	res, err := c.client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+		Commitment:                     rpc.CommitmentFinalized,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Meta == nil {
+		return nil, fmt.Errorf("transaction %s has no meta", txHash)
+	}
+	return new(big.Int).SetUint64(res.Meta.Fee), nil
+}
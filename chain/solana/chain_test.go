@@ -0,0 +1,184 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/crypto_custody/chain"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// fakeNode 模拟 Solana JSON-RPC：status 为签名状态（nil 表示节点未见过该交易），
+// blockhashValid 为 blockhash 是否仍有效，sends 记录 sendTransaction 次数
+type fakeNode struct {
+	mu             sync.Mutex
+	status         map[string]interface{}
+	blockhashValid bool
+	sends          int
+	finalizedSlot  uint64
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ctx := map[string]interface{}{"slot": n.finalizedSlot}
+	var result interface{}
+	switch req.Method {
+	case "getSignatureStatuses":
+		var sigs []string
+		json.Unmarshal(req.Params[0], &sigs)
+		value := make([]interface{}, len(sigs))
+		for i, sig := range sigs {
+			value[i] = n.status[sig]
+		}
+		result = map[string]interface{}{"context": ctx, "value": value}
+	case "isBlockhashValid":
+		result = map[string]interface{}{"context": ctx, "value": n.blockhashValid}
+	case "sendTransaction":
+		n.sends++
+		result = sol.Signature{}.String()
+	case "getSlot":
+		result = n.finalizedSlot
+	default:
+		http.Error(w, "unexpected method "+req.Method, http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func (n *fakeNode) setStatus(sig string, st interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.status[sig] = st
+}
+
+func (n *fakeNode) setBlockhashValid(valid bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.blockhashValid = valid
+}
+
+// signedTransfer 由测试密钥签名的一笔 SOL 转账
+func signedTransfer(t *testing.T, c *Chain) ([]byte, string) {
+	t.Helper()
+	key, err := DeriveKey(make([]byte, 32), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := DeriveKey(make([]byte, 32), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := sol.NewTransaction(c.solTransferInstructions(key.PublicKey(), to.PublicKey(), 1_000), sol.Hash{1}, sol.TransactionPayer(key.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utx, err := unsignedTx(key.PublicKey(), &builtTx{Tx: tx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := c.SignTx(utx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := c.SignedTxHash(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed, hash
+}
+
+func newFakeChain(t *testing.T) (*Chain, *fakeNode) {
+	t.Helper()
+	node := &fakeNode{status: map[string]interface{}{}, blockhashValid: true, finalizedSlot: 100}
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+	return New(srv.URL), node
+}
+
+// 节点已收到交易（即使只是 processed）时不重复发送，返回同一签名
+func TestBroadcastSkipsKnownSignature(t *testing.T) {
+	c, node := newFakeChain(t)
+	signed, hash := signedTransfer(t, c)
+	node.setStatus(hash, map[string]interface{}{"slot": 99, "confirmations": 0, "err": nil, "confirmationStatus": "processed"})
+
+	got, err := c.Broadcast(context.Background(), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != hash || node.sends != 0 {
+		t.Fatalf("Broadcast = %s with %d sends, want %s without sending", got, node.sends, hash)
+	}
+}
+
+// blockhash 已失效且交易未上链时不发送，返回 ErrTxExpired
+func TestBroadcastExpired(t *testing.T) {
+	c, node := newFakeChain(t)
+	signed, _ := signedTransfer(t, c)
+	node.setBlockhashValid(false)
+
+	if _, err := c.Broadcast(context.Background(), signed); !errors.Is(err, chain.ErrTxExpired) {
+		t.Fatalf("Broadcast err = %v, want %v", err, chain.ErrTxExpired)
+	}
+	if node.sends != 0 {
+		t.Fatalf("sent %d times after expiry", node.sends)
+	}
+}
+
+// 已广播的交易未上链、blockhash 失效后 Confirmations 返回 ErrTxExpired（同时匹配 ErrTxFailed）；
+// 只到 processed 的交易仍检查过期，被分叉丢弃后同样判定过期
+func TestConfirmationsExpiry(t *testing.T) {
+	c, node := newFakeChain(t)
+	ctx := context.Background()
+	signed, hash := signedTransfer(t, c)
+	if _, err := c.Broadcast(ctx, signed); err != nil {
+		t.Fatal(err)
+	}
+	if node.sends != 1 {
+		t.Fatalf("sends = %d, want 1", node.sends)
+	}
+	if n, err := c.Confirmations(ctx, hash); err != nil || n != 0 {
+		t.Fatalf("Confirmations before landing = (%d, %v), want (0, nil)", n, err)
+	}
+
+	node.setStatus(hash, map[string]interface{}{"slot": 99, "confirmations": 0, "err": nil, "confirmationStatus": "processed"})
+	if n, err := c.Confirmations(ctx, hash); err != nil || n != 0 {
+		t.Fatalf("Confirmations when processed = (%d, %v), want (0, nil)", n, err)
+	}
+
+	node.setStatus(hash, nil)
+	node.setBlockhashValid(false)
+	_, err := c.Confirmations(ctx, hash)
+	if !errors.Is(err, chain.ErrTxExpired) || !errors.Is(err, chain.ErrTxFailed) {
+		t.Fatalf("Confirmations after expiry err = %v, want %v and %v", err, chain.ErrTxExpired, chain.ErrTxFailed)
+	}
+}
+
+// 已 finalized 的交易按 finalized slot 计算确认数，blockhash 失效不影响
+func TestConfirmationsFinalized(t *testing.T) {
+	c, node := newFakeChain(t)
+	ctx := context.Background()
+	signed, hash := signedTransfer(t, c)
+	if _, err := c.Broadcast(ctx, signed); err != nil {
+		t.Fatal(err)
+	}
+	node.setStatus(hash, map[string]interface{}{"slot": 91, "confirmations": nil, "err": nil, "confirmationStatus": "finalized"})
+	node.setBlockhashValid(false)
+	if n, err := c.Confirmations(ctx, hash); err != nil || n != 10 {
+		t.Fatalf("Confirmations = (%d, %v), want (10, nil)", n, err)
+	}
+}
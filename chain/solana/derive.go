@@ -0,0 +1,85 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// ==========================
+// SLIP-10 ed25519 派生
+// ==========================
+// ed25519 只支持硬化派生，Solana 标准路径为 m/44'/501'/i'
+
+const (
+	CHAIN         = "solana"
+	COIN_TYPE     = 501
+	hardenedStart = uint32(0x80000000)
+)
+
+type slip10Node struct {
+	key       []byte
+	chainCode []byte
+}
+
+func newMasterNode(seed []byte) slip10Node {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	return slip10Node{key: sum[:32], chainCode: sum[32:]}
+}
+
+func (n slip10Node) child(index uint32) slip10Node {
+	// data = 0x00 || key || ser32(index)
+	data := make([]byte, 0, 37)
+	data = append(data, 0x00)
+	data = append(data, n.key...)
+	data = binary.BigEndian.AppendUint32(data, index|hardenedStart)
+
+	mac := hmac.New(sha512.New, n.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return slip10Node{key: sum[:32], chainCode: sum[32:]}
+}
+
+// DerivationPath 返回第 index 个地址的派生路径
+func DerivationPath(index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'", COIN_TYPE, index)
+}
+
+// DeriveKey 按 m/44'/501'/index' 从 BIP39 种子派生 Solana 私钥
+func DeriveKey(seed []byte, index uint32) (sol.PrivateKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length: %d", len(seed))
+	}
+	node := newMasterNode(seed).child(44).child(COIN_TYPE).child(index)
+	return sol.PrivateKey(ed25519.NewKeyFromSeed(node.key)), nil
+}
+
+// AccountPath 指定 account 下第 index 个密钥的路径 m/44'/501'/account'/index'，
+// 与三级的充值地址路径不重叠
+func AccountPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/%d'", COIN_TYPE, account, index)
+}
+
+// DeriveAccountKey 按 AccountPath 派生私钥，用于与充值地址隔离的冷钱包等
+func DeriveAccountKey(seed []byte, account, index uint32) (sol.PrivateKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length: %d", len(seed))
+	}
+	node := newMasterNode(seed).child(44).child(COIN_TYPE).child(account).child(index)
+	return sol.PrivateKey(ed25519.NewKeyFromSeed(node.key)), nil
+}
+
+// DeriveAddress 派生第 index 个地址（base58 公钥）
+func DeriveAddress(seed []byte, index uint32) (string, error) {
+	key, err := DeriveKey(seed, index)
+	if err != nil {
+		return "", err
+	}
+	return key.PublicKey().String(), nil
+}
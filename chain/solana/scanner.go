@@ -0,0 +1,111 @@
+package solana
+
+import (
+	"fmt"
+	"math/big"
+
+	sol "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Transfer 转入我方地址的一笔 SOL 或 SPL 转账，ScanRange 以 JSON 存入 chain.Event.Data
+type Transfer struct {
+	Slot         uint64   `json:"slot"`
+	BlockHash    string   `json:"block_hash"`
+	Signature    string   `json:"signature"`
+	Index        int      `json:"index"`         // 余额变化在交易内的位置
+	From         string   `json:"from"`          // 手续费支付方，尽力而为
+	To           string   `json:"to"`            // 我方钱包（owner）地址
+	TokenAccount string   `json:"token_account"` // SPL 的关联代币账户，SOL 为空
+	Mint         *string  `json:"mint"`          // SPL mint，原生 SOL 为 nil
+	Amount       *big.Int `json:"amount"`
+}
+
+// ExtractTransfers 找出区块中被监听地址的余额增加：
+// SOL 按交易前后 lamports 余额判断，SPL 按 owner 为监听地址的代币余额判断（即转入我方关联代币账户）。
+// 手续费支付方为平台自有钱包（热/冷/gas 钱包）的交易是补 gas、调拨等内部转账，不算充值
+func ExtractTransfers(slot uint64, block *rpc.GetBlockResult, watched, internal func(addr string) bool) ([]Transfer, error) {
+	var out []Transfer
+	for _, twm := range block.Transactions {
+		meta := twm.Meta
+		if meta == nil || meta.Err != nil {
+			continue
+		}
+		tx, err := twm.GetTransaction()
+		if err != nil {
+			return nil, fmt.Errorf("decode tx in slot %d: %w", slot, err)
+		}
+		if len(tx.Signatures) == 0 {
+			continue
+		}
+		sig := tx.Signatures[0].String()
+		payer := tx.Message.AccountKeys[0].String()
+		if internal != nil && internal(payer) {
+			continue
+		}
+
+		// 静态账户之后依次为地址查找表加载的可写、只读账户
+		keys := append(sol.PublicKeySlice{}, tx.Message.AccountKeys...)
+		keys = append(keys, meta.LoadedAddresses.Writable...)
+		keys = append(keys, meta.LoadedAddresses.ReadOnly...)
+
+		// 原生 SOL
+		for i, key := range keys {
+			if i >= len(meta.PreBalances) || i >= len(meta.PostBalances) {
+				break
+			}
+			addr := key.String()
+			if !watched(addr) || meta.PostBalances[i] <= meta.PreBalances[i] {
+				continue
+			}
+			out = append(out, Transfer{
+				Slot:      slot,
+				BlockHash: block.Blockhash.String(),
+				Signature: sig,
+				Index:     i,
+				From:      payer,
+				To:        addr,
+				Amount:    new(big.Int).SetUint64(meta.PostBalances[i] - meta.PreBalances[i]),
+			})
+		}
+
+		// SPL 代币
+		pre := make(map[uint16]*big.Int, len(meta.PreTokenBalances))
+		for _, tb := range meta.PreTokenBalances {
+			pre[tb.AccountIndex] = rawTokenAmount(tb)
+		}
+		for _, tb := range meta.PostTokenBalances {
+			if tb.Owner == nil || !watched(tb.Owner.String()) {
+				continue
+			}
+			delta := rawTokenAmount(tb)
+			if before, ok := pre[tb.AccountIndex]; ok {
+				delta.Sub(delta, before)
+			}
+			if delta.Sign() <= 0 || int(tb.AccountIndex) >= len(keys) {
+				continue
+			}
+			mint := tb.Mint.String()
+			out = append(out, Transfer{
+				Slot:         slot,
+				BlockHash:    block.Blockhash.String(),
+				Signature:    sig,
+				Index:        len(keys) + int(tb.AccountIndex),
+				From:         payer,
+				To:           tb.Owner.String(),
+				TokenAccount: keys[tb.AccountIndex].String(),
+				Mint:         &mint,
+				Amount:       delta,
+			})
+		}
+	}
+	return out, nil
+}
+
+func rawTokenAmount(tb rpc.TokenBalance) *big.Int {
+	v := new(big.Int)
+	if tb.UiTokenAmount != nil {
+		v.SetString(tb.UiTokenAmount.Amount, 10)
+	}
+	return v
+}
@@ -0,0 +1,78 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sol "github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ==========================
+// 转账交易构造
+// ==========================
+
+// builtTx 交易及其 blockhash 的有效期
+type builtTx struct {
+	Tx                   *sol.Transaction
+	LastValidBlockHeight uint64
+}
+
+func (c *Chain) solTransferInstructions(from, to sol.PublicKey, lamports uint64) []sol.Instruction {
+	return []sol.Instruction{system.NewTransferInstruction(lamports, from, to).Build()}
+}
+
+// splTransferInstructions 目标关联账户（ATA）不存在时由 from 付费创建
+func (c *Chain) splTransferInstructions(ctx context.Context, from, to, mint sol.PublicKey, amount uint64, decimals uint8) ([]sol.Instruction, error) {
+	srcATA, _, err := sol.FindAssociatedTokenAddress(from, mint)
+	if err != nil {
+		return nil, err
+	}
+	dstATA, _, err := sol.FindAssociatedTokenAddress(to, mint)
+	if err != nil {
+		return nil, err
+	}
+
+	var ixs []sol.Instruction
+	exists, err := c.accountExists(ctx, dstATA)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		ixs = append(ixs, associatedtokenaccount.NewCreateInstructionBuilder().
+			SetPayer(from).
+			SetWallet(to).
+			SetMint(mint).
+			Build())
+	}
+	ixs = append(ixs, token.NewTransferCheckedInstruction(amount, decimals, srcATA, mint, dstATA, from, nil).Build())
+	return ixs, nil
+}
+
+func (c *Chain) accountExists(ctx context.Context, account sol.PublicKey) (bool, error) {
+	_, err := c.client.GetAccountInfo(ctx, account)
+	if errors.Is(err, rpc.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// build 取最新 blockhash 组装交易，blockhash 约 150 个区块后失效
+func (c *Chain) build(ctx context.Context, payer sol.PublicKey, ixs []sol.Instruction) (*builtTx, error) {
+	recent, err := c.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("get latest blockhash: %w", err)
+	}
+	tx, err := sol.NewTransaction(ixs, recent.Value.Blockhash, sol.TransactionPayer(payer))
+	if err != nil {
+		return nil, err
+	}
+	return &builtTx{Tx: tx, LastValidBlockHeight: recent.Value.LastValidBlockHeight}, nil
+}
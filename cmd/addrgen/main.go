@@ -0,0 +1,73 @@
+// addrgen 由 SLIP-39 分片恢复主种子，按 BIP44 路径批量生成充值地址池。
+//
+//	addrgen [-config custody.yaml] [-chain ethereum] [-count 10]
+//
+// 分片由 keyceremony 生成，口令与分片从标准输入读取，主种子只存在于内存中。
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/crypto_custody/app"
+	"github.com/crypto_custody/service"
+	"github.com/crypto_custody/slip39"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_FILE)")
+	chainName := flag.String("chain", "", "chain section in config (default withdraw chain)")
+	count := flag.Int("count", 10, "number of addresses to generate")
+	flag.Parse()
+
+	cfg, err := app.LoadConfig(context.Background(), *configPath)
+	if err != nil {
+		log.Fatal("读取配置失败:", err)
+	}
+	ch := cfg.DefaultChain()
+	if *chainName != "" {
+		var ok bool
+		if ch, ok = cfg.Chain(*chainName); !ok {
+			log.Fatalf("链 %s 未配置", *chainName)
+		}
+	}
+	c, err := app.NewOfflineChain(ch)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := app.OpenDB(cfg.Database.DSN.Value())
+	if err != nil {
+		log.Fatal("连接数据库失败:", err)
+	}
+	defer app.CloseDB(db)
+	if err := app.CheckSchema(context.Background(), db); err != nil {
+		log.Fatal("数据库结构检查失败:", err)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	fmt.Fprint(os.Stderr, "passphrase (empty for none): ")
+	pass, _ := in.ReadString('\n')
+	seed, err := slip39.ReadAndCombine(in, os.Stderr, []byte(strings.TrimRight(pass, "\r\n")))
+	if err != nil {
+		log.Fatal("恢复主种子失败:", err)
+	}
+	defer func() {
+		for i := range seed {
+			seed[i] = 0
+		}
+	}()
+
+	addresses, err := service.GenerateAddressPool(db, c, seed, *count)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i, a := range addresses {
+		fmt.Printf("[%d] 地址: %s, 路径: %s\n", i, a.Address, a.DerivationPath)
+	}
+	fmt.Printf("成功生成 %d 个地址并写入数据库！\n", len(addresses))
+}
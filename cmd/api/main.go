@@ -0,0 +1,96 @@
+// api 钱包接口：充值地址、提现、地址簿、webhook，以及审批、调拨、对账、储备证明等管理接口。
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/crypto_custody/app"
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/config"
+	"github.com/crypto_custody/handler"
+	"github.com/crypto_custody/por"
+	"github.com/crypto_custody/repository"
+	"github.com/crypto_custody/router"
+	"github.com/crypto_custody/service"
+	"github.com/crypto_custody/webhook"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_FILE)")
+	flag.Parse()
+
+	ctx, stop := app.SignalContext()
+	defer stop()
+	cfg, err := app.LoadConfig(ctx, *configPath, config.NEED_RPC, config.NEED_AUTH, config.NEED_SIGNER, config.NEED_OFFLINE)
+	if err != nil {
+		log.Fatalf("load config err: %v", err)
+	}
+	db, err := app.OpenDB(cfg.Database.DSN.Value())
+	if err != nil {
+		log.Fatalf("open db err: %v", err)
+	}
+	defer app.CloseDB(db)
+	if err := app.CheckSchema(ctx, db); err != nil {
+		log.Fatalf("check schema err: %v", err)
+	}
+	chains, err := app.NewChains(cfg)
+	if err != nil {
+		log.Fatalf("new chain err: %v", err)
+	}
+	tokens, twoFactor, err := app.NewAuth(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	kycService, err := app.NewKYC(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	approvals := app.NewApprovals(cfg, db)
+	reconciler := app.NewReconciler(cfg, db)
+	withdrawals, err := app.NewWithdrawalService(cfg, db, chains, approvals, reconciler)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rebalancer, err := app.NewRebalancer(cfg, db, approvals)
+	if err != nil {
+		log.Fatal(err)
+	}
+	exporter, err := app.NewExporter(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	checker := app.NewChecker(db, chains)
+	addressBook := service.NewAddressBookService(repository.NewAddressBookRepository(db), checker, twoFactor, cfg.Withdraw.AddressLockPeriod)
+	wallet := service.NewWalletService(
+		repository.NewAddressRepository(db),
+		repository.NewDepositRepository(db),
+		repository.NewWithdrawRepository(db),
+		repository.NewTransactionRepository(db),
+		checker,
+		addressBook,
+		reconciler,
+		kycService,
+		app.TierLimits(cfg),
+	)
+	// 地址证明由离线签名后提交
+	generator := por.NewGenerator(db, app.PorAssets(cfg), nil)
+
+	r := router.SetupRouter(
+		handler.NewWalletHandler(wallet),
+		handler.NewApprovalHandler(withdrawals),
+		handler.NewRebalanceHandler(rebalancer, exporter),
+		handler.NewReconcileHandler(reconciler),
+		handler.NewPorHandler(generator),
+		handler.NewWebhookHandler(webhook.NewService(db)),
+		auth.Middleware(tokens),
+		auth.StepUp(twoFactor),
+	)
+
+	if err := app.Serve(ctx, cfg.HTTP.APIAddr, r, cfg.HTTP.ShutdownTimeout); err != nil {
+		log.Printf("api err: %v", err)
+		return
+	}
+	log.Println("api stopped")
+}
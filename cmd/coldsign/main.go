@@ -0,0 +1,179 @@
+// coldsign 离线签名机命令行，只在断网机器上运行。
+//
+//	coldsign keygen                       生成批次封装用的 ed25519 密钥对
+//	coldsign addresses [-count 5] [-shares] 列出冷钱包地址（BIP44 account 1，与充值地址隔离）
+//	coldsign sign -in unsigned.json -out signed.json \
+//	    -exporter-pub <hex> -signer-key <hex> [-shares] [-qr]
+//
+// 助记词（-shares 时为 SLIP-39 分片）从标准输入读取，不落盘；-qr 时输入输出均为每行一个二维码分片。
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/chain/evm"
+	"github.com/crypto_custody/chain/solana"
+	"github.com/crypto_custody/offline"
+	"github.com/crypto_custody/slip39"
+	"github.com/tyler-smith/go-bip39"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "keygen":
+		keygen()
+	case "addresses":
+		addresses(os.Args[2:])
+	case "sign":
+		sign(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: coldsign keygen | coldsign addresses [-count N] [-shares] | coldsign sign -in FILE -out FILE -exporter-pub HEX -signer-key HEX [-shares] [-qr]")
+	os.Exit(2)
+}
+
+func keygen() {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("public: ", hex.EncodeToString(pub))
+	fmt.Println("private:", hex.EncodeToString(priv.Seed()))
+}
+
+// addresses 列出种子派生的冷钱包地址，用于配置冷钱包
+func addresses(args []string) {
+	fs := flag.NewFlagSet("addresses", flag.ExitOnError)
+	count := fs.Uint("count", 5, "number of cold addresses per chain")
+	shares := fs.Bool("shares", false, "recover the seed from SLIP-39 shares instead of a BIP39 mnemonic")
+	fs.Parse(args)
+
+	seed, err := readSeed(*shares)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		for i := range seed {
+			seed[i] = 0
+		}
+	}()
+	for _, c := range []chain.Chain{evm.NewOffline(evm.Config{Name: "ethereum"}), solana.New("")} {
+		for i := uint32(0); i < uint32(*count); i++ {
+			addr, key, path, err := offline.ColdKey(c, seed, i)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for j := range key {
+				key[j] = 0
+			}
+			fmt.Printf("%s %s %s\n", c.Name(), path, addr)
+		}
+	}
+}
+
+func sign(args []string) {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	in := fs.String("in", "", "unsigned bundle file")
+	out := fs.String("out", "", "signed bundle file")
+	exporterPub := fs.String("exporter-pub", "", "online exporter ed25519 public key (hex)")
+	signerKey := fs.String("signer-key", "", "this signer's ed25519 private key (hex)")
+	evmChainID := fs.Int64("evm-chain-id", 1, "EVM chain id")
+	maxIndex := fs.Uint("max-index", 100, "number of cold addresses (BIP44 account 1) to derive when looking up keys")
+	qr := fs.Bool("qr", false, "read and write QR chunks, one per line")
+	shares := fs.Bool("shares", false, "recover the seed from SLIP-39 shares instead of a BIP39 mnemonic")
+	fs.Parse(args)
+	if *in == "" || *out == "" || *exporterPub == "" || *signerKey == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	pub, err := offline.ParsePublicKey(*exporterPub)
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, err := offline.ParsePrivateKey(*signerKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 离线实例，不连接节点
+	chain.Register(evm.NewOffline(evm.Config{Name: "ethereum", ChainID: *evmChainID}))
+	chain.Register(solana.New(""))
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *qr {
+		if data, err = offline.Assemble(strings.Split(string(data), "\n")); err != nil {
+			log.Fatal(err)
+		}
+	}
+	bundle, err := offline.Decode(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	seed, err := readSeed(*shares)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		for i := range seed {
+			seed[i] = 0
+		}
+	}()
+
+	signed, err := offline.SignBundle(bundle, pub, offline.NewSeedKeyring(seed, uint32(*maxIndex)), key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	encoded, err := signed.Encode()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *qr {
+		encoded = []byte(strings.Join(offline.Chunk(encoded, offline.QR_CHUNK_SIZE), "\n") + "\n")
+	}
+	if err := os.WriteFile(*out, encoded, 0o600); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "signed %d requests in bundle %s -> %s\n", len(signed.Items), signed.ID, *out)
+}
+
+func readSeed(shares bool) ([]byte, error) {
+	r := bufio.NewReader(os.Stdin)
+	if shares {
+		fmt.Fprint(os.Stderr, "passphrase (empty for none): ")
+		pass, _ := r.ReadString('\n')
+		return slip39.ReadAndCombine(r, os.Stderr, []byte(strings.TrimRight(pass, "\r\n")))
+	}
+	fmt.Fprint(os.Stderr, "mnemonic: ")
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return nil, err
+	}
+	mnemonic := strings.Join(strings.Fields(line), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, fmt.Errorf("invalid mnemonic")
+	}
+	fmt.Fprint(os.Stderr, "passphrase (empty for none): ")
+	pass, _ := r.ReadString('\n')
+	return bip39.NewSeed(mnemonic, strings.TrimRight(pass, "\r\n")), nil
+}
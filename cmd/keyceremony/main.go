@@ -0,0 +1,225 @@
+// keyceremony 主种子生成与 SLIP-39 分片备份仪式，只在断网机器上运行。
+//
+//	keyceremony generate -group 3of5 [-group 2of3 ...] [-group-threshold 1] [-bits 256]
+//	keyceremony verify                  校验单个分片（校验和与参数），不需要其他分片
+//	keyceremony recover                 输入分片直到达到阈值，输出地址指纹
+//
+// 主种子只存在于内存中，不写入磁盘或数据库；口令与分片均从标准输入读取。
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/crypto_custody/chain/evm"
+	"github.com/crypto_custody/chain/solana"
+	"github.com/crypto_custody/slip39"
+)
+
+const CLEAR_SCREEN = "\033[H\033[2J"
+
+var stdin = bufio.NewReader(os.Stdin)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = generate(os.Args[2:])
+	case "verify":
+		err = verify()
+	case "recover":
+		err = recoverSeed()
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: keyceremony generate -group MofN [-group MofN ...] [-group-threshold K] [-bits 128|256] | verify | recover")
+	os.Exit(2)
+}
+
+// groupsFlag 解析 -group 3of5，可重复
+type groupsFlag []slip39.Group
+
+func (g *groupsFlag) String() string { return fmt.Sprint(*g) }
+
+func (g *groupsFlag) Set(v string) error {
+	m, n, ok := strings.Cut(strings.ToLower(v), "of")
+	threshold, err1 := strconv.Atoi(m)
+	count, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil || threshold < 1 || threshold > count {
+		return fmt.Errorf("invalid group %q, want MofN", v)
+	}
+	*g = append(*g, slip39.Group{Threshold: threshold, Count: count})
+	return nil
+}
+
+func readLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// fingerprint 用主种子派生的首个地址作为公开指纹，便于核对恢复结果
+func fingerprint(seed []byte) (string, error) {
+	ethAddr, _, err := evm.NewOffline(evm.Config{Name: "ethereum"}).DeriveAddress(seed, 0)
+	if err != nil {
+		return "", err
+	}
+	solAddr, err := solana.DeriveAddress(seed, 0)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ethereum[0]=%s solana[0]=%s", ethAddr, solAddr), nil
+}
+
+func generate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	var groups groupsFlag
+	fs.Var(&groups, "group", "member threshold and count of a custodian group, e.g. 3of5 (repeatable)")
+	groupThreshold := fs.Int("group-threshold", 1, "number of groups required to recover")
+	bits := fs.Int("bits", 256, "master secret strength: 128 or 256")
+	exponent := fs.Int("exponent", 1, "PBKDF2 iteration exponent (10000 << e iterations)")
+	fs.Parse(args)
+	if len(groups) == 0 || (*bits != 128 && *bits != 256) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	pass, err := readLine("passphrase (empty for none): ")
+	if err != nil {
+		return err
+	}
+	confirm, err := readLine("repeat passphrase: ")
+	if err != nil {
+		return err
+	}
+	if pass != confirm {
+		return errors.New("passphrases do not match")
+	}
+
+	seed := make([]byte, *bits/8)
+	defer wipe(seed)
+	if _, err := rand.Read(seed); err != nil {
+		return err
+	}
+	shares, err := slip39.Split(seed, []byte(pass), *groupThreshold, groups, *exponent)
+	if err != nil {
+		return err
+	}
+	fp, err := fingerprint(seed)
+	if err != nil {
+		return err
+	}
+
+	// 逐个分发：每位保管人只看到自己的分片，确认抄写后清屏
+	for gi, group := range shares {
+		for mi, mnemonic := range group {
+			fmt.Fprint(os.Stderr, CLEAR_SCREEN)
+			fmt.Fprintf(os.Stderr, "group %d/%d (%d of %d), share %d/%d — custodian eyes only\n\n",
+				gi+1, len(shares), groups[gi].Threshold, groups[gi].Count, mi+1, len(group))
+			fmt.Fprintln(os.Stderr, mnemonic)
+			if _, err := readLine("\nwrite it down, then press Enter "); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Fprint(os.Stderr, CLEAR_SCREEN)
+
+	// 每位保管人回填自己的分片，确认抄写无误
+	for gi, group := range shares {
+		for mi, mnemonic := range group {
+			for {
+				line, err := readLine(fmt.Sprintf("verify group %d share %d: ", gi+1, mi+1))
+				if err != nil {
+					return err
+				}
+				if _, err := slip39.ParseShare(line); err != nil {
+					fmt.Fprintf(os.Stderr, "invalid share: %v, try again\n", err)
+					continue
+				}
+				if strings.Join(strings.Fields(strings.ToLower(line)), " ") != mnemonic {
+					fmt.Fprintln(os.Stderr, "does not match the issued share, try again")
+					continue
+				}
+				break
+			}
+			fmt.Fprint(os.Stderr, CLEAR_SCREEN)
+		}
+	}
+
+	// 用每组前阈值个分片做一次恢复演练
+	var subset []string
+	for gi := 0; gi < *groupThreshold; gi++ {
+		subset = append(subset, shares[gi][:groups[gi].Threshold]...)
+	}
+	recovered, err := slip39.Combine(subset, []byte(pass))
+	if err != nil {
+		return fmt.Errorf("recovery check: %w", err)
+	}
+	defer wipe(recovered)
+	if !bytes.Equal(recovered, seed) {
+		return errors.New("recovery check: recovered secret does not match")
+	}
+
+	first, _ := slip39.ParseShare(shares[0][0])
+	fmt.Printf("ceremony complete: identifier=%d groups=%d threshold=%d\n", first.Identifier, len(groups), *groupThreshold)
+	fmt.Printf("fingerprint: %s\n", fp)
+	return nil
+}
+
+func verify() error {
+	line, err := readLine("share: ")
+	if err != nil {
+		return err
+	}
+	s, err := slip39.ParseShare(line)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("valid share: identifier=%d group %d of %d (need %d groups), member %d (need %d members), %d-bit secret\n",
+		s.Identifier, s.GroupIndex+1, s.GroupCount, s.GroupThreshold, s.MemberIndex+1, s.MemberThreshold, len(s.Value)*8)
+	return nil
+}
+
+func recoverSeed() error {
+	pass, err := readLine("passphrase (empty for none): ")
+	if err != nil {
+		return err
+	}
+	seed, err := slip39.ReadAndCombine(stdin, os.Stderr, []byte(pass))
+	if err != nil {
+		return err
+	}
+	defer wipe(seed)
+	fp, err := fingerprint(seed)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("recovered, fingerprint: %s\n", fp)
+	return nil
+}
@@ -0,0 +1,159 @@
+// migrate 数据库迁移命令，部署新版本前执行；服务启动时只检查版本，不再自行建表。
+//
+//	migrate [-config custody.yaml | -dsn DSN] up [-to VERSION]
+//	migrate down [-steps 1]
+//	migrate status
+//	migrate check                 核对 GORM 模型与数据库结构，不一致时退出码为 1
+//	migrate force VERSION         只修改版本记录，不执行 SQL
+//
+// 由 AutoMigrate 建表的旧库：确认表结构与 0001_init 一致后执行 force 1，再 up。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/crypto_custody/app"
+	"github.com/crypto_custody/migrations"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_FILE)")
+	dsn := flag.String("dsn", "", "database dsn, overrides config")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+	}
+
+	ctx, stop := app.SignalContext()
+	defer stop()
+	if *dsn == "" {
+		cfg, err := app.LoadConfig(ctx, *configPath)
+		if err != nil {
+			log.Fatalf("load config err: %v", err)
+		}
+		*dsn = cfg.Database.DSN.Value()
+	}
+	db, err := app.OpenDB(*dsn)
+	if err != nil {
+		log.Fatalf("open db err: %v", err)
+	}
+	defer app.CloseDB(db)
+	// 迁移脚本很长，只输出错误
+	db = db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Error)})
+	m, err := migrations.New(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "up":
+		err = up(ctx, m, args)
+	case "down":
+		err = down(ctx, m, args)
+	case "status":
+		err = status(ctx, m)
+	case "check":
+		err = check(ctx, m, db)
+	case "force":
+		err = force(ctx, m, args)
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate [-config FILE | -dsn DSN] up [-to VERSION] | down [-steps N] | status | check | force VERSION")
+	os.Exit(2)
+}
+
+func up(ctx context.Context, m *migrations.Migrator, args []string) error {
+	fs := flag.NewFlagSet("up", flag.ExitOnError)
+	to := fs.Int64("to", 0, "target version (default latest)")
+	fs.Parse(args)
+	ran, err := m.Up(ctx, *to)
+	for _, mig := range ran {
+		fmt.Printf("applied %04d_%s\n", mig.Version, mig.Name)
+	}
+	if err == nil && len(ran) == 0 {
+		fmt.Println("no pending migrations")
+	}
+	return err
+}
+
+func down(ctx context.Context, m *migrations.Migrator, args []string) error {
+	fs := flag.NewFlagSet("down", flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	fs.Parse(args)
+	if *steps <= 0 {
+		return fmt.Errorf("steps must be positive")
+	}
+	reverted, err := m.Down(ctx, *steps)
+	for _, mig := range reverted {
+		fmt.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+	}
+	return err
+}
+
+func status(ctx context.Context, m *migrations.Migrator) error {
+	list, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		state := "pending"
+		if s.AppliedAt != nil {
+			state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if s.Modified {
+			state += " (file modified)"
+		}
+		fmt.Printf("%04d_%-32s %s\n", s.Version, s.Name, state)
+	}
+	return nil
+}
+
+func check(ctx context.Context, m *migrations.Migrator, db *gorm.DB) error {
+	if err := m.RequireLatest(ctx); err != nil {
+		return err
+	}
+	mismatches, err := migrations.Check(ctx, db, model.Models()...)
+	if err != nil {
+		return err
+	}
+	for _, mm := range mismatches {
+		fmt.Println(mm)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d schema mismatches", len(mismatches))
+	}
+	fmt.Println("schema matches models")
+	return nil
+}
+
+func force(ctx context.Context, m *migrations.Migrator, args []string) error {
+	if len(args) != 1 {
+		usage()
+	}
+	version, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || version < 0 || version > m.Latest() {
+		return fmt.Errorf("invalid version %s", args[0])
+	}
+	if err := m.Force(ctx, version); err != nil {
+		return err
+	}
+	fmt.Printf("version set to %d\n", version)
+	return nil
+}
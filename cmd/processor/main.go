@@ -0,0 +1,39 @@
+// processor 将扫块记录的链上事件转换为充值记录。
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/crypto_custody/app"
+	"github.com/crypto_custody/config"
+	"github.com/crypto_custody/service"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_FILE)")
+	flag.Parse()
+
+	ctx, stop := app.SignalContext()
+	defer stop()
+	cfg, err := app.LoadConfig(ctx, *configPath, config.NEED_RPC)
+	if err != nil {
+		log.Fatalf("load config err: %v", err)
+	}
+	db, err := app.OpenDB(cfg.Database.DSN.Value())
+	if err != nil {
+		log.Fatalf("open db err: %v", err)
+	}
+	defer app.CloseDB(db)
+	if err := app.CheckSchema(ctx, db); err != nil {
+		log.Fatalf("check schema err: %v", err)
+	}
+	// 事件解码委托给已注册的链适配器
+	if _, err := app.NewChains(cfg); err != nil {
+		log.Fatalf("new chain err: %v", err)
+	}
+
+	log.Println("processor running")
+	service.NewProcessor(db).Run(ctx)
+	log.Println("processor stopped")
+}
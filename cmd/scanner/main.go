@@ -0,0 +1,44 @@
+// scanner 扫描配置中每条链的区块并记录事件，从上次处理的区块继续，启动时检测重组。
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/crypto_custody/app"
+	"github.com/crypto_custody/config"
+	"github.com/crypto_custody/service"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_FILE)")
+	flag.Parse()
+
+	ctx, stop := app.SignalContext()
+	defer stop()
+	cfg, err := app.LoadConfig(ctx, *configPath, config.NEED_RPC)
+	if err != nil {
+		log.Fatalf("load config err: %v", err)
+	}
+	db, err := app.OpenDB(cfg.Database.DSN.Value())
+	if err != nil {
+		log.Fatalf("open db err: %v", err)
+	}
+	defer app.CloseDB(db)
+	if err := app.CheckSchema(ctx, db); err != nil {
+		log.Fatalf("check schema err: %v", err)
+	}
+	chains, err := app.NewChains(cfg)
+	if err != nil {
+		log.Fatalf("new chain err: %v", err)
+	}
+
+	var g app.Group
+	for name, c := range chains {
+		scanner := service.NewScanner(c, db)
+		g.Go(func() { scanner.Run(ctx) })
+		log.Printf("scanner running on %s", name)
+	}
+	g.Wait()
+	log.Println("scanner stopped")
+}
@@ -0,0 +1,88 @@
+// sweeper 将充值地址上达到阈值的余额归集到热钱包，并确认归集、记录手续费；
+// 配置了 gas 钱包的链为代币充值地址补充归集手续费。
+//
+//	sweeper [-config custody.yaml]
+//
+// 充值地址私钥由主种子派生：配置了 sweep.seed 时直接使用，否则从标准输入读取口令与 SLIP-39 分片，
+// 主种子只存在于内存中。
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/crypto_custody/app"
+	"github.com/crypto_custody/config"
+	"github.com/crypto_custody/service"
+	"github.com/crypto_custody/slip39"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_FILE)")
+	flag.Parse()
+
+	ctx, stop := app.SignalContext()
+	defer stop()
+	cfg, err := app.LoadConfig(ctx, *configPath, config.NEED_RPC)
+	if err != nil {
+		log.Fatalf("load config err: %v", err)
+	}
+	seed, err := readSeed(cfg)
+	if err != nil {
+		log.Fatalf("read seed err: %v", err)
+	}
+	defer func() {
+		for i := range seed {
+			seed[i] = 0
+		}
+	}()
+	db, err := app.OpenDB(cfg.Database.DSN.Value())
+	if err != nil {
+		log.Fatalf("open db err: %v", err)
+	}
+	defer app.CloseDB(db)
+	if err := app.CheckSchema(ctx, db); err != nil {
+		log.Fatalf("check schema err: %v", err)
+	}
+	chains, err := app.NewChains(cfg)
+	if err != nil {
+		log.Fatalf("new chain err: %v", err)
+	}
+	sweeper, err := app.NewSweeper(cfg, db, service.NewSeedKeySource(seed))
+	if err != nil {
+		log.Fatal(err)
+	}
+	stations, err := app.NewGasStations(cfg, db, chains, sweeper)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var g app.Group
+	g.Go(func() { sweeper.Run(ctx, time.Minute) })
+	for _, station := range stations {
+		g.Go(func() { station.Run(ctx, time.Minute) })
+	}
+	log.Println("sweeper running")
+
+	<-ctx.Done()
+	log.Println("sweeper shutting down")
+	g.Wait()
+	log.Println("sweeper stopped")
+}
+
+// readSeed 配置中的主种子，或由标准输入的 SLIP-39 分片恢复
+func readSeed(cfg *config.Config) ([]byte, error) {
+	if cfg.Sweep.Seed.IsSet() {
+		return hex.DecodeString(cfg.Sweep.Seed.Value())
+	}
+	in := bufio.NewReader(os.Stdin)
+	fmt.Fprint(os.Stderr, "passphrase (empty for none): ")
+	pass, _ := in.ReadString('\n')
+	return slip39.ReadAndCombine(in, os.Stderr, []byte(strings.TrimRight(pass, "\r\n")))
+}
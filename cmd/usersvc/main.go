@@ -0,0 +1,54 @@
+// usersvc 用户服务：注册、登录、二次验证与 KYC。
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/crypto_custody/app"
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/config"
+	"github.com/crypto_custody/handler"
+	"github.com/crypto_custody/router"
+	"github.com/crypto_custody/service"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_FILE)")
+	flag.Parse()
+
+	ctx, stop := app.SignalContext()
+	defer stop()
+	cfg, err := app.LoadConfig(ctx, *configPath, config.NEED_AUTH)
+	if err != nil {
+		log.Fatalf("load config err: %v", err)
+	}
+	db, err := app.OpenDB(cfg.Database.DSN.Value())
+	if err != nil {
+		log.Fatalf("open db err: %v", err)
+	}
+	defer app.CloseDB(db)
+	if err := app.CheckSchema(ctx, db); err != nil {
+		log.Fatalf("check schema err: %v", err)
+	}
+	tokens, twoFactor, err := app.NewAuth(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	kycService, err := app.NewKYC(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := router.SetupUserRouter(
+		handler.NewUserHandler(service.NewUserService(db, tokens, twoFactor)),
+		handler.NewKYCHandler(kycService),
+		auth.Middleware(tokens),
+	)
+
+	if err := app.Serve(ctx, cfg.HTTP.UserAddr, r, cfg.HTTP.ShutdownTimeout); err != nil {
+		log.Printf("user service err: %v", err)
+		return
+	}
+	log.Println("user service stopped")
+}
@@ -0,0 +1,65 @@
+// withdrawer 提现后台任务：提现申请转入提现流程、发送滞留的待发送提现、过期审批清理、冷热钱包调拨、链上对账、outbox 事件投递与 webhook 推送。
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/crypto_custody/app"
+	"github.com/crypto_custody/config"
+	"github.com/crypto_custody/outbox"
+	"github.com/crypto_custody/webhook"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_FILE)")
+	flag.Parse()
+
+	ctx, stop := app.SignalContext()
+	defer stop()
+	cfg, err := app.LoadConfig(ctx, *configPath, config.NEED_RPC, config.NEED_SIGNER)
+	if err != nil {
+		log.Fatalf("load config err: %v", err)
+	}
+	db, err := app.OpenDB(cfg.Database.DSN.Value())
+	if err != nil {
+		log.Fatalf("open db err: %v", err)
+	}
+	defer app.CloseDB(db)
+	if err := app.CheckSchema(ctx, db); err != nil {
+		log.Fatalf("check schema err: %v", err)
+	}
+	chains, err := app.NewChains(cfg)
+	if err != nil {
+		log.Fatalf("new chain err: %v", err)
+	}
+	approvals := app.NewApprovals(cfg, db)
+	reconciler := app.NewReconciler(cfg, db)
+	withdrawals, err := app.NewWithdrawalService(cfg, db, chains, approvals, reconciler)
+	if err != nil {
+		log.Fatal(err)
+	}
+	requests := app.NewRequestWorker(cfg, db, withdrawals)
+	rebalancer, err := app.NewRebalancer(cfg, db, approvals)
+	if err != nil {
+		log.Fatal(err)
+	}
+	webhooks := webhook.NewService(db)
+	relay := outbox.NewRelay(db, app.NewBroker(webhooks))
+
+	var g app.Group
+	g.Go(func() { requests.Run(ctx, 5*time.Second) })
+	g.Go(func() { withdrawals.RunSender(ctx, 30*time.Second) })
+	g.Go(func() { withdrawals.RunApprovalExpiry(ctx, time.Minute) })
+	g.Go(func() { rebalancer.Run(ctx, 5*time.Minute) })
+	g.Go(func() { reconciler.Run(ctx, 10*time.Minute) })
+	g.Go(func() { relay.Run(ctx, time.Second) })
+	g.Go(func() { webhooks.Run(ctx, 5*time.Second) })
+	log.Println("withdrawer running")
+
+	<-ctx.Done()
+	log.Println("withdrawer shutting down")
+	g.Wait()
+	log.Println("withdrawer stopped")
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ==========================
+// 运行配置
+// ==========================
+// cmd 下各服务共用同一份配置：先取默认值，再读 YAML 文件，最后用环境变量覆盖；
+// 密钥类字段（Secret）可以引用文件或密钥服务，加载后统一解析并在启动时校验。
+
+const (
+	CONFIG_FILE_ENV = "CONFIG_FILE"
+
+	CHAIN_TYPE_EVM    = "evm"
+	CHAIN_TYPE_SOLANA = "solana"
+
+	DEFAULT_API_ADDR         = ":8080"
+	DEFAULT_USER_ADDR        = ":8081"
+	DEFAULT_SHUTDOWN_TIMEOUT = 15 * time.Second
+	DEFAULT_TOTP_ISSUER      = "crypto_custody"
+	DEFAULT_KYC_BLOB_DIR     = "data/kyc"
+)
+
+type Config struct {
+	Database DatabaseConfig `yaml:"database"`
+	HTTP     HTTPConfig     `yaml:"http"`
+	Auth     AuthConfig     `yaml:"auth"`
+	KYC      KYCConfig      `yaml:"kyc"`
+	Withdraw WithdrawConfig `yaml:"withdraw"`
+	Risk     RiskConfig     `yaml:"risk"`
+	Offline  OfflineConfig  `yaml:"offline"`
+	Sweep    SweepConfig    `yaml:"sweep"`
+	Chains   []ChainConfig  `yaml:"chains"` // 环境变量前缀 CHAIN_<NAME>_，如 CHAIN_ETHEREUM_RPC_URL
+}
+
+type DatabaseConfig struct {
+	DSN Secret `yaml:"dsn" env:"DATABASE_DSN"`
+}
+
+type HTTPConfig struct {
+	APIAddr         string        `yaml:"api_addr" env:"API_ADDR"`                 // 钱包接口
+	UserAddr        string        `yaml:"user_addr" env:"USER_ADDR"`               // 用户服务
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"` // 收到退出信号后等待在途请求完成的时间
+}
+
+type AuthConfig struct {
+	JWTSecret  Secret        `yaml:"jwt_secret" env:"JWT_SECRET"`         // 至少 32 字节
+	TOTPKey    Secret        `yaml:"totp_key" env:"TOTP_ENCRYPTION_KEY"`  // hex 编码的 32 字节 AES 密钥
+	TOTPIssuer string        `yaml:"totp_issuer" env:"TOTP_ISSUER"`       // 验证器 App 中显示的发行方
+	AccessTTL  time.Duration `yaml:"access_ttl" env:"ACCESS_TOKEN_TTL"`   // 0 使用默认值
+	RefreshTTL time.Duration `yaml:"refresh_ttl" env:"REFRESH_TOKEN_TTL"` // 0 使用默认值
+}
+
+type KYCConfig struct {
+	BlobDir        string `yaml:"blob_dir" env:"KYC_BLOB_DIR"`               // 证件存储目录
+	Provider       string `yaml:"provider" env:"KYC_PROVIDER"`               // 为空时只支持人工审核
+	ProviderSecret Secret `yaml:"provider_secret" env:"KYC_PROVIDER_SECRET"` // 回调签名密钥
+}
+
+type WithdrawConfig struct {
+	Chain             string        `yaml:"chain" env:"WITHDRAW_CHAIN"`                    // 命令行工具未指定链时的默认链，为空取第一条链
+	ApprovalTrailKey  Secret        `yaml:"approval_trail_key" env:"APPROVAL_TRAIL_KEY"`   // 审批链 HMAC 密钥
+	ApprovalTTL       time.Duration `yaml:"approval_ttl" env:"APPROVAL_TTL"`               // 审批单有效期
+	ReviewRoles       []string      `yaml:"review_roles"`                                  // 风控要求人工审核时的审批角色
+	AddressLockPeriod time.Duration `yaml:"address_lock_period" env:"ADDRESS_LOCK_PERIOD"` // 地址簿新地址锁定期，0 使用默认值
+}
+
+type RiskConfig struct {
+	BlacklistFiles       []string      `yaml:"blacklist_files"`
+	SanctionsFiles       []string      `yaml:"sanctions_files"`
+	UserVelocityWindow   time.Duration `yaml:"user_velocity_window"`
+	UserVelocityMaxCount int64         `yaml:"user_velocity_max_count"`
+	GlobalVelocityWindow time.Duration `yaml:"global_velocity_window"`
+	NewAddressCooling    time.Duration `yaml:"new_address_cooling"`
+}
+
+type OfflineConfig struct {
+	ExportKey Secret `yaml:"export_key" env:"OFFLINE_EXPORT_KEY"`    // 在线端封装批次的 ed25519 私钥（hex）
+	SignerPub string `yaml:"signer_pub" env:"OFFLINE_SIGNER_PUBKEY"` // 离线签名机公钥（hex）
+}
+
+type SweepConfig struct {
+	Seed Secret `yaml:"seed" env:"DEPOSIT_SEED"` // 充值地址主种子（hex），为空时启动时从标准输入读取 SLIP-39 分片
+}
+
+// ChainConfig 单条链的节点、热钱包与币种参数
+type ChainConfig struct {
+	Name          string           `yaml:"name"`
+	Type          string           `yaml:"type"` // evm / solana，默认 evm
+	RPCURL        Secret           `yaml:"rpc_url" env:"RPC_URL"`
+	ChainID       int64            `yaml:"chain_id" env:"CHAIN_ID"` // 0 表示启动时从节点查询
+	Confirmations uint64           `yaml:"confirmations" env:"CONFIRMATIONS"`
+	HotWallet     WalletConfig     `yaml:"hot_wallet"`
+	ColdWallet    ColdWalletConfig `yaml:"cold_wallet"`
+	GasWallet     GasWalletConfig  `yaml:"gas_wallet"`
+	Currencies    []CurrencyConfig `yaml:"currencies"`
+}
+
+type WalletConfig struct {
+	Address string `yaml:"address" env:"HOT_WALLET_ADDRESS"`
+	Key     Secret `yaml:"key" env:"HOT_WALLET_KEY"` // hex 私钥
+}
+
+// ColdWalletConfig 冷钱包只配置地址，私钥在离线签名机或 Safe owner 手中
+type ColdWalletConfig struct {
+	Address string `yaml:"address" env:"COLD_WALLET_ADDRESS"`
+	Safe    bool   `yaml:"safe" env:"COLD_WALLET_SAFE"` // 地址为 Safe 多签合约（仅 EVM 链），由热钱包发起执行
+}
+
+// GasWalletConfig 为代币充值地址补充归集手续费的专用钱包，为空时不补 gas
+type GasWalletConfig struct {
+	Address string `yaml:"address" env:"GAS_WALLET_ADDRESS"`
+	Key     Secret `yaml:"key" env:"GAS_WALLET_KEY"` // hex 私钥
+}
+
+// CurrencyConfig 币种参数，金额均为最小单位的十进制字符串
+type CurrencyConfig struct {
+	Currency                string                     `yaml:"currency"`
+	Token                   *string                    `yaml:"token"` // 合约地址，为空表示原生币
+	Decimals                int                        `yaml:"decimals"`
+	ReconcileTolerance      string                     `yaml:"reconcile_tolerance"`
+	ReviewThreshold         string                     `yaml:"review_threshold"`
+	UserVelocityMaxAmount   string                     `yaml:"user_velocity_max_amount"`
+	GlobalVelocityMaxAmount string                     `yaml:"global_velocity_max_amount"`
+	ApprovalTiers           []ApprovalTierConfig       `yaml:"approval_tiers"`
+	Limits                  map[string]TierLimitConfig `yaml:"limits"`           // KYC 等级 -> 提现限额
+	Treasury                *TreasuryConfig            `yaml:"treasury"`         // 热钱包水位，为空表示不做冷热调拨
+	SweepThreshold          string                     `yaml:"sweep_threshold"`  // 充值地址余额达到该值时归集到热钱包，为空不归集
+	TokenPerNative          string                     `yaml:"token_per_native"` // 代币：1 个原生币最小单位折合的代币最小单位，如 "3/1000000000000"，用于判断归集是否为粉尘
+}
+
+// TreasuryConfig 热钱包余额水位：超过 Max 时转出到 Target，低于 Min 时从冷钱包补充到 Target
+type TreasuryConfig struct {
+	Min    string `yaml:"min"`
+	Target string `yaml:"target"`
+	Max    string `yaml:"max"`
+}
+
+type ApprovalTierConfig struct {
+	MinAmount string   `yaml:"min_amount"`
+	Required  int      `yaml:"required"`
+	Roles     []string `yaml:"roles"`
+}
+
+// TierLimitConfig 提现限额（主单位），-1 表示不限
+type TierLimitConfig struct {
+	Daily   float64 `yaml:"daily"`
+	Monthly float64 `yaml:"monthly"`
+}
+
+// Default 默认配置：以太坊主网 ETH，节点地址与密钥必须另行配置
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			APIAddr:         DEFAULT_API_ADDR,
+			UserAddr:        DEFAULT_USER_ADDR,
+			ShutdownTimeout: DEFAULT_SHUTDOWN_TIMEOUT,
+		},
+		Auth: AuthConfig{TOTPIssuer: DEFAULT_TOTP_ISSUER},
+		KYC:  KYCConfig{BlobDir: DEFAULT_KYC_BLOB_DIR},
+		Withdraw: WithdrawConfig{
+			ApprovalTTL: 24 * time.Hour,
+			ReviewRoles: []string{"risk"},
+		},
+		Risk: RiskConfig{
+			BlacklistFiles:       []string{"config/blacklist.txt"},
+			SanctionsFiles:       []string{"config/sanctions.txt"},
+			UserVelocityWindow:   24 * time.Hour,
+			UserVelocityMaxCount: 10,
+			GlobalVelocityWindow: time.Hour,
+			NewAddressCooling:    24 * time.Hour,
+		},
+		Chains: []ChainConfig{{
+			Name:          "ethereum",
+			Type:          CHAIN_TYPE_EVM,
+			Confirmations: 12,
+			Currencies: []CurrencyConfig{{
+				Currency:                "ETH",
+				Decimals:                18,
+				ReconcileTolerance:      "10000000000000000",     // 0.01 ETH
+				ReviewThreshold:         "5000000000000000000",   // 5 ETH
+				UserVelocityMaxAmount:   "10000000000000000000",  // 10 ETH / 24h
+				GlobalVelocityMaxAmount: "100000000000000000000", // 100 ETH / 1h
+				ApprovalTiers: []ApprovalTierConfig{
+					{MinAmount: "1000000000000000000", Required: 1, Roles: []string{"finance", "risk"}},         // >= 1 ETH
+					{MinAmount: "10000000000000000000", Required: 2, Roles: []string{"finance", "risk", "ops"}}, // >= 10 ETH
+				},
+				Limits: map[string]TierLimitConfig{
+					"basic":    {Daily: 2, Monthly: 20},
+					"advanced": {Daily: 100, Monthly: -1},
+				},
+			}},
+		}},
+	}
+}
+
+// Load 读取配置：path 为空时取 CONFIG_FILE 环境变量，仍为空则只用默认值与环境变量；
+// store 为 nil 时不支持 secret: 引用。needs 为当前服务必需的配置，缺失时返回错误
+func Load(ctx context.Context, path string, store SecretStore, needs ...Need) (*Config, error) {
+	cfg := Default()
+	if path == "" {
+		path = os.Getenv(CONFIG_FILE_ENV)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveSecrets(ctx, store); err != nil {
+		return nil, err
+	}
+	cfg.normalize()
+	if err := cfg.Validate(needs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) normalize() {
+	for i := range c.Chains {
+		ch := &c.Chains[i]
+		ch.Name = strings.ToLower(strings.TrimSpace(ch.Name))
+		if ch.Type == "" {
+			ch.Type = CHAIN_TYPE_EVM
+		}
+		for j := range ch.Currencies {
+			ch.Currencies[j].Currency = strings.ToUpper(strings.TrimSpace(ch.Currencies[j].Currency))
+		}
+	}
+	if c.Withdraw.Chain == "" && len(c.Chains) > 0 {
+		c.Withdraw.Chain = c.Chains[0].Name
+	}
+	c.Withdraw.Chain = strings.ToLower(c.Withdraw.Chain)
+}
+
+// Chain 按名称查找链配置
+func (c *Config) Chain(name string) (*ChainConfig, bool) {
+	for i := range c.Chains {
+		if c.Chains[i].Name == name {
+			return &c.Chains[i], true
+		}
+	}
+	return nil, false
+}
+
+// OwnAddresses 链上平台自有钱包地址（热钱包、冷钱包、gas 钱包），从这些地址转入充值地址的是内部转账
+func (ch *ChainConfig) OwnAddresses() []string {
+	var addrs []string
+	for _, addr := range []string{ch.HotWallet.Address, ch.ColdWallet.Address, ch.GasWallet.Address} {
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// DefaultChain 命令行工具未指定链时使用的链
+func (c *Config) DefaultChain() *ChainConfig {
+	ch, _ := c.Chain(c.Withdraw.Chain)
+	return ch
+}
+
+// WithdrawChains 配置了热钱包的链，提现按币种由所在链的热钱包签名
+func (c *Config) WithdrawChains() []*ChainConfig {
+	var chains []*ChainConfig
+	for i := range c.Chains {
+		if c.Chains[i].HotWallet.Address != "" {
+			chains = append(chains, &c.Chains[i])
+		}
+	}
+	return chains
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+database:
+  dsn: env:TEST_CONFIG_DSN
+withdraw:
+  approval_trail_key: secret:trail_key
+chains:
+  - name: Ethereum
+    rpc_url: https://eth.example
+    hot_wallet:
+      address: "0x00000000000000000000000000000000000000a1"
+      key: secret:eth_hot_key
+    currencies:
+      - currency: eth
+        decimals: 18
+  - name: solana
+    type: solana
+    rpc_url: https://sol.example
+    hot_wallet:
+      address: 11111111111111111111111111111111
+      key: "0a0b"
+    currencies:
+      - currency: sol
+        decimals: 9
+  - name: polygon
+    rpc_url: https://polygon.example
+    currencies:
+      - currency: matic
+        decimals: 18
+`
+
+// writeFile 在 dir 下写入文件，返回路径
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadResolvesEnvAndSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secrets := t.TempDir()
+	path := writeFile(t, dir, "custody.yaml", testConfig)
+	writeFile(t, secrets, "trail_key", "trail\n")
+	writeFile(t, secrets, "eth_hot_key", "0x01")
+	keyFile := writeFile(t, dir, "sol_key", "0c0d\n")
+
+	t.Setenv("TEST_CONFIG_DSN", "postgres://custody")
+	// 链配置的变量带 CHAIN_<NAME>_ 前缀，Secret 字段的 _FILE 变量指向密钥文件
+	t.Setenv("CHAIN_ETHEREUM_CONFIRMATIONS", "20")
+	t.Setenv("CHAIN_SOLANA_HOT_WALLET_KEY_FILE", keyFile)
+	t.Setenv("APPROVAL_TTL", "2h")
+
+	cfg, err := Load(context.Background(), path, DirStore(secrets), NEED_SIGNER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.DSN.Value() != "postgres://custody" {
+		t.Fatalf("dsn = %q, want the env reference resolved", cfg.Database.DSN.Value())
+	}
+	if cfg.Withdraw.ApprovalTrailKey.Value() != "trail" {
+		t.Fatalf("approval_trail_key = %q, want the secret store value without newline", cfg.Withdraw.ApprovalTrailKey.Value())
+	}
+	if cfg.Withdraw.ApprovalTTL.Hours() != 2 {
+		t.Fatalf("approval_ttl = %s, want 2h from env", cfg.Withdraw.ApprovalTTL)
+	}
+	eth, ok := cfg.Chain("ethereum")
+	if !ok || eth.Confirmations != 20 || eth.HotWallet.Key.Value() != "0x01" || eth.Currencies[0].Currency != "ETH" {
+		t.Fatalf("ethereum = %+v, want normalized names, env confirmations and resolved key", eth)
+	}
+	sol, _ := cfg.Chain("solana")
+	if sol.HotWallet.Key.Value() != "0c0d" {
+		t.Fatalf("solana hot wallet key = %q, want the _FILE override", sol.HotWallet.Key.Value())
+	}
+	// 打印配置时不泄露密钥
+	if s := eth.HotWallet.Key.String(); s != REDACTED {
+		t.Fatalf("secret prints as %q", s)
+	}
+
+	// 配置了热钱包的链都处理提现，未配置的不处理
+	var names []string
+	for _, ch := range cfg.WithdrawChains() {
+		names = append(names, ch.Name)
+	}
+	if strings.Join(names, ",") != "ethereum,solana" {
+		t.Fatalf("withdraw chains = %v, want [ethereum solana]", names)
+	}
+	if cfg.DefaultChain().Name != "ethereum" {
+		t.Fatalf("default chain = %s, want the first chain", cfg.DefaultChain().Name)
+	}
+}
+
+func TestLoadSecretErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "custody.yaml", testConfig)
+	t.Setenv("TEST_CONFIG_DSN", "postgres://custody")
+
+	// secret: 引用需要密钥服务
+	if _, err := Load(context.Background(), path, nil); !errors.Is(err, ErrNoSecretStore) {
+		t.Fatalf("Load without store err = %v, want %v", err, ErrNoSecretStore)
+	}
+	// 密钥不存在
+	if _, err := Load(context.Background(), path, DirStore(t.TempDir())); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("Load with empty store err = %v, want %v", err, ErrSecretNotFound)
+	}
+	// 密钥名不能逃出密钥目录
+	if _, err := DirStore(dir).GetSecret(context.Background(), "../custody.yaml"); err == nil {
+		t.Fatal("secret name with path accepted")
+	}
+}
+
+// 提现签名要求每条提现链的热钱包私钥为 hex
+func TestValidateWithdrawChainKeys(t *testing.T) {
+	dir := t.TempDir()
+	secrets := t.TempDir()
+	path := writeFile(t, dir, "custody.yaml", testConfig)
+	writeFile(t, secrets, "trail_key", "trail")
+	writeFile(t, secrets, "eth_hot_key", "0x01")
+	t.Setenv("TEST_CONFIG_DSN", "postgres://custody")
+	t.Setenv("CHAIN_SOLANA_HOT_WALLET_KEY", "not-hex")
+
+	_, err := Load(context.Background(), path, DirStore(secrets), NEED_SIGNER)
+	if err == nil || !strings.Contains(err.Error(), "chain solana: hot_wallet.key must be hex") {
+		t.Fatalf("err = %v, want solana hot wallet key rejected", err)
+	}
+}
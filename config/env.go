@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 环境变量覆盖
+// ==========================
+// 字段的 env 标签为变量名；链配置的变量加 CHAIN_<NAME>_ 前缀。
+// Secret 字段还可以用 <NAME>_FILE 指向密钥文件（如 Docker / Kubernetes 挂载的 secret）。
+
+const FILE_ENV_SUFFIX = "_FILE"
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	secretType   = reflect.TypeOf(Secret(""))
+	chainsType   = reflect.TypeOf([]ChainConfig(nil))
+)
+
+type lookupFunc func(key string) (string, bool)
+
+func (c *Config) applyEnv(lookup lookupFunc) error {
+	return applyEnv(reflect.ValueOf(c).Elem(), "", lookup)
+}
+
+// ChainEnvPrefix 链配置的环境变量前缀，如 ethereum -> CHAIN_ETHEREUM_
+func ChainEnvPrefix(name string) string {
+	return "CHAIN_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(name)) + "_"
+}
+
+func applyEnv(v reflect.Value, prefix string, lookup lookupFunc) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if f.Type == chainsType {
+			for j := 0; j < fv.Len(); j++ {
+				ch := fv.Index(j)
+				if err := applyEnv(ch, ChainEnvPrefix(ch.FieldByName("Name").String()), lookup); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if f.Type.Kind() == reflect.Struct {
+			if err := applyEnv(fv, prefix, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := f.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+		key := prefix + tag
+		if f.Type == secretType {
+			if path, ok := lookup(key + FILE_ENV_SUFFIX); ok && path != "" {
+				fv.SetString(SECRET_FILE_PREFIX + path)
+				continue
+			}
+		}
+		raw, ok := lookup(key)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := setValue(fv, raw); err != nil {
+			return fmt.Errorf("env %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func setValue(fv reflect.Value, raw string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
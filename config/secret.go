@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ==========================
+// 密钥
+// ==========================
+// Secret 字段的值可以是明文，也可以是引用：
+//
+//	file:/run/secrets/jwt   读取文件内容（去掉末尾换行）
+//	env:JWT_SECRET          读取环境变量
+//	secret:jwt              从 SecretStore 获取
+//
+// 解析后字段保存明文，打印与序列化时隐藏。
+
+const (
+	SECRET_FILE_PREFIX  = "file:"
+	SECRET_ENV_PREFIX   = "env:"
+	SECRET_STORE_PREFIX = "secret:"
+
+	REDACTED = "******"
+)
+
+var (
+	ErrSecretNotFound = errors.New("密钥不存在")
+	ErrNoSecretStore  = errors.New("未配置密钥服务，无法解析 secret: 引用")
+)
+
+// Secret 密钥类配置，通过 Value 取明文
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) IsSet() bool {
+	return s != ""
+}
+
+// String 防止密钥被打印到日志
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return REDACTED
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// SecretStore 外部密钥服务（Vault、KMS、云厂商 Secret Manager 等）的适配接口
+type SecretStore interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// DirStore 以目录下的文件为密钥，文件名即密钥名，适用于 Docker / Kubernetes 挂载的 secret
+type DirStore string
+
+func (d DirStore) GetSecret(ctx context.Context, name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	return readSecretFile(filepath.Join(string(d), name))
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrSecretNotFound, path)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// ResolveSecret 解析单个密钥引用
+func ResolveSecret(ctx context.Context, ref string, store SecretStore) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, SECRET_FILE_PREFIX):
+		return readSecretFile(strings.TrimPrefix(ref, SECRET_FILE_PREFIX))
+	case strings.HasPrefix(ref, SECRET_ENV_PREFIX):
+		name := strings.TrimPrefix(ref, SECRET_ENV_PREFIX)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%w: env %s", ErrSecretNotFound, name)
+		}
+		return v, nil
+	case strings.HasPrefix(ref, SECRET_STORE_PREFIX):
+		if store == nil {
+			return "", ErrNoSecretStore
+		}
+		return store.GetSecret(ctx, strings.TrimPrefix(ref, SECRET_STORE_PREFIX))
+	default:
+		return ref, nil
+	}
+}
+
+// resolveSecrets 将所有 Secret 字段中的引用替换为明文
+func (c *Config) resolveSecrets(ctx context.Context, store SecretStore) error {
+	return resolveSecrets(ctx, reflect.ValueOf(c).Elem(), "", store)
+}
+
+func resolveSecrets(ctx context.Context, v reflect.Value, path string, store SecretStore) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := t.Field(i).Tag.Get("yaml")
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := resolveSecrets(ctx, v.Field(i), name, store); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveSecrets(ctx, v.Index(i), fmt.Sprintf("%s[%d]", path, i), store); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if v.Type() != secretType || v.String() == "" {
+			return nil
+		}
+		plain, err := ResolveSecret(ctx, v.String(), store)
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", path, err)
+		}
+		v.SetString(plain)
+	}
+	return nil
+}
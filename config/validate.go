@@ -0,0 +1,193 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ==========================
+// 启动校验
+// ==========================
+
+// Need 服务启动必需的配置
+type Need string
+
+const (
+	NEED_RPC     Need = "rpc"     // 所有链的节点地址
+	NEED_AUTH    Need = "auth"    // JWT 与 TOTP 密钥
+	NEED_SIGNER  Need = "signer"  // 提现链热钱包与审批链密钥
+	NEED_OFFLINE Need = "offline" // 离线签名批次密钥
+)
+
+const (
+	MIN_JWT_SECRET_LENGTH = 32
+	TOTP_KEY_LENGTH       = 32
+)
+
+// Validate 校验配置结构及 needs 要求的配置项，返回全部问题
+func (c *Config) Validate(needs ...Need) error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !c.Database.DSN.IsSet() {
+		fail("database.dsn is required")
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		fail("http.shutdown_timeout must be positive")
+	}
+	if c.Auth.JWTSecret.IsSet() && len(c.Auth.JWTSecret) < MIN_JWT_SECRET_LENGTH {
+		fail("auth.jwt_secret must be at least %d bytes", MIN_JWT_SECRET_LENGTH)
+	}
+	if c.Auth.TOTPKey.IsSet() {
+		if key, err := hex.DecodeString(c.Auth.TOTPKey.Value()); err != nil || len(key) != TOTP_KEY_LENGTH {
+			fail("auth.totp_key must be %d bytes hex", TOTP_KEY_LENGTH)
+		}
+	}
+
+	if len(c.Chains) == 0 {
+		fail("at least one chain is required")
+	}
+	chains := map[string]bool{}
+	currencies := map[string]bool{}
+	for i, ch := range c.Chains {
+		field := fmt.Sprintf("chains[%d]", i)
+		if ch.Name == "" {
+			fail("%s.name is required", field)
+		} else if chains[ch.Name] {
+			fail("%s: duplicate chain %s", field, ch.Name)
+		}
+		chains[ch.Name] = true
+		if ch.Type != CHAIN_TYPE_EVM && ch.Type != CHAIN_TYPE_SOLANA {
+			fail("%s.type must be %s or %s", field, CHAIN_TYPE_EVM, CHAIN_TYPE_SOLANA)
+		}
+		if ch.GasWallet.Address != "" {
+			if _, err := hex.DecodeString(strings.TrimPrefix(ch.GasWallet.Key.Value(), "0x")); err != nil || !ch.GasWallet.Key.IsSet() {
+				fail("%s.gas_wallet.key must be hex", field)
+			}
+		}
+		if ch.ColdWallet.Safe && ch.Type != CHAIN_TYPE_EVM {
+			fail("%s.cold_wallet.safe is only supported on %s chains", field, CHAIN_TYPE_EVM)
+		}
+		for j, cur := range ch.Currencies {
+			cf := fmt.Sprintf("%s.currencies[%d]", field, j)
+			if cur.Currency == "" {
+				fail("%s.currency is required", cf)
+			} else if currencies[cur.Currency] {
+				fail("%s: duplicate currency %s", cf, cur.Currency)
+			}
+			currencies[cur.Currency] = true
+			if cur.Decimals < 0 || cur.Decimals > 36 {
+				fail("%s.decimals out of range", cf)
+			}
+			for name, amount := range map[string]string{
+				"sweep_threshold":            cur.SweepThreshold,
+				"reconcile_tolerance":        cur.ReconcileTolerance,
+				"review_threshold":           cur.ReviewThreshold,
+				"user_velocity_max_amount":   cur.UserVelocityMaxAmount,
+				"global_velocity_max_amount": cur.GlobalVelocityMaxAmount,
+			} {
+				if amount != "" && !isAmount(amount) {
+					fail("%s.%s must be a non-negative integer", cf, name)
+				}
+			}
+			for k, t := range cur.ApprovalTiers {
+				if !isAmount(t.MinAmount) {
+					fail("%s.approval_tiers[%d].min_amount must be a non-negative integer", cf, k)
+				}
+				if t.Required <= 0 || t.Required > len(t.Roles) {
+					fail("%s.approval_tiers[%d].required must be between 1 and the number of roles", cf, k)
+				}
+			}
+			for tier, l := range cur.Limits {
+				if !validLimit(l.Daily) || !validLimit(l.Monthly) {
+					fail("%s.limits.%s must be >= 0 or -1 for unlimited", cf, tier)
+				}
+			}
+			if cur.TokenPerNative != "" {
+				if r, ok := new(big.Rat).SetString(cur.TokenPerNative); !ok || r.Sign() <= 0 {
+					fail("%s.token_per_native must be a positive number or fraction", cf)
+				} else if cur.Token == nil {
+					fail("%s.token_per_native only applies to tokens", cf)
+				}
+			}
+			if t := cur.Treasury; t != nil {
+				if !isAmount(t.Min) || !isAmount(t.Target) || !isAmount(t.Max) {
+					fail("%s.treasury min, target and max must be non-negative integers", cf)
+				} else if amountOf(t.Min).Cmp(amountOf(t.Target)) > 0 || amountOf(t.Target).Cmp(amountOf(t.Max)) > 0 {
+					fail("%s.treasury must satisfy min <= target <= max", cf)
+				}
+				if ch.ColdWallet.Address == "" {
+					fail("%s.treasury requires %s.cold_wallet.address", cf, field)
+				}
+			}
+		}
+	}
+	if c.Sweep.Seed.IsSet() {
+		if _, err := hex.DecodeString(c.Sweep.Seed.Value()); err != nil {
+			fail("sweep.seed must be hex")
+		}
+	}
+	if c.Withdraw.Chain != "" && !chains[c.Withdraw.Chain] {
+		fail("withdraw.chain %s is not configured", c.Withdraw.Chain)
+	}
+
+	for _, need := range needs {
+		switch need {
+		case NEED_RPC:
+			for i, ch := range c.Chains {
+				if !ch.RPCURL.IsSet() {
+					fail("chains[%d].rpc_url is required (%sRPC_URL)", i, ChainEnvPrefix(ch.Name))
+				}
+			}
+		case NEED_AUTH:
+			if !c.Auth.JWTSecret.IsSet() {
+				fail("auth.jwt_secret is required")
+			}
+			if !c.Auth.TOTPKey.IsSet() {
+				fail("auth.totp_key is required")
+			}
+		case NEED_SIGNER:
+			if !c.Withdraw.ApprovalTrailKey.IsSet() {
+				fail("withdraw.approval_trail_key is required")
+			}
+			withdrawChains := c.WithdrawChains()
+			if len(withdrawChains) == 0 && len(c.Chains) > 0 {
+				fail("at least one chain needs hot_wallet.address to sign withdrawals")
+			}
+			for _, ch := range withdrawChains {
+				if _, err := hex.DecodeString(strings.TrimPrefix(ch.HotWallet.Key.Value(), "0x")); err != nil || !ch.HotWallet.Key.IsSet() {
+					fail("chain %s: hot_wallet.key must be hex", ch.Name)
+				}
+			}
+		case NEED_OFFLINE:
+			if !c.Offline.ExportKey.IsSet() {
+				fail("offline.export_key is required")
+			}
+			if c.Offline.SignerPub == "" {
+				fail("offline.signer_pub is required")
+			}
+		default:
+			fail("unknown config requirement %s", need)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isAmount(s string) bool {
+	n, ok := new(big.Int).SetString(s, 10)
+	return ok && n.Sign() >= 0
+}
+
+func amountOf(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+func validLimit(v float64) bool {
+	return v >= 0 || v == -1
+}
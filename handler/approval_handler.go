@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/crypto_custody/approval"
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ApprovalHandler struct {
+	svc *service.WithdrawalService
+}
+
+func NewApprovalHandler(svc *service.WithdrawalService) *ApprovalHandler {
+	return &ApprovalHandler{svc: svc}
+}
+
+// approvalRequest 操作员与角色取自访问令牌，请求体只带审批意见
+type approvalRequest struct {
+	Comment string `json:"comment"`
+}
+
+func approvalStatus(err error) int {
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, approval.ErrNotPending), errors.Is(err, approval.ErrExpired),
+		errors.Is(err, approval.ErrDuplicateRole), errors.Is(err, approval.ErrDuplicateOp):
+		return http.StatusConflict
+	case errors.Is(err, approval.ErrRoleNotAllowed):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// POST /api/admin/withdrawals/:id/approve
+func (h *ApprovalHandler) Approve(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid withdrawal id"})
+		return
+	}
+	var req approvalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	a, err := h.svc.ApproveWithdrawal(c, uint(id), auth.UserID(c), auth.Roles(c), req.Comment)
+	if err != nil {
+		c.JSON(approvalStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"approval": a})
+}
+
+// POST /api/admin/withdrawals/:id/reject
+func (h *ApprovalHandler) Reject(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid withdrawal id"})
+		return
+	}
+	var req approvalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	a, err := h.svc.RejectWithdrawal(c, uint(id), auth.UserID(c), auth.Roles(c), req.Comment)
+	if err != nil {
+		c.JSON(approvalStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"approval": a})
+}
+
+// GET /api/admin/withdrawals/:id/approvals
+func (h *ApprovalHandler) GetTrail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid withdrawal id"})
+		return
+	}
+
+	a, actions, err := h.svc.ApprovalTrail(c, uint(id))
+	if err != nil {
+		c.JSON(approvalStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"approval": a, "actions": actions})
+}
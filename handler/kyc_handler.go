@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/kyc"
+	"github.com/gin-gonic/gin"
+)
+
+type KYCHandler struct {
+	svc *kyc.Service
+}
+
+func NewKYCHandler(svc *kyc.Service) *KYCHandler {
+	return &KYCHandler{svc: svc}
+}
+
+func kycStatus(err error) int {
+	switch {
+	case errors.Is(err, kyc.ErrNotFound), errors.Is(err, kyc.ErrDocumentNotFound), errors.Is(err, kyc.ErrUnknownProvider):
+		return http.StatusNotFound
+	case errors.Is(err, kyc.ErrPending), errors.Is(err, kyc.ErrAlreadyApproved), errors.Is(err, kyc.ErrNotPending):
+		return http.StatusConflict
+	case errors.Is(err, kyc.ErrFinalRejection), errors.Is(err, kyc.ErrTooManyAttempts):
+		return http.StatusForbidden
+	case errors.Is(err, kyc.ErrCooldown):
+		return http.StatusTooManyRequests
+	case errors.Is(err, kyc.ErrInvalidDocument), errors.Is(err, kyc.ErrReasonRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, kyc.ErrInvalidCallback):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// POST /api/v1/user/kyc/submit
+// multipart 表单，字段 name、idNumber，证件文件按类型命名（id_front、id_back、selfie、proof_of_address）
+func (h *KYCHandler) Submit(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, kyc.MAX_DOCUMENTS*kyc.MAX_DOCUMENT_SIZE+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer form.RemoveAll()
+
+	var uploads []kyc.Upload
+	for field, files := range form.File {
+		for _, fh := range files {
+			f, err := fh.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			defer f.Close()
+			uploads = append(uploads, kyc.Upload{Type: field, Data: f})
+		}
+	}
+
+	app, err := h.svc.Submit(c, auth.UserID(c), c.PostForm("name"), c.PostForm("idNumber"), uploads)
+	if err != nil {
+		c.JSON(kycStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kycId": app.ID, "status": app.Status})
+}
+
+// GET /api/v1/user/kyc/status
+// 当前用户的 KYC 状态、拒绝原因及能否重新提交
+func (h *KYCHandler) Status(c *gin.Context) {
+	view, err := h.svc.Status(c, auth.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// POST /api/v1/user/kyc/callback/:provider
+// 外部 KYC 服务商回调审核结果
+func (h *KYCHandler) Callback(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, kyc.CALLBACK_MAX_BODY))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	app, err := h.svc.HandleCallback(c, c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		c.JSON(kycStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kycId": app.ID, "status": app.Status})
+}
+
+// GET /api/v1/admin/kyc?status=pending&page=1&size=20
+func (h *KYCHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	list, total, err := h.svc.List(c, c.Query("status"), page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "list": list})
+}
+
+// GET /api/v1/admin/kyc/:id
+func (h *KYCHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kyc id"})
+		return
+	}
+	app, err := h.svc.Get(c, id)
+	if err != nil {
+		c.JSON(kycStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kyc": app})
+}
+
+// GET /api/v1/admin/kyc/:id/documents/:docId
+// 下载证件原件
+func (h *KYCHandler) Document(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kyc id"})
+		return
+	}
+	docID, err := strconv.ParseUint(c.Param("docId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document id"})
+		return
+	}
+	doc, r, err := h.svc.Document(c, id, docID)
+	if err != nil {
+		c.JSON(kycStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer r.Close()
+	c.Header("Cache-Control", "no-store")
+	c.DataFromReader(http.StatusOK, doc.Size, doc.ContentType, r, nil)
+}
+
+// POST /api/v1/admin/kyc/:id/review
+// approved / rejected，通过时可指定等级 basic / advanced，拒绝须填写原因；final 表示不允许重新提交。
+// 审核人取自访问令牌
+func (h *KYCHandler) Review(c *gin.Context) {
+	var req struct {
+		Status string `json:"status" binding:"required,oneof=approved rejected"`
+		Tier   string `json:"tier" binding:"omitempty,oneof=basic advanced"`
+		Reason string `json:"reason"`
+		Final  bool   `json:"final"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kyc id"})
+		return
+	}
+
+	reviewerID := auth.UserID(c)
+	app, err := h.svc.Review(c, id, &reviewerID, req.Status, req.Tier, req.Reason, req.Final)
+	if err != nil {
+		c.JSON(kycStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kycId": app.ID, "status": app.Status, "tier": app.Tier, "reason": app.Reason})
+}
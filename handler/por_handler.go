@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/por"
+	"github.com/gin-gonic/gin"
+)
+
+type PorHandler struct {
+	generator *por.Generator
+}
+
+func NewPorHandler(generator *por.Generator) *PorHandler {
+	return &PorHandler{generator: generator}
+}
+
+func porStatus(err error) int {
+	switch {
+	case errors.Is(err, por.ErrSnapshotNotFound), errors.Is(err, por.ErrUserNotInTree):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GET /api/por/:currency
+// 公开最新快照的树根、总负债、储备及地址控制权签名
+func (h *PorHandler) GetLatest(c *gin.Context) {
+	snap, err := h.generator.Latest(c, c.Param("currency"))
+	if err != nil {
+		c.JSON(porStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"snapshot": snap})
+}
+
+// GET /api/wallet/proof-of-reserves?currency=ETH
+// 用户在最新快照中的包含证明
+func (h *PorHandler) GetUserProof(c *gin.Context) {
+	userID := auth.UserID(c)
+	currency := c.Query("currency")
+
+	snap, proof, err := h.generator.UserProof(c, currency, userID)
+	if err != nil {
+		c.JSON(porStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"snapshotId": snap.ID,
+		"root":       snap.Root,
+		"total":      snap.Liabilities,
+		"createdAt":  snap.CreatedAt,
+		"proof":      proof,
+	})
+}
+
+// POST /api/admin/por/:currency/snapshots
+func (h *PorHandler) CreateSnapshot(c *gin.Context) {
+	snap, err := h.generator.Snapshot(c, c.Param("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"snapshot": snap})
+}
+
+// POST /api/admin/por/snapshots/:id/address-proofs
+// 提交冷钱包等离线签名的地址控制权证明
+func (h *PorHandler) SubmitAddressProof(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid snapshot id"})
+		return
+	}
+	var req struct {
+		Address   string `json:"address" binding:"required"`
+		Signature string `json:"signature" binding:"required"` // hex
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(req.Signature, "0x"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signature"})
+		return
+	}
+	if err := h.generator.SubmitAddressProof(c, uint(id), req.Address, sig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "address proof accepted"})
+}
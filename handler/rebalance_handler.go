@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/offline"
+	"github.com/crypto_custody/service"
+	"github.com/gin-gonic/gin"
+)
+
+type RebalanceHandler struct {
+	rebalancer *service.Rebalancer
+	exporter   *offline.Exporter
+}
+
+func NewRebalanceHandler(rebalancer *service.Rebalancer, exporter *offline.Exporter) *RebalanceHandler {
+	return &RebalanceHandler{rebalancer: rebalancer, exporter: exporter}
+}
+
+// GET /api/admin/rebalances
+func (h *RebalanceHandler) List(c *gin.Context) {
+	list, err := h.rebalancer.List(c, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"records": list})
+}
+
+// POST /api/admin/rebalances/:id/approve
+func (h *RebalanceHandler) Approve(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rebalance id"})
+		return
+	}
+	var req approvalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	a, err := h.rebalancer.ApproveRefill(c, uint(id), auth.UserID(c), auth.Roles(c), req.Comment)
+	if err != nil {
+		c.JSON(approvalStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"approval": a})
+}
+
+// POST /api/admin/rebalances/:id/reject
+func (h *RebalanceHandler) Reject(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rebalance id"})
+		return
+	}
+	var req approvalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	a, err := h.rebalancer.RejectRefill(c, uint(id), auth.UserID(c), auth.Roles(c), req.Comment)
+	if err != nil {
+		c.JSON(approvalStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"approval": a})
+}
+
+// POST /api/admin/rebalances/:id/signed
+func (h *RebalanceHandler) SubmitSigned(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rebalance id"})
+		return
+	}
+	var req struct {
+		SignedTx string `json:"signedTx" binding:"required"` // hex
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	signed, err := hex.DecodeString(strings.TrimPrefix(req.SignedTx, "0x"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signed tx"})
+		return
+	}
+
+	txHash, err := h.rebalancer.SubmitSigned(c, uint(id), signed)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrRebalanceNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"txHash": txHash})
+}
+
+// POST /api/admin/safe/sign-requests/:id/signature
+// Safe owner 在线提交对 SafeTxHash 的签名
+func (h *RebalanceHandler) SubmitSafeSignature(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sign request id"})
+		return
+	}
+	var req struct {
+		Signature string `json:"signature" binding:"required"` // hex, 65 bytes
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(req.Signature, "0x"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signature"})
+		return
+	}
+	if err := h.rebalancer.SubmitSafeSignature(c, uint(id), sig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "signature accepted"})
+}
+
+// GET /api/admin/rebalances/export?qr=1&from=0x...
+// 导出审批通过的冷钱包交易，供离线签名机签名；Safe 多签按 owner 地址分别导出
+func (h *RebalanceHandler) Export(c *gin.Context) {
+	ids, err := h.rebalancer.ExportableSignRequests(c, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no approved cold transactions to export"})
+		return
+	}
+	bundle, err := h.exporter.Export(c, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := bundle.Encode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("qr") != "" {
+		c.JSON(http.StatusOK, gin.H{"id": bundle.ID, "chunks": offline.Chunk(data, offline.QR_CHUNK_SIZE)})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=unsigned-"+bundle.ID+".json")
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+// POST /api/admin/rebalances/import
+// 请求体为签名结果批次文件，或每行一个二维码分片
+func (h *RebalanceHandler) Import(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(body)), offline.QR_CHUNK_PREFIX+"/") {
+		if body, err = offline.Assemble(strings.Split(string(body), "\n")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	imported, err := h.exporter.Import(c, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.rebalancer.BroadcastSignedOnce(c); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"imported": len(imported)})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReconcileHandler struct {
+	reconciler *service.Reconciler
+}
+
+func NewReconcileHandler(reconciler *service.Reconciler) *ReconcileHandler {
+	return &ReconcileHandler{reconciler: reconciler}
+}
+
+// GET /api/admin/reconciliations?currency=ETH&limit=50
+func (h *ReconcileHandler) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	list, err := h.reconciler.Reports(c, c.Query("currency"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"records": list})
+}
+
+// GET /api/admin/reconciliations/:id
+// 对账报告及按地址的余额明细
+func (h *ReconcileHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid report id"})
+		return
+	}
+	report, err := h.reconciler.Report(c, uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrReportNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// POST /api/admin/reconciliations/run
+// 立即对账所有币种
+func (h *ReconcileHandler) Run(c *gin.Context) {
+	if err := h.reconciler.ReconcileOnce(c); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "reconciliation finished"})
+}
+
+// GET /api/admin/withdrawal-blocks
+func (h *ReconcileHandler) ListBlocks(c *gin.Context) {
+	list, err := h.reconciler.Blocks(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"records": list})
+}
+
+// POST /api/admin/withdrawal-blocks/:currency/release
+// 解除人取自访问令牌
+func (h *ReconcileHandler) Release(c *gin.Context) {
+	if err := h.reconciler.Release(c, c.Param("currency"), auth.UserID(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "withdrawals released"})
+}
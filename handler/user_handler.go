@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/service"
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	users *service.UserService
+}
+
+func NewUserHandler(users *service.UserService) *UserHandler {
+	return &UserHandler{users: users}
+}
+
+// authStatus 认证相关错误对应的 HTTP 状态码
+func authStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrWeakPassword):
+		return http.StatusBadRequest
+	case errors.Is(err, auth.ErrInvalidCredentials), errors.Is(err, auth.ErrInvalidCode),
+		errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrSessionRevoked), errors.Is(err, auth.ErrTokenReused):
+		return http.StatusUnauthorized
+	case errors.Is(err, auth.ErrTwoFactorLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, auth.ErrTwoFactorNotEnabled), errors.Is(err, auth.ErrTwoFactorEnabled), errors.Is(err, auth.ErrTwoFactorNotPending):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// POST /api/v1/user/register
+func (h *UserHandler) Register(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email"`
+		Phone    string `json:"phone"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.users.Register(c, req.Email, req.Phone, req.Password)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"userId": user.ID, "status": "created"})
+}
+
+// POST /api/v1/user/login
+// 开启二次验证的用户先拿到登录挑战，凭验证码在 /login/2fa 换取令牌
+func (h *UserHandler) Login(c *gin.Context) {
+	var req struct {
+		EmailOrPhone string `json:"email_or_phone"`
+		Password     string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.users.Login(c, req.EmailOrPhone, req.Password, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /api/v1/user/login/2fa
+func (h *UserHandler) LoginTwoFactor(c *gin.Context) {
+	var req struct {
+		Challenge string `json:"challenge" binding:"required"`
+		Code      string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.users.LoginTwoFactor(c, req.Challenge, req.Code, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /api/v1/user/token/refresh
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refreshToken" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.users.Refresh(c, req.RefreshToken)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": pair})
+}
+
+// POST /api/v1/user/logout?all=true
+func (h *UserHandler) Logout(c *gin.Context) {
+	if err := h.users.Logout(c, auth.UserID(c), auth.SessionID(c), c.Query("all") == "true"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "logged out"})
+}
+
+// POST /api/v1/user/2fa/enroll
+// 返回 otpauth URI 供验证器 App 扫码
+func (h *UserHandler) EnrollTwoFactor(c *gin.Context) {
+	secret, uri, err := h.users.EnrollTwoFactor(c, auth.UserID(c))
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"secret": secret, "otpauthUri": uri})
+}
+
+// POST /api/v1/user/2fa/confirm
+func (h *UserHandler) ConfirmTwoFactor(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.users.ConfirmTwoFactor(c, auth.UserID(c), req.Code)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "recoveryCodes": codes})
+}
+
+// POST /api/v1/user/2fa/disable
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.users.DisableTwoFactor(c, auth.UserID(c), req.Code); err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": false})
+}
+
+// POST /api/v1/user/2fa/recovery-codes
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.users.RegenerateRecoveryCodes(c, auth.UserID(c), req.Code)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
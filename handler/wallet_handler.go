@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"github.com/crypto_custody/auth"
 	"github.com/crypto_custody/service"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -17,7 +19,7 @@ func NewWalletHandler(svc *service.WalletService) *WalletHandler {
 
 // GET /api/wallet/deposit/address
 func (h *WalletHandler) GetDepositAddress(c *gin.Context) {
-	userID, _ := strconv.ParseUint(c.Query("userId"), 10, 64)
+	userID := auth.UserID(c)
 	currency := c.Query("currency")
 
 	addr, err := h.svc.GetDepositAddress(userID, currency)
@@ -30,7 +32,7 @@ func (h *WalletHandler) GetDepositAddress(c *gin.Context) {
 
 // GET /api/wallet/deposit/history
 func (h *WalletHandler) GetDepositHistory(c *gin.Context) {
-	userID, _ := strconv.ParseUint(c.Query("userId"), 10, 64)
+	userID := auth.UserID(c)
 	currency := c.Query("currency")
 	page, _ := strconv.Atoi(c.Query("page"))
 	size, _ := strconv.Atoi(c.Query("size"))
@@ -45,7 +47,7 @@ func (h *WalletHandler) GetDepositHistory(c *gin.Context) {
 
 // GET /api/wallet/withdraw/history
 func (h *WalletHandler) GetWithdrawHistory(c *gin.Context) {
-	userID, _ := strconv.ParseUint(c.Query("userId"), 10, 64)
+	userID := auth.UserID(c)
 	currency := c.Query("currency")
 	page, _ := strconv.Atoi(c.Query("page"))
 	size, _ := strconv.Atoi(c.Query("size"))
@@ -60,7 +62,7 @@ func (h *WalletHandler) GetWithdrawHistory(c *gin.Context) {
 
 // GET /api/wallet/balance
 func (h *WalletHandler) GetBalance(c *gin.Context) {
-	userID, _ := strconv.ParseUint(c.Query("userId"), 10, 64)
+	userID := auth.UserID(c)
 	currency := c.Query("currency")
 
 	available, frozen, err := h.svc.GetBalance(c, userID, currency)
@@ -70,3 +72,120 @@ func (h *WalletHandler) GetBalance(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"availableBalance": available, "frozenBalance": frozen})
 }
+
+// POST /api/wallet/withdraw
+// 需在 X-2FA-Code 头中附带二次验证码
+func (h *WalletHandler) RequestWithdraw(c *gin.Context) {
+	var req struct {
+		Currency string  `json:"currency" binding:"required"`
+		Address  string  `json:"address" binding:"required"`
+		Amount   float64 `json:"amount" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	withdraw, err := h.svc.RequestWithdraw(c, auth.UserID(c), req.Currency, req.Address, req.Amount)
+	if err != nil {
+		var limitErr *service.LimitExceededError
+		if errors.As(err, &limitErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "limit": limitErr})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"withdraw": withdraw})
+}
+
+// GET /api/wallet/address-book
+func (h *WalletHandler) ListAddressBook(c *gin.Context) {
+	userID := auth.UserID(c)
+	currency := c.Query("currency")
+
+	list, err := h.svc.AddressBook().ListEntries(c, userID, currency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setting, err := h.svc.AddressBook().GetSetting(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"whitelistOnly": setting.WhitelistOnly, "records": list})
+}
+
+// POST /api/wallet/address-book
+func (h *WalletHandler) AddAddressBookEntry(c *gin.Context) {
+	var req struct {
+		Currency string `json:"currency" binding:"required"`
+		Address  string `json:"address" binding:"required"`
+		Label    string `json:"label"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.svc.AddressBook().AddEntry(c, auth.UserID(c), req.Currency, req.Address, req.Label)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entry": entry})
+}
+
+// POST /api/wallet/address-book/:id/confirm
+func (h *WalletHandler) ConfirmAddressBookEntry(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.svc.AddressBook().ConfirmEntry(c, auth.UserID(c), id, req.Code)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrAddressBookNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entry": entry})
+}
+
+// DELETE /api/wallet/address-book/:id
+func (h *WalletHandler) DeleteAddressBookEntry(c *gin.Context) {
+	userID := auth.UserID(c)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err := h.svc.AddressBook().DeleteEntry(c, userID, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
+
+// PUT /api/wallet/withdraw/whitelist
+func (h *WalletHandler) SetWhitelistOnly(c *gin.Context) {
+	var req struct {
+		Enabled bool   `json:"enabled"`
+		Code    string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.AddressBook().SetWhitelistOnly(c, auth.UserID(c), req.Enabled, req.Code); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"whitelistOnly": req.Enabled})
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhooks *webhook.Service
+}
+
+func NewWebhookHandler(webhooks *webhook.Service) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+func webhookStatus(err error) int {
+	switch {
+	case errors.Is(err, webhook.ErrSubscriptionNotFound), errors.Is(err, webhook.ErrDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, webhook.ErrInvalidURL), errors.Is(err, webhook.ErrInvalidEvent):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GET /api/wallet/webhooks
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID := auth.UserID(c)
+	list, err := h.webhooks.List(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"list": list, "events": webhook.EVENTS})
+}
+
+// POST /api/wallet/webhooks
+// 返回的 secret 用于校验 X-Webhook-Signature，只返回这一次
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID := auth.UserID(c)
+	var req struct {
+		URL    string   `json:"url" binding:"required"`
+		Events []string `json:"events"` // 为空表示全部事件
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sub, secret, err := h.webhooks.Subscribe(c, userID, req.URL, req.Events)
+	if err != nil {
+		c.JSON(webhookStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscription": sub, "secret": secret})
+}
+
+// DELETE /api/wallet/webhooks/:id
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	userID := auth.UserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return
+	}
+	if err := h.webhooks.Delete(c, userID, uint(id)); err != nil {
+		c.JSON(webhookStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+// GET /api/wallet/webhooks/:id/deliveries?page=1&size=20
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	userID := auth.UserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	list, total, err := h.webhooks.Deliveries(c, userID, uint(id), page, size)
+	if err != nil {
+		c.JSON(webhookStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "list": list})
+}
+
+// POST /api/wallet/webhooks/deliveries/:id/redeliver
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID := auth.UserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+	if err := h.webhooks.Redeliver(c, userID, uint(id)); err != nil {
+		c.JSON(webhookStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "delivery queued"})
+}
@@ -0,0 +1,47 @@
+// Package testdb 测试用 PostgreSQL：TEST_DATABASE_DSN 指向一个可清空的测试库，未设置时跳过测试。
+package testdb
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/crypto_custody/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const DSN_ENV = "TEST_DATABASE_DSN"
+
+// Open 连接测试库并迁移到最新版本，清空 tables（级联清空引用它们的表）；测试结束时关闭连接
+func Open(t *testing.T, tables ...string) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(DSN_ENV)
+	if dsn == "" {
+		t.Skipf("%s not set", DSN_ENV)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) > 0 {
+		if err := db.Exec("TRUNCATE " + strings.Join(tables, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
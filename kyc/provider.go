@@ -0,0 +1,78 @@
+package kyc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/crypto_custody/blobstore"
+	"github.com/crypto_custody/model"
+)
+
+// ==========================
+// 外部 KYC 服务商
+// ==========================
+
+var ErrInvalidCallback = errors.New("KYC 回调签名或内容无效")
+
+// Result 服务商回调的审核结果
+type Result struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"` // approved / rejected
+	Tier      string `json:"tier"`   // 通过时授予的等级，为空则为 basic
+	Reason    string `json:"reason"`
+}
+
+// Provider 外部 KYC 服务商适配器：提交申请后服务商异步审核，结果通过回调送达
+type Provider interface {
+	Name() string
+	// Submit 将申请及证件提交给服务商，返回服务商侧的申请编号
+	Submit(ctx context.Context, app *model.KYC, docs []model.KYCDocument, blobs blobstore.Store) (string, error)
+	// ParseCallback 校验回调来源并解析结果
+	ParseCallback(header http.Header, body []byte) (*Result, error)
+}
+
+// HostedProvider 托管认证流程的服务商：用户在服务商页面完成认证，我方以申请 ID 作为编号，
+// 结果回调以共享密钥做 HMAC-SHA256 签名（hex，放在 SignatureHeader 中）
+type HostedProvider struct {
+	name            string
+	secret          []byte
+	SignatureHeader string
+}
+
+var _ Provider = (*HostedProvider)(nil)
+
+func NewHostedProvider(name string, secret []byte) *HostedProvider {
+	return &HostedProvider{name: name, secret: secret, SignatureHeader: "X-KYC-Signature"}
+}
+
+func (p *HostedProvider) Name() string {
+	return p.name
+}
+
+func (p *HostedProvider) Submit(ctx context.Context, app *model.KYC, docs []model.KYCDocument, blobs blobstore.Store) (string, error) {
+	return fmt.Sprintf("kyc-%d", app.ID), nil
+}
+
+func (p *HostedProvider) ParseCallback(header http.Header, body []byte) (*Result, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(header.Get(p.SignatureHeader), "sha256="))
+	if err != nil || len(sig) == 0 {
+		return nil, ErrInvalidCallback
+	}
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, ErrInvalidCallback
+	}
+	var r Result
+	if err := json.Unmarshal(body, &r); err != nil || r.Reference == "" {
+		return nil, ErrInvalidCallback
+	}
+	return &r, nil
+}
@@ -0,0 +1,67 @@
+package kyc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 回调只接受以共享密钥签名的原始请求体，签名可带 sha256= 前缀；
+// 签名缺失、错误、请求体被改动或缺少申请编号的回调一律拒绝
+func TestHostedProviderCallback(t *testing.T) {
+	p := NewHostedProvider("hosted", []byte("kyc-secret"))
+	body := []byte(`{"reference":"kyc-7","status":"approved","tier":"advanced"}`)
+
+	tests := []struct {
+		name      string
+		signature string
+		body      []byte
+		ok        bool
+	}{
+		{"hex", sign("kyc-secret", body), body, true},
+		{"prefixed", "sha256=" + sign("kyc-secret", body), body, true},
+		{"missing", "", body, false},
+		{"not hex", "sha256=zz", body, false},
+		{"other secret", sign("other", body), body, false},
+		{"tampered body", sign("kyc-secret", body), []byte(`{"reference":"kyc-8","status":"approved","tier":"advanced"}`), false},
+		{"no reference", sign("kyc-secret", []byte(`{"status":"approved"}`)), []byte(`{"status":"approved"}`), false},
+		{"not json", sign("kyc-secret", []byte("approved")), []byte("approved"), false},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.signature != "" {
+			header.Set("X-KYC-Signature", tt.signature)
+		}
+		r, err := p.ParseCallback(header, tt.body)
+		if !tt.ok {
+			if !errors.Is(err, ErrInvalidCallback) {
+				t.Fatalf("%s: err = %v, want %v", tt.name, err, ErrInvalidCallback)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if r.Reference != "kyc-7" || r.Status != "approved" || r.Tier != "advanced" {
+			t.Fatalf("%s: result = %+v", tt.name, r)
+		}
+	}
+}
+
+// 未配置的服务商回调直接拒绝，不解析请求体
+func TestHandleCallbackUnknownProvider(t *testing.T) {
+	s := NewService(nil, nil, NewHostedProvider("hosted", []byte("kyc-secret")))
+	if _, err := s.HandleCallback(context.Background(), "other", http.Header{}, nil); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("err = %v, want %v", err, ErrUnknownProvider)
+	}
+}
@@ -0,0 +1,443 @@
+// Package kyc 用户实名认证：证件上传、人工或外部服务商审核、被拒后的重新提交。
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/crypto_custody/blobstore"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/outbox"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	MAX_ATTEMPTS      = 5         // 最多提交次数
+	RESUBMIT_COOLDOWN = time.Hour // 被拒后多久可以重新提交
+	MAX_DOCUMENT_SIZE = 10 << 20  // 单个证件文件上限
+	MAX_DOCUMENTS     = 6         // 单次提交的文件数上限
+	CALLBACK_MAX_BODY = 1 << 20   // 服务商回调请求体上限
+	BLOB_PREFIX       = "kyc"     // blobstore key 前缀
+)
+
+// allowedTypes 按文件内容识别的类型，不信任客户端声明的 Content-Type
+var allowedTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+// REQUIRED_DOCUMENTS 每次提交必须包含的证件
+var REQUIRED_DOCUMENTS = []string{model.KYC_DOC_ID_FRONT, model.KYC_DOC_SELFIE}
+
+var documentTypes = map[string]bool{
+	model.KYC_DOC_ID_FRONT:         true,
+	model.KYC_DOC_ID_BACK:          true,
+	model.KYC_DOC_SELFIE:           true,
+	model.KYC_DOC_PROOF_OF_ADDRESS: true,
+}
+
+var (
+	ErrNotFound         = errors.New("KYC 申请不存在")
+	ErrDocumentNotFound = errors.New("KYC 证件不存在")
+	ErrPending          = errors.New("已有审核中的 KYC 申请")
+	ErrAlreadyApproved  = errors.New("KYC 已通过")
+	ErrFinalRejection   = errors.New("KYC 申请已被拒绝且不允许重新提交")
+	ErrTooManyAttempts  = errors.New("KYC 提交次数已达上限")
+	ErrCooldown         = errors.New("KYC 被拒后需等待一段时间才能重新提交")
+	ErrNotPending       = errors.New("KYC 申请不在待审核状态")
+	ErrReasonRequired   = errors.New("拒绝 KYC 申请必须填写原因")
+	ErrInvalidDocument  = errors.New("KYC 证件无效")
+	ErrUnknownProvider  = errors.New("未配置该 KYC 服务商")
+)
+
+// Upload 用户上传的一个证件文件
+type Upload struct {
+	Type string
+	Data io.Reader
+}
+
+// StatusView 用户查看的认证状态
+type StatusView struct {
+	Status       string     `json:"status"` // none / pending / approved / rejected
+	Tier         string     `json:"tier"`   // 当前生效的等级
+	Application  *model.KYC `json:"application,omitempty"`
+	CanSubmit    bool       `json:"canSubmit"`
+	NextSubmitAt *time.Time `json:"nextSubmitAt,omitempty"`
+	Remaining    int        `json:"remainingAttempts"`
+}
+
+type Service struct {
+	db        *gorm.DB
+	blobs     blobstore.Store
+	providers map[string]Provider
+	submitTo  Provider // 新申请提交给该服务商，nil 为人工审核
+}
+
+// NewService provider 为 nil 时全部申请走人工审核
+func NewService(db *gorm.DB, blobs blobstore.Store, provider Provider) *Service {
+	s := &Service{db: db, blobs: blobs, providers: map[string]Provider{}, submitTo: provider}
+	if provider != nil {
+		s.providers[provider.Name()] = provider
+	}
+	return s
+}
+
+func (s *Service) latest(tx *gorm.DB, userID uint64) (*model.KYC, int64, error) {
+	var count int64
+	if err := tx.Model(&model.KYC{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if count == 0 {
+		return nil, 0, nil
+	}
+	var app model.KYC
+	if err := tx.Where("user_id = ?", userID).Order("id DESC").First(&app).Error; err != nil {
+		return nil, 0, err
+	}
+	return &app, count, nil
+}
+
+// checkResubmit 重新提交规则：审核中或已通过最高等级不能提交（basic 可申请升级）；
+// 被拒且标记为最终拒绝、次数用完、冷却期内不能提交
+func checkResubmit(app *model.KYC, attempts int64, now time.Time) (*time.Time, error) {
+	if app == nil {
+		return nil, nil
+	}
+	switch app.Status {
+	case model.KYC_STATUS_PENDING:
+		return nil, ErrPending
+	case model.KYC_STATUS_APPROVED:
+		if app.Tier == model.KYC_TIER_ADVANCED {
+			return nil, ErrAlreadyApproved
+		}
+	}
+	if app.Final {
+		return nil, ErrFinalRejection
+	}
+	if attempts >= MAX_ATTEMPTS {
+		return nil, ErrTooManyAttempts
+	}
+	next := time.Unix(app.ReviewedAt, 0).Add(RESUBMIT_COOLDOWN)
+	if now.Before(next) {
+		return &next, ErrCooldown
+	}
+	return nil, nil
+}
+
+// Status 用户最新一次申请的状态及是否可以重新提交
+func (s *Service) Status(ctx context.Context, userID uint64) (*StatusView, error) {
+	app, attempts, err := s.latest(s.db.WithContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	tier, err := s.Tier(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	view := &StatusView{Status: "none", Tier: tier, Remaining: MAX_ATTEMPTS - int(attempts)}
+	if app != nil {
+		if err := s.db.WithContext(ctx).Where("kyc_id = ?", app.ID).Order("id").Find(&app.Documents).Error; err != nil {
+			return nil, err
+		}
+		view.Status = app.Status
+		view.Application = app
+	}
+	next, err := checkResubmit(app, attempts, time.Now())
+	view.CanSubmit = err == nil
+	view.NextSubmitAt = next
+	if view.Remaining < 0 || (app != nil && app.Final) {
+		view.Remaining = 0
+	}
+	return view, nil
+}
+
+// readDocument 读取并校验证件：大小、按内容识别的文件类型
+func readDocument(u Upload) ([]byte, string, error) {
+	if !documentTypes[u.Type] {
+		return nil, "", fmt.Errorf("%w: unknown type %q", ErrInvalidDocument, u.Type)
+	}
+	data, err := io.ReadAll(io.LimitReader(u.Data, MAX_DOCUMENT_SIZE+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 || len(data) > MAX_DOCUMENT_SIZE {
+		return nil, "", fmt.Errorf("%w: %s must be 1 byte to %d MB", ErrInvalidDocument, u.Type, MAX_DOCUMENT_SIZE>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, "", fmt.Errorf("%w: %s has unsupported content type %s", ErrInvalidDocument, u.Type, contentType)
+	}
+	return data, contentType, nil
+}
+
+// Submit 提交认证申请及证件。证件先写入 blobstore，数据库事务失败时删除
+func (s *Service) Submit(ctx context.Context, userID uint64, name, idNumber string, uploads []Upload) (*model.KYC, error) {
+	if name == "" || idNumber == "" {
+		return nil, errors.New("name and idNumber are required")
+	}
+	if len(uploads) > MAX_DOCUMENTS {
+		return nil, fmt.Errorf("%w: at most %d files", ErrInvalidDocument, MAX_DOCUMENTS)
+	}
+	present := map[string]bool{}
+	for _, u := range uploads {
+		present[u.Type] = true
+	}
+	for _, t := range REQUIRED_DOCUMENTS {
+		if !present[t] {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidDocument, t)
+		}
+	}
+	// 提交前先检查一次，避免无效上传
+	app, attempts, err := s.latest(s.db.WithContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := checkResubmit(app, attempts, time.Now()); err != nil {
+		return nil, err
+	}
+
+	docs := make([]model.KYCDocument, 0, len(uploads))
+	var keys []string
+	cleanup := func() {
+		for _, k := range keys {
+			if err := s.blobs.Delete(context.Background(), k); err != nil {
+				log.Printf("kyc: delete blob %s err: %v", k, err)
+			}
+		}
+	}
+	for _, u := range uploads {
+		data, contentType, err := readDocument(u)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		key := fmt.Sprintf("%s/%d/%s", BLOB_PREFIX, userID, uuid.NewString())
+		if err := s.blobs.Put(ctx, key, bytes.NewReader(data)); err != nil {
+			cleanup()
+			return nil, err
+		}
+		keys = append(keys, key)
+		sum := sha256.Sum256(data)
+		docs = append(docs, model.KYCDocument{
+			UserID:      userID,
+			Type:        u.Type,
+			BlobKey:     key,
+			ContentType: contentType,
+			Size:        int64(len(data)),
+			SHA256:      hex.EncodeToString(sum[:]),
+		})
+	}
+
+	var created model.KYC
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁住用户行，同一用户的并发提交串行执行
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Error; err != nil {
+			return err
+		}
+		app, attempts, err := s.latest(tx, userID)
+		if err != nil {
+			return err
+		}
+		if _, err := checkResubmit(app, attempts, time.Now()); err != nil {
+			return err
+		}
+		created = model.KYC{
+			UserID:   userID,
+			Name:     name,
+			IDNumber: idNumber,
+			Status:   model.KYC_STATUS_PENDING,
+			Attempt:  int(attempts) + 1,
+		}
+		if err := tx.Create(&created).Error; err != nil {
+			return err
+		}
+		for i := range docs {
+			docs[i].KYCID = created.ID
+		}
+		if err := tx.Create(&docs).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(tx, outbox.TOPIC_KYC_SUBMITTED, strconv.FormatUint(created.ID, 10), outbox.KYCSubmitted{
+			KYCID:  created.ID,
+			UserID: userID,
+		})
+	})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	created.Documents = docs
+
+	if s.submitTo != nil {
+		ref, err := s.submitTo.Submit(ctx, &created, docs, s.blobs)
+		if err != nil {
+			// 服务商不可用时留在人工审核队列
+			log.Printf("kyc #%d: submit to %s err: %v", created.ID, s.submitTo.Name(), err)
+			return &created, nil
+		}
+		if err := s.db.WithContext(ctx).Model(&created).
+			Updates(map[string]interface{}{"provider": s.submitTo.Name(), "provider_ref": ref}).Error; err != nil {
+			return nil, err
+		}
+		created.Provider, created.ProviderRef = s.submitTo.Name(), ref
+	}
+	return &created, nil
+}
+
+// Review 审核申请。reviewerID 为 nil 表示服务商回调；通过时授予 tier（为空则为 basic），
+// final 为 true 的拒绝不允许重新提交
+func (s *Service) Review(ctx context.Context, id uint64, reviewerID *uint64, status, tier, reason string, final bool) (*model.KYC, error) {
+	if status != model.KYC_STATUS_APPROVED && status != model.KYC_STATUS_REJECTED {
+		return nil, fmt.Errorf("invalid kyc status %q", status)
+	}
+	if status == model.KYC_STATUS_REJECTED && reason == "" {
+		return nil, ErrReasonRequired
+	}
+	if status == model.KYC_STATUS_APPROVED {
+		reason, final = "", false
+		if tier == "" {
+			tier = model.KYC_TIER_BASIC
+		}
+		if tier != model.KYC_TIER_BASIC && tier != model.KYC_TIER_ADVANCED {
+			return nil, fmt.Errorf("invalid kyc tier %q", tier)
+		}
+	} else {
+		tier = ""
+	}
+	var app model.KYC
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.KYC{}).Where("id = ? AND status = ?", id, model.KYC_STATUS_PENDING).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"tier":        tier,
+				"reason":      reason,
+				"final":       final,
+				"reviewer_id": reviewerID,
+				"reviewed_at": time.Now().Unix(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			if err := tx.First(&app, id).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return ErrNotPending
+		}
+		if err := tx.First(&app, id).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(tx, outbox.TOPIC_KYC_DECIDED, strconv.FormatUint(app.ID, 10), outbox.KYCDecided{
+			KYCID:  app.ID,
+			UserID: app.UserID,
+			Status: app.Status,
+			Reason: reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// HandleCallback 服务商回调审核结果
+func (s *Service) HandleCallback(ctx context.Context, providerName string, header http.Header, body []byte) (*model.KYC, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	result, err := p.ParseCallback(header, body)
+	if err != nil {
+		return nil, err
+	}
+	var app model.KYC
+	if err := s.db.WithContext(ctx).Where("provider = ? AND provider_ref = ?", providerName, result.Reference).
+		First(&app).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if result.Status == model.KYC_STATUS_REJECTED && result.Reason == "" {
+		result.Reason = "rejected by " + providerName
+	}
+	return s.Review(ctx, app.ID, nil, result.Status, result.Tier, result.Reason, false)
+}
+
+// Tier 用户当前等级：最近一次通过的申请授予的等级，没有通过的申请为 unverified。
+// 升级申请审核中或被拒时保持原等级
+func (s *Service) Tier(ctx context.Context, userID uint64) (string, error) {
+	var app model.KYC
+	err := s.db.WithContext(ctx).Where("user_id = ? AND status = ?", userID, model.KYC_STATUS_APPROVED).
+		Order("id DESC").First(&app).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.KYC_TIER_UNVERIFIED, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if app.Tier == "" {
+		return model.KYC_TIER_BASIC, nil
+	}
+	return app.Tier, nil
+}
+
+// List 审核列表，status 为空时返回全部
+func (s *Service) List(ctx context.Context, status string, page, size int) ([]model.KYC, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	q := s.db.WithContext(ctx).Model(&model.KYC{})
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []model.KYC
+	err := q.Order("id").Offset((page - 1) * size).Limit(size).Find(&list).Error
+	return list, total, err
+}
+
+// Get 申请详情及证件列表
+func (s *Service) Get(ctx context.Context, id uint64) (*model.KYC, error) {
+	var app model.KYC
+	if err := s.db.WithContext(ctx).Preload("Documents").First(&app, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &app, nil
+}
+
+// Document 读取证件内容，调用方负责关闭
+func (s *Service) Document(ctx context.Context, kycID, docID uint64) (*model.KYCDocument, io.ReadCloser, error) {
+	var doc model.KYCDocument
+	if err := s.db.WithContext(ctx).Where("id = ? AND kyc_id = ?", docID, kycID).First(&doc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrDocumentNotFound
+		}
+		return nil, nil, err
+	}
+	r, err := s.blobs.Get(ctx, doc.BlobKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &doc, r, nil
+}
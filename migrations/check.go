@@ -0,0 +1,162 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ==========================
+// 模型与表结构核对
+// ==========================
+// 迁移执行后，GORM 模型声明的表、列、类型和索引必须都存在于数据库中，
+// 否则运行时才会报错。数据库中多出的列和索引（如迁移补充的部分索引）不视为不一致，
+// 但模型未声明且 NOT NULL 无默认值的列会导致插入失败，也会报告。
+
+// Mismatch 一处不一致
+type Mismatch struct {
+	Table   string
+	Column  string // 表级问题为空
+	Problem string
+}
+
+func (m Mismatch) String() string {
+	if m.Column == "" {
+		return fmt.Sprintf("%s: %s", m.Table, m.Problem)
+	}
+	return fmt.Sprintf("%s.%s: %s", m.Table, m.Column, m.Problem)
+}
+
+var typeSizePattern = regexp.MustCompile(`^([a-z ]+)(?:\((\d+)(?:,\s*\d+)?\))?`)
+
+// 数据库类型别名，统一为 pg 内部类型名
+var typeAliases = map[string]string{
+	"bigserial":                "int8",
+	"bigint":                   "int8",
+	"serial":                   "int4",
+	"integer":                  "int4",
+	"int":                      "int4",
+	"smallserial":              "int2",
+	"smallint":                 "int2",
+	"boolean":                  "bool",
+	"decimal":                  "numeric",
+	"character varying":        "varchar",
+	"timestamp with time zone": "timestamptz",
+}
+
+// normalizeType 返回基础类型与长度（无长度为 0）
+func normalizeType(t string) (string, int64) {
+	m := typeSizePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(t)))
+	if m == nil {
+		return t, 0
+	}
+	base := strings.TrimSpace(m[1])
+	if alias, ok := typeAliases[base]; ok {
+		base = alias
+	}
+	size, _ := strconv.ParseInt(m[2], 10, 64)
+	return base, size
+}
+
+// Check 核对模型与当前数据库结构，返回全部不一致之处
+func Check(ctx context.Context, db *gorm.DB, models ...interface{}) ([]Mismatch, error) {
+	db = db.WithContext(ctx)
+	var mismatches []Mismatch
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("parse model %T: %w", model, err)
+		}
+		found, err := checkTable(db, stmt.Schema, model)
+		if err != nil {
+			return nil, fmt.Errorf("check table %s: %w", stmt.Schema.Table, err)
+		}
+		mismatches = append(mismatches, found...)
+	}
+	return mismatches, nil
+}
+
+func checkTable(db *gorm.DB, s *schema.Schema, model interface{}) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	fail := func(column, format string, args ...interface{}) {
+		mismatches = append(mismatches, Mismatch{Table: s.Table, Column: column, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	migrator := db.Migrator()
+	if !migrator.HasTable(model) {
+		fail("", "表不存在")
+		return mismatches, nil
+	}
+	columnTypes, err := migrator.ColumnTypes(model)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, ct := range columnTypes {
+		columns[ct.Name()] = ct
+	}
+
+	declared := map[string]bool{}
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.IgnoreMigration {
+			continue
+		}
+		declared[field.DBName] = true
+		ct, ok := columns[field.DBName]
+		if !ok {
+			fail(field.DBName, "列不存在")
+			continue
+		}
+		wantType, wantSize := normalizeType(db.Dialector.DataTypeOf(field))
+		gotType, _ := normalizeType(ct.DatabaseTypeName())
+		if wantType != gotType {
+			fail(field.DBName, "类型不一致：模型 %s，数据库 %s", wantType, gotType)
+		} else if gotSize, ok := ct.Length(); ok && wantType == "varchar" && wantSize != gotSize {
+			fail(field.DBName, "长度不一致：模型 %d，数据库 %d", wantSize, gotSize)
+		}
+		// 指针字段会写入 NULL
+		if nullable, ok := ct.Nullable(); ok && !nullable && field.FieldType.Kind() == reflect.Ptr {
+			fail(field.DBName, "模型允许 NULL，数据库为 NOT NULL")
+		}
+	}
+	for name, ct := range columns {
+		if declared[name] {
+			continue
+		}
+		nullable, _ := ct.Nullable()
+		if _, hasDefault := ct.DefaultValue(); !nullable && !hasDefault {
+			fail(name, "模型未声明的 NOT NULL 列，插入会失败")
+		}
+	}
+
+	var indexes []struct {
+		IndexName string
+		IndexDef  string
+	}
+	err = db.Raw("SELECT indexname AS index_name, indexdef AS index_def FROM pg_indexes WHERE schemaname = CURRENT_SCHEMA() AND tablename = ?", s.Table).
+		Scan(&indexes).Error
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]string, len(indexes))
+	for _, idx := range indexes {
+		defs[idx.IndexName] = idx.IndexDef
+	}
+	for _, idx := range s.ParseIndexes() {
+		def, ok := defs[idx.Name]
+		if !ok {
+			fail("", "索引 %s 不存在", idx.Name)
+			continue
+		}
+		if idx.Class == "UNIQUE" && !strings.HasPrefix(def, "CREATE UNIQUE INDEX") {
+			fail("", "索引 %s 应为唯一索引", idx.Name)
+		}
+	}
+	return mismatches, nil
+}
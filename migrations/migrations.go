@@ -0,0 +1,350 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ==========================
+// 数据库迁移
+// ==========================
+// 表结构只由 sql 目录下按版本号排序的迁移文件创建和修改，服务启动时不再 AutoMigrate。
+// 文件名格式 <版本>_<名称>.up.sql / .down.sql，版本号递增且不可复用；
+// 已执行的迁移记录在 schema_migrations，连同文件校验和，已发布的迁移文件不能再修改。
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// LOCK_KEY 迁移执行时持有的 pg advisory lock，多个实例同时执行 migrate 时串行
+const LOCK_KEY = 7_240_048_001
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	ErrChecksumMismatch = errors.New("已执行的迁移文件被修改")
+	ErrIrreversible     = errors.New("迁移没有 down 文件，无法回滚")
+	ErrUnknownVersion   = errors.New("数据库中存在程序未知的迁移版本")
+	ErrSchemaOutdated   = errors.New("数据库结构不是最新版本，请先执行 migrate up")
+)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // 为空表示不可回滚
+	Checksum string // Up 的 sha256
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:128"`
+	Checksum  string `gorm:"size:64"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 迁移执行状态
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	Modified  bool // 已执行后文件内容被修改
+}
+
+// Parse 读取目录下的迁移文件，按版本号排序
+func Parse(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := fileNamePattern.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", e.Name())
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version %s", e.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			sum := sha256.Sum256(data)
+			mig.Up, mig.Checksum = string(data), hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// All 程序内置的全部迁移
+func All() ([]Migration, error) {
+	return Parse(files, "sql")
+}
+
+// Migrator 在数据库上执行迁移
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New 使用内置迁移
+func New(db *gorm.DB) (*Migrator, error) {
+	list, err := All()
+	if err != nil {
+		return nil, err
+	}
+	return NewWithMigrations(db, list), nil
+}
+
+func NewWithMigrations(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Latest 内置迁移的最新版本
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// lock 在事务内取得迁移锁并确保记录表存在
+func lock(tx *gorm.DB) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", LOCK_KEY).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       varchar(128) NOT NULL,
+		checksum   varchar(64) NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+}
+
+// execScript 直接在连接上执行迁移脚本，不经过 gorm 的占位符替换；
+// 无参数时 pgx 使用简单协议，一个脚本可以包含多条语句
+func execScript(tx *gorm.DB, script string) error {
+	_, err := tx.Statement.ConnPool.ExecContext(tx.Statement.Context, script)
+	return err
+}
+
+func applied(tx *gorm.DB) (map[int64]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := tx.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[int64]SchemaMigration, len(rows))
+	for _, r := range rows {
+		done[r.Version] = r
+	}
+	return done, nil
+}
+
+// verify 已执行的迁移必须与内置文件一致
+func (m *Migrator) verify(done map[int64]SchemaMigration) error {
+	known := map[int64]bool{}
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		if r, ok := done[mig.Version]; ok && r.Checksum != mig.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+	for v := range done {
+		if !known[v] {
+			return fmt.Errorf("%w: %d", ErrUnknownVersion, v)
+		}
+	}
+	return nil
+}
+
+// Up 依次执行未执行的迁移直到 target（0 为最新版本），每个迁移一个事务，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context, target int64) ([]Migration, error) {
+	var ran []Migration
+	for _, mig := range m.migrations {
+		if target > 0 && mig.Version > target {
+			break
+		}
+		executed := false
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := lock(tx); err != nil {
+				return err
+			}
+			done, err := applied(tx)
+			if err != nil {
+				return err
+			}
+			if err := m.verify(done); err != nil {
+				return err
+			}
+			// 加锁后再判断，其他实例可能已执行
+			if _, ok := done[mig.Version]; ok {
+				return nil
+			}
+			if err := execScript(tx, mig.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			executed = true
+			return tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return ran, err
+		}
+		if executed {
+			ran = append(ran, mig)
+		}
+	}
+	return ran, nil
+}
+
+// Down 从最新已执行的版本开始回滚 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	for i := 0; i < steps; i++ {
+		var mig *Migration
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := lock(tx); err != nil {
+				return err
+			}
+			done, err := applied(tx)
+			if err != nil {
+				return err
+			}
+			if err := m.verify(done); err != nil {
+				return err
+			}
+			for j := len(m.migrations) - 1; j >= 0; j-- {
+				if _, ok := done[m.migrations[j].Version]; ok {
+					mig = &m.migrations[j]
+					break
+				}
+			}
+			if mig == nil {
+				return nil
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversible, mig.Version, mig.Name)
+			}
+			if err := execScript(tx, mig.Down); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			return tx.Delete(&SchemaMigration{}, mig.Version).Error
+		})
+		if err != nil {
+			return reverted, err
+		}
+		if mig == nil {
+			break
+		}
+		reverted = append(reverted, *mig)
+	}
+	return reverted, nil
+}
+
+// Force 不执行 SQL，直接把版本记录设为 version：不大于 version 的迁移记为已执行，其余删除。
+// 用于接管 AutoMigrate 时代已建好表的数据库，或在迁移失败并手工修复后修正记录。
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lock(tx); err != nil {
+			return err
+		}
+		if err := tx.Where("version > ?", version).Delete(&SchemaMigration{}).Error; err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			row := SchemaMigration{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, AppliedAt: time.Now()}
+			if err := tx.Save(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status 全部迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var list []Status
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lock(tx); err != nil {
+			return err
+		}
+		done, err := applied(tx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			s := Status{Migration: mig}
+			if r, ok := done[mig.Version]; ok {
+				at := r.AppliedAt
+				s.AppliedAt = &at
+				s.Modified = r.Checksum != mig.Checksum
+			}
+			list = append(list, s)
+		}
+		return nil
+	})
+	return list, err
+}
+
+// RequireLatest 服务启动时调用：数据库必须已执行全部内置迁移，且迁移文件未被修改
+func (m *Migrator) RequireLatest(ctx context.Context) error {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return ErrSchemaOutdated
+	}
+	done, err := applied(db)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(done); err != nil {
+		return err
+	}
+	for _, mig := range m.migrations {
+		if _, ok := done[mig.Version]; !ok {
+			return fmt.Errorf("%w: %d_%s 未执行", ErrSchemaOutdated, mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func parse(t *testing.T, files map[string]string) []Migration {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	for name, data := range files {
+		fsys["sql/"+name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	list, err := Parse(fsys, "sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+// 校验和只覆盖 up 文件：修改已执行的 up 文件时拒绝继续，只补 down 文件不影响；
+// 数据库中有程序不认识的版本时同样拒绝
+func TestVerifyChecksum(t *testing.T) {
+	released := parse(t, map[string]string{
+		"0001_init.up.sql":   "CREATE TABLE a (id bigint);",
+		"0002_more.up.sql":   "CREATE TABLE b (id bigint);",
+		"0002_more.down.sql": "DROP TABLE b;",
+	})
+	done := map[int64]SchemaMigration{}
+	for _, mig := range released {
+		done[mig.Version] = SchemaMigration{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum}
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		done  map[int64]SchemaMigration
+		want  error
+	}{
+		{"unchanged", map[string]string{
+			"0001_init.up.sql":   "CREATE TABLE a (id bigint);",
+			"0002_more.up.sql":   "CREATE TABLE b (id bigint);",
+			"0002_more.down.sql": "DROP TABLE b;",
+		}, done, nil},
+		{"down added", map[string]string{
+			"0001_init.up.sql":   "CREATE TABLE a (id bigint);",
+			"0001_init.down.sql": "DROP TABLE a;",
+			"0002_more.up.sql":   "CREATE TABLE b (id bigint);",
+			"0002_more.down.sql": "DROP TABLE b;",
+		}, done, nil},
+		{"new pending migration", map[string]string{
+			"0001_init.up.sql": "CREATE TABLE a (id bigint);",
+			"0002_more.up.sql": "CREATE TABLE b (id bigint);",
+			"0003_next.up.sql": "CREATE TABLE c (id bigint);",
+		}, done, nil},
+		{"applied up modified", map[string]string{
+			"0001_init.up.sql": "CREATE TABLE a (id bigint, name text);",
+			"0002_more.up.sql": "CREATE TABLE b (id bigint);",
+		}, done, ErrChecksumMismatch},
+		{"unknown applied version", map[string]string{
+			"0001_init.up.sql": "CREATE TABLE a (id bigint);",
+		}, done, ErrUnknownVersion},
+	}
+	for _, tt := range tests {
+		m := NewWithMigrations(nil, parse(t, tt.files))
+		if err := m.verify(tt.done); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: verify err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// 内置迁移文件名合法、版本从 1 连续递增
+func TestEmbeddedMigrations(t *testing.T) {
+	list, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, mig := range list {
+		if mig.Version != int64(i+1) || len(mig.Checksum) != 64 {
+			t.Fatalf("migration #%d = %d_%s checksum %q, want version %d", i, mig.Version, mig.Name, mig.Checksum, i+1)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+-- 回滚初始表结构，按依赖逆序删除
+
+DROP TABLE IF EXISTS addresses;
+DROP TABLE IF EXISTS hd_wallets;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS reserve_address_proofs;
+DROP TABLE IF EXISTS reserve_leafs;
+DROP TABLE IF EXISTS reserve_snapshots;
+DROP TABLE IF EXISTS safe_transactions;
+DROP TABLE IF EXISTS rebalances;
+DROP TABLE IF EXISTS withdrawal_blocks;
+DROP TABLE IF EXISTS reconcile_entries;
+DROP TABLE IF EXISTS reconcile_reports;
+DROP TABLE IF EXISTS approval_actions;
+DROP TABLE IF EXISTS withdrawal_approvals;
+DROP TABLE IF EXISTS risk_decisions;
+DROP TABLE IF EXISTS chain_nonces;
+DROP TABLE IF EXISTS sign_requests;
+DROP TABLE IF EXISTS withdrawals;
+DROP TABLE IF EXISTS withdraw_settings;
+DROP TABLE IF EXISTS address_book_entries;
+DROP TABLE IF EXISTS wallet_transactions;
+DROP TABLE IF EXISTS wallet_withdraws;
+DROP TABLE IF EXISTS wallet_deposits;
+DROP TABLE IF EXISTS wallet_addresses;
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kycs;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS two_factors;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS auth_sessions;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS processed_messages;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS gas_top_ups;
+DROP TABLE IF EXISTS sweeps;
+DROP TABLE IF EXISTS deposits;
+DROP TABLE IF EXISTS address_pools;
+DROP TABLE IF EXISTS onchain_events;
+DROP TABLE IF EXISTS processed_blocks;
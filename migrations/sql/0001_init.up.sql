@@ -0,0 +1,572 @@
+-- 初始表结构：原先由各服务 AutoMigrate 创建的全部表，补充唯一约束、外键与非空约束
+
+-- ==========================
+-- 链扫描与充值
+-- ==========================
+
+CREATE TABLE processed_blocks (
+    id           bigserial PRIMARY KEY,
+    chain        varchar(32) NOT NULL,
+    block_number bigint NOT NULL,
+    block_hash   varchar(128),
+    created_at   timestamptz
+);
+CREATE UNIQUE INDEX idx_chain_block ON processed_blocks (chain, block_number);
+
+CREATE TABLE onchain_events (
+    id           bigserial PRIMARY KEY,
+    chain        varchar(32) NOT NULL,
+    block_number bigint NOT NULL,
+    block_hash   varchar(128),
+    tx_hash      varchar(128) NOT NULL,
+    log_index    bigint NOT NULL,
+    address      varchar(128),
+    topics       text,
+    data         bytea,
+    processed    boolean NOT NULL DEFAULT false,
+    created_at   timestamptz
+);
+CREATE UNIQUE INDEX idx_event_unique ON onchain_events (chain, tx_hash, log_index);
+CREATE INDEX idx_onchain_events_block_number ON onchain_events (block_number);
+CREATE INDEX idx_onchain_events_processed ON onchain_events (processed);
+
+CREATE TABLE address_pools (
+    id         bigserial PRIMARY KEY,
+    chain      varchar(32) NOT NULL,
+    address    varchar(128) NOT NULL,
+    hd_index   bigint NOT NULL,
+    user_id    bigint,
+    used       boolean NOT NULL DEFAULT false,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX idx_address_pools_address ON address_pools (address);
+CREATE INDEX idx_address_pools_chain ON address_pools (chain);
+
+CREATE TABLE deposits (
+    id           bigserial PRIMARY KEY,
+    chain        varchar(32) NOT NULL,
+    token        varchar(128),
+    to_address   varchar(128) NOT NULL,
+    user_id      bigint,
+    amount       text NOT NULL,
+    tx_hash      varchar(128) NOT NULL,
+    block_number bigint NOT NULL,
+    confirmed    boolean NOT NULL DEFAULT false,
+    created_at   timestamptz
+);
+CREATE INDEX idx_deposits_chain ON deposits (chain);
+CREATE INDEX idx_deposits_to_address ON deposits (to_address);
+CREATE INDEX idx_deposits_tx_hash ON deposits (tx_hash);
+CREATE INDEX idx_deposits_block_number ON deposits (block_number);
+
+CREATE TABLE sweeps (
+    id           bigserial PRIMARY KEY,
+    chain        varchar(32) NOT NULL,
+    token        varchar(128),
+    from_address varchar(128) NOT NULL,
+    to_address   varchar(128) NOT NULL,
+    amount       text,
+    fee          text,
+    tx_hash      varchar(128),
+    status       varchar(20) NOT NULL,
+    error        text,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE INDEX idx_sweep_from ON sweeps (chain, from_address);
+CREATE INDEX idx_sweeps_tx_hash ON sweeps (tx_hash);
+CREATE INDEX idx_sweeps_status ON sweeps (status);
+
+CREATE TABLE gas_top_ups (
+    id         bigserial PRIMARY KEY,
+    chain      varchar(32) NOT NULL,
+    address    varchar(128) NOT NULL,
+    token      varchar(128),
+    amount     text,
+    fee        text,
+    tx_hash    varchar(128),
+    sweep_id   bigint,
+    leftover   text,
+    status     varchar(20) NOT NULL,
+    error      text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX idx_gas_topup_addr ON gas_top_ups (chain, address);
+CREATE INDEX idx_gas_top_ups_tx_hash ON gas_top_ups (tx_hash);
+CREATE INDEX idx_gas_top_ups_sweep_id ON gas_top_ups (sweep_id);
+CREATE INDEX idx_gas_top_ups_status ON gas_top_ups (status);
+
+-- ==========================
+-- 事件投递
+-- ==========================
+
+CREATE TABLE outbox_events (
+    id              bigserial PRIMARY KEY,
+    event_id        varchar(36) NOT NULL,
+    topic           varchar(64) NOT NULL,
+    aggregate_id    varchar(64),
+    payload         bytea,
+    attempts        bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz,
+    published_at    timestamptz,
+    last_error      text,
+    created_at      timestamptz
+);
+CREATE UNIQUE INDEX idx_outbox_events_event_id ON outbox_events (event_id);
+CREATE INDEX idx_outbox_events_topic ON outbox_events (topic);
+CREATE INDEX idx_outbox_events_next_attempt_at ON outbox_events (next_attempt_at);
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at);
+
+CREATE TABLE processed_messages (
+    consumer   varchar(64) NOT NULL,
+    event_id   varchar(36) NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (consumer, event_id)
+);
+
+-- ==========================
+-- 用户与认证
+-- ==========================
+
+-- 注册时邮箱、手机号二选一，未填写的为空字符串，空值不参与唯一约束
+CREATE TABLE users (
+    id         bigserial PRIMARY KEY,
+    email      text NOT NULL DEFAULT '',
+    phone      text NOT NULL DEFAULT '',
+    password   text NOT NULL,
+    created_at bigint,
+    updated_at bigint
+);
+CREATE UNIQUE INDEX idx_users_email ON users (email) WHERE email <> '';
+CREATE UNIQUE INDEX idx_users_phone ON users (phone) WHERE phone <> '';
+
+CREATE TABLE auth_sessions (
+    id         varchar(36) PRIMARY KEY,
+    user_id    bigint NOT NULL REFERENCES users (id),
+    user_agent varchar(255),
+    ip         varchar(64),
+    revoked_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX idx_auth_sessions_user_id ON auth_sessions (user_id);
+
+CREATE TABLE refresh_tokens (
+    jti        varchar(36) PRIMARY KEY,
+    session_id varchar(36) NOT NULL REFERENCES auth_sessions (id),
+    user_id    bigint NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens (session_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+
+CREATE TABLE two_factors (
+    user_id         bigint PRIMARY KEY REFERENCES users (id),
+    secret          varchar(255) NOT NULL,
+    enabled         boolean NOT NULL DEFAULT false,
+    last_step       bigint NOT NULL DEFAULT 0,
+    failed_attempts bigint NOT NULL DEFAULT 0,
+    locked_until    timestamptz,
+    confirmed_at    timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+
+CREATE TABLE recovery_codes (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL REFERENCES users (id),
+    code_hash  varchar(64) NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+-- ==========================
+-- KYC
+-- ==========================
+
+CREATE TABLE kycs (
+    id           bigserial PRIMARY KEY,
+    user_id      bigint NOT NULL REFERENCES users (id),
+    name         text,
+    id_number    text,
+    status       varchar(20) NOT NULL,
+    tier         varchar(20),
+    attempt      bigint NOT NULL DEFAULT 0,
+    reason       text,
+    final        boolean NOT NULL DEFAULT false,
+    provider     varchar(32),
+    provider_ref varchar(128),
+    reviewer_id  bigint,
+    reviewed_at  bigint,
+    created_at   bigint,
+    updated_at   bigint
+);
+CREATE INDEX idx_kycs_user_id ON kycs (user_id);
+CREATE INDEX idx_kycs_status ON kycs (status);
+CREATE INDEX idx_kycs_provider_ref ON kycs (provider_ref);
+
+CREATE TABLE kyc_documents (
+    id           bigserial PRIMARY KEY,
+    kyc_id       bigint NOT NULL REFERENCES kycs (id),
+    user_id      bigint NOT NULL,
+    type         varchar(32) NOT NULL,
+    blob_key     varchar(255) NOT NULL,
+    content_type varchar(64),
+    size         bigint,
+    sha256       varchar(64),
+    created_at   bigint
+);
+CREATE INDEX idx_kyc_documents_kyc_id ON kyc_documents (kyc_id);
+CREATE INDEX idx_kyc_documents_user_id ON kyc_documents (user_id);
+
+-- ==========================
+-- 钱包账本
+-- ==========================
+
+CREATE TABLE wallet_addresses (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint NOT NULL,
+    currency    varchar(16) NOT NULL,
+    address     varchar(256),
+    hd_path     varchar(128),
+    type        smallint NOT NULL,
+    status      smallint NOT NULL DEFAULT 0,
+    create_time timestamptz
+);
+CREATE UNIQUE INDEX idx_wallet_addresses_address ON wallet_addresses (address);
+COMMENT ON COLUMN wallet_addresses.type IS '0=热钱包,1=冷钱包';
+COMMENT ON COLUMN wallet_addresses.status IS '0=启用,1=停用';
+
+CREATE TABLE wallet_deposits (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint NOT NULL,
+    currency    varchar(16) NOT NULL,
+    ref_id      bigint,
+    type        smallint NOT NULL,
+    amount      decimal(32,8) NOT NULL,
+    status      smallint NOT NULL DEFAULT 0,
+    create_time timestamptz
+);
+COMMENT ON COLUMN wallet_deposits.type IS '1=充值,2=提现,3=手续费';
+COMMENT ON COLUMN wallet_deposits.status IS '0=处理中,1=成功,2=失败';
+
+CREATE TABLE wallet_withdraws (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint NOT NULL,
+    currency    varchar(16) NOT NULL,
+    address     varchar(256) NOT NULL,
+    amount      decimal(32,8) NOT NULL CHECK (amount > 0),
+    tx_id       varchar(128),
+    status      smallint NOT NULL DEFAULT 0,
+    create_time timestamptz,
+    update_time timestamptz
+);
+COMMENT ON COLUMN wallet_withdraws.status IS '0=Pending,1=Signed,2=Broadcasted,3=Confirmed,4=Failed';
+
+CREATE TABLE wallet_transactions (
+    id            bigserial PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    currency      varchar(16) NOT NULL,
+    address       varchar(256),
+    tx_id         varchar(128),
+    amount        decimal(32,8) NOT NULL,
+    confirmations bigint DEFAULT 0,
+    status        smallint NOT NULL DEFAULT 0,
+    block_height  bigint,
+    create_time   timestamptz,
+    update_time   timestamptz
+);
+CREATE INDEX idx_wallet_transactions_user_currency ON wallet_transactions (user_id, currency);
+COMMENT ON COLUMN wallet_transactions.status IS '0=Pending,1=Confirmed,2=Credited,3=Failed';
+
+CREATE TABLE address_book_entries (
+    id            bigserial PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    currency      varchar(16) NOT NULL,
+    address       varchar(256) NOT NULL,
+    label         varchar(64),
+    status        smallint NOT NULL DEFAULT 0,
+    confirm_time  timestamptz,
+    activate_time timestamptz,
+    create_time   timestamptz,
+    update_time   timestamptz
+);
+CREATE UNIQUE INDEX idx_address_book_user_addr ON address_book_entries (user_id, currency, address);
+COMMENT ON COLUMN address_book_entries.status IS '0=待确认,1=已确认';
+COMMENT ON COLUMN address_book_entries.activate_time IS '锁定期结束后可用';
+
+CREATE TABLE withdraw_settings (
+    user_id        bigint PRIMARY KEY,
+    whitelist_only boolean NOT NULL DEFAULT false,
+    update_time    timestamptz
+);
+COMMENT ON COLUMN withdraw_settings.whitelist_only IS '仅允许提现到地址簿中已生效的地址';
+
+-- ==========================
+-- 提现、签名与风控
+-- ==========================
+
+CREATE TABLE withdrawals (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    currency   varchar(16) NOT NULL,
+    address    varchar(64) NOT NULL,
+    amount     text NOT NULL,
+    status     varchar(20) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX idx_withdrawals_user_id ON withdrawals (user_id);
+
+CREATE TABLE sign_requests (
+    id            bigserial PRIMARY KEY,
+    withdrawal_id bigint,
+    chain         varchar(32) NOT NULL,
+    from_address  varchar(128) NOT NULL,
+    unsigned      bytea,
+    valid_until   bigint NOT NULL DEFAULT 0,
+    signed        bytea,
+    bundle_id     varchar(64),
+    safe_tx_id    bigint,
+    status        text NOT NULL,
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
+CREATE INDEX idx_sign_requests_withdrawal_id ON sign_requests (withdrawal_id);
+CREATE INDEX idx_sign_requests_bundle_id ON sign_requests (bundle_id);
+CREATE INDEX idx_sign_requests_safe_tx_id ON sign_requests (safe_tx_id);
+
+CREATE TABLE chain_nonces (
+    chain      text NOT NULL,
+    address    text NOT NULL,
+    next_nonce bigint NOT NULL DEFAULT 0,
+    updated_at timestamptz,
+    PRIMARY KEY (chain, address)
+);
+
+CREATE TABLE risk_decisions (
+    id            bigserial PRIMARY KEY,
+    withdrawal_id bigint NOT NULL,
+    decision      text NOT NULL,
+    reasons       text,
+    created_at    timestamptz
+);
+CREATE INDEX idx_risk_decisions_withdrawal_id ON risk_decisions (withdrawal_id);
+
+-- ==========================
+-- 审批
+-- ==========================
+
+CREATE TABLE withdrawal_approvals (
+    id            bigserial PRIMARY KEY,
+    kind          varchar(16) NOT NULL DEFAULT 'withdrawal',
+    withdrawal_id bigint NOT NULL,
+    currency      varchar(16),
+    amount        text,
+    required      bigint NOT NULL,
+    roles         varchar(255),
+    status        varchar(20) NOT NULL,
+    expires_at    timestamptz,
+    hash          varchar(64),
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
+CREATE UNIQUE INDEX idx_approval_subject ON withdrawal_approvals (kind, withdrawal_id);
+CREATE INDEX idx_withdrawal_approvals_status ON withdrawal_approvals (status);
+
+CREATE TABLE approval_actions (
+    id          bigserial PRIMARY KEY,
+    approval_id bigint NOT NULL REFERENCES withdrawal_approvals (id),
+    operator_id bigint NOT NULL,
+    role        varchar(32),
+    action      varchar(16) NOT NULL,
+    comment     text,
+    prev_hash   varchar(64),
+    hash        varchar(64) NOT NULL,
+    created_at  timestamptz
+);
+CREATE INDEX idx_approval_actions_approval_id ON approval_actions (approval_id);
+CREATE INDEX idx_approval_actions_operator_id ON approval_actions (operator_id);
+
+-- ==========================
+-- 对账
+-- ==========================
+
+CREATE TABLE reconcile_reports (
+    id          bigserial PRIMARY KEY,
+    chain       varchar(32),
+    currency    varchar(16) NOT NULL,
+    liabilities text,
+    holdings    text,
+    gap         text,
+    tolerance   text,
+    status      varchar(20) NOT NULL,
+    error       text,
+    created_at  timestamptz
+);
+CREATE INDEX idx_reconcile_reports_currency ON reconcile_reports (currency);
+CREATE INDEX idx_reconcile_reports_status ON reconcile_reports (status);
+
+CREATE TABLE reconcile_entries (
+    id         bigserial PRIMARY KEY,
+    report_id  bigint NOT NULL REFERENCES reconcile_reports (id),
+    address    varchar(128),
+    kind       varchar(16),
+    balance    text,
+    utxo_count bigint,
+    error      text
+);
+CREATE INDEX idx_reconcile_entries_report_id ON reconcile_entries (report_id);
+
+CREATE TABLE withdrawal_blocks (
+    currency    varchar(16) PRIMARY KEY,
+    active      boolean NOT NULL DEFAULT false,
+    report_id   bigint,
+    reason      text,
+    blocked_at  timestamptz,
+    released_by bigint,
+    released_at timestamptz,
+    updated_at  timestamptz
+);
+
+-- ==========================
+-- 冷热钱包调拨与多签
+-- ==========================
+
+CREATE TABLE rebalances (
+    id              bigserial PRIMARY KEY,
+    currency        varchar(16) NOT NULL,
+    chain           varchar(32) NOT NULL,
+    token           varchar(128),
+    direction       varchar(16) NOT NULL,
+    from_address    varchar(128),
+    to_address      varchar(128),
+    amount          text NOT NULL,
+    sign_request_id bigint,
+    safe_tx_id      bigint,
+    tx_hash         varchar(128),
+    status          varchar(20) NOT NULL,
+    error           text,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+CREATE INDEX idx_rebalances_currency ON rebalances (currency);
+CREATE INDEX idx_rebalances_status ON rebalances (status);
+
+CREATE TABLE safe_transactions (
+    id           bigserial PRIMARY KEY,
+    chain        varchar(32) NOT NULL,
+    safe_address varchar(128) NOT NULL,
+    nonce        bigint NOT NULL,
+    safe_tx_hash varchar(66) NOT NULL,
+    payload      bytea,
+    threshold    bigint NOT NULL,
+    exec_tx_hash varchar(128),
+    status       varchar(20) NOT NULL,
+    error        text,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE UNIQUE INDEX idx_safe_transactions_safe_tx_hash ON safe_transactions (safe_tx_hash);
+CREATE INDEX idx_safe_transactions_safe_address ON safe_transactions (safe_address);
+CREATE INDEX idx_safe_transactions_status ON safe_transactions (status);
+
+-- ==========================
+-- 储备证明
+-- ==========================
+
+CREATE TABLE reserve_snapshots (
+    id          bigserial PRIMARY KEY,
+    currency    varchar(16) NOT NULL,
+    root        varchar(64),
+    liabilities text,
+    reserves    text,
+    leaf_count  bigint,
+    created_at  timestamptz
+);
+CREATE INDEX idx_reserve_snapshots_currency ON reserve_snapshots (currency);
+
+CREATE TABLE reserve_leafs (
+    id          bigserial PRIMARY KEY,
+    snapshot_id bigint NOT NULL REFERENCES reserve_snapshots (id),
+    user_id     bigint NOT NULL,
+    leaf_index  bigint NOT NULL,
+    nonce       varchar(32),
+    balance     text
+);
+CREATE UNIQUE INDEX idx_reserve_leaf_user ON reserve_leafs (snapshot_id, user_id);
+
+CREATE TABLE reserve_address_proofs (
+    id          bigserial PRIMARY KEY,
+    snapshot_id bigint NOT NULL REFERENCES reserve_snapshots (id),
+    chain       varchar(32),
+    address     varchar(128),
+    balance     text,
+    message     text,
+    signature   text,
+    error       text,
+    created_at  timestamptz
+);
+CREATE INDEX idx_reserve_address_proofs_snapshot_id ON reserve_address_proofs (snapshot_id);
+
+-- ==========================
+-- Webhook
+-- ==========================
+
+CREATE TABLE webhook_subscriptions (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    url        varchar(512) NOT NULL,
+    secret     varchar(64),
+    events     varchar(255),
+    active     boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX idx_webhook_subscriptions_user_id ON webhook_subscriptions (user_id);
+
+-- 订阅删除时一并删除其投递记录
+CREATE TABLE webhook_deliveries (
+    id              bigserial PRIMARY KEY,
+    subscription_id bigint NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        varchar(36) NOT NULL,
+    event           varchar(64),
+    payload         bytea,
+    status          varchar(20) NOT NULL,
+    attempts        bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz,
+    response_code   bigint,
+    last_error      text,
+    delivered_at    timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+CREATE UNIQUE INDEX idx_webhook_delivery_event ON webhook_deliveries (subscription_id, event_id);
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+-- ==========================
+-- HD 钱包
+-- ==========================
+
+CREATE TABLE hd_wallets (
+    id               bigserial PRIMARY KEY,
+    seed_fingerprint varchar(64) NOT NULL,
+    coin_type        bigint NOT NULL
+);
+
+CREATE TABLE addresses (
+    id              bigserial PRIMARY KEY,
+    wallet_id       bigint NOT NULL REFERENCES hd_wallets (id),
+    derivation_path varchar(255) NOT NULL,
+    address         varchar(64) NOT NULL,
+    used            boolean NOT NULL DEFAULT false,
+    user_id         bigint
+);
+CREATE UNIQUE INDEX idx_addresses_address ON addresses (address);
+CREATE INDEX idx_addresses_wallet_id ON addresses (wallet_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_deposit_unique;
+ALTER TABLE deposits DROP COLUMN IF EXISTS log_index;
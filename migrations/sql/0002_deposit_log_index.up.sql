@@ -0,0 +1,9 @@
+-- 充值按 (chain, tx_hash, log_index) 唯一：同一交易中转入同一地址的多笔转账分别入账，重放同一区间不会重复入账
+
+ALTER TABLE deposits ADD COLUMN log_index bigint;
+
+-- 历史充值没有记录日志序号，记为 -id，不与真实序号（>= 0）冲突；处理器对这些记录仍按 tx_hash + to_address 去重
+UPDATE deposits SET log_index = -id;
+
+ALTER TABLE deposits ALTER COLUMN log_index SET NOT NULL;
+CREATE UNIQUE INDEX idx_deposit_unique ON deposits (chain, tx_hash, log_index);
@@ -0,0 +1,3 @@
+-- 0003 只转换存量地址的写法，没有改表结构，回滚只删除版本记录。
+-- 原始大小写写法未保留，无法恢复；规范形式（EVM 全小写等）对 0003 之前的版本同样是合法地址，不影响读取。
+SELECT 1;
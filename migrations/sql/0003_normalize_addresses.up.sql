@@ -0,0 +1,52 @@
+-- 地址统一为规范形式后按等值比较（见 address.Normalize）：EVM 地址转小写，其余地址去除首尾空白。
+-- base58 地址（Tron / Solana）区分大小写，不做转换。
+-- 该迁移不可回滚：原始大小写写法不再保留。
+
+-- 唯一索引所在的表若存在仅大小写不同的重复地址，需人工处理后再执行
+DO $$
+DECLARE
+    dup text;
+BEGIN
+    SELECT string_agg(t || ': ' || a, ', ') INTO dup FROM (
+        SELECT 'address_pools' AS t, lower(btrim(address)) AS a FROM address_pools
+            WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' GROUP BY 2 HAVING count(*) > 1
+        UNION ALL
+        SELECT 'addresses', lower(btrim(address)) FROM addresses
+            WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' GROUP BY 2 HAVING count(*) > 1
+        UNION ALL
+        SELECT 'wallet_addresses', lower(btrim(address)) FROM wallet_addresses
+            WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' GROUP BY 2 HAVING count(*) > 1
+    ) d;
+    IF dup IS NOT NULL THEN
+        RAISE EXCEPTION 'duplicate addresses differing only in case: %', dup;
+    END IF;
+END $$;
+
+-- 地址簿中同一用户同一币种的重复地址只保留一条：优先已确认、锁定期最早结束、最早添加
+DELETE FROM address_book_entries e USING (
+    SELECT id, row_number() OVER (
+        PARTITION BY user_id, currency,
+            CASE WHEN btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' THEN lower(btrim(address)) ELSE btrim(address) END
+        ORDER BY status DESC, activate_time ASC NULLS LAST, id ASC
+    ) AS rn
+    FROM address_book_entries
+) d
+WHERE e.id = d.id AND d.rn > 1;
+
+UPDATE address_pools SET address = lower(btrim(address)) WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' AND address <> lower(btrim(address));
+UPDATE address_pools SET address = btrim(address) WHERE address <> btrim(address);
+
+UPDATE addresses SET address = lower(btrim(address)) WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' AND address <> lower(btrim(address));
+UPDATE addresses SET address = btrim(address) WHERE address <> btrim(address);
+
+UPDATE wallet_addresses SET address = lower(btrim(address)) WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' AND address <> lower(btrim(address));
+UPDATE wallet_addresses SET address = btrim(address) WHERE address <> btrim(address);
+
+UPDATE address_book_entries SET address = lower(btrim(address)) WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' AND address <> lower(btrim(address));
+UPDATE address_book_entries SET address = btrim(address) WHERE address <> btrim(address);
+
+UPDATE deposits SET to_address = lower(btrim(to_address)) WHERE btrim(to_address) ~ '^0[xX][0-9a-fA-F]{40}$' AND to_address <> lower(btrim(to_address));
+UPDATE sweeps SET from_address = lower(btrim(from_address)) WHERE btrim(from_address) ~ '^0[xX][0-9a-fA-F]{40}$' AND from_address <> lower(btrim(from_address));
+UPDATE gas_top_ups SET address = lower(btrim(address)) WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' AND address <> lower(btrim(address));
+UPDATE withdrawals SET address = lower(btrim(address)) WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' AND address <> lower(btrim(address));
+UPDATE wallet_withdraws SET address = lower(btrim(address)) WHERE btrim(address) ~ '^0[xX][0-9a-fA-F]{40}$' AND address <> lower(btrim(address));
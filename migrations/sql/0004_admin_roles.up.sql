@@ -0,0 +1,9 @@
+-- 管理后台角色：管理接口只接受带角色的访问令牌，审批人、对账解除人、KYC 审核人均取自令牌。
+-- 授予角色：INSERT INTO admin_roles (user_id, role, created_at) VALUES (1, 'finance', now());
+
+CREATE TABLE admin_roles (
+    user_id    bigint NOT NULL,
+    role       varchar(32) NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (user_id, role)
+);
@@ -0,0 +1,4 @@
+-- 审批单记录目标地址并计入审批链起点的 HMAC，签名前与待发送交易的地址、金额比对。
+-- 旧审批单没有地址，签名校验会失败：升级前须等待审批中、已审批未发送的提现处理完毕。
+
+ALTER TABLE withdrawal_approvals ADD COLUMN address varchar(128);
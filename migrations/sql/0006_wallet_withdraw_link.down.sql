@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_wallet_withdraws_withdrawal_id;
+ALTER TABLE wallet_withdraws DROP COLUMN IF EXISTS withdrawal_id;
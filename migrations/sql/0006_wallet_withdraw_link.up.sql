@@ -0,0 +1,4 @@
+-- 钱包接口提交的提现申请转入提现流程（风控、审批、签名）后关联到提现记录，状态由提现流程回写
+
+ALTER TABLE wallet_withdraws ADD COLUMN withdrawal_id bigint;
+CREATE UNIQUE INDEX idx_wallet_withdraws_withdrawal_id ON wallet_withdraws (withdrawal_id);
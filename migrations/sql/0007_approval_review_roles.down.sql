@@ -0,0 +1 @@
+ALTER TABLE withdrawal_approvals DROP COLUMN IF EXISTS review_roles;
@@ -0,0 +1,4 @@
+-- 风控要求人工审核的提现除金额档位的审批外，还须有 review_roles 中至少一个角色审批。
+-- 旧审批单该列为空，按原规则校验。
+
+ALTER TABLE withdrawal_approvals ADD COLUMN review_roles varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- 提现签名后、广播前记录交易哈希；广播结果不确定的提现按哈希查询链上状态
+
+ALTER TABLE withdrawals ADD COLUMN tx_hash varchar(128) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE reconcile_reports DROP COLUMN IF EXISTS gas_top_ups;
+ALTER TABLE reconcile_reports DROP COLUMN IF EXISTS fees;
+DROP TABLE IF EXISTS network_fees;
@@ -0,0 +1,16 @@
+-- 对账扣除平台交易消耗的原生币手续费、计入 gas 钱包转入充值地址的金额
+
+CREATE TABLE network_fees (
+    id         bigserial PRIMARY KEY,
+    chain      varchar(32) NOT NULL,
+    source     varchar(16) NOT NULL,
+    ref_id     bigint NOT NULL,
+    tx_hash    varchar(128),
+    amount     text NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX idx_network_fee_ref ON network_fees (source, ref_id);
+CREATE INDEX idx_network_fees_chain ON network_fees (chain);
+
+ALTER TABLE reconcile_reports ADD COLUMN fees text;
+ALTER TABLE reconcile_reports ADD COLUMN gas_top_ups text;
@@ -0,0 +1 @@
+ALTER TABLE sweeps DROP COLUMN IF EXISTS nonce;
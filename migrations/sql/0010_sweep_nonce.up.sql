@@ -0,0 +1,3 @@
+-- 归集交易的 nonce：超时未上链的 EVM 归集以相同 nonce 替换，不再另发新交易
+
+ALTER TABLE sweeps ADD COLUMN nonce bigint;
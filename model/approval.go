@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+)
+
+// 提现审批单：一笔提现对应一张审批单，记录所需审批人数与可审批角色
+// Kind 区分审批对象，如用户提现、冷钱包调拨，WithdrawalID 为对应对象的 ID
+type WithdrawalApproval struct {
+	ID           uint   `gorm:"primaryKey"`
+	Kind         string `gorm:"size:16;default:withdrawal;uniqueIndex:idx_approval_subject"`
+	WithdrawalID uint   `gorm:"uniqueIndex:idx_approval_subject"`
+	Currency     string `gorm:"size:16"`
+	Address      string `gorm:"size:128"` // 目标地址，签名前与待发送交易比对
+	Amount       string // 最小单位金额，decimal string
+	Required     int    // 需要的审批人数（N）
+	Roles        string `gorm:"size:255"`      // 可审批角色（M 个），逗号分隔
+	ReviewRoles  string `gorm:"size:255"`      // 风控人工审核角色，非空时其中至少一个须审批，逗号分隔
+	Status       string `gorm:"size:20;index"` // pending / approved / rejected / expired
+	ExpiresAt    time.Time
+	Hash         string `gorm:"size:64"` // 审批单参数的 HMAC，作为审批链起点
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// 审批记录：只追加，每条记录的 Hash 覆盖上一条的 Hash，构成不可篡改的审批链
+type ApprovalAction struct {
+	ID         uint   `gorm:"primaryKey"`
+	ApprovalID uint   `gorm:"index"`
+	OperatorID uint64 `gorm:"index"`
+	Role       string `gorm:"size:32"`
+	Action     string `gorm:"size:16"` // approve / reject
+	Comment    string `gorm:"type:text"`
+	PrevHash   string `gorm:"size:64"`
+	Hash       string `gorm:"size:64"`
+	CreatedAt  time.Time
+}
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// AuthSession 一次登录会话；刷新令牌在同一会话内轮换，撤销会话后其访问令牌立即失效
+type AuthSession struct {
+	ID        string     `gorm:"primaryKey;size:36" json:"id"`
+	UserID    uint64     `gorm:"index" json:"userId"`
+	UserAgent string     `gorm:"size:255" json:"userAgent"`
+	IP        string     `gorm:"size:64" json:"ip"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// RefreshToken 已签发的刷新令牌，按 JTI 记录；使用后即作废，已作废的令牌再次出现视为泄露，撤销整个会话
+type RefreshToken struct {
+	JTI       string     `gorm:"primaryKey;size:36"`
+	SessionID string     `gorm:"size:36;index"`
+	UserID    uint64     `gorm:"index"`
+	ExpiresAt time.Time  `gorm:"index"`
+	UsedAt    *time.Time // 轮换时间
+	CreatedAt time.Time
+}
+
+// TwoFactor 用户的 TOTP 二次验证，密钥加密存储；Enabled 为 false 表示已生成密钥尚未确认
+type TwoFactor struct {
+	UserID         uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Secret         string `gorm:"size:255"` // AES-GCM 加密后的 base64
+	Enabled        bool
+	LastStep       int64 // 最近一次通过验证的时间步，同一验证码不能重复使用
+	FailedAttempts int
+	LockedUntil    *time.Time
+	ConfirmedAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// RecoveryCode 一次性恢复码，只保存哈希
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint64 `gorm:"index"`
+	CodeHash  string `gorm:"size:64"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// AdminRole 管理后台角色，一个用户可有多个角色；登录与刷新时写入访问令牌，撤销后最迟在访问令牌过期时失效
+type AdminRole struct {
+	UserID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Role      string `gorm:"primaryKey;size:32"`
+	CreatedAt time.Time
+}
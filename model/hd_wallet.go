@@ -0,0 +1,19 @@
+package model
+
+// HDWallet 由主种子派生的钱包，只保存种子指纹，不保存种子
+type HDWallet struct {
+	ID              uint   `gorm:"primaryKey"`
+	SeedFingerprint string `gorm:"size:64"`
+	CoinType        int
+	Addresses       []Address `gorm:"foreignKey:WalletID"`
+}
+
+// Address 钱包按 BIP44 路径派生出的地址
+type Address struct {
+	ID             uint   `gorm:"primaryKey"`
+	WalletID       uint   `gorm:"index"`
+	DerivationPath string `gorm:"size:255"`
+	Address        string `gorm:"uniqueIndex;size:64"`
+	Used           bool   `gorm:"default:false"`
+	UserID         *uint
+}
@@ -0,0 +1,56 @@
+package model
+
+// KYC 审核状态
+const (
+	KYC_STATUS_PENDING  = "pending"
+	KYC_STATUS_APPROVED = "approved"
+	KYC_STATUS_REJECTED = "rejected"
+)
+
+// KYC 等级，决定提现限额
+const (
+	KYC_TIER_UNVERIFIED = "unverified"
+	KYC_TIER_BASIC      = "basic"
+	KYC_TIER_ADVANCED   = "advanced"
+)
+
+// KYC 证件类型
+const (
+	KYC_DOC_ID_FRONT         = "id_front"
+	KYC_DOC_ID_BACK          = "id_back"
+	KYC_DOC_SELFIE           = "selfie"
+	KYC_DOC_PROOF_OF_ADDRESS = "proof_of_address"
+)
+
+// KYC 一次认证申请，被拒后重新提交会生成新的申请，历史申请保留
+type KYC struct {
+	ID          uint64        `gorm:"primaryKey" json:"id"`
+	UserID      uint64        `gorm:"index" json:"userId"`
+	Name        string        `json:"name"`
+	IDNumber    string        `json:"idNumber"`
+	Status      string        `gorm:"size:20;index" json:"status"` // pending/approved/rejected
+	Tier        string        `gorm:"size:20" json:"tier"`         // 审核通过时授予的等级：basic/advanced
+	Attempt     int           `json:"attempt"`                     // 第几次提交
+	Reason      string        `gorm:"type:text" json:"reason"`     // 拒绝原因，展示给用户
+	Final       bool          `json:"final"`                       // 拒绝且不允许重新提交
+	Provider    string        `gorm:"size:32" json:"provider"`     // 外部 KYC 服务商，空为人工审核
+	ProviderRef string        `gorm:"size:128;index" json:"providerRef"`
+	ReviewerID  *uint64       `json:"reviewerId"`
+	ReviewedAt  int64         `json:"reviewedAt"`
+	Documents   []KYCDocument `gorm:"foreignKey:KYCID" json:"documents,omitempty"`
+	CreatedAt   int64         `json:"createdAt"`
+	UpdatedAt   int64         `json:"updatedAt"`
+}
+
+// KYCDocument 申请附带的证件文件，内容保存在 blobstore
+type KYCDocument struct {
+	ID          uint64 `gorm:"primaryKey" json:"id"`
+	KYCID       uint64 `gorm:"index" json:"kycId"`
+	UserID      uint64 `gorm:"index" json:"userId"`
+	Type        string `gorm:"size:32" json:"type"`
+	BlobKey     string `gorm:"size:255" json:"-"`
+	ContentType string `gorm:"size:64" json:"contentType"`
+	Size        int64  `json:"size"`
+	SHA256      string `gorm:"size:64" json:"sha256"`
+	CreatedAt   int64  `json:"createdAt"`
+}
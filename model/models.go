@@ -0,0 +1,21 @@
+package model
+
+// Models 全部持久化模型，表结构由 migrations 下的 SQL 创建，
+// 启动检查与 migrate check 据此核对模型与数据库结构是否一致
+func Models() []interface{} {
+	return []interface{}{
+		&ProcessedBlock{}, &OnchainEvent{}, &AddressPool{}, &Deposit{}, &Sweep{}, &GasTopUp{},
+		&OutboxEvent{}, &ProcessedMessage{},
+		&User{}, &AuthSession{}, &RefreshToken{}, &TwoFactor{}, &RecoveryCode{}, &AdminRole{},
+		&KYC{}, &KYCDocument{},
+		&WalletAddress{}, &WalletDeposit{}, &WalletWithdraw{}, &WalletTransaction{},
+		&AddressBookEntry{}, &WithdrawSetting{},
+		&Withdrawal{}, &SignRequest{}, &ChainNonce{}, &RiskDecision{},
+		&WithdrawalApproval{}, &ApprovalAction{},
+		&ReconcileReport{}, &ReconcileEntry{}, &NetworkFee{}, &WithdrawalBlock{},
+		&Rebalance{}, &SafeTransaction{},
+		&ReserveSnapshot{}, &ReserveLeaf{}, &ReserveAddressProof{},
+		&WebhookSubscription{}, &WebhookDelivery{},
+		&HDWallet{}, &Address{},
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// OutboxEvent 与业务状态变更同事务写入的领域事件，由 relay 投递到消息队列
+type OutboxEvent struct {
+	ID            uint   `gorm:"primaryKey"`
+	EventID       string `gorm:"size:36;uniqueIndex"` // 去重 ID，消费方据此幂等
+	Topic         string `gorm:"size:64;index"`
+	AggregateID   string `gorm:"size:64"` // 业务对象 ID，如提现 ID、用户 ID
+	Payload       []byte `gorm:"type:bytea"`
+	Attempts      int
+	NextAttemptAt time.Time  `gorm:"index"`
+	PublishedAt   *time.Time `gorm:"index"`
+	LastError     string     `gorm:"type:text"`
+	CreatedAt     time.Time
+}
+
+// ProcessedMessage 消费方已处理的事件，at-least-once 投递下的幂等记录
+type ProcessedMessage struct {
+	Consumer  string `gorm:"primaryKey;size:64"`
+	EventID   string `gorm:"primaryKey;size:36"`
+	CreatedAt time.Time
+}
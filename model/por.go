@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// ReserveSnapshot 一次储备金证明快照：用户负债的 Merkle-sum 树根与我方地址控制权证明，金额均为最小单位
+type ReserveSnapshot struct {
+	ID            uint   `gorm:"primaryKey"`
+	Currency      string `gorm:"size:16;index"`
+	Root          string `gorm:"size:64"`   // Merkle-sum 树根哈希（hex）
+	Liabilities   string `gorm:"type:text"` // 树根金额，即全部用户资产之和
+	Reserves      string `gorm:"type:text"` // 已证明控制权的地址链上余额之和
+	LeafCount     int
+	AddressProofs []ReserveAddressProof `gorm:"foreignKey:SnapshotID"`
+	CreatedAt     time.Time
+}
+
+// ReserveLeaf 快照中的一个用户叶子，Nonce 用于隐藏用户 ID
+type ReserveLeaf struct {
+	ID         uint   `gorm:"primaryKey"`
+	SnapshotID uint   `gorm:"uniqueIndex:idx_reserve_leaf_user"`
+	UserID     uint64 `gorm:"uniqueIndex:idx_reserve_leaf_user"`
+	LeafIndex  int
+	Nonce      string `gorm:"size:32"`
+	Balance    string `gorm:"type:text"`
+}
+
+// ReserveAddressProof 对快照树根的签名消息，证明我方控制该地址
+type ReserveAddressProof struct {
+	ID         uint   `gorm:"primaryKey"`
+	SnapshotID uint   `gorm:"index"`
+	Chain      string `gorm:"size:32"`
+	Address    string `gorm:"size:128"`
+	Balance    string `gorm:"type:text"`
+	Message    string `gorm:"type:text"`
+	Signature  string `gorm:"type:text"` // hex；为空表示尚未签名，见 Error
+	Error      string `gorm:"type:text"`
+	CreatedAt  time.Time
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// 冷热钱包调拨方向
+const (
+	REBALANCE_HOT_TO_COLD = "hot_to_cold"
+	REBALANCE_COLD_TO_HOT = "cold_to_hot"
+)
+
+// 调拨状态
+const (
+	REBALANCE_STATUS_APPROVING   = "approving"   // 冷转热：等待审批
+	REBALANCE_STATUS_APPROVED    = "approved"    // 冷转热：审批通过，等待离线签名（Safe 为收集 owner 签名）
+	REBALANCE_STATUS_BROADCASTED = "broadcasted" // 已广播，等待确认
+	REBALANCE_STATUS_CONFIRMED   = "confirmed"
+	REBALANCE_STATUS_REJECTED    = "rejected"
+	REBALANCE_STATUS_EXPIRED     = "expired"
+	REBALANCE_STATUS_FAILED      = "failed"
+)
+
+// Rebalance 一笔冷热钱包之间的资金调拨
+type Rebalance struct {
+	ID            uint    `gorm:"primaryKey"`
+	Currency      string  `gorm:"size:16;index"`
+	Chain         string  `gorm:"size:32"`
+	Token         *string `gorm:"size:128"`
+	Direction     string  `gorm:"size:16"`
+	FromAddress   string  `gorm:"size:128"`
+	ToAddress     string  `gorm:"size:128"`
+	Amount        string  `gorm:"type:text"` // 最小单位
+	SignRequestID *uint   // 冷转热的待离线签名交易
+	SafeTxID      *uint   // 冷钱包为 Safe 多签时的多签交易
+	TxHash        *string `gorm:"size:128"`
+	Status        string  `gorm:"size:20;index"`
+	Error         string  `gorm:"type:text"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
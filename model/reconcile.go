@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// 对账结果
+const (
+	RECONCILE_STATUS_OK         = "ok"         // 差额在容忍范围内
+	RECONCILE_STATUS_MISMATCH   = "mismatch"   // 差额超限，已暂停该币种提现
+	RECONCILE_STATUS_INCOMPLETE = "incomplete" // 部分地址余额查询失败，不做判断
+)
+
+// ReconcileReport 一次链上持仓与账本负债的对账（按链 + 币种），金额均为最小单位
+type ReconcileReport struct {
+	ID          uint             `gorm:"primaryKey"`
+	Chain       string           `gorm:"size:32"`
+	Currency    string           `gorm:"size:16;index"`
+	Liabilities string           `gorm:"type:text"` // 账本中用户资产合计
+	Fees        string           `gorm:"type:text"` // 原生币：平台交易已消耗的手续费合计，见 NetworkFee
+	GasTopUps   string           `gorm:"type:text"` // 原生币：gas 钱包转入充值地址的合计
+	Holdings    string           `gorm:"type:text"` // 热、冷、充值地址链上余额合计
+	Gap         string           `gorm:"type:text"` // Holdings - (Liabilities - Fees + GasTopUps)，负数表示资产不足
+	Tolerance   string           `gorm:"type:text"`
+	Status      string           `gorm:"size:20;index"`
+	Error       string           `gorm:"type:text"`
+	Entries     []ReconcileEntry `gorm:"foreignKey:ReportID"`
+	CreatedAt   time.Time
+}
+
+// ReconcileEntry 对账明细：单个地址的链上余额
+type ReconcileEntry struct {
+	ID        uint   `gorm:"primaryKey"`
+	ReportID  uint   `gorm:"index"`
+	Address   string `gorm:"size:128"`
+	Kind      string `gorm:"size:16"` // hot / cold / deposit
+	Balance   string `gorm:"type:text"`
+	UTXOCount int    // UTXO 链的未花费输出个数
+	Error     string `gorm:"type:text"`
+}
+
+// 手续费来源
+const (
+	FEE_SOURCE_SWEEP      = "sweep"
+	FEE_SOURCE_WITHDRAWAL = "withdrawal"
+	FEE_SOURCE_REBALANCE  = "rebalance"
+)
+
+// NetworkFee 热、冷、充值地址发出的交易消耗的原生币手续费，每笔归集、提现、调拨一条，
+// 对账时从该链原生币的预期持仓中扣除；gas 钱包不计入持仓，补 gas 交易的手续费不记录
+type NetworkFee struct {
+	ID        uint   `gorm:"primaryKey"`
+	Chain     string `gorm:"size:32;index"`
+	Source    string `gorm:"size:16;uniqueIndex:idx_network_fee_ref"`
+	RefID     uint   `gorm:"uniqueIndex:idx_network_fee_ref"`
+	TxHash    string `gorm:"size:128"`
+	Amount    string `gorm:"type:text"` // 最小单位
+	CreatedAt time.Time
+}
+
+// WithdrawalBlock 币种提现熔断：对账差额超限时自动设置，需人工解除
+type WithdrawalBlock struct {
+	Currency   string `gorm:"primaryKey;size:16"`
+	Active     bool
+	ReportID   uint
+	Reason     string `gorm:"type:text"`
+	BlockedAt  time.Time
+	ReleasedBy *uint64
+	ReleasedAt *time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Safe 多签交易状态
+const (
+	SAFE_TX_STATUS_COLLECTING = "collecting" // 收集 owner 签名
+	SAFE_TX_STATUS_EXECUTED   = "executed"   // execTransaction 已广播
+	SAFE_TX_STATUS_CANCELLED  = "cancelled"  // 调拨被拒绝或审批过期
+	SAFE_TX_STATUS_FAILED     = "failed"
+)
+
+// SafeTransaction 一笔从 Safe 多签冷钱包转出的交易，每个 owner 对应一条 SignRequest
+type SafeTransaction struct {
+	ID          uint    `gorm:"primaryKey"`
+	Chain       string  `gorm:"size:32"`
+	SafeAddress string  `gorm:"size:128;index"`
+	Nonce       uint64  // Safe 合约 nonce
+	SafeTxHash  string  `gorm:"size:66;uniqueIndex"` // EIP-712 摘要，owner 签名的对象
+	Payload     []byte  `gorm:"type:bytea"`          // SafeTx JSON，与各 SignRequest.Unsigned 相同
+	Threshold   uint64  // 提案时链上的签名阈值
+	ExecTxHash  *string `gorm:"size:128"`
+	Status      string  `gorm:"size:20;index"`
+	Error       string  `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
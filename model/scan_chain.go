@@ -2,8 +2,6 @@ package model
 
 import (
 	"time"
-
-	"gorm.io/gorm"
 )
 
 type ProcessedBlock struct {
@@ -16,7 +14,7 @@ type ProcessedBlock struct {
 
 type OnchainEvent struct {
 	ID          uint   `gorm:"primaryKey"`
-	Chain       string `gorm:"size:32;index:idx_event_unique,priority:1"`
+	Chain       string `gorm:"size:32;index:idx_event_unique,unique,priority:1"`
 	BlockNumber int64  `gorm:"index"`
 	BlockHash   string `gorm:"size:128"`
 	TxHash      string `gorm:"size:128;index:idx_event_unique,priority:2"`
@@ -32,25 +30,23 @@ type AddressPool struct {
 	ID        uint   `gorm:"primaryKey"`
 	Chain     string `gorm:"size:32;index"`
 	Address   string `gorm:"size:128;uniqueIndex"`
+	HDIndex   uint32 // BIP44 地址索引，归集时据此派生私钥
 	UserID    *int64
 	Used      bool
 	CreatedAt time.Time
 }
 
+// Deposit 一笔充值，按 (chain, tx_hash, log_index) 唯一，同一交易中的多笔转账分别入账
 type Deposit struct {
 	ID          uint    `gorm:"primaryKey"`
-	Chain       string  `gorm:"size:32;index"`
+	Chain       string  `gorm:"size:32;index;uniqueIndex:idx_deposit_unique,priority:1"`
 	Token       *string `gorm:"size:128;null"` // token contract address for ERC20, nil for native
 	ToAddress   string  `gorm:"size:128;index"`
 	UserID      *int64
 	Amount      string `gorm:"type:text"` // use string to store big integers (wei/satoshi)
-	TxHash      string `gorm:"size:128;index"`
+	TxHash      string `gorm:"size:128;index;uniqueIndex:idx_deposit_unique,priority:2"`
+	LogIndex    int    `gorm:"uniqueIndex:idx_deposit_unique,priority:3"` // 日志 / 输出在交易内的序号；迁移前的历史充值为负数
 	BlockNumber int64  `gorm:"index"`
 	Confirmed   bool
 	CreatedAt   time.Time
 }
-
-// helper: create tables
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(&ProcessedBlock{}, &OnchainEvent{}, &AddressPool{}, &Deposit{})
-}
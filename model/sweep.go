@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// 归集状态
+const (
+	SWEEP_STATUS_PENDING     = "pending"     // 已创建，尚未广播
+	SWEEP_STATUS_BROADCASTED = "broadcasted" // 已广播，等待确认
+	SWEEP_STATUS_REPLACED    = "replaced"    // 超时未上链，已广播同 nonce 的替换交易，原交易仍可能上链
+	SWEEP_STATUS_CONFIRMED   = "confirmed"
+	SWEEP_STATUS_FAILED      = "failed"
+)
+
+// Sweep 一笔从充值地址到热钱包的归集交易
+type Sweep struct {
+	ID          uint    `gorm:"primaryKey"`
+	Chain       string  `gorm:"size:32;index:idx_sweep_from"`
+	Token       *string `gorm:"size:128"` // 代币合约 / mint，原生币为 nil
+	FromAddress string  `gorm:"size:128;index:idx_sweep_from"`
+	ToAddress   string  `gorm:"size:128"`
+	Amount      string  `gorm:"type:text"` // 归集金额（最小单位）
+	Fee         string  `gorm:"type:text"` // 手续费（原生币最小单位），广播时为上限，确认后为实际消耗
+	TxHash      *string `gorm:"size:128;index"`
+	Nonce       *uint64 // 账户模型链（EVM）的交易 nonce，同 nonce 的替换交易最多一笔上链
+	Status      string  `gorm:"size:20;index"`
+	Error       string  `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// 补 gas 状态
+const (
+	GAS_TOPUP_STATUS_BROADCASTED = "broadcasted" // 已向充值地址转入 gas，等待确认
+	GAS_TOPUP_STATUS_CONFIRMED   = "confirmed"   // gas 已到账，等待发起代币归集
+	GAS_TOPUP_STATUS_SWEPT       = "swept"       // 已发起代币归集
+	GAS_TOPUP_STATUS_RECONCILED  = "reconciled"  // 归集确认，剩余 gas 已对账
+	GAS_TOPUP_STATUS_FAILED      = "failed"
+)
+
+// GasTopUp 一笔从 gas 钱包向充值地址补充手续费的交易
+type GasTopUp struct {
+	ID        uint    `gorm:"primaryKey"`
+	Chain     string  `gorm:"size:32;index:idx_gas_topup_addr"`
+	Address   string  `gorm:"size:128;index:idx_gas_topup_addr"` // 充值地址
+	Token     string  `gorm:"size:128"`                          // 待归集的代币
+	Amount    string  `gorm:"type:text"`                         // 补充的原生币数量（最小单位）
+	Fee       string  `gorm:"type:text"`                         // 补 gas 交易本身的预估手续费
+	TxHash    *string `gorm:"size:128;index"`
+	SweepID   *uint   `gorm:"index"`
+	Leftover  string  `gorm:"type:text"` // 归集后充值地址剩余的原生币（gas 粉尘）
+	Status    string  `gorm:"size:20;index"`
+	Error     string  `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,12 @@
+package model
+
+// 用户表（users）：用户服务负责注册、登录，钱包、KYC、认证等表按 user_id 关联；
+// 余额不存在用户表上，以 wallet_transaction 流水为准
+type User struct {
+	ID        uint64 `gorm:"primaryKey" json:"id"`
+	Email     string `gorm:"uniqueIndex" json:"email"`
+	Phone     string `gorm:"uniqueIndex" json:"phone"`
+	Password  string `json:"-"` // bcrypt 哈希，历史数据可能为明文，登录成功后改为哈希
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
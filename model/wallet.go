@@ -30,15 +30,17 @@ type WalletDeposit struct {
 
 // 钱包提现记录表（wallet_withdraw）
 type WalletWithdraw struct {
-	ID        uint64    `gorm:"primaryKey;column:id" json:"id"`
-	UserID    uint64    `gorm:"column:user_id;not null" json:"user_id"`
-	Currency  string    `gorm:"column:currency;type:varchar(16);not null" json:"currency"`
-	Address   string    `gorm:"column:address;type:varchar(256);not null" json:"address"`
-	Amount    float64   `gorm:"column:amount;type:decimal(32,8);not null" json:"amount"`
-	TxID      string    `gorm:"column:tx_id;type:varchar(128)" json:"tx_id"`
-	Status    int8      `gorm:"column:status;not null;default:0;comment:0=Pending,1=Signed,2=Broadcasted,3=Confirmed,4=Failed" json:"status"`
-	CreatedAt time.Time `gorm:"column:create_time;autoCreateTime" json:"create_time"`
-	UpdatedAt time.Time `gorm:"column:update_time;autoUpdateTime" json:"update_time"`
+	ID       uint64  `gorm:"primaryKey;column:id" json:"id"`
+	UserID   uint64  `gorm:"column:user_id;not null" json:"user_id"`
+	Currency string  `gorm:"column:currency;type:varchar(16);not null" json:"currency"`
+	Address  string  `gorm:"column:address;type:varchar(256);not null" json:"address"`
+	Amount   float64 `gorm:"column:amount;type:decimal(32,8);not null" json:"amount"`
+	TxID     string  `gorm:"column:tx_id;type:varchar(128)" json:"tx_id"`
+	Status   int8    `gorm:"column:status;not null;default:0;comment:0=Pending,1=Signed,2=Broadcasted,3=Confirmed,4=Failed" json:"status"`
+	// 转入提现流程后对应的提现记录（withdrawals），状态由提现流程回写
+	WithdrawalID *uint     `gorm:"column:withdrawal_id;uniqueIndex" json:"withdrawal_id"`
+	CreatedAt    time.Time `gorm:"column:create_time;autoCreateTime" json:"create_time"`
+	UpdatedAt    time.Time `gorm:"column:update_time;autoUpdateTime" json:"update_time"`
 }
 
 // 钱包资金流水表（wallet_transaction）
@@ -55,3 +57,24 @@ type WalletTransaction struct {
 	CreatedAt     time.Time `gorm:"column:create_time;autoCreateTime" json:"create_time"`
 	UpdatedAt     time.Time `gorm:"column:update_time;autoUpdateTime" json:"update_time"`
 }
+
+// 提现地址簿（address_book_entry）
+type AddressBookEntry struct {
+	ID          uint64     `gorm:"primaryKey;column:id" json:"id"`
+	UserID      uint64     `gorm:"column:user_id;not null;uniqueIndex:idx_address_book_user_addr" json:"user_id"`
+	Currency    string     `gorm:"column:currency;type:varchar(16);not null;uniqueIndex:idx_address_book_user_addr" json:"currency"`
+	Address     string     `gorm:"column:address;type:varchar(256);not null;uniqueIndex:idx_address_book_user_addr" json:"address"`
+	Label       string     `gorm:"column:label;type:varchar(64)" json:"label"`
+	Status      int8       `gorm:"column:status;not null;default:0;comment:0=待确认,1=已确认" json:"status"`
+	ConfirmedAt *time.Time `gorm:"column:confirm_time" json:"confirm_time"`
+	ActivateAt  *time.Time `gorm:"column:activate_time;comment:锁定期结束后可用" json:"activate_time"`
+	CreatedAt   time.Time  `gorm:"column:create_time;autoCreateTime" json:"create_time"`
+	UpdatedAt   time.Time  `gorm:"column:update_time;autoUpdateTime" json:"update_time"`
+}
+
+// 用户提现设置（withdraw_setting）
+type WithdrawSetting struct {
+	UserID        uint64    `gorm:"primaryKey;column:user_id" json:"user_id"`
+	WhitelistOnly bool      `gorm:"column:whitelist_only;not null;default:false;comment:仅允许提现到地址簿中已生效的地址" json:"whitelist_only"`
+	UpdatedAt     time.Time `gorm:"column:update_time;autoUpdateTime" json:"update_time"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// 回调投递状态
+const (
+	WEBHOOK_DELIVERY_PENDING   = "pending"
+	WEBHOOK_DELIVERY_SUCCEEDED = "succeeded"
+	WEBHOOK_DELIVERY_FAILED    = "failed" // 超过最大重试次数，可手动重新投递
+)
+
+// WebhookSubscription 商户/用户的回调订阅
+type WebhookSubscription struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint64    `gorm:"index" json:"userId"`
+	URL       string    `gorm:"size:512" json:"url"`
+	Secret    string    `gorm:"size:64" json:"-"`       // HMAC 签名密钥，只在创建时返回
+	Events    string    `gorm:"size:255" json:"events"` // 订阅的事件，逗号分隔，"*" 表示全部
+	Active    bool      `gorm:"default:true" json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// WebhookDelivery 回调投递记录，同一订阅同一事件只投递一份
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint       `gorm:"uniqueIndex:idx_webhook_delivery_event" json:"subscriptionId"`
+	EventID        string     `gorm:"size:36;uniqueIndex:idx_webhook_delivery_event" json:"eventId"`
+	Event          string     `gorm:"size:64" json:"event"`
+	Payload        []byte     `gorm:"type:bytea" json:"-"`
+	Status         string     `gorm:"size:20;index" json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"nextAttemptAt"`
+	ResponseCode   int        `json:"responseCode"`
+	LastError      string     `gorm:"type:text" json:"lastError"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
@@ -4,25 +4,15 @@ import (
 	"time"
 )
 
-// 用户表：记录余额（示例中只考虑 ETH，生产要支持多币种）
-type User struct {
-	ID        uint   `gorm:"primaryKey"`
-	Balance   string // 用户余额（wei，decimal string 存储）
-	Frozen    string // 冻结金额（未完成的提现）
-	CreatedAt time.Time
-	UpdatedAt time.Time
-}
-
 // 提现表：记录用户发起的提现请求
 type Withdrawal struct {
 	ID        uint   `gorm:"primaryKey"`
-	UserID    uint   // 用户ID
-	Chain     string // 区块链，如 "ethereum"
-	ToAddress string // 提现地址
-	Amount    string // 提现金额（wei，decimal string）
-	Fee       string // 手续费（wei，decimal string）
-	Status    string // 状态：pending / approved / signed / broadcasted / confirmed / failed
-	TxHash    *string
+	UserID    uint   `gorm:"index"`
+	Currency  string `gorm:"size:16"`
+	Address   string `gorm:"size:64"`
+	Amount    string // 最小单位金额，decimal string
+	Status    string `gorm:"size:20"`  // pending / approving / approved / signing / broadcasting / unconfirmed / rejected / expired / success / failed
+	TxHash    string `gorm:"size:128"` // 签名后、广播前写入
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -31,9 +21,14 @@ type Withdrawal struct {
 type SignRequest struct {
 	ID           uint   `gorm:"primaryKey"`
 	WithdrawalID uint   // 关联的提现记录
+	Chain        string `gorm:"size:32"`
+	FromAddress  string `gorm:"size:128"`   // 签名地址
 	Unsigned     []byte `gorm:"type:bytea"` // 未签名交易 JSON
-	Signed       []byte `gorm:"type:bytea"` // 签名结果 RLP
-	Status       string // 状态：created / signed / failed
+	ValidUntil   uint64 // 交易失效高度，0 表示不过期
+	Signed       []byte `gorm:"type:bytea"`    // 签名结果 RLP
+	BundleID     string `gorm:"size:64;index"` // 离线签名导出批次
+	SafeTxID     *uint  `gorm:"index"`         // Safe 多签交易，每个 owner 一条签名请求
+	Status       string // 状态：created / exported / signed / failed
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -45,3 +40,12 @@ type ChainNonce struct {
 	NextNonce uint64
 	UpdatedAt time.Time
 }
+
+// 风控决策表：每笔提现的风控结论及命中原因，只追加不修改
+type RiskDecision struct {
+	ID           uint   `gorm:"primaryKey"`
+	WithdrawalID uint   `gorm:"index"`
+	Decision     string // allow / review / reject
+	Reasons      string `gorm:"type:text"` // 命中规则原因，JSON 数组
+	CreatedAt    time.Time
+}
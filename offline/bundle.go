@@ -0,0 +1,160 @@
+package offline
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ==========================
+// 离线签名批次文件
+// ==========================
+// 在线端导出待签名交易为 unsigned 批次，离线签名机签名后生成同 ID 的 signed 批次。
+// 批次内容做 SHA-256 校验和，并由生成方的 ed25519 密钥签名，双方各自只信任对方的公钥。
+
+const (
+	BUNDLE_VERSION = 1
+
+	KIND_UNSIGNED = "unsigned"
+	KIND_SIGNED   = "signed"
+)
+
+var (
+	ErrChecksum        = errors.New("bundle checksum mismatch")
+	ErrSignature       = errors.New("bundle signature invalid")
+	ErrUntrustedSigner = errors.New("bundle sealed by untrusted key")
+	ErrKind            = errors.New("unexpected bundle kind")
+	ErrMismatch        = errors.New("signed item does not match exported request")
+)
+
+// Item 一笔待签名 / 已签名交易
+type Item struct {
+	SignRequestID uint   `json:"sign_request_id"`
+	Chain         string `json:"chain"`
+	From          string `json:"from"`
+	Payload       []byte `json:"payload,omitempty"` // 未签名交易，只在 unsigned 批次中出现
+	ValidUntil    uint64 `json:"valid_until,omitempty"`
+	PayloadHash   string `json:"payload_hash"` // 未签名交易的 SHA-256，两种批次都携带
+	Signed        []byte `json:"signed,omitempty"`
+}
+
+type Bundle struct {
+	Version   int       `json:"version"`
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Items     []Item    `json:"items"`
+	Checksum  string    `json:"checksum"`
+	Signer    string    `json:"signer"`    // 封装方 ed25519 公钥（hex）
+	Signature string    `json:"signature"` // 对 Checksum 的签名（hex）
+}
+
+// NewBundleID 随机批次 ID
+func NewBundleID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func PayloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// digest 对除校验和与签名之外的全部字段计算 SHA-256
+func (b *Bundle) digest() (string, error) {
+	content, err := json.Marshal(struct {
+		Version   int       `json:"version"`
+		Kind      string    `json:"kind"`
+		ID        string    `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+		Items     []Item    `json:"items"`
+	}{b.Version, b.Kind, b.ID, b.CreatedAt, b.Items})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Seal 计算校验和并签名
+func (b *Bundle) Seal(key ed25519.PrivateKey) error {
+	checksum, err := b.digest()
+	if err != nil {
+		return err
+	}
+	b.Checksum = checksum
+	b.Signer = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	b.Signature = hex.EncodeToString(ed25519.Sign(key, []byte(checksum)))
+	return nil
+}
+
+// Verify 校验批次完整性，并确认由 trusted 公钥封装
+func (b *Bundle) Verify(trusted ed25519.PublicKey) error {
+	if b.Version != BUNDLE_VERSION {
+		return fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	checksum, err := b.digest()
+	if err != nil {
+		return err
+	}
+	if checksum != b.Checksum {
+		return ErrChecksum
+	}
+	if b.Signer != hex.EncodeToString(trusted) {
+		return ErrUntrustedSigner
+	}
+	sig, err := hex.DecodeString(b.Signature)
+	if err != nil || !ed25519.Verify(trusted, []byte(b.Checksum), sig) {
+		return ErrSignature
+	}
+	for _, it := range b.Items {
+		if b.Kind == KIND_UNSIGNED && PayloadHash(it.Payload) != it.PayloadHash {
+			return fmt.Errorf("%w: request %d payload hash", ErrChecksum, it.SignRequestID)
+		}
+	}
+	return nil
+}
+
+func (b *Bundle) Encode() ([]byte, error) {
+	return json.MarshalIndent(b, "", "  ")
+}
+
+func Decode(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("decode bundle: %w", err)
+	}
+	return &b, nil
+}
+
+// ParsePublicKey / ParsePrivateKey 解析 hex 编码的 ed25519 密钥；私钥可为 32 字节种子或 64 字节私钥
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+	switch len(b) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(b), nil
+	default:
+		return nil, errors.New("invalid ed25519 private key length")
+	}
+}
@@ -0,0 +1,127 @@
+package offline
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testKey(seed byte) ed25519.PrivateKey {
+	s := make([]byte, ed25519.SeedSize)
+	s[0] = seed
+	return ed25519.NewKeyFromSeed(s)
+}
+
+func testBundle(t *testing.T, key ed25519.PrivateKey) *Bundle {
+	t.Helper()
+	payload := []byte(`{"nonce":"0x1"}`)
+	b := &Bundle{
+		Version:   BUNDLE_VERSION,
+		Kind:      KIND_UNSIGNED,
+		ID:        "0123456789abcdef",
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Items: []Item{{
+			SignRequestID: 7,
+			Chain:         "ethereum",
+			From:          "0x00000000000000000000000000000000000000a1",
+			Payload:       payload,
+			PayloadHash:   PayloadHash(payload),
+		}},
+	}
+	if err := b.Seal(key); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// flipHex 改动 hex 串的首字符
+func flipHex(s string) string {
+	if s[0] == '0' {
+		return "1" + s[1:]
+	}
+	return "0" + s[1:]
+}
+
+// 封装后的批次经过编码、解码仍能通过校验
+func TestBundleRoundTrip(t *testing.T) {
+	key := testKey(1)
+	data, err := testBundle(t, key).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Verify(key.Public().(ed25519.PublicKey)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundleVerifyRejects(t *testing.T) {
+	key := testKey(1)
+	trusted := key.Public().(ed25519.PublicKey)
+	tests := []struct {
+		name    string
+		tamper  func(b *Bundle)
+		trusted ed25519.PublicKey
+		want    error
+	}{
+		{"item request id", func(b *Bundle) { b.Items[0].SignRequestID = 8 }, trusted, ErrChecksum},
+		{"kind", func(b *Bundle) { b.Kind = KIND_SIGNED }, trusted, ErrChecksum},
+		{"payload", func(b *Bundle) { b.Items[0].Payload = []byte(`{"nonce":"0x2"}`) }, trusted, ErrChecksum},
+		// 重新计算校验和后，原签名不再匹配
+		{"resealed checksum", func(b *Bundle) {
+			b.Items[0].From = "0x00000000000000000000000000000000000000b2"
+			b.Checksum, _ = b.digest()
+		}, trusted, ErrSignature},
+		{"other signer", func(b *Bundle) { _ = b.Seal(testKey(2)) }, trusted, ErrUntrustedSigner},
+		{"untrusted key", func(b *Bundle) {}, testKey(2).Public().(ed25519.PublicKey), ErrUntrustedSigner},
+		{"signature", func(b *Bundle) { b.Signature = flipHex(b.Signature) }, trusted, ErrSignature},
+		{"payload hash", func(b *Bundle) {
+			b.Items[0].PayloadHash = PayloadHash([]byte("other"))
+			_ = b.Seal(key)
+		}, trusted, ErrChecksum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testBundle(t, key)
+			tt.tamper(b)
+			if err := b.Verify(tt.trusted); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBundleVersion(t *testing.T) {
+	key := testKey(1)
+	b := testBundle(t, key)
+	b.Version = BUNDLE_VERSION + 1
+	if err := b.Seal(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Verify(key.Public().(ed25519.PublicKey)); err == nil {
+		t.Fatal("expected unsupported version error")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	key := testKey(3)
+	seed, err := ParsePrivateKey("03" + "00000000000000000000000000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !seed.Equal(key) {
+		t.Fatal("seed form does not derive the same key")
+	}
+	for _, s := range []string{"zz", "0300"} {
+		if _, err := ParsePrivateKey(s); err == nil {
+			t.Fatalf("ParsePrivateKey(%q) accepted", s)
+		}
+	}
+	if _, err := ParsePublicKey("00"); err == nil {
+		t.Fatal("ParsePublicKey accepted a short key")
+	}
+}
@@ -0,0 +1,140 @@
+package offline
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+// Exporter 在线端：导出待签名请求，导入离线签名结果
+type Exporter struct {
+	db        *gorm.DB
+	key       ed25519.PrivateKey // 在线端封装 unsigned 批次的密钥
+	signerPub ed25519.PublicKey  // 离线签名机公钥，只接受其封装的 signed 批次
+}
+
+func NewExporter(db *gorm.DB, key ed25519.PrivateKey, signerPub ed25519.PublicKey) *Exporter {
+	return &Exporter{db: db, key: key, signerPub: signerPub}
+}
+
+// Export 将 created 状态的签名请求打包，并标记为 exported
+func (e *Exporter) Export(ctx context.Context, ids []uint) (*Bundle, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("nothing to export")
+	}
+	id, err := NewBundleID()
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		Version:   BUNDLE_VERSION,
+		Kind:      KIND_UNSIGNED,
+		ID:        id,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	err = e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var requests []model.SignRequest
+		if err := tx.Where("id IN ? AND status = ?", ids, "created").Order("id").Find(&requests).Error; err != nil {
+			return err
+		}
+		if len(requests) != len(ids) {
+			return fmt.Errorf("only %d of %d sign requests are exportable", len(requests), len(ids))
+		}
+		for _, r := range requests {
+			if len(r.Unsigned) == 0 {
+				return fmt.Errorf("sign request %d has no unsigned transaction", r.ID)
+			}
+			b.Items = append(b.Items, Item{
+				SignRequestID: r.ID,
+				Chain:         r.Chain,
+				From:          r.FromAddress,
+				Payload:       r.Unsigned,
+				ValidUntil:    r.ValidUntil,
+				PayloadHash:   PayloadHash(r.Unsigned),
+			})
+		}
+		return tx.Model(&model.SignRequest{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": "exported", "bundle_id": b.ID}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, b.Seal(e.key)
+}
+
+// Import 校验签名结果批次：封装方、批次 ID、每笔的请求与未签名交易哈希、签名交易内容均须匹配，
+// 全部通过后才写入签名结果，任一不符整批拒绝
+func (e *Exporter) Import(ctx context.Context, data []byte) ([]model.SignRequest, error) {
+	b, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Verify(e.signerPub); err != nil {
+		return nil, err
+	}
+	if b.Kind != KIND_SIGNED {
+		return nil, ErrKind
+	}
+
+	var imported []model.SignRequest
+	err = e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var exported []model.SignRequest
+		if err := tx.Where("bundle_id = ? AND status = ?", b.ID, "exported").Find(&exported).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]*model.SignRequest, len(exported))
+		for i := range exported {
+			byID[exported[i].ID] = &exported[i]
+		}
+		for _, it := range b.Items {
+			r, ok := byID[it.SignRequestID]
+			if !ok {
+				return fmt.Errorf("%w: request %d not in bundle %s", ErrMismatch, it.SignRequestID, b.ID)
+			}
+			if err := verifyItem(r, it); err != nil {
+				return err
+			}
+			r.Signed = it.Signed
+			r.Status = "signed"
+			imported = append(imported, *r)
+		}
+		for _, r := range imported {
+			if err := tx.Model(&model.SignRequest{}).Where("id = ?", r.ID).
+				Updates(map[string]interface{}{"signed": r.Signed, "status": r.Status}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
+func verifyItem(r *model.SignRequest, it Item) error {
+	if it.PayloadHash != PayloadHash(r.Unsigned) || it.Chain != r.Chain || it.From != r.FromAddress {
+		return fmt.Errorf("%w: request %d", ErrMismatch, r.ID)
+	}
+	if len(it.Signed) == 0 || bytes.Equal(it.Signed, r.Unsigned) {
+		return fmt.Errorf("%w: request %d not signed", ErrMismatch, r.ID)
+	}
+	c, err := chain.Get(r.Chain)
+	if err != nil {
+		return err
+	}
+	if v, ok := c.(chain.SignedVerifier); ok {
+		utx := &chain.UnsignedTx{Chain: r.Chain, From: r.FromAddress, Payload: r.Unsigned, ValidUntil: r.ValidUntil}
+		if err := v.VerifySigned(utx, it.Signed); err != nil {
+			return fmt.Errorf("%w: request %d: %v", ErrMismatch, r.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package offline
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ==========================
+// 二维码分片
+// ==========================
+// 分片格式：CCB/<序号>/<总数>/<整体 SHA-256 前 8 字节 hex>:<base64 数据>
+// 每个分片单独生成一个二维码，扫描顺序任意
+
+const (
+	QR_CHUNK_PREFIX = "CCB"
+	QR_CHUNK_SIZE   = 1200 // 每片原始字节数，base64 后约 1.6KB，适合 QR version 30 左右
+)
+
+func digestTag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Chunk 将数据切分为二维码分片
+func Chunk(data []byte, size int) []string {
+	if size <= 0 {
+		size = QR_CHUNK_SIZE
+	}
+	total := (len(data) + size - 1) / size
+	if total == 0 {
+		total = 1
+	}
+	tag := digestTag(data)
+	chunks := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * size
+		if end > len(data) {
+			end = len(data)
+		}
+		chunks = append(chunks, fmt.Sprintf("%s/%d/%d/%s:%s",
+			QR_CHUNK_PREFIX, i+1, total, tag, base64.StdEncoding.EncodeToString(data[i*size:end])))
+	}
+	return chunks
+}
+
+// Assemble 还原分片，校验数量与整体摘要
+func Assemble(chunks []string) ([]byte, error) {
+	var (
+		parts [][]byte
+		tag   string
+	)
+	for _, c := range chunks {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		head, body, ok := strings.Cut(c, ":")
+		fields := strings.Split(head, "/")
+		if !ok || len(fields) != 4 || fields[0] != QR_CHUNK_PREFIX {
+			return nil, fmt.Errorf("invalid chunk header %q", head)
+		}
+		idx, err1 := strconv.Atoi(fields[1])
+		total, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || idx < 1 || idx > total {
+			return nil, fmt.Errorf("invalid chunk index %q", head)
+		}
+		if parts == nil {
+			parts, tag = make([][]byte, total), fields[3]
+		}
+		if total != len(parts) || fields[3] != tag {
+			return nil, errors.New("chunks belong to different payloads")
+		}
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", idx, err)
+		}
+		parts[idx-1] = data
+	}
+	if parts == nil {
+		return nil, errors.New("no chunks")
+	}
+	var out []byte
+	for i, p := range parts {
+		if p == nil {
+			return nil, fmt.Errorf("missing chunk %d/%d", i+1, len(parts))
+		}
+		out = append(out, p...)
+	}
+	if digestTag(out) != tag {
+		return nil, ErrChecksum
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package offline
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/chain/evm"
+	"github.com/crypto_custody/chain/solana"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ==========================
+// 离线签名机
+// ==========================
+
+// Keyring 按链和地址提供签名私钥
+type Keyring interface {
+	Key(chainName, from string) ([]byte, error)
+}
+
+// COLD_ACCOUNT 冷钱包密钥的 BIP44 account，与 account 0 的充值地址隔离：
+// 同一种子既用于充值地址又用于冷钱包时，泄露的充值地址私钥不会是冷钱包私钥
+const COLD_ACCOUNT = 1
+
+// SeedKeyring 从 BIP39 种子派生 COLD_ACCOUNT 下 [0, MaxIndex) 范围内的冷钱包地址私钥
+type SeedKeyring struct {
+	seed     []byte
+	maxIndex uint32
+}
+
+func NewSeedKeyring(seed []byte, maxIndex uint32) *SeedKeyring {
+	return &SeedKeyring{seed: seed, maxIndex: maxIndex}
+}
+
+// ColdKey 派生第 index 个冷钱包密钥，返回地址、私钥与派生路径
+func ColdKey(c chain.Chain, seed []byte, index uint32) (string, []byte, string, error) {
+	switch c.CoinType() {
+	case evm.COIN_TYPE:
+		key, err := evm.DeriveAccountKey(seed, COLD_ACCOUNT, index)
+		if err != nil {
+			return "", nil, "", err
+		}
+		return crypto.PubkeyToAddress(key.PublicKey).Hex(), crypto.FromECDSA(key), evm.AccountPath(COLD_ACCOUNT, index), nil
+	case solana.COIN_TYPE:
+		key, err := solana.DeriveAccountKey(seed, COLD_ACCOUNT, index)
+		if err != nil {
+			return "", nil, "", err
+		}
+		return key.PublicKey().String(), []byte(ed25519.PrivateKey(key)), solana.AccountPath(COLD_ACCOUNT, index), nil
+	}
+	return "", nil, "", fmt.Errorf("no key derivation for chain %s", c.Name())
+}
+
+func (k *SeedKeyring) Key(chainName, from string) ([]byte, error) {
+	c, err := chain.Get(chainName)
+	if err != nil {
+		return nil, err
+	}
+	want, err := c.NormalizeAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < k.maxIndex; i++ {
+		addr, key, _, err := ColdKey(c, k.seed, i)
+		if err != nil {
+			return nil, err
+		}
+		if got, err := c.NormalizeAddress(addr); err == nil && got == want {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("address %s not derived from seed within %d cold indexes", from, k.maxIndex)
+}
+
+// SignBundle 校验在线端封装的 unsigned 批次，逐笔签名后生成由 signerKey 封装的 signed 批次
+func SignBundle(in *Bundle, exporterPub ed25519.PublicKey, keys Keyring, signerKey ed25519.PrivateKey) (*Bundle, error) {
+	if err := in.Verify(exporterPub); err != nil {
+		return nil, err
+	}
+	if in.Kind != KIND_UNSIGNED {
+		return nil, ErrKind
+	}
+	out := &Bundle{
+		Version:   BUNDLE_VERSION,
+		Kind:      KIND_SIGNED,
+		ID:        in.ID,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	for _, it := range in.Items {
+		c, err := chain.Get(it.Chain)
+		if err != nil {
+			return nil, err
+		}
+		key, err := keys.Key(it.Chain, it.From)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %w", it.SignRequestID, err)
+		}
+		utx := &chain.UnsignedTx{Chain: it.Chain, From: it.From, Payload: it.Payload, ValidUntil: it.ValidUntil}
+		signed, err := c.SignTx(utx, key)
+		if err != nil {
+			return nil, fmt.Errorf("sign request %d: %w", it.SignRequestID, err)
+		}
+		out.Items = append(out.Items, Item{
+			SignRequestID: it.SignRequestID,
+			Chain:         it.Chain,
+			From:          it.From,
+			PayloadHash:   it.PayloadHash,
+			Signed:        signed,
+		})
+	}
+	return out, out.Seal(signerKey)
+}
@@ -0,0 +1,46 @@
+package outbox
+
+// ==========================
+// 事件内容
+// ==========================
+
+type UserRegistered struct {
+	UserID uint64 `json:"userId"`
+	Email  string `json:"email"`
+	Phone  string `json:"phone"`
+}
+
+type KYCSubmitted struct {
+	KYCID  uint64 `json:"kycId"`
+	UserID uint64 `json:"userId"`
+}
+
+type KYCDecided struct {
+	KYCID  uint64 `json:"kycId"`
+	UserID uint64 `json:"userId"`
+	Status string `json:"status"` // approved / rejected
+	Reason string `json:"reason,omitempty"`
+}
+
+// DepositEvent deposit.seen / deposit.confirmed / deposit.credited
+type DepositEvent struct {
+	DepositID   uint    `json:"depositId"`
+	UserID      *int64  `json:"userId"`
+	Chain       string  `json:"chain"`
+	Token       *string `json:"token,omitempty"`
+	Address     string  `json:"address"`
+	Amount      string  `json:"amount"` // 最小单位
+	TxHash      string  `json:"txHash"`
+	BlockNumber int64   `json:"blockNumber"`
+	Status      string  `json:"status"` // seen / confirmed / credited
+}
+
+type WithdrawalStatusChanged struct {
+	WithdrawalID uint   `json:"withdrawalId"`
+	UserID       uint   `json:"userId"`
+	Currency     string `json:"currency"`
+	Address      string `json:"address"`
+	Amount       string `json:"amount"` // 最小单位
+	Status       string `json:"status"`
+	TxHash       string `json:"txHash,omitempty"`
+}
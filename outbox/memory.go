@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Handler 事件处理函数，返回错误时 Relay 会重试投递
+type Handler func(ctx context.Context, msg Message) error
+
+// MemoryBroker 进程内 Broker：同步调用订阅者，按事件 ID 去重，
+// 用于单进程部署和测试
+type MemoryBroker struct {
+	mu        sync.Mutex
+	handlers  map[string][]Handler
+	delivered map[string]bool
+}
+
+var _ Broker = (*MemoryBroker)(nil)
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{handlers: map[string][]Handler{}, delivered: map[string]bool{}}
+}
+
+// Subscribe 订阅主题，topic 为 "*" 时接收全部事件
+func (b *MemoryBroker) Subscribe(topic string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], h)
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	if b.delivered[msg.ID] {
+		b.mu.Unlock()
+		return nil
+	}
+	handlers := append(append([]Handler(nil), b.handlers[msg.Topic]...), b.handlers["*"]...)
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		if err := h(ctx, msg); err != nil {
+			return fmt.Errorf("%s handler: %w", msg.Topic, err)
+		}
+	}
+	b.mu.Lock()
+	b.delivered[msg.ID] = true
+	b.mu.Unlock()
+	return nil
+}
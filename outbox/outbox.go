@@ -0,0 +1,70 @@
+// Package outbox 事务性发件箱：业务代码在自己的数据库事务中写入事件，
+// Relay 异步投递到 Broker，投递成功前会重试（at-least-once），消费方按 EventID 去重。
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 领域事件主题
+const (
+	TOPIC_USER_REGISTERED           = "user.registered"
+	TOPIC_KYC_SUBMITTED             = "kyc.submitted"
+	TOPIC_KYC_DECIDED               = "kyc.decided"
+	TOPIC_DEPOSIT_SEEN              = "deposit.seen"
+	TOPIC_DEPOSIT_CONFIRMED         = "deposit.confirmed"
+	TOPIC_DEPOSIT_CREDITED          = "deposit.credited"
+	TOPIC_WITHDRAWAL_STATUS_CHANGED = "withdrawal.status_changed"
+)
+
+// Message 投递给 Broker 的事件
+type Message struct {
+	ID          string          `json:"id"`
+	Topic       string          `json:"topic"`
+	AggregateID string          `json:"aggregateId"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// Broker 消息队列适配器，Publish 返回 nil 即视为投递成功
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Publish 在调用方事务 tx 中写入事件，事务回滚时事件一并丢弃
+func Publish(tx *gorm.DB, topic, aggregateID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s payload: %w", topic, err)
+	}
+	return tx.Create(&model.OutboxEvent{
+		EventID:       uuid.NewString(),
+		Topic:         topic,
+		AggregateID:   aggregateID,
+		Payload:       data,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// Consume 消费方幂等处理：在同一事务中登记 EventID 并执行 fn，重复投递的事件直接跳过
+func Consume(db *gorm.DB, consumer string, msg Message, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.ProcessedMessage{Consumer: consumer, EventID: msg.ID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		return fn(tx)
+	})
+}
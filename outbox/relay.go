@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==========================
+// Relay
+// ==========================
+// 按写入顺序取出未投递事件逐条发布；失败的事件按指数退避重试，不阻塞后续事件。
+// 多个 Relay 实例可同时运行，行锁 SKIP LOCKED 保证同一事件不会被并发投递。
+
+const (
+	RELAY_BATCH_SIZE  = 100
+	RELAY_MIN_BACKOFF = time.Second
+	RELAY_MAX_BACKOFF = 10 * time.Minute
+)
+
+type Relay struct {
+	db     *gorm.DB
+	broker Broker
+}
+
+func NewRelay(db *gorm.DB, broker Broker) *Relay {
+	return &Relay{db: db, broker: broker}
+}
+
+func backoff(attempts int) time.Duration {
+	d := RELAY_MIN_BACKOFF
+	for i := 1; i < attempts && d < RELAY_MAX_BACKOFF; i++ {
+		d *= 2
+	}
+	if d > RELAY_MAX_BACKOFF {
+		d = RELAY_MAX_BACKOFF
+	}
+	return d
+}
+
+// RelayOnce 投递一批到期事件，返回成功投递的数量
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	published := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []model.OutboxEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("id").Limit(RELAY_BATCH_SIZE).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		for _, ev := range events {
+			err := r.broker.Publish(ctx, Message{
+				ID:          ev.EventID,
+				Topic:       ev.Topic,
+				AggregateID: ev.AggregateID,
+				Payload:     ev.Payload,
+				CreatedAt:   ev.CreatedAt,
+			})
+			now := time.Now()
+			if err != nil {
+				log.Printf("outbox event %s (%s) attempt %d err: %v", ev.EventID, ev.Topic, ev.Attempts+1, err)
+				if err := tx.Model(&ev).Updates(map[string]interface{}{
+					"attempts":        ev.Attempts + 1,
+					"next_attempt_at": now.Add(backoff(ev.Attempts + 1)),
+					"last_error":      err.Error(),
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Model(&ev).Updates(map[string]interface{}{
+				"attempts":     ev.Attempts + 1,
+				"published_at": now,
+				"last_error":   "",
+			}).Error; err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	return published, err
+}
+
+// Run 定期投递；一批满额时立即继续下一批
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := r.RelayOnce(ctx)
+		if err != nil {
+			log.Printf("outbox relay err: %v", err)
+		}
+		if n == RELAY_BATCH_SIZE {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+)
+
+// 退避从 1 秒起按次数翻倍，不超过上限
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{10, 512 * time.Second},
+		{11, RELAY_MAX_BACKOFF},
+		{50, RELAY_MAX_BACKOFF},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Fatalf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// 投递失败的事件记录错误并退避，不阻塞后续事件；到期后重试成功，已投递的事件不再投递
+func TestRelayRetry(t *testing.T) {
+	db := testdb.Open(t, "outbox_events")
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	fail := true
+	var got []string
+	broker.Subscribe("*", func(ctx context.Context, msg Message) error {
+		if msg.Topic == TOPIC_KYC_SUBMITTED && fail {
+			return errors.New("consumer down")
+		}
+		got = append(got, msg.Topic)
+		return nil
+	})
+	for _, topic := range []string{TOPIC_KYC_SUBMITTED, TOPIC_USER_REGISTERED} {
+		if err := Publish(db, topic, "1", map[string]int{"userId": 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	relay := NewRelay(db, broker)
+	relayOnce := func(want int) {
+		t.Helper()
+		n, err := relay.RelayOnce(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Fatalf("published %d, want %d", n, want)
+		}
+	}
+
+	relayOnce(1)
+	var failed model.OutboxEvent
+	if err := db.Where("topic = ?", TOPIC_KYC_SUBMITTED).First(&failed).Error; err != nil {
+		t.Fatal(err)
+	}
+	if failed.PublishedAt != nil || failed.Attempts != 1 || failed.LastError == "" || !failed.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("failed event = %+v, want one attempt with error and a later retry", failed)
+	}
+
+	// 退避期内不重试
+	relayOnce(0)
+
+	fail = false
+	if err := db.Model(&failed).Update("next_attempt_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	relayOnce(1)
+	relayOnce(0)
+	if err := db.First(&failed, failed.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if failed.PublishedAt == nil || failed.Attempts != 2 || failed.LastError != "" {
+		t.Fatalf("retried event = %+v, want published on the second attempt", failed)
+	}
+	if len(got) != 2 || got[0] != TOPIC_USER_REGISTERED || got[1] != TOPIC_KYC_SUBMITTED {
+		t.Fatalf("delivered %v, want the later event first and the retried one once", got)
+	}
+}
@@ -0,0 +1,290 @@
+package por
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/repository"
+	"gorm.io/gorm"
+)
+
+// ==========================
+// 快照生成与查询
+// ==========================
+
+var (
+	ErrSnapshotNotFound = errors.New("储备金证明快照不存在")
+	ErrUserNotInTree    = errors.New("用户不在快照中")
+)
+
+// 冷热钱包地址类型，对应 WalletAddress.Type
+const (
+	walletTypeHot  = 0
+	walletTypeCold = 1
+)
+
+// Asset 参与储备金证明的币种
+type Asset struct {
+	Currency string
+	Chain    string
+	Token    *string // nil 表示原生币
+}
+
+type Generator struct {
+	db     *gorm.DB
+	ledger *repository.LedgerRepository
+	assets []Asset
+	signer MessageSigner // 可为 nil，此时地址证明全部由离线签名后提交
+}
+
+func NewGenerator(db *gorm.DB, assets []Asset, signer MessageSigner) *Generator {
+	return &Generator{db: db, ledger: repository.NewLedgerRepository(db), assets: assets, signer: signer}
+}
+
+func (g *Generator) asset(currency string) (Asset, error) {
+	for _, a := range g.assets {
+		if a.Currency == currency {
+			return a, nil
+		}
+	}
+	return Asset{}, fmt.Errorf("currency %s is not configured for proof of reserves", currency)
+}
+
+// userBalances 每个用户的资产：已入账充值减去已广播的提现（最小单位）
+func (g *Generator) userBalances(ctx context.Context, a Asset) (map[uint64]*big.Int, error) {
+	balances, err := g.ledger.UserBalances(ctx, a.Chain, a.Token, a.Currency)
+	if err != nil {
+		return nil, err
+	}
+	for userID, b := range balances {
+		if b.Sign() < 0 {
+			return nil, fmt.Errorf("user %d has negative %s balance %s, fix the ledger before publishing", userID, a.Currency, b)
+		}
+	}
+	return balances, nil
+}
+
+// Snapshot 生成用户负债快照与地址控制权证明
+func (g *Generator) Snapshot(ctx context.Context, currency string) (*model.ReserveSnapshot, error) {
+	a, err := g.asset(currency)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := g.userBalances(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	if len(balances) == 0 {
+		return nil, ErrEmptyTree
+	}
+	userIDs := make([]uint64, 0, len(balances))
+	for id := range balances {
+		userIDs = append(userIDs, id)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+	leaves := make([]model.ReserveLeaf, 0, len(userIDs))
+	nodes := make([]Node, 0, len(userIDs))
+	for i, id := range userIDs {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		node, err := Leaf(UserHash(id, nonce), balances[id])
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		leaves = append(leaves, model.ReserveLeaf{
+			UserID:    id,
+			LeafIndex: i,
+			Nonce:     hex.EncodeToString(nonce),
+			Balance:   balances[id].String(),
+		})
+	}
+	tree, err := Build(nodes)
+	if err != nil {
+		return nil, err
+	}
+	root := tree.Root()
+
+	snap := model.ReserveSnapshot{
+		Currency:    currency,
+		Root:        hex.EncodeToString(root.Hash[:]),
+		Liabilities: root.Sum.String(),
+		LeafCount:   len(leaves),
+	}
+	proofs, reserves, err := g.addressProofs(ctx, a, snap.Root)
+	if err != nil {
+		return nil, err
+	}
+	snap.Reserves = reserves.String()
+
+	err = g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&snap).Error; err != nil {
+			return err
+		}
+		for i := range leaves {
+			leaves[i].SnapshotID = snap.ID
+		}
+		if err := tx.CreateInBatches(&leaves, 500).Error; err != nil {
+			return err
+		}
+		for i := range proofs {
+			proofs[i].SnapshotID = snap.ID
+		}
+		if len(proofs) > 0 {
+			return tx.Create(&proofs).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	snap.AddressProofs = proofs
+	log.Printf("proof of reserves %s #%d: root=%s liabilities=%s reserves=%s users=%d",
+		currency, snap.ID, snap.Root, snap.Liabilities, snap.Reserves, snap.LeafCount)
+	return &snap, nil
+}
+
+// addressProofs 对该币种的热、冷钱包地址签名并查询余额；没有私钥的地址留待离线签名后提交
+func (g *Generator) addressProofs(ctx context.Context, a Asset, root string) ([]model.ReserveAddressProof, *big.Int, error) {
+	c, err := chain.Get(a.Chain)
+	if err != nil {
+		return nil, nil, err
+	}
+	var wallets []model.WalletAddress
+	if err := g.db.WithContext(ctx).
+		Where("currency = ? AND type IN ?", a.Currency, []int8{walletTypeHot, walletTypeCold}).
+		Order("id").Find(&wallets).Error; err != nil {
+		return nil, nil, err
+	}
+	reserves := new(big.Int)
+	var proofs []model.ReserveAddressProof
+	for _, w := range wallets {
+		msg := ControlMessage(a.Currency, root, w.Address)
+		p := model.ReserveAddressProof{Chain: a.Chain, Address: w.Address, Message: string(msg)}
+		balance, err := c.Balance(ctx, w.Address, a.Token)
+		if err != nil {
+			return nil, nil, fmt.Errorf("balance of %s: %w", w.Address, err)
+		}
+		p.Balance = balance.String()
+		if g.signer == nil {
+			p.Error = "awaiting offline signature"
+		} else if sig, err := g.signer.SignMessage(a.Chain, w.Address, msg); err != nil {
+			p.Error = err.Error()
+		} else {
+			p.Signature = hex.EncodeToString(sig)
+			reserves.Add(reserves, balance)
+		}
+		proofs = append(proofs, p)
+	}
+	return proofs, reserves, nil
+}
+
+// SubmitAddressProof 提交离线签名的地址控制权证明，校验通过后计入储备
+func (g *Generator) SubmitAddressProof(ctx context.Context, snapshotID uint, address string, sig []byte) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p model.ReserveAddressProof
+		if err := tx.Where("snapshot_id = ? AND LOWER(address) = ?", snapshotID, strings.ToLower(address)).
+			First(&p).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("address %s is not part of snapshot %d", address, snapshotID)
+			}
+			return err
+		}
+		if p.Signature != "" {
+			return fmt.Errorf("address %s already proven", address)
+		}
+		if err := VerifyMessage(p.Chain, p.Address, []byte(p.Message), sig); err != nil {
+			return err
+		}
+		var snap model.ReserveSnapshot
+		if err := tx.First(&snap, snapshotID).Error; err != nil {
+			return err
+		}
+		reserves, _ := new(big.Int).SetString(snap.Reserves, 10)
+		balance, _ := new(big.Int).SetString(p.Balance, 10)
+		if reserves == nil || balance == nil {
+			return fmt.Errorf("snapshot %d has invalid amounts", snapshotID)
+		}
+		if err := tx.Model(&p).Updates(map[string]interface{}{"signature": hex.EncodeToString(sig), "error": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&snap).Update("reserves", reserves.Add(reserves, balance).String()).Error
+	})
+}
+
+// Latest 最新快照，含地址控制权证明
+func (g *Generator) Latest(ctx context.Context, currency string) (*model.ReserveSnapshot, error) {
+	var snap model.ReserveSnapshot
+	if err := g.db.WithContext(ctx).Preload("AddressProofs").
+		Where("currency = ?", currency).Order("id DESC").First(&snap).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// UserProof 用户在最新快照中的包含证明，返回前先自行校验
+func (g *Generator) UserProof(ctx context.Context, currency string, userID uint64) (*model.ReserveSnapshot, *Proof, error) {
+	snap, err := g.Latest(ctx, currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	var leaves []model.ReserveLeaf
+	if err := g.db.WithContext(ctx).Where("snapshot_id = ?", snap.ID).Order("leaf_index").Find(&leaves).Error; err != nil {
+		return nil, nil, err
+	}
+	nodes := make([]Node, len(leaves))
+	index := -1
+	for i, l := range leaves {
+		nonce, err := hex.DecodeString(l.Nonce)
+		if err != nil {
+			return nil, nil, err
+		}
+		balance, ok := new(big.Int).SetString(l.Balance, 10)
+		if !ok {
+			return nil, nil, ErrInvalidAmount
+		}
+		if nodes[i], err = Leaf(UserHash(l.UserID, nonce), balance); err != nil {
+			return nil, nil, err
+		}
+		if l.UserID == userID {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, nil, ErrUserNotInTree
+	}
+	tree, err := Build(nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, err := tree.Proof(index)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof := &Proof{
+		UserID:  userID,
+		Nonce:   leaves[index].Nonce,
+		Balance: leaves[index].Balance,
+		Index:   index,
+		Path:    path,
+	}
+	if err := proof.Verify(snap.Root, snap.Liabilities); err != nil {
+		return nil, nil, fmt.Errorf("snapshot %d: %w", snap.ID, err)
+	}
+	return snap, proof, nil
+}
@@ -0,0 +1,114 @@
+package por
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/chain/evm"
+	"github.com/crypto_custody/chain/solana"
+	"github.com/crypto_custody/offline"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	sol "github.com/gagliardetto/solana-go"
+)
+
+// ==========================
+// 地址控制权证明
+// ==========================
+// 对包含快照树根的消息签名：EVM 使用 personal_sign (EIP-191)，Solana 使用 ed25519 直接签名。
+// 消息绑定树根，签名不能挪用到其他快照。
+
+// ControlMessage 地址控制权证明的签名消息
+func ControlMessage(currency, root, address string) []byte {
+	return []byte(fmt.Sprintf("crypto_custody proof of reserves\ncurrency: %s\nroot: %s\naddress: %s", currency, root, address))
+}
+
+// MessageSigner 用地址私钥对消息签名
+type MessageSigner interface {
+	SignMessage(chainName, address string, msg []byte) ([]byte, error)
+}
+
+// KeyringSigner 从 Keyring 取私钥签名，热钱包可在线签名，冷钱包地址由离线签名机另行提交
+type KeyringSigner struct {
+	keys offline.Keyring
+}
+
+func NewKeyringSigner(keys offline.Keyring) *KeyringSigner {
+	return &KeyringSigner{keys: keys}
+}
+
+func (s *KeyringSigner) SignMessage(chainName, address string, msg []byte) ([]byte, error) {
+	c, err := chain.Get(chainName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.keys.Key(chainName, address)
+	if err != nil {
+		return nil, err
+	}
+	switch c.CoinType() {
+	case evm.COIN_TYPE:
+		priv, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := crypto.Sign(accounts.TextHash(msg), priv)
+		if err != nil {
+			return nil, err
+		}
+		sig[64] += 27
+		return sig, nil
+	case solana.COIN_TYPE:
+		return ed25519.Sign(ed25519.PrivateKey(key), msg), nil
+	}
+	return nil, fmt.Errorf("message signing not supported on %s", chainName)
+}
+
+// VerifyMessage 校验地址对消息的签名
+func VerifyMessage(chainName, address string, msg, sig []byte) error {
+	c, err := chain.Get(chainName)
+	if err != nil {
+		return err
+	}
+	switch c.CoinType() {
+	case evm.COIN_TYPE:
+		if len(sig) != crypto.SignatureLength || (sig[64] != 27 && sig[64] != 28) {
+			return errors.New("invalid signature")
+		}
+		raw := append([]byte(nil), sig...)
+		raw[64] -= 27
+		pub, err := crypto.SigToPub(accounts.TextHash(msg), raw)
+		if err != nil {
+			return err
+		}
+		if signer := crypto.PubkeyToAddress(*pub).Hex(); !strings.EqualFold(signer, address) {
+			return fmt.Errorf("signed by %s, expected %s", signer, address)
+		}
+		return nil
+	case solana.COIN_TYPE:
+		pub, err := sol.PublicKeyFromBase58(address)
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(ed25519.PublicKey(pub[:]), msg, sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("message verification not supported on %s", chainName)
+}
+
+// MapKeyring 地址到私钥的静态映射，用于热钱包
+type MapKeyring map[string][]byte
+
+func (k MapKeyring) Key(chainName, from string) ([]byte, error) {
+	for addr, key := range k {
+		if strings.EqualFold(addr, from) {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no key for %s", from)
+}
@@ -0,0 +1,201 @@
+// Package por 储备金证明：用户负债快照为 Merkle-sum 树，每个用户可验证自己的余额包含在公布的树根与总额中，
+// 并对我方地址签名证明控制权。
+package por
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ==========================
+// Merkle-sum 树
+// ==========================
+// 叶子 = sha256(0x00 || userHash || balance)，内部节点 = sha256(0x01 || 左哈希 || 左金额 || 右哈希 || 右金额)，
+// 节点金额为左右之和。金额编码为 32 字节大端，必须非负，因此树根金额不可能小于任一用户的余额。
+
+const (
+	HASH_SIZE   = sha256.Size
+	AMOUNT_SIZE = 32
+)
+
+var (
+	ErrEmptyTree      = errors.New("por: no leaves")
+	ErrInvalidAmount  = errors.New("por: amount must be non-negative and fit in 256 bits")
+	ErrProofMismatch  = errors.New("por: proof does not match root")
+	ErrLeafOutOfRange = errors.New("por: leaf index out of range")
+)
+
+var maxAmount = new(big.Int).Lsh(big.NewInt(1), AMOUNT_SIZE*8)
+
+// Node 树节点
+type Node struct {
+	Hash [HASH_SIZE]byte
+	Sum  *big.Int
+}
+
+// UserHash 用户 ID 加随机 nonce 的哈希，树中不暴露用户 ID
+func UserHash(userID uint64, nonce []byte) [HASH_SIZE]byte {
+	buf := make([]byte, 8, 8+len(nonce))
+	binary.BigEndian.PutUint64(buf, userID)
+	return sha256.Sum256(append(buf, nonce...))
+}
+
+func encodeAmount(v *big.Int) ([]byte, error) {
+	if v == nil || v.Sign() < 0 || v.Cmp(maxAmount) >= 0 {
+		return nil, ErrInvalidAmount
+	}
+	return v.FillBytes(make([]byte, AMOUNT_SIZE)), nil
+}
+
+// Leaf 用户叶子
+func Leaf(userHash [HASH_SIZE]byte, balance *big.Int) (Node, error) {
+	amount, err := encodeAmount(balance)
+	if err != nil {
+		return Node{}, err
+	}
+	buf := make([]byte, 0, 1+HASH_SIZE+AMOUNT_SIZE)
+	buf = append(buf, 0x00)
+	buf = append(buf, userHash[:]...)
+	buf = append(buf, amount...)
+	return Node{Hash: sha256.Sum256(buf), Sum: new(big.Int).Set(balance)}, nil
+}
+
+func parent(l, r Node) (Node, error) {
+	sum := new(big.Int).Add(l.Sum, r.Sum)
+	ls, err := encodeAmount(l.Sum)
+	if err != nil {
+		return Node{}, err
+	}
+	rs, err := encodeAmount(r.Sum)
+	if err != nil {
+		return Node{}, err
+	}
+	if _, err := encodeAmount(sum); err != nil {
+		return Node{}, err
+	}
+	buf := make([]byte, 0, 1+2*(HASH_SIZE+AMOUNT_SIZE))
+	buf = append(buf, 0x01)
+	buf = append(buf, l.Hash[:]...)
+	buf = append(buf, ls...)
+	buf = append(buf, r.Hash[:]...)
+	buf = append(buf, rs...)
+	return Node{Hash: sha256.Sum256(buf), Sum: sum}, nil
+}
+
+// padding 奇数层补齐用的零金额节点；复制末尾节点会重复计入金额
+func padding() Node {
+	n, _ := Leaf([HASH_SIZE]byte{}, new(big.Int))
+	return n
+}
+
+// Tree 按层保存全部节点，levels[0] 为叶子层
+type Tree struct {
+	levels [][]Node
+}
+
+func Build(leaves []Node) (*Tree, error) {
+	if len(leaves) == 0 {
+		return nil, ErrEmptyTree
+	}
+	level := append([]Node(nil), leaves...)
+	t := &Tree{}
+	for {
+		if len(level) > 1 && len(level)%2 == 1 {
+			level = append(level, padding())
+		}
+		t.levels = append(t.levels, level)
+		if len(level) == 1 {
+			return t, nil
+		}
+		next := make([]Node, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			p, err := parent(level[i], level[i+1])
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, p)
+		}
+		level = next
+	}
+}
+
+func (t *Tree) Root() Node {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// ProofStep 路径上的兄弟节点，Left 表示兄弟在左侧
+type ProofStep struct {
+	Hash string `json:"hash"`
+	Sum  string `json:"sum"`
+	Left bool   `json:"left"`
+}
+
+// Proof 用户包含证明：用户凭 userId 与 nonce 重算叶子，沿路径求出树根与总额
+type Proof struct {
+	UserID  uint64      `json:"userId"`
+	Nonce   string      `json:"nonce"` // hex
+	Balance string      `json:"balance"`
+	Index   int         `json:"index"`
+	Path    []ProofStep `json:"path"`
+}
+
+// Proof 生成第 index 个叶子的包含证明
+func (t *Tree) Proof(index int) ([]ProofStep, error) {
+	if index < 0 || index >= len(t.levels[0]) {
+		return nil, ErrLeafOutOfRange
+	}
+	var path []ProofStep
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		path = append(path, ProofStep{
+			Hash: hex.EncodeToString(level[sibling].Hash[:]),
+			Sum:  level[sibling].Sum.String(),
+			Left: sibling < index,
+		})
+		index /= 2
+	}
+	return path, nil
+}
+
+// Verify 重算树根，须与公布的根哈希和总额一致
+func (p *Proof) Verify(rootHash, total string) error {
+	nonce, err := hex.DecodeString(p.Nonce)
+	if err != nil {
+		return fmt.Errorf("por: invalid nonce: %w", err)
+	}
+	balance, ok := new(big.Int).SetString(p.Balance, 10)
+	if !ok {
+		return ErrInvalidAmount
+	}
+	node, err := Leaf(UserHash(p.UserID, nonce), balance)
+	if err != nil {
+		return err
+	}
+	for _, step := range p.Path {
+		sibling := Node{Sum: new(big.Int)}
+		h, err := hex.DecodeString(step.Hash)
+		if err != nil || len(h) != HASH_SIZE {
+			return fmt.Errorf("por: invalid path hash %q", step.Hash)
+		}
+		copy(sibling.Hash[:], h)
+		if _, ok := sibling.Sum.SetString(step.Sum, 10); !ok {
+			return ErrInvalidAmount
+		}
+		if step.Left {
+			node, err = parent(sibling, node)
+		} else {
+			node, err = parent(node, sibling)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if hex.EncodeToString(node.Hash[:]) != rootHash || node.Sum.String() != total {
+		return ErrProofMismatch
+	}
+	return nil
+}
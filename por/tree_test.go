@@ -0,0 +1,130 @@
+package por
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func testProofs(t *testing.T, balances []int64) (*Tree, []*Proof) {
+	t.Helper()
+	nodes := make([]Node, len(balances))
+	proofs := make([]*Proof, len(balances))
+	for i, b := range balances {
+		userID := uint64(100 + i)
+		nonce := []byte(fmt.Sprintf("nonce-%d", i))
+		node, err := Leaf(UserHash(userID, nonce), big.NewInt(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes[i] = node
+		proofs[i] = &Proof{UserID: userID, Nonce: hex.EncodeToString(nonce), Balance: big.NewInt(b).String(), Index: i}
+	}
+	tree, err := Build(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range proofs {
+		if p.Path, err = tree.Proof(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tree, proofs
+}
+
+// 每个叶子的证明都能还原树根与总额，奇数层补齐的节点不计入金额
+func TestProofVerify(t *testing.T) {
+	tests := [][]int64{
+		{42},
+		{1, 2},
+		{5, 0, 7},
+		{10, 20, 30, 40, 50},
+		{3, 1, 4, 1, 5, 9, 2, 6},
+	}
+	for _, balances := range tests {
+		t.Run(fmt.Sprint(balances), func(t *testing.T) {
+			tree, proofs := testProofs(t, balances)
+			root := tree.Root()
+			var total int64
+			for _, b := range balances {
+				total += b
+			}
+			if root.Sum.Int64() != total {
+				t.Fatalf("root sum = %s, want %d", root.Sum, total)
+			}
+			rootHash := hex.EncodeToString(root.Hash[:])
+			for i, p := range proofs {
+				if err := p.Verify(rootHash, root.Sum.String()); err != nil {
+					t.Fatalf("leaf %d: %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestProofVerifyRejectsTampering(t *testing.T) {
+	tree, proofs := testProofs(t, []int64{10, 20, 30, 40, 50})
+	root := tree.Root()
+	rootHash := hex.EncodeToString(root.Hash[:])
+	total := root.Sum.String()
+
+	tests := []struct {
+		name   string
+		tamper func(p *Proof)
+		root   string
+		total  string
+	}{
+		{"balance", func(p *Proof) { p.Balance = "31" }, rootHash, total},
+		{"user id", func(p *Proof) { p.UserID++ }, rootHash, total},
+		{"nonce", func(p *Proof) { p.Nonce = hex.EncodeToString([]byte("other")) }, rootHash, total},
+		// 兄弟节点金额调低可以掩盖负债，必须改变树根
+		{"sibling sum", func(p *Proof) { p.Path[0].Sum = "0" }, rootHash, total},
+		{"sibling side", func(p *Proof) { p.Path[0].Left = !p.Path[0].Left }, rootHash, total},
+		{"missing step", func(p *Proof) { p.Path = p.Path[:len(p.Path)-1] }, rootHash, total},
+		{"published total", func(p *Proof) {}, rootHash, "149"},
+		{"published root", func(p *Proof) {}, hex.EncodeToString(make([]byte, HASH_SIZE)), total},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *proofs[2]
+			p.Path = append([]ProofStep(nil), proofs[2].Path...)
+			tt.tamper(&p)
+			if err := p.Verify(tt.root, tt.total); !errors.Is(err, ErrProofMismatch) {
+				t.Fatalf("err = %v, want %v", err, ErrProofMismatch)
+			}
+		})
+	}
+}
+
+func TestInvalidAmounts(t *testing.T) {
+	if _, err := Build(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Fatalf("Build(nil) err = %v, want %v", err, ErrEmptyTree)
+	}
+	if _, err := Leaf([HASH_SIZE]byte{}, big.NewInt(-1)); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("negative leaf err = %v, want %v", err, ErrInvalidAmount)
+	}
+	if _, err := Leaf([HASH_SIZE]byte{}, maxAmount); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("oversized leaf err = %v, want %v", err, ErrInvalidAmount)
+	}
+	// 两个合法叶子相加溢出 256 位
+	half := new(big.Int).Rsh(maxAmount, 1)
+	a, _ := Leaf([HASH_SIZE]byte{1}, half)
+	b, _ := Leaf([HASH_SIZE]byte{2}, half)
+	if _, err := Build([]Node{a, b}); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("overflowing sum err = %v, want %v", err, ErrInvalidAmount)
+	}
+
+	tree, proofs := testProofs(t, []int64{1, 2, 3})
+	if _, err := tree.Proof(4); !errors.Is(err, ErrLeafOutOfRange) {
+		t.Fatalf("Proof(4) err = %v, want %v", err, ErrLeafOutOfRange)
+	}
+	root := tree.Root()
+	p := *proofs[0]
+	p.Path = append([]ProofStep(nil), proofs[0].Path...)
+	p.Path[0].Sum = "-2"
+	if err := p.Verify(hex.EncodeToString(root.Hash[:]), root.Sum.String()); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("negative sibling err = %v, want %v", err, ErrInvalidAmount)
+	}
+}
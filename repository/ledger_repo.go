@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+// LedgerRepository 用户资产账本：处理器入账的充值（deposits）减去已广播的提现（withdrawals），
+// 两张表的金额都是最小单位，按 NUMERIC 精确求和
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// SENT_WITHDRAWAL_STATUSES 资金已经或可能已离开热钱包的提现状态；broadcasting / unconfirmed 确认失败后转为 failed，不再计入
+var SENT_WITHDRAWAL_STATUSES = []string{"broadcasting", "unconfirmed", "success"}
+
+type ledgerRow struct {
+	UserID uint64
+	Total  string
+}
+
+// UserBalances 按用户汇总链上某资产的负债（最小单位），token 为 nil 表示原生币；结果可能为负，由调用方处理
+func (r *LedgerRepository) UserBalances(ctx context.Context, chain string, token *string, currency string) (map[uint64]*big.Int, error) {
+	deposits := r.db.WithContext(ctx).Model(&model.Deposit{}).
+		Select("user_id, SUM(CAST(amount AS NUMERIC))::TEXT AS total").
+		Where("chain = ? AND confirmed AND user_id IS NOT NULL", chain)
+	switch {
+	case token == nil:
+		deposits = deposits.Where("token IS NULL")
+	case strings.HasPrefix(address.NormalizeAny(*token), "0x"):
+		// EVM 合约地址按日志原样记录，大小写不定
+		deposits = deposits.Where("LOWER(token) = ?", address.NormalizeAny(*token))
+	default:
+		deposits = deposits.Where("token = ?", strings.TrimSpace(*token))
+	}
+	var credited []ledgerRow
+	if err := deposits.Group("user_id").Scan(&credited).Error; err != nil {
+		return nil, err
+	}
+	var withdrawn []ledgerRow
+	if err := r.db.WithContext(ctx).Model(&model.Withdrawal{}).
+		Select("user_id, SUM(CAST(amount AS NUMERIC))::TEXT AS total").
+		Where("currency = ? AND status IN ?", currency, SENT_WITHDRAWAL_STATUSES).
+		Group("user_id").Scan(&withdrawn).Error; err != nil {
+		return nil, err
+	}
+
+	balances := make(map[uint64]*big.Int)
+	add := func(rows []ledgerRow, sign int) error {
+		for _, row := range rows {
+			v, ok := new(big.Int).SetString(row.Total, 10)
+			if !ok {
+				return fmt.Errorf("invalid ledger sum %q for user %d", row.Total, row.UserID)
+			}
+			if sign < 0 {
+				v.Neg(v)
+			}
+			if balances[row.UserID] == nil {
+				balances[row.UserID] = new(big.Int)
+			}
+			balances[row.UserID].Add(balances[row.UserID], v)
+		}
+		return nil
+	}
+	if err := add(credited, 1); err != nil {
+		return nil, err
+	}
+	if err := add(withdrawn, -1); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// Liabilities 全部用户的负债合计（最小单位）
+func (r *LedgerRepository) Liabilities(ctx context.Context, chain string, token *string, currency string) (*big.Int, error) {
+	balances, err := r.UserBalances(ctx, chain, token, currency)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	for _, b := range balances {
+		total.Add(total, b)
+	}
+	return total, nil
+}
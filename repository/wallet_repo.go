@@ -2,8 +2,13 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"math/big"
+	"time"
+
 	"github.com/crypto_custody/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AddressRepository struct {
@@ -22,6 +27,26 @@ func (r *AddressRepository) FindByUserAndCurrency(userId uint64, currency string
 	return &addr, nil
 }
 
+// IsOwnAddress 地址是否为平台自有：热/冷钱包或用户充值地址池
+// 地址均以规范形式存储（见 address.Normalize），addr 须已规范化
+func (r *AddressRepository) IsOwnAddress(ctx context.Context, chain, addr string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.WalletAddress{}).
+		Where("address = ?", addr).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&model.AddressPool{}).
+		Where("chain = ? AND address = ?", chain, addr).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 type DepositRepository struct {
 	db *gorm.DB
 }
@@ -76,6 +101,34 @@ func (r *WithdrawRepository) SumPendingByUserAndCurrency(ctx context.Context, us
 	return sum, err
 }
 
+// SumSince 自 since 起未失败的提现总额（十进制精确求和），用于限额检查
+func (r *WithdrawRepository) SumSince(ctx context.Context, userId uint64, currency string, since time.Time) (*big.Rat, error) {
+	var sum string
+	err := r.db.WithContext(ctx).Model(&model.WalletWithdraw{}).Select("COALESCE(SUM(amount), 0)::TEXT").
+		Where("user_id=? AND currency=? AND status<>4 AND create_time>=?", userId, currency, since).Scan(&sum).Error
+	if err != nil {
+		return nil, err
+	}
+	total, ok := new(big.Rat).SetString(sum)
+	if !ok {
+		return nil, fmt.Errorf("invalid withdraw sum %q", sum)
+	}
+	return total, nil
+}
+
+// Transaction 在同一事务中执行 fn，fn 内使用传入的 repo
+func (r *WithdrawRepository) Transaction(ctx context.Context, fn func(repo *WithdrawRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&WithdrawRepository{db: tx})
+	})
+}
+
+// LockUser 锁定用户行（SELECT ... FOR UPDATE）直到事务结束，同一用户的提现申请串行检查限额并写入
+func (r *WithdrawRepository) LockUser(ctx context.Context, userId uint64) error {
+	var user model.User
+	return r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userId).Error
+}
+
 type TransactionRepository struct {
 	db *gorm.DB
 }
@@ -83,3 +136,74 @@ type TransactionRepository struct {
 func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
+
+type AddressBookRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressBookRepository(db *gorm.DB) *AddressBookRepository {
+	return &AddressBookRepository{db: db}
+}
+
+func (r *AddressBookRepository) Create(ctx context.Context, entry *model.AddressBookEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *AddressBookRepository) FindByID(ctx context.Context, userId, id uint64) (*model.AddressBookEntry, error) {
+	var entry model.AddressBookEntry
+	if err := r.db.WithContext(ctx).Where("id=? AND user_id=?", id, userId).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *AddressBookRepository) ListByUser(ctx context.Context, userId uint64, currency string) ([]*model.AddressBookEntry, error) {
+	var list []*model.AddressBookEntry
+	q := r.db.WithContext(ctx).Where("user_id=?", userId)
+	if currency != "" {
+		q = q.Where("currency=?", currency)
+	}
+	if err := q.Order("id DESC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Confirm 待确认条目置为已确认，并设置锁定期结束时间
+func (r *AddressBookRepository) Confirm(ctx context.Context, entry *model.AddressBookEntry, confirmedAt, activateAt time.Time) error {
+	res := r.db.WithContext(ctx).Model(entry).
+		Where("status=0").
+		Updates(map[string]interface{}{"status": 1, "confirm_time": confirmedAt, "activate_time": activateAt})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *AddressBookRepository) Delete(ctx context.Context, userId, id uint64) error {
+	return r.db.WithContext(ctx).Where("id=? AND user_id=?", id, userId).Delete(&model.AddressBookEntry{}).Error
+}
+
+// IsUsable 地址是否在地址簿中已确认且锁定期已过；EVM 地址大小写不敏感
+func (r *AddressBookRepository) IsUsable(ctx context.Context, userId uint64, currency, addr string, now time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.AddressBookEntry{}).
+		Where("user_id=? AND currency=? AND address=? AND status=1 AND activate_time<=?", userId, currency, addr, now).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *AddressBookRepository) GetSetting(ctx context.Context, userId uint64) (*model.WithdrawSetting, error) {
+	setting := model.WithdrawSetting{UserID: userId}
+	if err := r.db.WithContext(ctx).Where("user_id=?", userId).Limit(1).Find(&setting).Error; err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *AddressBookRepository) SaveSetting(ctx context.Context, setting *model.WithdrawSetting) error {
+	return r.db.WithContext(ctx).Save(setting).Error
+}
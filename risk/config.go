@@ -0,0 +1,93 @@
+package risk
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// LimitConfig 限额配置，金额为最小单位的十进制字符串
+type LimitConfig struct {
+	Window    time.Duration
+	MaxCount  int64
+	MaxAmount map[string]string
+	Action    Action // 超限时的处理，默认 review
+}
+
+// Config 风控规则配置
+type Config struct {
+	BlacklistFiles    []string // 命中拒绝
+	SanctionsFiles    []string // 命中拒绝
+	UserVelocity      LimitConfig
+	GlobalVelocity    LimitConfig
+	NewAddressCooling time.Duration
+	ReviewThresholds  map[string]string // currency -> 最小单位金额
+}
+
+// NewEngineFromConfig 按配置组装规则，名单文件读取失败时返回错误
+func NewEngineFromConfig(cfg Config, history History) (*Engine, error) {
+	var rules []Rule
+	if len(cfg.BlacklistFiles) > 0 {
+		l, err := LoadAddressList("blacklist", ActionReject, cfg.BlacklistFiles...)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, l)
+	}
+	if len(cfg.SanctionsFiles) > 0 {
+		l, err := LoadAddressList("sanctions", ActionReject, cfg.SanctionsFiles...)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, l)
+	}
+
+	userLimit, err := cfg.UserVelocity.limit()
+	if err != nil {
+		return nil, fmt.Errorf("user velocity: %w", err)
+	}
+	globalLimit, err := cfg.GlobalVelocity.limit()
+	if err != nil {
+		return nil, fmt.Errorf("global velocity: %w", err)
+	}
+	rules = append(rules,
+		NewUserVelocityRule(userLimit, history, cfg.UserVelocity.action()),
+		NewGlobalVelocityRule(globalLimit, history, cfg.GlobalVelocity.action()),
+		NewCoolingRule(cfg.NewAddressCooling, history),
+	)
+
+	thresholds, err := parseAmounts(cfg.ReviewThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("review thresholds: %w", err)
+	}
+	rules = append(rules, NewLargeAmountRule(thresholds))
+	return NewEngine(rules...), nil
+}
+
+func (c LimitConfig) limit() (Limit, error) {
+	amounts, err := parseAmounts(c.MaxAmount)
+	if err != nil {
+		return Limit{}, err
+	}
+	return Limit{Window: c.Window, MaxCount: c.MaxCount, MaxAmount: amounts}, nil
+}
+
+func (c LimitConfig) action() Action {
+	if c.Action == "" {
+		return ActionReview
+	}
+	return c.Action
+}
+
+func parseAmounts(in map[string]string) (map[string]*big.Int, error) {
+	out := make(map[string]*big.Int, len(in))
+	for currency, s := range in {
+		v, ok := new(big.Int).SetString(s, 10)
+		if !ok || v.Sign() < 0 {
+			return nil, fmt.Errorf("invalid amount %q for %s", s, currency)
+		}
+		out[strings.ToUpper(currency)] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,107 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ==========================
+// 风控引擎
+// ==========================
+// 提现在审批前依次经过所有规则，取最严格的结果：reject > review > allow
+
+type Action string
+
+const (
+	ActionAllow  Action = "allow"
+	ActionReview Action = "review" // 需要人工审核
+	ActionReject Action = "reject"
+)
+
+func (a Action) severity() int {
+	switch a {
+	case ActionReject:
+		return 2
+	case ActionReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Request 待评估的提现
+type Request struct {
+	UserID   uint64
+	Currency string
+	To       string
+	Amount   *big.Int // 最小单位（wei / satoshi / lamports）
+	At       time.Time
+}
+
+// Result 单条规则的结果
+type Result struct {
+	Rule   string
+	Action Action
+	Reason string
+}
+
+// Decision 引擎最终结论
+type Decision struct {
+	Action  Action
+	Results []Result // 只包含命中（非 allow）的规则
+}
+
+// Reasons 命中规则的原因
+func (d Decision) Reasons() []string {
+	reasons := make([]string, 0, len(d.Results))
+	for _, r := range d.Results {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", r.Rule, r.Reason))
+	}
+	return reasons
+}
+
+func (d Decision) String() string {
+	if len(d.Results) == 0 {
+		return string(d.Action)
+	}
+	return fmt.Sprintf("%s (%s)", d.Action, strings.Join(d.Reasons(), "; "))
+}
+
+// Rule 风控规则；未命中返回 ActionAllow
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, req Request) (Action, string, error)
+}
+
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Evaluate 执行全部规则；规则出错时按需人工审核处理，不直接放行
+func (e *Engine) Evaluate(ctx context.Context, req Request) Decision {
+	if req.At.IsZero() {
+		req.At = time.Now()
+	}
+	decision := Decision{Action: ActionAllow}
+	for _, rule := range e.rules {
+		action, reason, err := rule.Evaluate(ctx, req)
+		if err != nil {
+			action, reason = ActionReview, fmt.Sprintf("rule error: %v", err)
+		}
+		if action == ActionAllow {
+			continue
+		}
+		decision.Results = append(decision.Results, Result{Rule: rule.Name(), Action: action, Reason: reason})
+		if action.severity() > decision.Action.severity() {
+			decision.Action = action
+		}
+	}
+	return decision
+}
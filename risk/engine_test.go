@@ -0,0 +1,84 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// staticRule 固定返回 action，err 非 nil 时返回错误
+type staticRule struct {
+	name   string
+	action Action
+	err    error
+	seen   Request
+}
+
+func (r *staticRule) Name() string { return r.name }
+
+func (r *staticRule) Evaluate(ctx context.Context, req Request) (Action, string, error) {
+	r.seen = req
+	return r.action, "because " + r.name, r.err
+}
+
+// 取最严格的结果，只记录命中的规则；规则出错按人工审核处理
+func TestEngineEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []Rule
+		action Action
+		hits   int
+	}{
+		{"no rules", nil, ActionAllow, 0},
+		{"all allow", []Rule{&staticRule{name: "a", action: ActionAllow}}, ActionAllow, 0},
+		{"review", []Rule{&staticRule{name: "a", action: ActionAllow}, &staticRule{name: "b", action: ActionReview}}, ActionReview, 1},
+		{"reject wins", []Rule{&staticRule{name: "a", action: ActionReject}, &staticRule{name: "b", action: ActionReview}}, ActionReject, 2},
+		{"error reviews", []Rule{&staticRule{name: "a", action: ActionAllow, err: errors.New("db down")}}, ActionReview, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewEngine(tt.rules...).Evaluate(context.Background(), Request{Currency: "ETH", Amount: big.NewInt(1)})
+			if d.Action != tt.action || len(d.Results) != tt.hits {
+				t.Fatalf("decision = %s with %d results, want %s with %d", d, len(d.Results), tt.action, tt.hits)
+			}
+		})
+	}
+
+	// 未指定时间时按当前时间评估
+	rule := &staticRule{name: "a", action: ActionAllow}
+	NewEngine(rule).Evaluate(context.Background(), Request{Amount: big.NewInt(1)})
+	if time.Since(rule.seen.At) > time.Minute {
+		t.Fatalf("request time = %s, want now", rule.seen.At)
+	}
+}
+
+// 按配置组装规则：名单文件缺失时报错，金额必须是非负整数
+func TestNewEngineFromConfig(t *testing.T) {
+	history := &fakeHistory{}
+	if _, err := NewEngineFromConfig(Config{BlacklistFiles: []string{t.TempDir() + "/missing.txt"}}, history); err == nil {
+		t.Fatal("missing blacklist file accepted")
+	}
+	if _, err := NewEngineFromConfig(Config{UserVelocity: LimitConfig{Window: time.Hour, MaxAmount: map[string]string{"eth": "-1"}}}, history); err == nil {
+		t.Fatal("negative velocity amount accepted")
+	}
+	if _, err := NewEngineFromConfig(Config{ReviewThresholds: map[string]string{"eth": "1.5"}}, history); err == nil {
+		t.Fatal("fractional review threshold accepted")
+	}
+
+	e, err := NewEngineFromConfig(Config{
+		UserVelocity:     LimitConfig{Window: time.Hour, MaxCount: 1},
+		ReviewThresholds: map[string]string{"eth": "100"},
+	}, history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 超限默认转人工审核，币种大小写不敏感
+	history.userCount = 1
+	history.first = map[string]time.Time{"0xabc": time.Now().Add(-time.Hour)}
+	d := e.Evaluate(context.Background(), Request{UserID: 1, Currency: "ETH", To: "0xabc", Amount: big.NewInt(100)})
+	if d.Action != ActionReview || len(d.Results) != 2 {
+		t.Fatalf("decision = %s, want review from user_velocity and large_amount", d)
+	}
+}
@@ -0,0 +1,95 @@
+package risk
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/crypto_custody/address"
+)
+
+// AddressList 地址名单（黑名单 / 制裁名单），从文件加载，地址按规范形式比较（见 address.Normalize），
+// EVM 地址大小写不敏感，base58 地址区分大小写
+// 文件格式：每行一个地址，# 开头为注释，地址后可跟空白分隔的备注
+type AddressList struct {
+	name   string
+	files  []string
+	action Action
+
+	mu    sync.RWMutex
+	addrs map[string]string // 规范地址 -> 来源文件
+}
+
+// LoadAddressList 加载名单，命中时返回 action
+func LoadAddressList(name string, action Action, files ...string) (*AddressList, error) {
+	l := &AddressList{name: name, files: files, action: action}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Reload 重新读取全部文件，任一文件失败时保留旧名单
+func (l *AddressList) Reload() error {
+	addrs := map[string]string{}
+	for _, file := range l.files {
+		if err := readListFile(file, addrs); err != nil {
+			return err
+		}
+	}
+	l.mu.Lock()
+	l.addrs = addrs
+	l.mu.Unlock()
+	return nil
+}
+
+func readListFile(file string, into map[string]string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("open list %s: %w", file, err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		into[address.NormalizeAny(strings.Fields(line)[0])] = file
+	}
+	return sc.Err()
+}
+
+// Contains 地址是否在名单中
+func (l *AddressList) Contains(addr string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.addrs[address.NormalizeAny(addr)]
+	return ok
+}
+
+// Len 名单条数
+func (l *AddressList) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.addrs)
+}
+
+func (l *AddressList) Name() string { return l.name }
+
+func (l *AddressList) Evaluate(ctx context.Context, req Request) (Action, string, error) {
+	key, err := address.NormalizeForCurrency(req.Currency, req.To)
+	if err != nil {
+		key = address.NormalizeAny(req.To)
+	}
+	l.mu.RLock()
+	src, ok := l.addrs[key]
+	l.mu.RUnlock()
+	if !ok {
+		return ActionAllow, "", nil
+	}
+	return l.action, fmt.Sprintf("address %s listed in %s", req.To, src), nil
+}
@@ -0,0 +1,71 @@
+package risk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crypto_custody/address"
+)
+
+const (
+	TEST_LISTED_EVM    = "0x52908400098527886E0F7030069857D2E4169EE7"
+	TEST_LISTED_SOLANA = "So11111111111111111111111111111111111111112"
+)
+
+func writeList(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 名单跳过注释和空行，地址后可跟备注；EVM 地址不区分大小写，Solana 地址区分
+func TestAddressList(t *testing.T) {
+	address.RegisterChain("risktest", address.ChainEthereum)
+	address.RegisterCurrency("RTEST", "risktest")
+	address.RegisterChain("risktestsol", address.ChainSolana)
+	address.RegisterCurrency("RSOL", "risktestsol")
+	path := writeList(t, "# OFAC\n\n"+TEST_LISTED_EVM+"  lazarus\n  "+TEST_LISTED_SOLANA+"\n")
+
+	l, err := LoadAddressList("sanctions", ActionReject, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Len() != 2 {
+		t.Fatalf("len = %d, want 2", l.Len())
+	}
+	tests := []struct {
+		currency, to string
+		action       Action
+	}{
+		{"RTEST", TEST_LISTED_EVM, ActionReject},
+		{"RTEST", "0x52908400098527886e0f7030069857d2e4169ee7", ActionReject},
+		{"RTEST", "0x0000000000000000000000000000000000000001", ActionAllow},
+		{"RSOL", TEST_LISTED_SOLANA, ActionReject},
+		{"RSOL", "so11111111111111111111111111111111111111112", ActionAllow},
+	}
+	for _, tt := range tests {
+		action, reason, err := l.Evaluate(context.Background(), Request{Currency: tt.currency, To: tt.to})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if action != tt.action {
+			t.Fatalf("%s %s: action = %s (%s), want %s", tt.currency, tt.to, action, reason, tt.action)
+		}
+	}
+
+	// 重新加载失败时保留旧名单
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reload(); err == nil {
+		t.Fatal("reload of a missing file succeeded")
+	}
+	if !l.Contains(TEST_LISTED_EVM) {
+		t.Fatal("list cleared after a failed reload")
+	}
+}
@@ -0,0 +1,140 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// History 规则所需的提现历史查询（已拒绝的提现不计入）
+type History interface {
+	// UserWithdrawals 用户在 since 之后该币种的提现笔数与总额
+	UserWithdrawals(ctx context.Context, userID uint64, currency string, since time.Time) (int64, *big.Int, error)
+	// GlobalWithdrawals 全平台在 since 之后该币种的提现笔数与总额
+	GlobalWithdrawals(ctx context.Context, currency string, since time.Time) (int64, *big.Int, error)
+	// FirstWithdrawalTo 用户第一次提现到该地址的时间，没有记录时 ok=false
+	FirstWithdrawalTo(ctx context.Context, userID uint64, to string) (first time.Time, ok bool, err error)
+}
+
+// Limit 滑动窗口限额；MaxCount / MaxAmount 为 0 或缺省表示不限制
+type Limit struct {
+	Window    time.Duration
+	MaxCount  int64
+	MaxAmount map[string]*big.Int // currency -> 最小单位金额
+}
+
+// ==========================
+// 频率 / 限额
+// ==========================
+
+// VelocityRule 单用户或全局的窗口内笔数、金额限制（含本笔）
+type VelocityRule struct {
+	global  bool
+	limit   Limit
+	history History
+	action  Action
+}
+
+func NewUserVelocityRule(limit Limit, history History, action Action) *VelocityRule {
+	return &VelocityRule{limit: limit, history: history, action: action}
+}
+
+func NewGlobalVelocityRule(limit Limit, history History, action Action) *VelocityRule {
+	return &VelocityRule{global: true, limit: limit, history: history, action: action}
+}
+
+func (r *VelocityRule) Name() string {
+	if r.global {
+		return "global_velocity"
+	}
+	return "user_velocity"
+}
+
+func (r *VelocityRule) Evaluate(ctx context.Context, req Request) (Action, string, error) {
+	if r.limit.Window <= 0 {
+		return ActionAllow, "", nil
+	}
+	since := req.At.Add(-r.limit.Window)
+	var (
+		count int64
+		total *big.Int
+		err   error
+	)
+	if r.global {
+		count, total, err = r.history.GlobalWithdrawals(ctx, req.Currency, since)
+	} else {
+		count, total, err = r.history.UserWithdrawals(ctx, req.UserID, req.Currency, since)
+	}
+	if err != nil {
+		return ActionAllow, "", err
+	}
+
+	if r.limit.MaxCount > 0 && count+1 > r.limit.MaxCount {
+		return r.action, fmt.Sprintf("%d withdrawals in %s exceeds limit %d", count+1, r.limit.Window, r.limit.MaxCount), nil
+	}
+	if max := r.limit.MaxAmount[strings.ToUpper(req.Currency)]; max != nil && max.Sign() > 0 {
+		sum := new(big.Int).Add(total, req.Amount)
+		if sum.Cmp(max) > 0 {
+			return r.action, fmt.Sprintf("amount %s in %s exceeds limit %s", sum, r.limit.Window, max), nil
+		}
+	}
+	return ActionAllow, "", nil
+}
+
+// ==========================
+// 新地址冷却期
+// ==========================
+
+// CoolingRule 用户首次使用某地址后的 period 内，提现到该地址都需要人工审核
+type CoolingRule struct {
+	period  time.Duration
+	history History
+}
+
+func NewCoolingRule(period time.Duration, history History) *CoolingRule {
+	return &CoolingRule{period: period, history: history}
+}
+
+func (r *CoolingRule) Name() string { return "new_address_cooling" }
+
+func (r *CoolingRule) Evaluate(ctx context.Context, req Request) (Action, string, error) {
+	if r.period <= 0 {
+		return ActionAllow, "", nil
+	}
+	first, ok, err := r.history.FirstWithdrawalTo(ctx, req.UserID, req.To)
+	if err != nil {
+		return ActionAllow, "", err
+	}
+	if !ok {
+		return ActionReview, fmt.Sprintf("first withdrawal to %s", req.To), nil
+	}
+	if until := first.Add(r.period); req.At.Before(until) {
+		return ActionReview, fmt.Sprintf("address %s in cooling period until %s", req.To, until.Format(time.RFC3339)), nil
+	}
+	return ActionAllow, "", nil
+}
+
+// ==========================
+// 大额人工审核
+// ==========================
+
+// LargeAmountRule 单笔金额达到阈值时转人工审核
+type LargeAmountRule struct {
+	thresholds map[string]*big.Int
+}
+
+func NewLargeAmountRule(thresholds map[string]*big.Int) *LargeAmountRule {
+	return &LargeAmountRule{thresholds: thresholds}
+}
+
+func (r *LargeAmountRule) Name() string { return "large_amount" }
+
+func (r *LargeAmountRule) Evaluate(ctx context.Context, req Request) (Action, string, error) {
+	threshold := r.thresholds[strings.ToUpper(req.Currency)]
+	if threshold == nil || threshold.Sign() <= 0 || req.Amount.Cmp(threshold) < 0 {
+		return ActionAllow, "", nil
+	}
+	return ActionReview, fmt.Sprintf("amount %s >= review threshold %s", req.Amount, threshold), nil
+}
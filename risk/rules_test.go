@@ -0,0 +1,136 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// fakeHistory 固定的提现历史；first 为各地址首次提现时间，since 记录最近一次查询的窗口起点
+type fakeHistory struct {
+	userCount, globalCount int64
+	userTotal, globalTotal *big.Int
+	first                  map[string]time.Time
+	err                    error
+	since                  time.Time
+}
+
+func total(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
+
+func (h *fakeHistory) UserWithdrawals(ctx context.Context, userID uint64, currency string, since time.Time) (int64, *big.Int, error) {
+	h.since = since
+	return h.userCount, total(h.userTotal), h.err
+}
+
+func (h *fakeHistory) GlobalWithdrawals(ctx context.Context, currency string, since time.Time) (int64, *big.Int, error) {
+	h.since = since
+	return h.globalCount, total(h.globalTotal), h.err
+}
+
+func (h *fakeHistory) FirstWithdrawalTo(ctx context.Context, userID uint64, to string) (time.Time, bool, error) {
+	first, ok := h.first[to]
+	return first, ok, h.err
+}
+
+// 窗口内笔数、金额均含本笔；未配置的币种和窗口不限制
+func TestVelocityRule(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	limit := Limit{Window: time.Hour, MaxCount: 3, MaxAmount: map[string]*big.Int{"ETH": big.NewInt(1000)}}
+	tests := []struct {
+		name     string
+		global   bool
+		limit    Limit
+		history  fakeHistory
+		currency string
+		amount   int64
+		action   Action
+	}{
+		{"under limits", false, limit, fakeHistory{userCount: 1, userTotal: big.NewInt(500)}, "eth", 500, ActionAllow},
+		{"count reached", false, limit, fakeHistory{userCount: 3}, "ETH", 1, ActionReject},
+		{"amount exceeded", false, limit, fakeHistory{userCount: 1, userTotal: big.NewInt(500)}, "ETH", 501, ActionReject},
+		{"other currency", false, limit, fakeHistory{userTotal: big.NewInt(5000)}, "SOL", 5000, ActionAllow},
+		{"global", true, limit, fakeHistory{globalCount: 3}, "ETH", 1, ActionReject},
+		{"global ignores user history", true, limit, fakeHistory{userCount: 3}, "ETH", 1, ActionAllow},
+		{"no window", false, Limit{MaxCount: 1}, fakeHistory{userCount: 5}, "ETH", 1, ActionAllow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := tt.history
+			rule := NewUserVelocityRule(tt.limit, &history, ActionReject)
+			if tt.global {
+				rule = NewGlobalVelocityRule(tt.limit, &history, ActionReject)
+			}
+			action, reason, err := rule.Evaluate(context.Background(), Request{UserID: 1, Currency: tt.currency, Amount: big.NewInt(tt.amount), At: now})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action != tt.action {
+				t.Fatalf("action = %s (%s), want %s", action, reason, tt.action)
+			}
+			if tt.limit.Window > 0 && !history.since.Equal(now.Add(-tt.limit.Window)) {
+				t.Fatalf("window start = %s, want %s", history.since, now.Add(-tt.limit.Window))
+			}
+		})
+	}
+
+	history := &fakeHistory{err: errors.New("db down")}
+	if _, _, err := NewUserVelocityRule(limit, history, ActionReject).Evaluate(context.Background(), Request{Currency: "ETH", Amount: big.NewInt(1), At: now}); err == nil {
+		t.Fatal("history error swallowed")
+	}
+}
+
+// 首次提现到某地址及之后的冷却期内转人工审核
+func TestCoolingRule(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	history := &fakeHistory{first: map[string]time.Time{
+		"0xrecent": now.Add(-time.Hour),
+		"0xold":    now.Add(-48 * time.Hour),
+	}}
+	tests := []struct {
+		period time.Duration
+		to     string
+		action Action
+	}{
+		{24 * time.Hour, "0xnew", ActionReview},
+		{24 * time.Hour, "0xrecent", ActionReview},
+		{24 * time.Hour, "0xold", ActionAllow},
+		{0, "0xnew", ActionAllow},
+	}
+	for _, tt := range tests {
+		action, reason, err := NewCoolingRule(tt.period, history).Evaluate(context.Background(), Request{UserID: 1, To: tt.to, At: now})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if action != tt.action {
+			t.Fatalf("period %s to %s: action = %s (%s), want %s", tt.period, tt.to, action, reason, tt.action)
+		}
+	}
+}
+
+// 单笔达到阈值（含等于）转人工审核
+func TestLargeAmountRule(t *testing.T) {
+	rule := NewLargeAmountRule(map[string]*big.Int{"ETH": big.NewInt(100)})
+	tests := []struct {
+		currency string
+		amount   int64
+		action   Action
+	}{
+		{"ETH", 99, ActionAllow},
+		{"ETH", 100, ActionReview},
+		{"eth", 101, ActionReview},
+		{"SOL", 1000, ActionAllow},
+	}
+	for _, tt := range tests {
+		action, _, _ := rule.Evaluate(context.Background(), Request{Currency: tt.currency, Amount: big.NewInt(tt.amount)})
+		if action != tt.action {
+			t.Fatalf("%d %s: action = %s, want %s", tt.amount, tt.currency, action, tt.action)
+		}
+	}
+}
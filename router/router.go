@@ -1,22 +1,74 @@
 package router
 
 import (
+	"github.com/crypto_custody/auth"
 	"github.com/crypto_custody/handler"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(walletHandler *handler.WalletHandler) *gin.Engine {
+func SetupRouter(walletHandler *handler.WalletHandler, approvalHandler *handler.ApprovalHandler, rebalanceHandler *handler.RebalanceHandler, reconcileHandler *handler.ReconcileHandler, porHandler *handler.PorHandler, webhookHandler *handler.WebhookHandler, authMiddleware, stepUp gin.HandlerFunc) *gin.Engine {
 	r := gin.Default()
 
-	api := r.Group("/api/wallet")
+	// 用户身份只从访问令牌获取
+	api := r.Group("/api/wallet", authMiddleware)
 	{
-		//api.GET("/deposit/address", walletHandler.GetDepositAddress)
-		//api.POST("/withdraw", walletHandler.RequestWithdraw)
-
 		api.GET("/deposit/address", walletHandler.GetDepositAddress)
 		api.GET("/deposit/history", walletHandler.GetDepositHistory)
 		api.GET("/withdraw/history", walletHandler.GetWithdrawHistory)
 		api.GET("/balance", walletHandler.GetBalance)
+
+		// 提现与地址簿变更需要二次验证；确认地址与关闭白名单由请求体中的验证码校验
+		api.POST("/withdraw", stepUp, walletHandler.RequestWithdraw)
+
+		api.GET("/address-book", walletHandler.ListAddressBook)
+		api.POST("/address-book", stepUp, walletHandler.AddAddressBookEntry)
+		api.POST("/address-book/:id/confirm", walletHandler.ConfirmAddressBookEntry)
+		api.DELETE("/address-book/:id", stepUp, walletHandler.DeleteAddressBookEntry)
+		api.PUT("/withdraw/whitelist", walletHandler.SetWhitelistOnly)
+
+		api.GET("/proof-of-reserves", porHandler.GetUserProof)
+
+		api.GET("/webhooks", webhookHandler.List)
+		api.POST("/webhooks", webhookHandler.Create)
+		api.DELETE("/webhooks/:id", webhookHandler.Delete)
+		api.GET("/webhooks/:id/deliveries", webhookHandler.Deliveries)
+		api.POST("/webhooks/deliveries/:id/redeliver", webhookHandler.Redeliver)
+	}
+
+	r.GET("/api/por/:currency", porHandler.GetLatest)
+
+	// 管理接口按路由组要求对应的管理角色，操作员身份与角色只取自令牌
+	admin := r.Group("/api/admin", authMiddleware)
+
+	approvals := admin.Group("", auth.RequireRole(auth.ROLE_FINANCE, auth.ROLE_RISK, auth.ROLE_OPS))
+	{
+		approvals.POST("/withdrawals/:id/approve", approvalHandler.Approve)
+		approvals.POST("/withdrawals/:id/reject", approvalHandler.Reject)
+		approvals.GET("/withdrawals/:id/approvals", approvalHandler.GetTrail)
+
+		// 调拨审批与提现共用审批档位，需要多个角色
+		approvals.GET("/rebalances", rebalanceHandler.List)
+		approvals.POST("/rebalances/:id/approve", rebalanceHandler.Approve)
+		approvals.POST("/rebalances/:id/reject", rebalanceHandler.Reject)
+
+		approvals.GET("/reconciliations", reconcileHandler.List)
+		approvals.GET("/reconciliations/:id", reconcileHandler.Get)
+		approvals.GET("/withdrawal-blocks", reconcileHandler.ListBlocks)
+	}
+
+	// 调拨交易的导出、导入与签名提交，解除提现暂停与储备金证明只允许运维
+	ops := admin.Group("", auth.RequireRole(auth.ROLE_OPS))
+	{
+		ops.GET("/rebalances/export", rebalanceHandler.Export)
+		ops.POST("/rebalances/import", rebalanceHandler.Import)
+		ops.POST("/rebalances/:id/signed", rebalanceHandler.SubmitSigned)
+		ops.POST("/safe/sign-requests/:id/signature", rebalanceHandler.SubmitSafeSignature)
+
+		ops.POST("/reconciliations/run", reconcileHandler.Run)
+		ops.POST("/withdrawal-blocks/:currency/release", reconcileHandler.Release)
+
+		ops.POST("/por/:currency/snapshots", porHandler.CreateSnapshot)
+		ops.POST("/por/snapshots/:id/address-proofs", porHandler.SubmitAddressProof)
 	}
 
 	return r
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// testRouter 以请求头 X-Test-Roles 作为令牌中的角色；处理器不依赖服务的路径（非法 ID）用于观察角色检查是否放行
+func testRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	authMiddleware := func(c *gin.Context) {
+		c.Set(auth.CTX_USER_ID, uint64(1))
+		if roles := c.GetHeader("X-Test-Roles"); roles != "" {
+			c.Set(auth.CTX_ROLES, strings.Split(roles, ","))
+		}
+		c.Next()
+	}
+	return SetupRouter(&handler.WalletHandler{}, &handler.ApprovalHandler{}, &handler.RebalanceHandler{},
+		&handler.ReconcileHandler{}, &handler.PorHandler{}, &handler.WebhookHandler{}, authMiddleware, func(c *gin.Context) { c.Next() })
+}
+
+func TestAdminRoles(t *testing.T) {
+	r := testRouter()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		roles  string
+		want   int
+	}{
+		{"reviewer approves withdrawal", http.MethodPost, "/api/admin/withdrawals/x/approve", auth.ROLE_KYC_REVIEWER, http.StatusForbidden},
+		{"no role approves withdrawal", http.MethodPost, "/api/admin/withdrawals/x/approve", "", http.StatusForbidden},
+		{"finance approves withdrawal", http.MethodPost, "/api/admin/withdrawals/x/approve", auth.ROLE_FINANCE, http.StatusBadRequest},
+		{"risk rejects withdrawal", http.MethodPost, "/api/admin/withdrawals/x/reject", auth.ROLE_RISK, http.StatusBadRequest},
+		{"reviewer approves rebalance", http.MethodPost, "/api/admin/rebalances/x/approve", auth.ROLE_KYC_REVIEWER, http.StatusForbidden},
+		{"ops approves rebalance", http.MethodPost, "/api/admin/rebalances/x/approve", auth.ROLE_OPS, http.StatusBadRequest},
+		{"reviewer releases block", http.MethodPost, "/api/admin/withdrawal-blocks/ETH/release", auth.ROLE_KYC_REVIEWER, http.StatusForbidden},
+		{"finance releases block", http.MethodPost, "/api/admin/withdrawal-blocks/ETH/release", auth.ROLE_FINANCE, http.StatusForbidden},
+		{"reviewer submits signed rebalance", http.MethodPost, "/api/admin/rebalances/x/signed", auth.ROLE_KYC_REVIEWER, http.StatusForbidden},
+		{"finance submits signed rebalance", http.MethodPost, "/api/admin/rebalances/x/signed", auth.ROLE_FINANCE, http.StatusForbidden},
+		{"ops submits signed rebalance", http.MethodPost, "/api/admin/rebalances/x/signed", auth.ROLE_OPS, http.StatusBadRequest},
+		{"risk imports rebalance", http.MethodPost, "/api/admin/rebalances/import", auth.ROLE_RISK, http.StatusForbidden},
+		{"risk submits safe signature", http.MethodPost, "/api/admin/safe/sign-requests/x/signature", auth.ROLE_RISK, http.StatusForbidden},
+		{"ops submits safe signature", http.MethodPost, "/api/admin/safe/sign-requests/x/signature", auth.ROLE_OPS, http.StatusBadRequest},
+		{"one allowed role is enough", http.MethodPost, "/api/admin/rebalances/x/signed", auth.ROLE_KYC_REVIEWER + "," + auth.ROLE_OPS, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}"))
+			req.Header.Set("X-Test-Roles", tt.roles)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("%s %s as %q = %d, want %d", tt.method, tt.path, tt.roles, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireRoleWithoutRolesDenies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		c.Set(auth.CTX_ROLES, []string{auth.ROLE_OPS})
+		c.Next()
+	}, auth.RequireRole(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,40 @@
+package router
+
+import (
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/handler"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupUserRouter(userHandler *handler.UserHandler, kycHandler *handler.KYCHandler, authMiddleware gin.HandlerFunc) *gin.Engine {
+	r := gin.Default()
+
+	api := r.Group("/api/v1/user")
+	{
+		api.POST("/register", userHandler.Register)
+		api.POST("/login", userHandler.Login)
+		api.POST("/login/2fa", userHandler.LoginTwoFactor)
+		api.POST("/token/refresh", userHandler.Refresh)
+		api.POST("/logout", authMiddleware, userHandler.Logout)
+
+		api.POST("/2fa/enroll", authMiddleware, userHandler.EnrollTwoFactor)
+		api.POST("/2fa/confirm", authMiddleware, userHandler.ConfirmTwoFactor)
+		api.POST("/2fa/disable", authMiddleware, userHandler.DisableTwoFactor)
+		api.POST("/2fa/recovery-codes", authMiddleware, userHandler.RegenerateRecoveryCodes)
+
+		api.POST("/kyc/submit", authMiddleware, kycHandler.Submit)
+		api.GET("/kyc/status", authMiddleware, kycHandler.Status)
+		api.POST("/kyc/callback/:provider", kycHandler.Callback)
+	}
+
+	// KYC 审核只对带审核角色的访问令牌开放，审核人取自令牌
+	admin := r.Group("/api/v1/admin/kyc", authMiddleware, auth.RequireRole(auth.ROLE_KYC_REVIEWER))
+	{
+		admin.GET("", kycHandler.List)
+		admin.GET("/:id", kycHandler.Get)
+		admin.GET("/:id/documents/:docId", kycHandler.Document)
+		admin.POST("/:id/review", kycHandler.Review)
+	}
+
+	return r
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/repository"
+	"gorm.io/gorm"
+)
+
+// 新地址默认锁定 24 小时，防止账户被盗后立即添加地址提走资产
+const DEFAULT_ADDRESS_LOCK_PERIOD = 24 * time.Hour
+
+var (
+	ErrAddressNotWhitelisted = errors.New("提现地址不在地址簿中或尚未生效")
+	ErrAddressBookNotFound   = errors.New("地址簿条目不存在或已确认")
+)
+
+// TwoFactorVerifier 二次验证（如 TOTP）；code 校验失败返回错误
+type TwoFactorVerifier interface {
+	Verify(ctx context.Context, userID uint64, code string) error
+}
+
+// AddressBookService 用户提现地址簿：新增条目需 2FA 确认，确认后经过锁定期才可用于提现
+type AddressBookService struct {
+	repo        *repository.AddressBookRepository
+	addrChecker *address.Checker
+	twoFactor   TwoFactorVerifier
+	lockPeriod  time.Duration
+}
+
+func NewAddressBookService(repo *repository.AddressBookRepository, checker *address.Checker, twoFactor TwoFactorVerifier, lockPeriod time.Duration) *AddressBookService {
+	if lockPeriod <= 0 {
+		lockPeriod = DEFAULT_ADDRESS_LOCK_PERIOD
+	}
+	return &AddressBookService{
+		repo:        repo,
+		addrChecker: checker,
+		twoFactor:   twoFactor,
+		lockPeriod:  lockPeriod,
+	}
+}
+
+// AddEntry 新增地址，状态为待确认
+func (s *AddressBookService) AddEntry(ctx context.Context, userID uint64, currency, addr, label string) (*model.AddressBookEntry, error) {
+	if err := s.addrChecker.Check(ctx, currency, addr); err != nil {
+		return nil, err
+	}
+	addr, err := address.NormalizeForCurrency(currency, addr)
+	if err != nil {
+		return nil, err
+	}
+	entry := &model.AddressBookEntry{
+		UserID:   userID,
+		Currency: currency,
+		Address:  addr,
+		Label:    label,
+		Status:   0, // 待确认
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ConfirmEntry 2FA 确认地址，锁定期从确认时开始计算
+func (s *AddressBookService) ConfirmEntry(ctx context.Context, userID, id uint64, code string) (*model.AddressBookEntry, error) {
+	if err := s.twoFactor.Verify(ctx, userID, code); err != nil {
+		return nil, fmt.Errorf("二次验证失败: %w", err)
+	}
+	entry, err := s.repo.FindByID(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAddressBookNotFound
+		}
+		return nil, err
+	}
+	now := time.Now()
+	activateAt := now.Add(s.lockPeriod)
+	if err := s.repo.Confirm(ctx, entry, now, activateAt); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAddressBookNotFound
+		}
+		return nil, err
+	}
+	entry.Status = 1
+	entry.ConfirmedAt = &now
+	entry.ActivateAt = &activateAt
+	return entry, nil
+}
+
+func (s *AddressBookService) ListEntries(ctx context.Context, userID uint64, currency string) ([]*model.AddressBookEntry, error) {
+	return s.repo.ListByUser(ctx, userID, currency)
+}
+
+func (s *AddressBookService) DeleteEntry(ctx context.Context, userID, id uint64) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+// SetWhitelistOnly 开启无需验证；关闭会放宽提现限制，需要 2FA
+func (s *AddressBookService) SetWhitelistOnly(ctx context.Context, userID uint64, enabled bool, code string) error {
+	if !enabled {
+		if err := s.twoFactor.Verify(ctx, userID, code); err != nil {
+			return fmt.Errorf("二次验证失败: %w", err)
+		}
+	}
+	return s.repo.SaveSetting(ctx, &model.WithdrawSetting{UserID: userID, WhitelistOnly: enabled})
+}
+
+func (s *AddressBookService) GetSetting(ctx context.Context, userID uint64) (*model.WithdrawSetting, error) {
+	return s.repo.GetSetting(ctx, userID)
+}
+
+// CheckWithdrawAddress 开启白名单模式时，只允许提现到已生效的地址簿地址；addr 须已规范化
+func (s *AddressBookService) CheckWithdrawAddress(ctx context.Context, userID uint64, currency, addr string) error {
+	setting, err := s.repo.GetSetting(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !setting.WhitelistOnly {
+		return nil
+	}
+	ok, err := s.repo.IsUsable(ctx, userID, currency, addr, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAddressNotWhitelisted
+	}
+	return nil
+}
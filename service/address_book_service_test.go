@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/repository"
+)
+
+// fixedCode 验证码为 "123456" 时通过
+type fixedCode struct{}
+
+func (fixedCode) Verify(ctx context.Context, userID uint64, code string) error {
+	if code != "123456" {
+		return errors.New("wrong code")
+	}
+	return nil
+}
+
+// 白名单模式下地址簿地址经 2FA 确认并度过锁定期后才可提现；关闭白名单需要 2FA
+func TestAddressBookLockPeriod(t *testing.T) {
+	db := testdb.Open(t, "address_book_entries", "withdraw_settings")
+	ctx := context.Background()
+	address.RegisterChain(TEST_WITHDRAW_CHAIN, address.ChainEthereum)
+	address.RegisterCurrency(TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_CHAIN)
+	checker := address.NewChecker(repository.NewAddressRepository(db))
+	book := NewAddressBookService(repository.NewAddressBookRepository(db), checker, fixedCode{}, time.Hour)
+	canonical := strings.ToLower(TEST_WITHDRAW_TO)
+
+	// 未开启白名单时不限制
+	if err := book.CheckWithdrawAddress(ctx, 1, TEST_WITHDRAW_CURRENCY, canonical); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.SetWhitelistOnly(ctx, 1, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := book.AddEntry(ctx, 1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, "cold storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Address != canonical {
+		t.Fatalf("stored address %s, want %s", entry.Address, canonical)
+	}
+	usable := func() error {
+		t.Helper()
+		return book.CheckWithdrawAddress(ctx, 1, TEST_WITHDRAW_CURRENCY, canonical)
+	}
+	if err := usable(); !errors.Is(err, ErrAddressNotWhitelisted) {
+		t.Fatalf("unconfirmed entry err = %v, want %v", err, ErrAddressNotWhitelisted)
+	}
+
+	if _, err := book.ConfirmEntry(ctx, 1, entry.ID, "000000"); err == nil {
+		t.Fatal("confirmed with a wrong code")
+	}
+	if _, err := book.ConfirmEntry(ctx, 2, entry.ID, "123456"); !errors.Is(err, ErrAddressBookNotFound) {
+		t.Fatalf("other user's entry err = %v, want %v", err, ErrAddressBookNotFound)
+	}
+	confirmed, err := book.ConfirmEntry(ctx, 1, entry.ID, "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := confirmed.ActivateAt.Sub(*confirmed.ConfirmedAt); d != time.Hour {
+		t.Fatalf("lock period = %s, want 1h", d)
+	}
+	if _, err := book.ConfirmEntry(ctx, 1, entry.ID, "123456"); !errors.Is(err, ErrAddressBookNotFound) {
+		t.Fatalf("second confirmation err = %v, want %v", err, ErrAddressBookNotFound)
+	}
+
+	// 锁定期内不可用，结束后可用；其他币种、其他用户不受影响
+	if err := usable(); !errors.Is(err, ErrAddressNotWhitelisted) {
+		t.Fatalf("locked entry err = %v, want %v", err, ErrAddressNotWhitelisted)
+	}
+	if err := db.Model(&model.AddressBookEntry{}).Where("id = ?", entry.ID).
+		UpdateColumn("activate_time", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := usable(); err != nil {
+		t.Fatalf("entry after lock period: %v", err)
+	}
+	if err := book.CheckWithdrawAddress(ctx, 1, "ETH", canonical); !errors.Is(err, ErrAddressNotWhitelisted) {
+		t.Fatalf("other currency err = %v, want %v", err, ErrAddressNotWhitelisted)
+	}
+
+	if err := book.SetWhitelistOnly(ctx, 1, false, "000000"); err == nil {
+		t.Fatal("whitelist disabled with a wrong code")
+	}
+	if err := book.SetWhitelistOnly(ctx, 1, false, "123456"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,143 +3,85 @@ package service
 import (
 	"encoding/hex"
 	"fmt"
-	"log"
 
-	"github.com/btcsuite/btcd/btcutil/hdkeychain"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/model"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/tyler-smith/go-bip39"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ==========================
-// 数据库模型
+// 地址池生成
 // ==========================
-type HDWallet struct {
-	ID              uint   `gorm:"primaryKey"`
-	Mnemonic        string `gorm:"type:text"`
-	SeedFingerprint string `gorm:"size:64"`
-	CoinType        int
-	Addresses       []Address `gorm:"foreignKey:WalletID"`
-}
-
-type Address struct {
-	ID             uint   `gorm:"primaryKey"`
-	WalletID       uint   `gorm:"index"`
-	DerivationPath string `gorm:"size:255"`
-	Address        string `gorm:"uniqueIndex;size:64"`
-	Used           bool   `gorm:"default:false"`
-	UserID         *uint
-}
-
-// ==========================
-// 主逻辑 - 简化版本
-// ==========================
-func main() {
-	// 连接数据库
-	dsn := "host=localhost port=5432 user=postgres password=123456 dbname=wallet sslmode=disable"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatal("连接数据库失败:", err)
-	}
-
-	// 自动迁移表
-	if err := db.AutoMigrate(&HDWallet{}, &Address{}); err != nil {
-		log.Fatal("自动迁移失败:", err)
-	}
 
-	// === Step 1: 生成助记词 & 种子 ===
-	entropy, err := bip39.NewEntropy(256)
-	if err != nil {
-		log.Fatal("生成熵失败:", err)
-	}
-	mnemonic, err := bip39.NewMnemonic(entropy)
-	if err != nil {
-		log.Fatal("生成助记词失败:", err)
+// GenerateAddressPool 由主种子按链适配器的 BIP44 路径批量生成地址池，HDWallet 只保存种子指纹；
+// 地址以规范形式同时写入 addresses 与扫块、入账、归集使用的 address_pools。
+// 同一种子在该链上已生成过地址时，从已有最大索引的下一个继续，重复执行即可扩充地址池
+func GenerateAddressPool(db *gorm.DB, c chain.Chain, seed []byte, count int) ([]model.Address, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("地址数量必须大于 0: %d", count)
 	}
-	seed := bip39.NewSeed(mnemonic, "")
-
-	fmt.Println("助记词:", mnemonic)
-
-	// === Step 2: 存 HDWallet ===
 	seedHash := crypto.Keccak256(seed)
-	hd := HDWallet{
-		Mnemonic:        mnemonic,
-		SeedFingerprint: hex.EncodeToString(seedHash[:8]),
-		CoinType:        60,
-	}
-	result := db.Create(&hd)
-	if result.Error != nil {
-		log.Fatal("创建钱包失败:", result.Error)
-	}
-
-	// === Step 3: 生成主私钥 ===
-	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
-	if err != nil {
-		log.Fatal("生成主密钥失败:", err)
-	}
-
-	// === Step 4: 根据 BIP44 路径派生密钥 ===
-	deriveKey := func(parent *hdkeychain.ExtendedKey, index uint32) *hdkeychain.ExtendedKey {
-		child, err := parent.Derive(index)
-		if err != nil {
-			log.Fatal("派生失败:", err)
+	fingerprint := hex.EncodeToString(seedHash[:8])
+
+	var addresses []model.Address
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 同一种子、币种只保留一个钱包记录，行锁避免并发生成时索引重复
+		hd := model.HDWallet{SeedFingerprint: fingerprint, CoinType: int(c.CoinType())}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("seed_fingerprint = ? AND coin_type = ?", hd.SeedFingerprint, hd.CoinType).
+			Order("id").FirstOrCreate(&hd).Error; err != nil {
+			return fmt.Errorf("创建钱包失败: %w", err)
 		}
-		return child
-	}
-
-	purpose := deriveKey(masterKey, hdkeychain.HardenedKeyStart+44)
-	coinType := deriveKey(purpose, hdkeychain.HardenedKeyStart+60)
-	account := deriveKey(coinType, hdkeychain.HardenedKeyStart+0)
-	change := deriveKey(account, 0)
-
-	// === Step 5: 批量生成地址池 ===
-	numAddresses := 10
-	var addresses []Address
-
-	for i := 0; i < numAddresses; i++ {
-		addressIndex, err := change.Derive(uint32(i))
+		start, err := nextHDIndex(tx, c.Name(), fingerprint)
 		if err != nil {
-			log.Fatal("派生 addressIndex 失败:", err)
+			return fmt.Errorf("查询地址索引失败: %w", err)
 		}
 
-		// 1. 获取公钥对象
-		pubKey, err := addressIndex.ECPubKey()
-		if err != nil {
-			log.Fatal("获取公钥失败:", err)
+		addresses = make([]model.Address, 0, count)
+		pool := make([]model.AddressPool, 0, count)
+		for i := start; i < start+uint32(count); i++ {
+			derived, derivationPath, err := c.DeriveAddress(seed, i)
+			if err != nil {
+				return fmt.Errorf("派生地址失败: %w", err)
+			}
+			addr, err := c.NormalizeAddress(derived)
+			if err != nil {
+				return fmt.Errorf("派生地址格式错误: %w", err)
+			}
+			addresses = append(addresses, model.Address{
+				WalletID:       hd.ID,
+				DerivationPath: derivationPath,
+				Address:        addr,
+				Used:           false,
+			})
+			pool = append(pool, model.AddressPool{
+				Chain:   c.Name(),
+				Address: addr,
+				HDIndex: i,
+			})
 		}
-
-		// 2. 转换为压缩公钥字节
-		publicKeyBytes := pubKey.SerializeCompressed()
-
-		// 3. 转换为 ECDSA 公钥 (以太坊用)
-		publicKeyECDSA, err := crypto.DecompressPubkey(publicKeyBytes)
-		if err != nil {
-			log.Fatal("解压缩公钥失败:", err)
+		if err := tx.Create(&addresses).Error; err != nil {
+			return fmt.Errorf("创建地址失败: %w", err)
 		}
-
-		// 4. 生成以太坊地址
-		ethAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-
-		derivationPath := fmt.Sprintf("m/44'/60'/0'/0/%d", i)
-
-		addresses = append(addresses, Address{
-			WalletID:       hd.ID,
-			DerivationPath: derivationPath,
-			Address:        ethAddress.Hex(),
-			Used:           false,
-		})
-
-		fmt.Printf("[%d] 地址: %s, 路径: %s\n", i, ethAddress.Hex(), derivationPath)
-		fmt.Printf("     公钥: %x\n", publicKeyBytes)
-	}
-
-	// === Step 6: 批量插入地址 ===
-	result = db.Create(&addresses)
-	if result.Error != nil {
-		log.Fatal("创建地址失败:", result.Error)
+		if err := tx.Create(&pool).Error; err != nil {
+			return fmt.Errorf("写入地址池失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return addresses, nil
+}
 
-	fmt.Printf("成功生成 %d 个地址并写入数据库！\n", numAddresses)
+// nextHDIndex 该种子在链上已生成地址的最大索引加一，尚未生成时为 0
+func nextHDIndex(tx *gorm.DB, chainName, fingerprint string) (uint32, error) {
+	var next uint32
+	err := tx.Raw(`SELECT COALESCE(MAX(p.hd_index) + 1, 0) FROM address_pools p
+		JOIN addresses a ON a.address = p.address
+		JOIN hd_wallets w ON w.id = a.wallet_id
+		WHERE p.chain = ? AND w.seed_fingerprint = ?`, chainName, fingerprint).Scan(&next).Error
+	return next, err
 }
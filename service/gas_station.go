@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+// ==========================
+// Gas 补充
+// ==========================
+// 代币充值地址上没有原生币，无法支付归集手续费。
+// GasStation 从专用 gas 钱包向这些地址转入恰好等于预估手续费的原生币，
+// 确认到账后触发代币归集，归集确认后对地址上剩余的 gas 粉尘对账。
+
+type GasStation struct {
+	db            *gorm.DB
+	sweeper       *Sweeper
+	chain         chain.Chain
+	gasAddress    string
+	gasKey        []byte
+	confirmations uint64
+}
+
+func NewGasStation(db *gorm.DB, sweeper *Sweeper, c chain.Chain, gasAddress, gasKeyHex string, confirmations uint64) (*GasStation, error) {
+	key, err := hex.DecodeString(strings.TrimPrefix(gasKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas wallet key: %w", err)
+	}
+	return &GasStation{
+		db:            db,
+		sweeper:       sweeper,
+		chain:         c,
+		gasAddress:    gasAddress,
+		gasKey:        key,
+		confirmations: confirmations,
+	}, nil
+}
+
+// tokenRules 本链上的代币归集规则
+func (g *GasStation) tokenRules() []SweepRule {
+	var rules []SweepRule
+	for _, r := range g.sweeper.rules {
+		if r.Chain == g.chain.Name() && !r.isNative() {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func (g *GasStation) ruleFor(token string) (SweepRule, bool) {
+	for _, r := range g.tokenRules() {
+		if *r.Token == token {
+			return r, true
+		}
+	}
+	return SweepRule{}, false
+}
+
+func (g *GasStation) active(ctx context.Context, addr, token string) (bool, error) {
+	var count int64
+	err := g.db.WithContext(ctx).Model(&model.GasTopUp{}).
+		Where("chain = ? AND address = ? AND token = ? AND status IN ?", g.chain.Name(), addr, token, []string{
+			model.GAS_TOPUP_STATUS_BROADCASTED,
+			model.GAS_TOPUP_STATUS_CONFIRMED,
+			model.GAS_TOPUP_STATUS_SWEPT,
+		}).Count(&count).Error
+	return count > 0, err
+}
+
+// TopUpOnce 为待归集但 gas 不足的充值地址补充手续费
+func (g *GasStation) TopUpOnce(ctx context.Context) error {
+	var pool []model.AddressPool
+	if err := g.db.WithContext(ctx).Where("chain = ? AND used = ?", g.chain.Name(), true).Find(&pool).Error; err != nil {
+		return err
+	}
+	for _, rule := range g.tokenRules() {
+		for _, p := range pool {
+			if err := g.topUp(ctx, rule, p.Address); err != nil {
+				log.Printf("gas top-up %s for %s err: %v", p.Address, rule.Currency, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *GasStation) topUp(ctx context.Context, rule SweepRule, addr string) error {
+	busy, err := g.active(ctx, addr, *rule.Token)
+	if err != nil || busy {
+		return err
+	}
+	utx, _, fee, err := g.sweeper.sweepable(ctx, g.chain, rule, addr)
+	if err != nil || utx == nil {
+		return err
+	}
+	native, err := g.chain.Balance(ctx, addr, nil)
+	if err != nil {
+		return err
+	}
+	if native.Cmp(fee) >= 0 {
+		return nil // gas 足够，由归集器直接处理
+	}
+	// 只补差额，地址上已有的 gas 粉尘会被计入
+	need := new(big.Int).Sub(fee, native)
+
+	req := chain.TransferRequest{From: g.gasAddress, To: addr, Amount: need}
+	topUpFee, err := g.chain.EstimateFee(ctx, req)
+	if err != nil {
+		return err
+	}
+	topUp := model.GasTopUp{
+		Chain:   g.chain.Name(),
+		Address: addr,
+		Token:   *rule.Token,
+		Amount:  need.String(),
+		Fee:     topUpFee.String(),
+	}
+	txHash, err := g.send(ctx, req)
+	if err != nil {
+		topUp.Status = model.GAS_TOPUP_STATUS_FAILED
+		topUp.Error = err.Error()
+		g.db.WithContext(ctx).Create(&topUp)
+		return err
+	}
+	topUp.Status = model.GAS_TOPUP_STATUS_BROADCASTED
+	topUp.TxHash = &txHash
+	log.Printf("gas top-up %s to %s for %s tx=%s", need, addr, rule.Currency, txHash)
+	return g.db.WithContext(ctx).Create(&topUp).Error
+}
+
+func (g *GasStation) send(ctx context.Context, req chain.TransferRequest) (string, error) {
+	utx, err := g.chain.BuildTx(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	signed, err := g.chain.SignTx(utx, g.gasKey)
+	if err != nil {
+		return "", err
+	}
+	return g.chain.Broadcast(ctx, signed)
+}
+
+func (g *GasStation) update(ctx context.Context, t *model.GasTopUp, fields map[string]interface{}) {
+	if err := g.db.WithContext(ctx).Model(t).Updates(fields).Error; err != nil {
+		log.Printf("gas top-up #%d update err: %v", t.ID, err)
+	}
+}
+
+// ProgressOnce 推进补 gas 流程：确认到账 -> 触发归集 -> 归集确认后对账
+func (g *GasStation) ProgressOnce(ctx context.Context) error {
+	var topUps []model.GasTopUp
+	if err := g.db.WithContext(ctx).
+		Where("chain = ? AND status IN ?", g.chain.Name(), []string{
+			model.GAS_TOPUP_STATUS_BROADCASTED,
+			model.GAS_TOPUP_STATUS_CONFIRMED,
+			model.GAS_TOPUP_STATUS_SWEPT,
+		}).Order("id").Find(&topUps).Error; err != nil {
+		return err
+	}
+	for i := range topUps {
+		t := &topUps[i]
+		var err error
+		switch t.Status {
+		case model.GAS_TOPUP_STATUS_BROADCASTED:
+			err = g.checkFunded(ctx, t)
+		case model.GAS_TOPUP_STATUS_CONFIRMED:
+			err = g.triggerSweep(ctx, t)
+		case model.GAS_TOPUP_STATUS_SWEPT:
+			err = g.reconcile(ctx, t)
+		}
+		if err != nil {
+			log.Printf("gas top-up #%d err: %v", t.ID, err)
+		}
+	}
+	return nil
+}
+
+func (g *GasStation) checkFunded(ctx context.Context, t *model.GasTopUp) error {
+	confirmations, err := g.chain.Confirmations(ctx, *t.TxHash)
+	if errors.Is(err, chain.ErrTxFailed) {
+		// 补 gas 交易回滚，下一轮 TopUpOnce 按差额重新补
+		g.update(ctx, t, map[string]interface{}{"status": model.GAS_TOPUP_STATUS_FAILED, "error": err.Error()})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if confirmations < g.confirmations {
+		return nil
+	}
+	t.Status = model.GAS_TOPUP_STATUS_CONFIRMED
+	g.update(ctx, t, map[string]interface{}{"status": t.Status})
+	return g.triggerSweep(ctx, t)
+}
+
+func (g *GasStation) triggerSweep(ctx context.Context, t *model.GasTopUp) error {
+	rule, ok := g.ruleFor(t.Token)
+	if !ok {
+		return fmt.Errorf("no sweep rule for token %s", t.Token)
+	}
+	var p model.AddressPool
+	if err := g.db.WithContext(ctx).Where("chain = ? AND address = ?", t.Chain, t.Address).First(&p).Error; err != nil {
+		return err
+	}
+	sweep, err := g.sweeper.SweepAddress(ctx, rule, p)
+	if err != nil {
+		return err
+	}
+	if sweep == nil {
+		// 归集器可能已在 gas 到账后先行归集，直接关联该笔归集
+		var existing model.Sweep
+		err := g.db.WithContext(ctx).
+			Where("chain = ? AND from_address = ? AND token = ? AND created_at >= ?", t.Chain, t.Address, t.Token, t.CreatedAt).
+			Order("id DESC").Limit(1).Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing.ID != 0 {
+			sweep = &existing
+		}
+	}
+	if sweep == nil {
+		// 手续费上涨导致 gas 仍不足等情况，结束本次补充，下一轮按差额重新补
+		g.update(ctx, t, map[string]interface{}{
+			"status": model.GAS_TOPUP_STATUS_FAILED,
+			"error":  "sweep not started after top-up",
+		})
+		return nil
+	}
+	g.update(ctx, t, map[string]interface{}{
+		"status":   model.GAS_TOPUP_STATUS_SWEPT,
+		"sweep_id": sweep.ID,
+	})
+	return nil
+}
+
+// reconcile 归集确认后记录地址上剩余的 gas 粉尘
+func (g *GasStation) reconcile(ctx context.Context, t *model.GasTopUp) error {
+	var sweep model.Sweep
+	if err := g.db.WithContext(ctx).First(&sweep, *t.SweepID).Error; err != nil {
+		return err
+	}
+	switch sweep.Status {
+	case model.SWEEP_STATUS_FAILED:
+		g.update(ctx, t, map[string]interface{}{
+			"status": model.GAS_TOPUP_STATUS_FAILED,
+			"error":  fmt.Sprintf("sweep #%d failed: %s", sweep.ID, sweep.Error),
+		})
+		return nil
+	case model.SWEEP_STATUS_CONFIRMED:
+	default:
+		return nil
+	}
+	leftover, err := g.chain.Balance(ctx, t.Address, nil)
+	if err != nil {
+		return err
+	}
+	if leftover.Sign() > 0 {
+		log.Printf("gas dust on %s after sweep #%d: %s (topped up %s)", t.Address, sweep.ID, leftover, t.Amount)
+	}
+	g.update(ctx, t, map[string]interface{}{
+		"status":   model.GAS_TOPUP_STATUS_RECONCILED,
+		"leftover": leftover.String(),
+	})
+	return nil
+}
+
+// Run 定期补 gas 并推进流程
+func (g *GasStation) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := g.ProgressOnce(ctx); err != nil {
+			log.Printf("gas station progress err: %v", err)
+		}
+		if err := g.TopUpOnce(ctx); err != nil {
+			log.Printf("gas station top-up err: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+)
+
+const TEST_GAS_WALLET = "0x00000000000000000000000000000000000000a9"
+
+// 代币地址缺少 gas 时只补差额，到账后触发代币归集，归集确认后记录剩余 gas 粉尘
+func TestGasStationTopUp(t *testing.T) {
+	db := testdb.Open(t, "sweeps", "address_pools", "gas_top_ups", "wallet_deposits")
+	ctx := context.Background()
+	token := TEST_RECONCILE_TOKEN
+	c := &nonceChain{
+		balances: map[string]int64{
+			token + ":" + TEST_DEPOSIT_ADDRESS: 500,
+			TEST_DEPOSIT_ADDRESS:               3, // 地址上已有的 gas 粉尘计入
+		},
+		pending:       map[string]uint64{TEST_GAS_WALLET: 100},
+		mined:         map[string]uint64{},
+		confirmations: map[string]uint64{},
+		gasPrice:      1,
+	}
+	chain.Register(c)
+	createRows(t, db, &model.AddressPool{Chain: TEST_SWEEP_CHAIN, Address: TEST_DEPOSIT_ADDRESS, Used: true})
+	sweeper := NewSweeper(db, staticKeys{}, []SweepRule{{
+		Chain: TEST_SWEEP_CHAIN, Token: &token, Currency: "STOKEN", FeeCurrency: "STEST", HotAddress: TEST_HOT_WALLET,
+		Threshold: big.NewInt(100), Confirmations: 1,
+	}})
+	gas, err := NewGasStation(db, sweeper, c, TEST_GAS_WALLET, "01", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topUp := func() model.GasTopUp {
+		t.Helper()
+		var rows []model.GasTopUp
+		if err := db.Order("id").Find(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 {
+			t.Fatalf("gas top-ups = %+v, want one", rows)
+		}
+		return rows[0]
+	}
+	run := func() {
+		t.Helper()
+		if err := sweeper.ConfirmOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := gas.ProgressOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := gas.TopUpOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 归集手续费 10，地址上有 3，补 7；进行中的补充不重复发起
+	run()
+	run()
+	tu := topUp()
+	if tu.Status != model.GAS_TOPUP_STATUS_BROADCASTED || tu.Amount != "7" || len(c.sent) != 1 || *tu.TxHash != c.sent[0] {
+		t.Fatalf("top-up = %s amount %s, sent = %v, want one broadcast of 7", tu.Status, tu.Amount, c.sent)
+	}
+
+	// 到账后触发代币归集
+	c.confirmations[*tu.TxHash] = 1
+	c.balances[TEST_DEPOSIT_ADDRESS] = 10
+	run()
+	tu = topUp()
+	sweeps := sweepRows(t, db)
+	if tu.Status != model.GAS_TOPUP_STATUS_SWEPT || len(sweeps) != 1 || tu.SweepID == nil || *tu.SweepID != sweeps[0].ID || sweeps[0].Amount != "500" {
+		t.Fatalf("top-up = %s sweep %v, sweeps = %+v, want swept with the 500 token sweep", tu.Status, tu.SweepID, sweeps)
+	}
+
+	// 归集确认后记录剩余 gas
+	c.confirmations[*sweeps[0].TxHash] = 1
+	c.balances[token+":"+TEST_DEPOSIT_ADDRESS] = 0
+	c.balances[TEST_DEPOSIT_ADDRESS] = 2
+	run()
+	tu = topUp()
+	if tu.Status != model.GAS_TOPUP_STATUS_RECONCILED || tu.Leftover != "2" {
+		t.Fatalf("top-up = %s leftover %s, want reconciled with 2 left", tu.Status, tu.Leftover)
+	}
+	if len(c.sent) != 2 {
+		t.Fatalf("sent = %v, want the top-up and the sweep", c.sent)
+	}
+}
@@ -0,0 +1,602 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/crypto_custody/approval"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+// ==========================
+// 冷热钱包调拨
+// ==========================
+// 热钱包余额超过 Max 时，将超出 Target 的部分转入冷钱包；
+// 低于 Min 时生成冷钱包补充到 Target 的调拨单，经审批和离线签名后广播；
+// 冷钱包为 Safe 多签时，经审批后收集 owner 签名，达到阈值后执行。
+
+// 冷热钱包地址类型，对应 WalletAddress.Type
+const (
+	WALLET_TYPE_HOT  = 0
+	WALLET_TYPE_COLD = 1
+)
+
+var ErrRebalanceNotFound = errors.New("调拨单不存在")
+
+// TreasuryRule 单个币种的热钱包水位
+type TreasuryRule struct {
+	Currency      string
+	Chain         string
+	Token         *string // nil 表示原生币
+	Min           *big.Int
+	Target        *big.Int
+	Max           *big.Int
+	Confirmations uint64
+	ColdAddress   string // 冷钱包地址
+	ColdSafe      bool   // 冷钱包地址为 Safe 多签合约
+}
+
+type Rebalancer struct {
+	db        *gorm.DB
+	rules     []TreasuryRule
+	hotKeys   map[string][]byte // 热钱包地址（小写） -> 私钥
+	approvals *approval.Service
+	safes     *SafeService // 没有 ColdSafe 规则时可为 nil
+}
+
+func NewRebalancer(db *gorm.DB, rules []TreasuryRule, hotKeys map[string][]byte, approvals *approval.Service, safes *SafeService) *Rebalancer {
+	keys := make(map[string][]byte, len(hotKeys))
+	for addr, key := range hotKeys {
+		keys[strings.ToLower(addr)] = key
+	}
+	return &Rebalancer{
+		db:        db,
+		rules:     rules,
+		hotKeys:   keys,
+		approvals: approvals.ForKind(approval.KindRebalance),
+		safes:     safes,
+	}
+}
+
+func (r *Rebalancer) walletAddresses(ctx context.Context, currency string, walletType int8) ([]model.WalletAddress, error) {
+	var list []model.WalletAddress
+	err := r.db.WithContext(ctx).
+		Where("currency = ? AND type = ? AND status = 0", currency, walletType).
+		Order("id").Find(&list).Error
+	return list, err
+}
+
+// active 该币种是否有未完成的调拨，避免重复调拨
+func (r *Rebalancer) active(ctx context.Context, currency string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Rebalance{}).
+		Where("currency = ? AND status IN ?", currency, []string{
+			model.REBALANCE_STATUS_APPROVING,
+			model.REBALANCE_STATUS_APPROVED,
+			model.REBALANCE_STATUS_BROADCASTED,
+		}).Count(&count).Error
+	return count > 0, err
+}
+
+// CheckOnce 按水位检查所有币种
+func (r *Rebalancer) CheckOnce(ctx context.Context) error {
+	for _, rule := range r.rules {
+		if err := r.check(ctx, rule); err != nil {
+			log.Printf("rebalance %s err: %v", rule.Currency, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rebalancer) check(ctx context.Context, rule TreasuryRule) error {
+	busy, err := r.active(ctx, rule.Currency)
+	if err != nil || busy {
+		return err
+	}
+	c, err := chain.Get(rule.Chain)
+	if err != nil {
+		return err
+	}
+	hots, err := r.walletAddresses(ctx, rule.Currency, WALLET_TYPE_HOT)
+	if err != nil {
+		return err
+	}
+	if len(hots) == 0 || rule.ColdAddress == "" {
+		return fmt.Errorf("currency %s needs both hot and cold addresses", rule.Currency)
+	}
+
+	// 水位按全部热地址之和判断
+	balances := make([]*big.Int, len(hots))
+	total := new(big.Int)
+	for i, h := range hots {
+		b, err := c.Balance(ctx, h.Address, rule.Token)
+		if err != nil {
+			return err
+		}
+		balances[i] = b
+		total.Add(total, b)
+	}
+
+	switch {
+	case total.Cmp(rule.Max) > 0:
+		return r.sweepToCold(ctx, c, rule, hots, balances, new(big.Int).Sub(total, rule.Target))
+	case total.Cmp(rule.Min) < 0:
+		return r.requestRefill(ctx, rule, rule.ColdAddress, hots[0].Address, new(big.Int).Sub(rule.Target, total))
+	}
+	return nil
+}
+
+// sweepToCold 按热地址顺序，从各地址自身余额中转出，直到超出部分转完；
+// 原生币预留本笔手续费，没有私钥的热地址跳过
+func (r *Rebalancer) sweepToCold(ctx context.Context, c chain.Chain, rule TreasuryRule, hots []model.WalletAddress, balances []*big.Int, excess *big.Int) error {
+	remaining := new(big.Int).Set(excess)
+	for i, h := range hots {
+		if remaining.Sign() <= 0 {
+			break
+		}
+		if _, ok := r.hotKeys[strings.ToLower(h.Address)]; !ok {
+			continue
+		}
+		available := new(big.Int).Set(balances[i])
+		if rule.Token == nil {
+			fee, err := c.EstimateFee(ctx, chain.TransferRequest{From: h.Address, To: rule.ColdAddress, Amount: available})
+			if err != nil {
+				return err
+			}
+			available.Sub(available, fee)
+		}
+		if available.Sign() <= 0 {
+			continue
+		}
+		amount := remaining
+		if available.Cmp(remaining) < 0 {
+			amount = available
+		}
+		if err := r.sendToCold(ctx, c, rule, h.Address, new(big.Int).Set(amount)); err != nil {
+			return err
+		}
+		remaining = new(big.Int).Sub(remaining, amount)
+	}
+	if remaining.Sign() > 0 {
+		log.Printf("rebalance %s: %s above target left in hot wallets without keys or fee headroom", rule.Currency, remaining)
+	}
+	return nil
+}
+
+// sendToCold 热钱包在线签名，直接广播
+func (r *Rebalancer) sendToCold(ctx context.Context, c chain.Chain, rule TreasuryRule, from string, amount *big.Int) error {
+	key := r.hotKeys[strings.ToLower(from)]
+	to := rule.ColdAddress
+	rb := model.Rebalance{
+		Currency:    rule.Currency,
+		Chain:       rule.Chain,
+		Token:       rule.Token,
+		Direction:   model.REBALANCE_HOT_TO_COLD,
+		FromAddress: from,
+		ToAddress:   to,
+		Amount:      amount.String(),
+	}
+	txHash, err := func() (string, error) {
+		utx, err := c.BuildTx(ctx, chain.TransferRequest{From: from, To: to, Token: rule.Token, Amount: amount})
+		if err != nil {
+			return "", err
+		}
+		signed, err := c.SignTx(utx, key)
+		if err != nil {
+			return "", err
+		}
+		return c.Broadcast(ctx, signed)
+	}()
+	if err != nil {
+		rb.Status = model.REBALANCE_STATUS_FAILED
+		rb.Error = err.Error()
+		r.db.WithContext(ctx).Create(&rb)
+		return err
+	}
+	rb.Status = model.REBALANCE_STATUS_BROADCASTED
+	rb.TxHash = &txHash
+	log.Printf("rebalance %s %s hot %s -> cold tx=%s", amount, rule.Currency, from, txHash)
+	return r.db.WithContext(ctx).Create(&rb).Error
+}
+
+// requestRefill 创建冷转热调拨单与审批单（或 Safe 多签提案），等待审批和离线签名；
+// 冷钱包交易在导出时才构造，避免审批期间 nonce、gas 价格或 blockhash 失效
+func (r *Rebalancer) requestRefill(ctx context.Context, rule TreasuryRule, from, to string, amount *big.Int) error {
+	req := chain.TransferRequest{From: from, To: to, Token: rule.Token, Amount: amount}
+	if rule.ColdSafe {
+		if r.safes == nil {
+			return fmt.Errorf("currency %s uses a safe cold wallet but no safe service is configured", rule.Currency)
+		}
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			st, err := r.safes.WithTx(tx).Propose(ctx, rule.Chain, req)
+			if err != nil {
+				return err
+			}
+			return r.createRefill(ctx, tx, rule, req, model.Rebalance{SafeTxID: &st.ID})
+		})
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sr := model.SignRequest{
+			Chain:       rule.Chain,
+			FromAddress: from,
+			Status:      "created",
+		}
+		if err := tx.Create(&sr).Error; err != nil {
+			return err
+		}
+		return r.createRefill(ctx, tx, rule, req, model.Rebalance{SignRequestID: &sr.ID})
+	})
+}
+
+// buildRefillTxs 为审批通过、尚未导出的冷钱包签名请求按当前链上状态重新构造未签名交易
+func (r *Rebalancer) buildRefillTxs(ctx context.Context) error {
+	var list []model.Rebalance
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN sign_requests ON sign_requests.id = rebalances.sign_request_id").
+		Where("rebalances.status = ? AND sign_requests.status = ?", model.REBALANCE_STATUS_APPROVED, "created").
+		Find(&list).Error; err != nil {
+		return err
+	}
+	for _, rb := range list {
+		amount, ok := new(big.Int).SetString(rb.Amount, 10)
+		if !ok {
+			return fmt.Errorf("调拨单 %d 金额错误: %s", rb.ID, rb.Amount)
+		}
+		c, err := chain.Get(rb.Chain)
+		if err != nil {
+			return err
+		}
+		utx, err := c.BuildTx(ctx, chain.TransferRequest{From: rb.FromAddress, To: rb.ToAddress, Token: rb.Token, Amount: amount})
+		if err != nil {
+			return fmt.Errorf("rebalance #%d build tx: %w", rb.ID, err)
+		}
+		if err := r.db.WithContext(ctx).Model(&model.SignRequest{}).
+			Where("id = ? AND status = ?", *rb.SignRequestID, "created").
+			Updates(map[string]interface{}{
+				"from_address": utx.From,
+				"unsigned":     utx.Payload,
+				"valid_until":  utx.ValidUntil,
+			}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createRefill 在事务中创建冷转热调拨单并提交审批，rb 已填好签名请求或多签交易
+func (r *Rebalancer) createRefill(ctx context.Context, tx *gorm.DB, rule TreasuryRule, req chain.TransferRequest, rb model.Rebalance) error {
+	rb.Currency = rule.Currency
+	rb.Chain = rule.Chain
+	rb.Token = rule.Token
+	rb.Direction = model.REBALANCE_COLD_TO_HOT
+	rb.FromAddress = req.From
+	rb.ToAddress = req.To
+	rb.Amount = req.Amount.String()
+	rb.Status = model.REBALANCE_STATUS_APPROVING
+	if err := tx.Create(&rb).Error; err != nil {
+		return err
+	}
+	// 冷钱包动用资金一律需要人工审批
+	if _, err := r.approvals.WithTx(tx).Submit(ctx, approval.Subject{
+		ID:         rb.ID,
+		Currency:   rule.Currency,
+		Address:    rb.ToAddress,
+		Amount:     req.Amount,
+		RiskReview: true,
+	}); err != nil {
+		return err
+	}
+	log.Printf("rebalance #%d: refill %s %s cold -> hot awaiting approval", rb.ID, req.Amount, rule.Currency)
+	return nil
+}
+
+// verifyApproved 按调拨单的目标地址与金额校验审批链
+func (r *Rebalancer) verifyApproved(ctx context.Context, rb *model.Rebalance) error {
+	amount, ok := new(big.Int).SetString(rb.Amount, 10)
+	if !ok {
+		return fmt.Errorf("调拨单 %d 金额错误: %s", rb.ID, rb.Amount)
+	}
+	return r.approvals.VerifyApproved(ctx, approval.Subject{
+		ID:         rb.ID,
+		Currency:   rb.Currency,
+		Address:    rb.ToAddress,
+		Amount:     amount,
+		RiskReview: true,
+	})
+}
+
+func (r *Rebalancer) get(ctx context.Context, id uint) (*model.Rebalance, error) {
+	var rb model.Rebalance
+	if err := r.db.WithContext(ctx).First(&rb, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRebalanceNotFound
+		}
+		return nil, err
+	}
+	return &rb, nil
+}
+
+// ApproveRefill 审批冷转热调拨，审批完成后等待离线签名
+func (r *Rebalancer) ApproveRefill(ctx context.Context, id uint, operatorID uint64, roles []string, comment string) (*model.WithdrawalApproval, error) {
+	a, err := r.approvals.Approve(ctx, id, operatorID, roles, comment)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status == approval.StatusApproved {
+		err = r.db.WithContext(ctx).Model(&model.Rebalance{}).
+			Where("id = ? AND status = ?", id, model.REBALANCE_STATUS_APPROVING).
+			Update("status", model.REBALANCE_STATUS_APPROVED).Error
+	}
+	return a, err
+}
+
+func (r *Rebalancer) RejectRefill(ctx context.Context, id uint, operatorID uint64, roles []string, comment string) (*model.WithdrawalApproval, error) {
+	a, err := r.approvals.Reject(ctx, id, operatorID, roles, comment)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&model.Rebalance{}).
+		Where("id = ?", id).
+		Update("status", model.REBALANCE_STATUS_REJECTED).Error; err != nil {
+		return nil, err
+	}
+	return a, r.cancelSafeTxs(ctx, []uint{id})
+}
+
+// cancelSafeTxs 关闭被拒绝或过期调拨的多签交易，owner 不再需要签名
+func (r *Rebalancer) cancelSafeTxs(ctx context.Context, ids []uint) error {
+	var safeTxIDs []uint
+	if err := r.db.WithContext(ctx).Model(&model.Rebalance{}).
+		Where("id IN ? AND safe_tx_id IS NOT NULL", ids).
+		Pluck("safe_tx_id", &safeTxIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range safeTxIDs {
+		if err := r.safes.Cancel(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubmitSigned 提交离线签名结果，校验审批链后广播
+func (r *Rebalancer) SubmitSigned(ctx context.Context, id uint, signed []byte) (string, error) {
+	rb, err := r.get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if rb.Status != model.REBALANCE_STATUS_APPROVED {
+		return "", fmt.Errorf("调拨单 %d 当前状态为 %s，不能广播", id, rb.Status)
+	}
+	if rb.SignRequestID == nil {
+		return "", fmt.Errorf("调拨单 %d 为 Safe 多签，请提交 owner 签名", id)
+	}
+	var sr model.SignRequest
+	if err := r.db.WithContext(ctx).First(&sr, *rb.SignRequestID).Error; err != nil {
+		return "", err
+	}
+	if len(sr.Unsigned) == 0 {
+		return "", fmt.Errorf("调拨单 %d 的交易尚未构造，请先导出", id)
+	}
+	c, err := chain.Get(rb.Chain)
+	if err != nil {
+		return "", err
+	}
+	if v, ok := c.(chain.SignedVerifier); ok {
+		utx := &chain.UnsignedTx{Chain: sr.Chain, From: sr.FromAddress, Payload: sr.Unsigned, ValidUntil: sr.ValidUntil}
+		if err := v.VerifySigned(utx, signed); err != nil {
+			return "", fmt.Errorf("签名交易与调拨单不符: %w", err)
+		}
+	}
+	return r.broadcast(ctx, c, rb, signed)
+}
+
+// broadcast 广播前重新校验审批链
+func (r *Rebalancer) broadcast(ctx context.Context, c chain.Chain, rb *model.Rebalance, signed []byte) (string, error) {
+	if err := r.verifyApproved(ctx, rb); err != nil {
+		return "", fmt.Errorf("审批校验失败: %w", err)
+	}
+	txHash, err := c.Broadcast(ctx, signed)
+	if err != nil {
+		return "", err
+	}
+	return txHash, r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SignRequest{}).Where("id = ?", *rb.SignRequestID).
+			Updates(map[string]interface{}{"signed": signed, "status": "signed"}).Error; err != nil {
+			return err
+		}
+		return tx.Model(rb).Updates(map[string]interface{}{
+			"status":  model.REBALANCE_STATUS_BROADCASTED,
+			"tx_hash": txHash,
+		}).Error
+	})
+}
+
+// ExportableSignRequests 审批通过、尚未导出的冷钱包签名请求（含 Safe owner 签名请求），导出前构造交易；
+// from 非空时只导出该签名地址的请求，供各 owner 的离线签名机分别签名
+func (r *Rebalancer) ExportableSignRequests(ctx context.Context, from string) ([]uint, error) {
+	if err := r.buildRefillTxs(ctx); err != nil {
+		return nil, err
+	}
+	var ids []uint
+	q := r.db.WithContext(ctx).Model(&model.SignRequest{}).
+		Joins("JOIN rebalances ON rebalances.sign_request_id = sign_requests.id OR rebalances.safe_tx_id = sign_requests.safe_tx_id").
+		Where("rebalances.status = ? AND sign_requests.status = ?", model.REBALANCE_STATUS_APPROVED, "created")
+	if from != "" {
+		q = q.Where("LOWER(sign_requests.from_address) = ?", strings.ToLower(from))
+	}
+	err := q.Pluck("sign_requests.id", &ids).Error
+	return ids, err
+}
+
+// SubmitSafeSignature owner 在线提交 Safe 签名，达到阈值时立即执行
+func (r *Rebalancer) SubmitSafeSignature(ctx context.Context, signRequestID uint, sig []byte) error {
+	if r.safes == nil {
+		return errors.New("safe multisig is not configured")
+	}
+	if err := r.safes.SubmitSignature(ctx, signRequestID, sig); err != nil {
+		return err
+	}
+	return r.ExecuteSafeOnce(ctx)
+}
+
+// ExecuteSafeOnce 审批通过、owner 签名已达到阈值的 Safe 调拨，校验审批链后执行
+func (r *Rebalancer) ExecuteSafeOnce(ctx context.Context) error {
+	var list []model.Rebalance
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND safe_tx_id IS NOT NULL", model.REBALANCE_STATUS_APPROVED).
+		Find(&list).Error; err != nil {
+		return err
+	}
+	for i := range list {
+		rb := &list[i]
+		if err := r.verifyApproved(ctx, rb); err != nil {
+			log.Printf("rebalance #%d approval check err: %v", rb.ID, err)
+			continue
+		}
+		txHash, err := r.safes.Execute(ctx, *rb.SafeTxID)
+		if errors.Is(err, ErrSafeThresholdNotMet) {
+			continue
+		}
+		if err != nil {
+			log.Printf("rebalance #%d safe exec err: %v", rb.ID, err)
+			continue
+		}
+		if err := r.db.WithContext(ctx).Model(rb).Updates(map[string]interface{}{
+			"status":  model.REBALANCE_STATUS_BROADCASTED,
+			"tx_hash": txHash,
+		}).Error; err != nil {
+			return err
+		}
+		log.Printf("rebalance #%d cold safe -> hot tx=%s", rb.ID, txHash)
+	}
+	return nil
+}
+
+// BroadcastSignedOnce 广播已导入离线签名结果的调拨
+func (r *Rebalancer) BroadcastSignedOnce(ctx context.Context) error {
+	var list []model.Rebalance
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN sign_requests ON sign_requests.id = rebalances.sign_request_id").
+		Where("rebalances.status = ? AND sign_requests.status = ?", model.REBALANCE_STATUS_APPROVED, "signed").
+		Find(&list).Error; err != nil {
+		return err
+	}
+	if r.safes != nil {
+		if err := r.ExecuteSafeOnce(ctx); err != nil {
+			return err
+		}
+	}
+	for i := range list {
+		rb := &list[i]
+		var sr model.SignRequest
+		if err := r.db.WithContext(ctx).First(&sr, *rb.SignRequestID).Error; err != nil {
+			return err
+		}
+		c, err := chain.Get(rb.Chain)
+		if err != nil {
+			return err
+		}
+		txHash, err := r.broadcast(ctx, c, rb, sr.Signed)
+		if err != nil {
+			log.Printf("rebalance #%d broadcast err: %v", rb.ID, err)
+			continue
+		}
+		log.Printf("rebalance #%d cold -> hot tx=%s", rb.ID, txHash)
+	}
+	return nil
+}
+
+func (r *Rebalancer) List(ctx context.Context, status string) ([]model.Rebalance, error) {
+	var list []model.Rebalance
+	q := r.db.WithContext(ctx).Order("id DESC")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	return list, q.Find(&list).Error
+}
+
+func (r *Rebalancer) ruleFor(currency string) (TreasuryRule, bool) {
+	for _, rule := range r.rules {
+		if rule.Currency == currency {
+			return rule, true
+		}
+	}
+	return TreasuryRule{}, false
+}
+
+// ConfirmOnce 检查已广播调拨的确认数，并将过期审批的调拨置为 expired
+func (r *Rebalancer) ConfirmOnce(ctx context.Context) error {
+	ids, err := r.approvals.ExpireStale(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		if err := r.db.WithContext(ctx).Model(&model.Rebalance{}).
+			Where("id IN ? AND status = ?", ids, model.REBALANCE_STATUS_APPROVING).
+			Update("status", model.REBALANCE_STATUS_EXPIRED).Error; err != nil {
+			return err
+		}
+		if err := r.cancelSafeTxs(ctx, ids); err != nil {
+			return err
+		}
+	}
+
+	var list []model.Rebalance
+	if err := r.db.WithContext(ctx).Where("status = ?", model.REBALANCE_STATUS_BROADCASTED).Find(&list).Error; err != nil {
+		return err
+	}
+	for _, rb := range list {
+		rule, ok := r.ruleFor(rb.Currency)
+		if !ok {
+			continue
+		}
+		c, err := chain.Get(rb.Chain)
+		if err != nil {
+			return err
+		}
+		confirmations, err := c.Confirmations(ctx, *rb.TxHash)
+		if errors.Is(err, chain.ErrTxFailed) {
+			r.db.WithContext(ctx).Model(&rb).Where("status = ?", model.REBALANCE_STATUS_BROADCASTED).
+				Updates(map[string]interface{}{"status": model.REBALANCE_STATUS_FAILED, "error": err.Error()})
+			continue
+		}
+		if err != nil {
+			log.Printf("rebalance #%d confirmations err: %v", rb.ID, err)
+			continue
+		}
+		if confirmations >= rule.Confirmations {
+			r.db.WithContext(ctx).Model(&rb).Update("status", model.REBALANCE_STATUS_CONFIRMED)
+		}
+	}
+	return nil
+}
+
+// Run 定期检查水位与调拨确认
+func (r *Rebalancer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.BroadcastSignedOnce(ctx); err != nil {
+			log.Printf("rebalance broadcast err: %v", err)
+		}
+		if err := r.ConfirmOnce(ctx); err != nil {
+			log.Printf("rebalance confirm err: %v", err)
+		}
+		if err := r.CheckOnce(ctx); err != nil {
+			log.Printf("rebalance check err: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
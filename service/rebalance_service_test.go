@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/approval"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+)
+
+// 热钱包合计在 [Min, Max] 内不调拨；超过 Max 时按地址顺序把超出 Target 的部分转入冷钱包（原生币预留手续费），
+// 进行中的调拨不重复发起；低于 Min 时生成补充到 Target 的冷转热调拨单并提交人工审批
+func TestRebalanceThresholds(t *testing.T) {
+	db := testdb.Open(t, "wallet_addresses", "rebalances", "sign_requests", "withdrawal_approvals")
+	ctx := context.Background()
+	const currency, hot2 = "RBTEST", "0x00000000000000000000000000000000000000a2"
+	c := &nonceChain{
+		balances:      map[string]int64{TEST_HOT_WALLET: 400, hot2: 300},
+		pending:       map[string]uint64{},
+		mined:         map[string]uint64{},
+		confirmations: map[string]uint64{},
+		gasPrice:      1,
+	}
+	chain.Register(c)
+	createRows(t, db,
+		&model.WalletAddress{Currency: currency, Address: TEST_HOT_WALLET, Type: WALLET_TYPE_HOT},
+		&model.WalletAddress{Currency: currency, Address: hot2, Type: WALLET_TYPE_HOT},
+	)
+	approvals := approval.NewService(db, approval.Policy{TTL: time.Hour, ReviewRoles: []string{"ops"}}, []byte("trail-key"))
+	r := NewRebalancer(db, []TreasuryRule{{
+		Currency: currency, Chain: TEST_SWEEP_CHAIN, ColdAddress: TEST_COLD_WALLET, Confirmations: 1,
+		Min: big.NewInt(200), Target: big.NewInt(500), Max: big.NewInt(1000),
+	}}, map[string][]byte{TEST_HOT_WALLET: {1}, hot2: {2}}, approvals, nil)
+	rebalances := func() []model.Rebalance {
+		t.Helper()
+		var list []model.Rebalance
+		if err := db.Order("id").Find(&list).Error; err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+	check := func() {
+		t.Helper()
+		if err := r.CheckOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check()
+	if list := rebalances(); len(list) != 0 {
+		t.Fatalf("rebalances = %+v within thresholds, want none", list)
+	}
+
+	// 合计 1300，超出 Target 800：第一个地址转出 400 - 10 手续费，其余由第二个地址转出
+	c.balances[TEST_HOT_WALLET], c.balances[hot2] = 700, 600
+	check()
+	check()
+	list := rebalances()
+	if len(list) != 2 || list[0].FromAddress != TEST_HOT_WALLET || list[0].Amount != "690" || list[1].FromAddress != hot2 || list[1].Amount != "110" {
+		t.Fatalf("rebalances = %+v, want 690 and 110 to cold", list)
+	}
+	for _, rb := range list {
+		if rb.Direction != model.REBALANCE_HOT_TO_COLD || rb.Status != model.REBALANCE_STATUS_BROADCASTED || rb.ToAddress != TEST_COLD_WALLET {
+			t.Fatalf("rebalance = %+v, want broadcasted hot to cold", rb)
+		}
+	}
+	if len(c.sent) != 2 {
+		t.Fatalf("sent = %v, want two transfers", c.sent)
+	}
+
+	// 调拨完成后合计 150，低于 Min：补充 350，等待审批
+	if err := db.Model(&model.Rebalance{}).Where("1 = 1").Update("status", model.REBALANCE_STATUS_CONFIRMED).Error; err != nil {
+		t.Fatal(err)
+	}
+	c.balances[TEST_HOT_WALLET], c.balances[hot2] = 100, 50
+	check()
+	list = rebalances()
+	refill := list[len(list)-1]
+	if len(list) != 3 || refill.Direction != model.REBALANCE_COLD_TO_HOT || refill.Amount != "350" || refill.Status != model.REBALANCE_STATUS_APPROVING ||
+		refill.FromAddress != TEST_COLD_WALLET || refill.ToAddress != TEST_HOT_WALLET || refill.SignRequestID == nil {
+		t.Fatalf("refill = %+v, want 350 cold to hot awaiting approval", refill)
+	}
+	var a model.WithdrawalApproval
+	if err := db.Where("withdrawal_id = ?", refill.ID).First(&a).Error; err != nil {
+		t.Fatal(err)
+	}
+	if a.Kind != approval.KindRebalance || a.ReviewRoles != "ops" || a.Status != approval.StatusPending {
+		t.Fatalf("approval = %+v, want a pending rebalance review by ops", a)
+	}
+	if len(c.sent) != 2 {
+		t.Fatalf("sent = %v, refill must not be broadcast before approval", c.sent)
+	}
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==========================
+// 链上对账
+// ==========================
+// 按链和币种汇总账本中的用户资产（负债），与热、冷、充值地址的链上余额比较，
+// 差额超过容忍值时自动暂停该币种提现，人工核查后解除。
+// 原生币的持仓还会因平台交易的手续费减少、因 gas 钱包补 gas 增加（gas 钱包是平台资金，不计入持仓），
+// 手续费记入 network_fees，对账时与补 gas 金额一起调整预期持仓。
+
+var (
+	ErrWithdrawalsBlocked = errors.New("对账差额超限，该币种提现已暂停")
+	ErrReportNotFound     = errors.New("对账报告不存在")
+)
+
+// WithdrawalGate 提现前检查币种是否被暂停
+type WithdrawalGate interface {
+	CheckWithdrawalAllowed(ctx context.Context, currency string) error
+}
+
+// ReconcileRule 单个币种的对账参数
+type ReconcileRule struct {
+	Currency    string
+	Chain       string
+	Token       *string // nil 表示原生币
+	Tolerance   *big.Int
+	HotAddress  string // 热钱包地址，取自链配置
+	ColdAddress string // 冷钱包（或 Safe 多签）地址，取自链配置
+}
+
+type Reconciler struct {
+	db     *gorm.DB
+	ledger *repository.LedgerRepository
+	rules  []ReconcileRule
+}
+
+var _ WithdrawalGate = (*Reconciler)(nil)
+
+func NewReconciler(db *gorm.DB, rules []ReconcileRule) *Reconciler {
+	return &Reconciler{db: db, ledger: repository.NewLedgerRepository(db), rules: rules}
+}
+
+// ReconcileOnce 对所有币种对账一次
+func (r *Reconciler) ReconcileOnce(ctx context.Context) error {
+	for _, rule := range r.rules {
+		report, err := r.Reconcile(ctx, rule)
+		if err != nil {
+			log.Printf("reconcile %s err: %v", rule.Currency, err)
+			continue
+		}
+		if report.Status != model.RECONCILE_STATUS_OK {
+			log.Printf("reconcile %s #%d: %s liabilities=%s holdings=%s gap=%s",
+				rule.Currency, report.ID, report.Status, report.Liabilities, report.Holdings, report.Gap)
+		}
+	}
+	return nil
+}
+
+// Reconcile 生成一份对账报告，差额超限时在同一事务中暂停提现
+func (r *Reconciler) Reconcile(ctx context.Context, rule ReconcileRule) (*model.ReconcileReport, error) {
+	c, err := chain.Get(rule.Chain)
+	if err != nil {
+		return nil, err
+	}
+	liabilities, err := r.liabilities(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	fees, topUps := new(big.Int), new(big.Int)
+	if rule.Token == nil {
+		if err := r.recordFees(ctx, c); err != nil {
+			return nil, err
+		}
+		if fees, topUps, err = r.nativeAdjustments(ctx, rule.Chain); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := r.addresses(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	holdings := new(big.Int)
+	var failed int
+	for i := range entries {
+		e := &entries[i]
+		balance, utxos, err := balanceOf(ctx, c, rule, e.Address)
+		if err != nil {
+			e.Error = err.Error()
+			failed++
+			continue
+		}
+		e.Balance = balance.String()
+		e.UTXOCount = utxos
+		holdings.Add(holdings, balance)
+	}
+
+	expected := new(big.Int).Sub(liabilities, fees)
+	expected.Add(expected, topUps)
+	gap := new(big.Int).Sub(holdings, expected)
+	report := model.ReconcileReport{
+		Chain:       rule.Chain,
+		Currency:    rule.Currency,
+		Liabilities: liabilities.String(),
+		Fees:        fees.String(),
+		GasTopUps:   topUps.String(),
+		Holdings:    holdings.String(),
+		Gap:         gap.String(),
+		Tolerance:   rule.Tolerance.String(),
+		Status:      model.RECONCILE_STATUS_OK,
+		Entries:     entries,
+	}
+	switch {
+	case failed > 0:
+		// 余额不全时差额没有意义，不触发暂停，等待下次对账
+		report.Status = model.RECONCILE_STATUS_INCOMPLETE
+		report.Error = fmt.Sprintf("%d of %d address balances unavailable", failed, len(entries))
+	case new(big.Int).Abs(gap).Cmp(rule.Tolerance) > 0:
+		report.Status = model.RECONCILE_STATUS_MISMATCH
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		if report.Status != model.RECONCILE_STATUS_MISMATCH {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{"active", "report_id", "reason", "blocked_at", "released_by", "released_at", "updated_at"}),
+		}).Create(&model.WithdrawalBlock{
+			Currency:  rule.Currency,
+			Active:    true,
+			ReportID:  report.ID,
+			Reason:    fmt.Sprintf("gap %s exceeds tolerance %s", report.Gap, report.Tolerance),
+			BlockedAt: time.Now(),
+		}).Error
+	})
+	return &report, err
+}
+
+// liabilities 已入账充值减去已广播的提现（最小单位）
+func (r *Reconciler) liabilities(ctx context.Context, rule ReconcileRule) (*big.Int, error) {
+	return r.ledger.Liabilities(ctx, rule.Chain, rule.Token, rule.Currency)
+}
+
+// recordFees 为已上链的归集、提现、调拨补记手续费，每笔只记一次。
+// 归集确认时已按链上实际消耗更新 Fee；提现与调拨按交易哈希查询，链不支持查询手续费时不记录。
+// 执行失败的提现（已上链）同样消耗手续费；失败的归集、调拨无法区分回滚与未上链，不记录
+func (r *Reconciler) recordFees(ctx context.Context, c chain.Chain) error {
+	db := r.db.WithContext(ctx)
+	recorded := func(source string) *gorm.DB {
+		return db.Model(&model.NetworkFee{}).Select("ref_id").Where("source = ?", source)
+	}
+
+	var sweeps []model.Sweep
+	if err := db.Where("chain = ? AND status = ? AND tx_hash IS NOT NULL AND fee <> '' AND id NOT IN (?)",
+		c.Name(), model.SWEEP_STATUS_CONFIRMED, recorded(model.FEE_SOURCE_SWEEP)).
+		Find(&sweeps).Error; err != nil {
+		return err
+	}
+	var fees []model.NetworkFee
+	for _, sw := range sweeps {
+		fees = append(fees, model.NetworkFee{Chain: sw.Chain, Source: model.FEE_SOURCE_SWEEP, RefID: sw.ID, TxHash: *sw.TxHash, Amount: sw.Fee})
+	}
+
+	if reader, ok := c.(chain.FeeReader); ok {
+		txFee := func(source string, id uint, txHash string) {
+			fee, err := reader.TxFee(ctx, txHash)
+			if err != nil {
+				// 交易尚未上链或节点暂不可用，下次对账重试
+				log.Printf("reconcile %s: fee of %s #%d (%s): %v", c.Name(), source, id, txHash, err)
+				return
+			}
+			fees = append(fees, model.NetworkFee{Chain: c.Name(), Source: source, RefID: id, TxHash: txHash, Amount: fee.String()})
+		}
+
+		currencies, err := r.withdrawalCurrencies(ctx, c.Name())
+		if err != nil {
+			return err
+		}
+		var withdrawals []model.Withdrawal
+		if len(currencies) > 0 {
+			if err := db.Where("currency IN ? AND status IN ? AND tx_hash <> '' AND id NOT IN (?)",
+				currencies, []string{"success", "failed"}, recorded(model.FEE_SOURCE_WITHDRAWAL)).
+				Find(&withdrawals).Error; err != nil {
+				return err
+			}
+		}
+		for _, w := range withdrawals {
+			txFee(model.FEE_SOURCE_WITHDRAWAL, w.ID, w.TxHash)
+		}
+
+		var rebalances []model.Rebalance
+		if err := db.Where("chain = ? AND status = ? AND tx_hash IS NOT NULL AND id NOT IN (?)",
+			c.Name(), model.REBALANCE_STATUS_CONFIRMED, recorded(model.FEE_SOURCE_REBALANCE)).
+			Find(&rebalances).Error; err != nil {
+			return err
+		}
+		for _, rb := range rebalances {
+			txFee(model.FEE_SOURCE_REBALANCE, rb.ID, *rb.TxHash)
+		}
+	}
+
+	if len(fees) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&fees).Error
+}
+
+// withdrawalCurrencies 有提现记录的币种中属于该链的
+func (r *Reconciler) withdrawalCurrencies(ctx context.Context, chainName string) ([]string, error) {
+	var all []string
+	if err := r.db.WithContext(ctx).Model(&model.Withdrawal{}).Distinct("currency").Pluck("currency", &all).Error; err != nil {
+		return nil, err
+	}
+	var currencies []string
+	for _, cur := range all {
+		if ch, err := address.ChainOf(cur); err == nil && ch == chainName {
+			currencies = append(currencies, cur)
+		}
+	}
+	return currencies, nil
+}
+
+// nativeAdjustments 链上原生币已记录的手续费合计，与 gas 钱包已转入充值地址的合计
+func (r *Reconciler) nativeAdjustments(ctx context.Context, chainName string) (*big.Int, *big.Int, error) {
+	sum := func(q *gorm.DB) (*big.Int, error) {
+		var total string
+		if err := q.Select("COALESCE(SUM(CAST(amount AS NUMERIC)), 0)::TEXT").Scan(&total).Error; err != nil {
+			return nil, err
+		}
+		v, ok := new(big.Int).SetString(total, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid sum %q", total)
+		}
+		return v, nil
+	}
+	db := r.db.WithContext(ctx)
+	fees, err := sum(db.Model(&model.NetworkFee{}).Where("chain = ?", chainName))
+	if err != nil {
+		return nil, nil, err
+	}
+	topUps, err := sum(db.Model(&model.GasTopUp{}).Where("chain = ? AND status IN ?", chainName, []string{
+		model.GAS_TOPUP_STATUS_CONFIRMED,
+		model.GAS_TOPUP_STATUS_SWEPT,
+		model.GAS_TOPUP_STATUS_RECONCILED,
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+	return fees, topUps, nil
+}
+
+// addresses 该币种的热、冷钱包地址（链配置与 wallet_addresses 中登记的）与链上的充值地址，按小写去重
+func (r *Reconciler) addresses(ctx context.Context, rule ReconcileRule) ([]model.ReconcileEntry, error) {
+	var wallets []model.WalletAddress
+	if err := r.db.WithContext(ctx).Where("currency = ?", rule.Currency).Order("id").Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	var pool []model.AddressPool
+	if err := r.db.WithContext(ctx).Where("chain = ? AND user_id IS NOT NULL", rule.Chain).Order("id").Find(&pool).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var entries []model.ReconcileEntry
+	add := func(addr, kind string) {
+		key := strings.ToLower(addr)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		entries = append(entries, model.ReconcileEntry{Address: addr, Kind: kind})
+	}
+	if rule.HotAddress != "" {
+		add(rule.HotAddress, "hot")
+	}
+	if rule.ColdAddress != "" {
+		add(rule.ColdAddress, "cold")
+	}
+	for _, w := range wallets {
+		kind := "hot"
+		if w.Type == WALLET_TYPE_COLD {
+			kind = "cold"
+		}
+		add(w.Address, kind)
+	}
+	for _, p := range pool {
+		add(p.Address, "deposit")
+	}
+	return entries, nil
+}
+
+// balanceOf UTXO 链的原生币按未花费输出求和，其余按账户余额
+func balanceOf(ctx context.Context, c chain.Chain, rule ReconcileRule, addr string) (*big.Int, int, error) {
+	if lister, ok := c.(chain.UTXOLister); ok && rule.Token == nil {
+		utxos, err := lister.ListUnspent(ctx, addr)
+		if err != nil {
+			return nil, 0, err
+		}
+		sum := new(big.Int)
+		for _, u := range utxos {
+			sum.Add(sum, u.Amount)
+		}
+		return sum, len(utxos), nil
+	}
+	balance, err := c.Balance(ctx, addr, rule.Token)
+	return balance, 0, err
+}
+
+// CheckWithdrawalAllowed 币种处于暂停状态时拒绝提现
+func (r *Reconciler) CheckWithdrawalAllowed(ctx context.Context, currency string) error {
+	var block model.WithdrawalBlock
+	err := r.db.WithContext(ctx).Where("currency = ? AND active = ?", currency, true).First(&block).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrWithdrawalsBlocked, block.Reason)
+}
+
+// Release 人工核查后解除提现暂停
+func (r *Reconciler) Release(ctx context.Context, currency string, operatorID uint64) error {
+	now := time.Now()
+	res := r.db.WithContext(ctx).Model(&model.WithdrawalBlock{}).
+		Where("currency = ? AND active = ?", currency, true).
+		Updates(map[string]interface{}{"active": false, "released_by": operatorID, "released_at": now})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("currency %s is not blocked", currency)
+	}
+	log.Printf("withdrawals for %s released by operator %d", currency, operatorID)
+	return nil
+}
+
+// Reports 最近的对账报告（不含明细）
+func (r *Reconciler) Reports(ctx context.Context, currency string, limit int) ([]model.ReconcileReport, error) {
+	var list []model.ReconcileReport
+	q := r.db.WithContext(ctx).Order("id DESC").Limit(limit)
+	if currency != "" {
+		q = q.Where("currency = ?", currency)
+	}
+	return list, q.Find(&list).Error
+}
+
+// Report 单份对账报告及按地址的明细
+func (r *Reconciler) Report(ctx context.Context, id uint) (*model.ReconcileReport, error) {
+	var report model.ReconcileReport
+	if err := r.db.WithContext(ctx).Preload("Entries").First(&report, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Blocks 当前暂停提现的币种
+func (r *Reconciler) Blocks(ctx context.Context) ([]model.WithdrawalBlock, error) {
+	var list []model.WithdrawalBlock
+	return list, r.db.WithContext(ctx).Where("active = ?", true).Find(&list).Error
+}
+
+// Run 定期对账
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.ReconcileOnce(ctx); err != nil {
+			log.Printf("reconcile err: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
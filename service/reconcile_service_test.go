@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+const (
+	TEST_RECONCILE_CHAIN = "reconciletest"
+	TEST_RECONCILE_TOKEN = "0x00000000000000000000000000000000000000c0"
+	TEST_COLD_WALLET     = "0x00000000000000000000000000000000000000b1"
+	TEST_DEPOSIT_ADDRESS = "0x00000000000000000000000000000000000000d1"
+	TEST_GAS_ADDRESS     = "0x00000000000000000000000000000000000000d2" // 只收到过补 gas 的充值地址
+)
+
+// reconcileChain 余额按 "地址" 或 "代币:地址" 查表；fees 为各交易的实际手续费，缺失时 TxFee 失败
+type reconcileChain struct {
+	chain.Chain
+	balances map[string]int64
+	fees     map[string]int64
+}
+
+func (c *reconcileChain) Name() string { return TEST_RECONCILE_CHAIN }
+
+func (c *reconcileChain) Balance(ctx context.Context, addr string, token *string) (*big.Int, error) {
+	key := strings.ToLower(addr)
+	if token != nil {
+		key = *token + ":" + key
+	}
+	return big.NewInt(c.balances[key]), nil
+}
+
+func (c *reconcileChain) TxFee(ctx context.Context, txHash string) (*big.Int, error) {
+	fee, ok := c.fees[txHash]
+	if !ok {
+		return nil, errors.New("receipt not found")
+	}
+	return big.NewInt(fee), nil
+}
+
+func createRows(t *testing.T, db *gorm.DB, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// 原生币对账扣除归集、提现、调拨的手续费，计入补 gas 金额；热、冷钱包地址取自规则；
+// 手续费每笔只记一次，读不到的下次对账补记
+func TestReconcileNativeFees(t *testing.T) {
+	db := testdb.Open(t, "deposits", "withdrawals", "sweeps", "gas_top_ups", "rebalances", "network_fees",
+		"reconcile_reports", "reconcile_entries", "withdrawal_blocks", "address_pools", "wallet_addresses")
+	ctx := context.Background()
+	const currency, tokenCurrency = "RTEST", "RTOKEN"
+	address.RegisterChain(TEST_RECONCILE_CHAIN, address.ChainEthereum)
+	address.RegisterCurrency(currency, TEST_RECONCILE_CHAIN)
+	address.RegisterCurrency(tokenCurrency, TEST_RECONCILE_CHAIN)
+
+	// 用户 1 充值 1000 与 500 代币；归集 990（手续费 10），提现 300（手续费 5），热转冷 400（手续费 3），
+	// 向用户 2 的充值地址补 gas 50
+	c := &reconcileChain{
+		balances: map[string]int64{
+			TEST_HOT_WALLET:  1000 - 10 - 300 - 5 - 400 - 3,
+			TEST_COLD_WALLET: 400,
+			TEST_GAS_ADDRESS: 50,
+			TEST_RECONCILE_TOKEN + ":" + TEST_DEPOSIT_ADDRESS: 500,
+		},
+		fees: map[string]int64{"w1": 5, "r1": 3},
+	}
+	chain.Register(c)
+	user1, user2 := int64(1), int64(2)
+	token := TEST_RECONCILE_TOKEN
+	sweepTx, rebalanceTx := "s1", "r1"
+	createRows(t, db,
+		&model.AddressPool{Chain: TEST_RECONCILE_CHAIN, Address: TEST_DEPOSIT_ADDRESS, UserID: &user1},
+		&model.AddressPool{Chain: TEST_RECONCILE_CHAIN, Address: TEST_GAS_ADDRESS, HDIndex: 1, UserID: &user2},
+		&model.Deposit{Chain: TEST_RECONCILE_CHAIN, ToAddress: TEST_DEPOSIT_ADDRESS, UserID: &user1, Amount: "1000", TxHash: "d1", Confirmed: true},
+		&model.Deposit{Chain: TEST_RECONCILE_CHAIN, Token: &token, ToAddress: TEST_DEPOSIT_ADDRESS, UserID: &user1, Amount: "500", TxHash: "d2", Confirmed: true},
+		&model.Sweep{Chain: TEST_RECONCILE_CHAIN, FromAddress: TEST_DEPOSIT_ADDRESS, ToAddress: TEST_HOT_WALLET, Amount: "990", Fee: "10", TxHash: &sweepTx, Status: model.SWEEP_STATUS_CONFIRMED},
+		&model.Withdrawal{UserID: 1, Currency: currency, Address: TEST_WITHDRAW_TO, Amount: "300", Status: "success", TxHash: "w1"},
+		&model.Rebalance{Currency: currency, Chain: TEST_RECONCILE_CHAIN, Direction: model.REBALANCE_HOT_TO_COLD, FromAddress: TEST_HOT_WALLET, ToAddress: TEST_COLD_WALLET, Amount: "400", TxHash: &rebalanceTx, Status: model.REBALANCE_STATUS_CONFIRMED},
+		&model.GasTopUp{Chain: TEST_RECONCILE_CHAIN, Address: TEST_GAS_ADDRESS, Token: token, Amount: "50", Fee: "2", Status: model.GAS_TOPUP_STATUS_CONFIRMED},
+	)
+
+	r := NewReconciler(db, nil)
+	rule := ReconcileRule{Currency: currency, Chain: TEST_RECONCILE_CHAIN, Tolerance: new(big.Int), HotAddress: TEST_HOT_WALLET, ColdAddress: TEST_COLD_WALLET}
+	check := func(rule ReconcileRule, status, fees, gap string) *model.ReconcileReport {
+		t.Helper()
+		report, err := r.Reconcile(ctx, rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Status != status || report.Fees != fees || report.Gap != gap {
+			t.Fatalf("%s report = %s fees=%s gap=%s (liabilities=%s holdings=%s top-ups=%s), want %s fees=%s gap=%s",
+				rule.Currency, report.Status, report.Fees, report.Gap, report.Liabilities, report.Holdings, report.GasTopUps, status, fees, gap)
+		}
+		return report
+	}
+
+	report := check(rule, model.RECONCILE_STATUS_OK, "18", "0")
+	if report.GasTopUps != "50" {
+		t.Fatalf("gas top-ups = %s, want 50", report.GasTopUps)
+	}
+	kinds := map[string]string{}
+	for _, e := range report.Entries {
+		kinds[strings.ToLower(e.Address)] = e.Kind
+	}
+	if kinds[TEST_HOT_WALLET] != "hot" || kinds[TEST_COLD_WALLET] != "cold" || kinds[TEST_DEPOSIT_ADDRESS] != "deposit" {
+		t.Fatalf("entries = %v, want hot and cold wallets from the rule", kinds)
+	}
+
+	// 再次对账不重复记手续费
+	check(rule, model.RECONCILE_STATUS_OK, "18", "0")
+
+	// 新提现的手续费暂时读不到：差额为手续费，记账后恢复
+	createRows(t, db, &model.Withdrawal{UserID: 1, Currency: currency, Address: TEST_WITHDRAW_TO, Amount: "100", Status: "success", TxHash: "w2"})
+	c.balances[TEST_HOT_WALLET] -= 100 + 7
+	check(rule, model.RECONCILE_STATUS_MISMATCH, "18", "-7")
+	c.fees["w2"] = 7
+	check(rule, model.RECONCILE_STATUS_OK, "25", "0")
+
+	var count int64
+	if err := db.Model(&model.NetworkFee{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Fatalf("network fees = %d, want 4", count)
+	}
+
+	// 代币不扣原生币手续费
+	check(ReconcileRule{Currency: tokenCurrency, Chain: TEST_RECONCILE_CHAIN, Token: &token, Tolerance: new(big.Int), HotAddress: TEST_HOT_WALLET},
+		model.RECONCILE_STATUS_OK, "0", "0")
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/chain/evm"
+	"github.com/crypto_custody/model"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+// ==========================
+// Safe 多签冷钱包
+// ==========================
+// 提案时为 Safe 的每个 owner 生成一条 SignRequest（内容为同一笔 SafeTx），
+// owner 通过离线签名批次或直接提交签名，签名数达到链上阈值后由执行账户调用 execTransaction。
+
+var (
+	ErrSafeTxNotFound        = errors.New("多签交易不存在")
+	ErrSafeThresholdNotMet   = errors.New("多签签名数未达到阈值")
+	ErrSafeSignRequestClosed = errors.New("签名请求已完成或已关闭")
+)
+
+type SafeService struct {
+	db          *gorm.DB
+	executor    string // 发起 execTransaction 并支付 gas 的热钱包地址
+	executorKey []byte
+}
+
+func NewSafeService(db *gorm.DB, executor, executorKeyHex string) (*SafeService, error) {
+	key, err := hex.DecodeString(strings.TrimPrefix(executorKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid executor key: %w", err)
+	}
+	return &SafeService{db: db, executor: executor, executorKey: key}, nil
+}
+
+// WithTx 返回在给定事务中读写的副本，用于与业务单据同事务创建提案
+func (s *SafeService) WithTx(tx *gorm.DB) *SafeService {
+	cp := *s
+	cp.db = tx
+	return &cp
+}
+
+func evmChain(name string) (*evm.Chain, error) {
+	c, err := chain.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	ec, ok := c.(*evm.Chain)
+	if !ok {
+		return nil, fmt.Errorf("chain %s does not support safe multisig", name)
+	}
+	return ec, nil
+}
+
+// nextNonce 链上 nonce 与本地未执行提案之后的 nonce 取较大值，允许多笔提案排队
+func (s *SafeService) nextNonce(ctx context.Context, safe string, onchain *big.Int) (*big.Int, error) {
+	var pending []uint64
+	if err := s.db.WithContext(ctx).Model(&model.SafeTransaction{}).
+		Where("safe_address = ? AND status = ?", safe, model.SAFE_TX_STATUS_COLLECTING).
+		Pluck("nonce", &pending).Error; err != nil {
+		return nil, err
+	}
+	nonce := new(big.Int).Set(onchain)
+	for _, n := range pending {
+		if next := new(big.Int).SetUint64(n + 1); next.Cmp(nonce) > 0 {
+			nonce = next
+		}
+	}
+	return nonce, nil
+}
+
+// Propose 创建多签交易，并为每个 owner 创建待签名请求
+func (s *SafeService) Propose(ctx context.Context, chainName string, req chain.TransferRequest) (*model.SafeTransaction, error) {
+	c, err := evmChain(chainName)
+	if err != nil {
+		return nil, err
+	}
+	owners, threshold, onchainNonce, err := c.SafeInfo(ctx, req.From)
+	if err != nil {
+		return nil, err
+	}
+	if threshold == 0 || uint64(len(owners)) < threshold {
+		return nil, fmt.Errorf("safe %s: invalid threshold %d of %d owners", req.From, threshold, len(owners))
+	}
+	nonce, err := s.nextNonce(ctx, req.From, onchainNonce)
+	if err != nil {
+		return nil, err
+	}
+	safeTx, err := c.BuildSafeTx(ctx, req, nonce)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(safeTx)
+	if err != nil {
+		return nil, err
+	}
+
+	st := model.SafeTransaction{
+		Chain:       chainName,
+		SafeAddress: req.From,
+		Nonce:       nonce.Uint64(),
+		SafeTxHash:  safeTx.Hash().Hex(),
+		Payload:     payload,
+		Threshold:   threshold,
+		Status:      model.SAFE_TX_STATUS_COLLECTING,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&st).Error; err != nil {
+			return err
+		}
+		for _, owner := range owners {
+			sr := model.SignRequest{
+				Chain:       chainName,
+				FromAddress: owner.Hex(),
+				Unsigned:    payload,
+				SafeTxID:    &st.ID,
+				Status:      "created",
+			}
+			if err := tx.Create(&sr).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("safe tx #%d: %s nonce=%d hash=%s needs %d of %d owners", st.ID, req.From, st.Nonce, st.SafeTxHash, threshold, len(owners))
+	return &st, nil
+}
+
+func (s *SafeService) get(ctx context.Context, id uint) (*model.SafeTransaction, error) {
+	var st model.SafeTransaction
+	if err := s.db.WithContext(ctx).First(&st, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSafeTxNotFound
+		}
+		return nil, err
+	}
+	return &st, nil
+}
+
+// SubmitSignature owner 在线提交对 SafeTxHash 的签名（如硬件钱包签名结果）
+func (s *SafeService) SubmitSignature(ctx context.Context, signRequestID uint, sig []byte) error {
+	var sr model.SignRequest
+	if err := s.db.WithContext(ctx).First(&sr, signRequestID).Error; err != nil {
+		return err
+	}
+	if sr.SafeTxID == nil {
+		return fmt.Errorf("sign request %d is not a safe signature request", signRequestID)
+	}
+	if sr.Status != "created" && sr.Status != "exported" {
+		return ErrSafeSignRequestClosed
+	}
+	c, err := evmChain(sr.Chain)
+	if err != nil {
+		return err
+	}
+	utx := &chain.UnsignedTx{Chain: sr.Chain, From: sr.FromAddress, Payload: sr.Unsigned}
+	if err := c.VerifySigned(utx, sig); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&sr).
+		Updates(map[string]interface{}{"signed": sig, "status": "signed"}).Error
+}
+
+// signatures 已收集的 owner 签名，逐条重新校验签名者
+func (s *SafeService) signatures(ctx context.Context, st *model.SafeTransaction, safeTx *evm.SafeTx) (map[common.Address][]byte, error) {
+	var requests []model.SignRequest
+	if err := s.db.WithContext(ctx).
+		Where("safe_tx_id = ? AND status = ?", st.ID, "signed").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	sigs := make(map[common.Address][]byte, len(requests))
+	for _, sr := range requests {
+		signer, err := evm.RecoverSafeSigner(safeTx, sr.Signed)
+		if err != nil {
+			return nil, fmt.Errorf("sign request %d: %w", sr.ID, err)
+		}
+		if !strings.EqualFold(signer.Hex(), sr.FromAddress) {
+			return nil, fmt.Errorf("sign request %d signed by %s, expected %s", sr.ID, signer.Hex(), sr.FromAddress)
+		}
+		sigs[signer] = sr.Signed
+	}
+	return sigs, nil
+}
+
+// Execute 签名数达到阈值时广播 execTransaction，返回交易哈希
+func (s *SafeService) Execute(ctx context.Context, id uint) (string, error) {
+	st, err := s.get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if st.Status != model.SAFE_TX_STATUS_COLLECTING {
+		return "", fmt.Errorf("多签交易 %d 当前状态为 %s，不能执行", id, st.Status)
+	}
+	safeTx, ok := evm.DecodeSafeTx(st.Payload)
+	if !ok || safeTx.Hash().Hex() != st.SafeTxHash {
+		return "", fmt.Errorf("safe tx %d: payload does not match hash", id)
+	}
+	sigs, err := s.signatures(ctx, st, safeTx)
+	if err != nil {
+		return "", err
+	}
+	if uint64(len(sigs)) < st.Threshold {
+		return "", ErrSafeThresholdNotMet
+	}
+
+	c, err := evmChain(st.Chain)
+	if err != nil {
+		return "", err
+	}
+	txHash, err := func() (string, error) {
+		utx, err := c.BuildExecTx(ctx, safeTx, evm.PackSafeSignatures(sigs), s.executor)
+		if err != nil {
+			return "", err
+		}
+		signed, err := c.SignTx(utx, s.executorKey)
+		if err != nil {
+			return "", err
+		}
+		return c.Broadcast(ctx, signed)
+	}()
+	if err != nil {
+		s.db.WithContext(ctx).Model(st).Update("error", err.Error())
+		return "", err
+	}
+	log.Printf("safe tx #%d executed with %d signatures tx=%s", st.ID, len(sigs), txHash)
+	return txHash, s.db.WithContext(ctx).Model(st).Updates(map[string]interface{}{
+		"status":       model.SAFE_TX_STATUS_EXECUTED,
+		"exec_tx_hash": txHash,
+		"error":        "",
+	}).Error
+}
+
+// Cancel 关闭未执行的多签交易及其未完成的签名请求
+func (s *SafeService) Cancel(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SafeTransaction{}).
+			Where("id = ? AND status = ?", id, model.SAFE_TX_STATUS_COLLECTING).
+			Update("status", model.SAFE_TX_STATUS_CANCELLED).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.SignRequest{}).
+			Where("safe_tx_id = ? AND status IN ?", id, []string{"created", "exported"}).
+			Update("status", "failed").Error
+	})
+}
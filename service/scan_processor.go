@@ -2,58 +2,38 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"github.com/crypto_custody/chain"
 	model "github.com/crypto_custody/model"
-	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
-	"gorm.io/driver/postgres"
+	"github.com/crypto_custody/outbox"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log"
-	"math/big"
-	"os"
-	"strings"
+	"strconv"
 	"time"
 )
 
 const (
-	PROCESS_DB_DSN        = "host=localhost user=postgres password=postgres dbname=custody sslmode=disable"
-	PROCESS_CHAIN         = "ethereum"
 	BATCH_PROCESS_SIZE    = 100
 	POLL_PROCESS_INTERVAL = 2 * time.Second
 )
 
-// Transfer event signature: Transfer(address,address,uint256)
-var transferEventSig = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
-
-// minimal ERC20 ABI for Transfer decoding
-const erc20ABIJSON = `[{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"from","type":"address"},{"indexed":true,"internalType":"address","name":"to","type":"address"},{"indexed":false,"internalType":"uint256","name":"value","type":"uint256"}],"name":"Transfer","type":"event"}]`
+// depositKey 充值唯一键：一笔转账对应交易内的一个日志 / 输出
+var depositKey = []clause.Column{{Name: "chain"}, {Name: "tx_hash"}, {Name: "log_index"}}
 
+// Processor turns stored onchain events into deposits. Events of every
+// registered chain are handled; decoding is delegated to the chain adapter.
 type Processor struct {
-	db  *gorm.DB
-	erc abi.ABI
+	db *gorm.DB
 }
 
-func NewProcessor(dsn string) (*Processor, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		return nil, err
-	}
-	if err := model.AutoMigrate(db); err != nil {
-		return nil, err
-	}
-	erc, err := abi.JSON(strings.NewReader(erc20ABIJSON))
-	if err != nil {
-		return nil, err
-	}
-	return &Processor{db: db, erc: erc}, nil
+func NewProcessor(db *gorm.DB) *Processor {
+	return &Processor{db: db}
 }
 
 func (p *Processor) fetchPendingEvents(ctx context.Context, limit int) ([]model.OnchainEvent, error) {
 	var evs []model.OnchainEvent
 	if err := p.db.WithContext(ctx).
-		Where("chain = ? AND processed = false", PROCESS_CHAIN).
+		Where("chain IN ? AND processed = false", chain.Names()).
 		Order("block_number asc, id asc").
 		Limit(limit).
 		Find(&evs).Error; err != nil {
@@ -66,35 +46,6 @@ func (p *Processor) markEventProcessedTx(tx *gorm.DB, evID uint) error {
 	return tx.Model(&model.OnchainEvent{}).Where("id = ?", evID).Update("processed", true).Error
 }
 
-// parseTransfer tries to decode ERC20 Transfer: returns (from,to,value) or error
-func (p *Processor) parseTransfer(ev model.OnchainEvent) (common.Address, common.Address, *big.Int, error) {
-	// topic[0] must be transferEventSig
-	var topics []common.Hash
-	if err := json.Unmarshal([]byte(ev.Topics), &topics); err != nil {
-		return common.Address{}, common.Address{}, nil, fmt.Errorf("unmarshal topics: %w", err)
-	}
-	if len(topics) == 0 {
-		return common.Address{}, common.Address{}, nil, fmt.Errorf("no topics")
-	}
-	if topics[0] != transferEventSig {
-		return common.Address{}, common.Address{}, nil, fmt.Errorf("not transfer event")
-	}
-	// decode indexed: topics[1] = from, topics[2]=to, data = value
-	if len(topics) < 3 {
-		return common.Address{}, common.Address{}, nil, fmt.Errorf("topics len < 3")
-	}
-	from := common.BytesToAddress(topics[1].Bytes()[12:])
-	to := common.BytesToAddress(topics[2].Bytes()[12:])
-	// value in data (non-indexed)
-	var out struct{ Value *big.Int }
-	err := p.erc.UnpackIntoInterface(&out, "Transfer", ev.Data)
-	if err != nil {
-		// sometimes event might encode differently; attempt raw parsing
-		return common.Address{}, common.Address{}, nil, fmt.Errorf("abi unpack err: %w", err)
-	}
-	return from, to, out.Value, nil
-}
-
 func (p *Processor) processEvent(ctx context.Context, ev model.OnchainEvent) error {
 	// start tx
 	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -105,50 +56,99 @@ func (p *Processor) processEvent(ctx context.Context, ev model.OnchainEvent) err
 			return nil
 		}
 
-		// try parse transfer
-		_, to, value, err := p.parseTransfer(ev)
+		c, err := chain.Get(ev.Chain)
 		if err != nil {
-			// Not an ERC20 Transfer or parse failed; mark processed to skip (or keep unprocessed if you want to handle other types)
-			if err := p.markEventProcessedTx(tx, ev.ID); err != nil {
-				return err
-			}
+			// chain not registered in this process: leave unprocessed
 			return nil
 		}
 
-		// check if 'to' is in our address pool
-		var ap model.AddressPool
-		if err := tx.Where("address = ?", strings.ToLower(to.Hex())).First(&ap).Error; err != nil {
-			// try uppercase format or no match: mark processed and skip (or keep unprocessed for manual review)
-			_ = p.markEventProcessedTx(tx, ev.ID)
-			return nil
+		// try parse transfers
+		transfers, err := c.ParseTransfers(chain.Event{
+			BlockNumber: ev.BlockNumber,
+			BlockHash:   ev.BlockHash,
+			TxHash:      ev.TxHash,
+			LogIndex:    ev.LogIndex,
+			Address:     ev.Address,
+			Topics:      ev.Topics,
+			Data:        ev.Data,
+		})
+		if err != nil || len(transfers) == 0 {
+			// Not a transfer or parse failed; mark processed to skip (or keep unprocessed if you want to handle other types)
+			return p.markEventProcessedTx(tx, ev.ID)
 		}
 
-		// create deposit if not exist for same tx/logindex
-		// check duplicate deposit by tx_hash + to_address + amount
-		var dup model.Deposit
-		if err := tx.Where("tx_hash = ? AND to_address = ?", ev.TxHash, strings.ToLower(to.Hex())).First(&dup).Error; err == nil {
-			// already exists -> mark event processed and return
-			if err := p.markEventProcessedTx(tx, ev.ID); err != nil {
+		for _, t := range transfers {
+			// 地址池按规范形式存储
+			to, err := c.NormalizeAddress(t.To)
+			if err != nil {
+				log.Printf("event id=%d: invalid transfer recipient %q: %v", ev.ID, t.To, err)
+				continue
+			}
+			// check if 'to' is in our address pool
+			var ap model.AddressPool
+			if err := tx.Where("chain = ? AND address = ?", ev.Chain, to).First(&ap).Error; err != nil {
+				// no match: skip (or keep for manual review)
+				continue
+			}
+
+			// 迁移前的历史充值没有日志序号（log_index < 0），仍按 tx_hash + to_address 去重
+			var legacy int64
+			if err := tx.Model(&model.Deposit{}).
+				Where("chain = ? AND tx_hash = ? AND to_address = ? AND log_index < 0", ev.Chain, t.TxHash, to).
+				Count(&legacy).Error; err != nil {
 				return err
 			}
-			return nil
-		}
+			if legacy > 0 {
+				continue
+			}
 
-		amountText := value.Text(10)
-		tokenAddr := ev.Address // contract address
+			dep := model.Deposit{
+				Chain:       ev.Chain,
+				Token:       t.Token,
+				ToAddress:   to,
+				UserID:      ap.UserID,
+				Amount:      t.Amount.Text(10),
+				TxHash:      t.TxHash,
+				LogIndex:    t.LogIndex,
+				BlockNumber: t.BlockNumber,
+				Confirmed:   true, // since scanner only processes after confirmations
+			}
+			res := tx.Clauses(clause.OnConflict{Columns: depositKey, DoNothing: true}).Create(&dep)
+			if res.Error != nil {
+				return res.Error
+			}
+			// 已入账（事件重放），不重复发布充值事件
+			if res.RowsAffected == 0 {
+				continue
+			}
+			// 扫块只交给处理器已确认的区块，充值在同一时刻被发现、确认并入账，三个事件依次写入
+			for _, e := range []struct{ topic, status string }{
+				{outbox.TOPIC_DEPOSIT_SEEN, "seen"},
+				{outbox.TOPIC_DEPOSIT_CONFIRMED, "confirmed"},
+				{outbox.TOPIC_DEPOSIT_CREDITED, "credited"},
+			} {
+				if err := outbox.Publish(tx, e.topic, strconv.FormatUint(uint64(dep.ID), 10), outbox.DepositEvent{
+					DepositID:   dep.ID,
+					UserID:      dep.UserID,
+					Chain:       dep.Chain,
+					Token:       dep.Token,
+					Address:     dep.ToAddress,
+					Amount:      dep.Amount,
+					TxHash:      dep.TxHash,
+					BlockNumber: dep.BlockNumber,
+					Status:      e.status,
+				}); err != nil {
+					return err
+				}
+			}
 
-		dep := model.Deposit{
-			Chain:       PROCESS_CHAIN,
-			Token:       &tokenAddr,
-			ToAddress:   strings.ToLower(to.Hex()),
-			UserID:      ap.UserID,
-			Amount:      amountText,
-			TxHash:      ev.TxHash,
-			BlockNumber: ev.BlockNumber,
-			Confirmed:   true, // since scanner only processes after confirmations
-		}
-		if err := tx.Create(&dep).Error; err != nil {
-			return err
+			// optionally: update address_pool used flag
+			if !ap.Used {
+				ap.Used = true
+				if err := tx.Save(&ap).Error; err != nil {
+					return err
+				}
+			}
 		}
 
 		// mark event processed
@@ -156,15 +156,6 @@ func (p *Processor) processEvent(ctx context.Context, ev model.OnchainEvent) err
 			return err
 		}
 
-		// optionally: update address_pool used flag and link to user
-		if ap.ID != 0 && !ap.Used {
-			ap.Used = true
-			ap.UserID = ap.UserID // already set
-			if err := tx.Save(&ap).Error; err != nil {
-				return err
-			}
-		}
-
 		// success transaction commit
 		return nil
 	})
@@ -194,16 +185,3 @@ func (p *Processor) Run(ctx context.Context) {
 		}
 	}
 }
-
-func main() {
-	dsn := PROCESS_DB_DSN
-	if v := os.Getenv("DATABASE_DSN"); v != "" {
-		dsn = v
-	}
-	p, err := NewProcessor(dsn)
-	if err != nil {
-		log.Fatalf("new processor err: %v", err)
-	}
-	ctx := context.Background()
-	p.Run(ctx)
-}
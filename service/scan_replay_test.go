@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+const (
+	TEST_CHAIN     = "replaytest"
+	TEST_DEPOSITOR = "0x00000000000000000000000000000000000000aa"
+)
+
+// replayChain 固定事件的链：Topics 为收款地址，Data 为金额
+type replayChain struct {
+	chain.Chain
+	safe   uint64
+	events []chain.Event
+}
+
+func (c *replayChain) Name() string { return TEST_CHAIN }
+
+func (c *replayChain) NormalizeAddress(addr string) (string, error) {
+	return address.NormalizeEVM(addr)
+}
+
+func (c *replayChain) SafeHeight(ctx context.Context) (uint64, error) { return c.safe, nil }
+
+func (c *replayChain) BlockHash(ctx context.Context, height uint64) (string, error) {
+	return fmt.Sprintf("0x%064x", height), nil
+}
+
+func (c *replayChain) ScanRange(ctx context.Context, from, to uint64, watched chain.AddressFilter) ([]chain.Event, error) {
+	var out []chain.Event
+	for _, e := range c.events {
+		if uint64(e.BlockNumber) >= from && uint64(e.BlockNumber) <= to {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (c *replayChain) ParseTransfers(ev chain.Event) ([]chain.Transfer, error) {
+	amount, ok := new(big.Int).SetString(string(ev.Data), 10)
+	if !ok {
+		return nil, nil
+	}
+	return []chain.Transfer{{
+		TxHash:      ev.TxHash,
+		LogIndex:    ev.LogIndex,
+		BlockNumber: ev.BlockNumber,
+		To:          ev.Topics,
+		Amount:      amount,
+	}}, nil
+}
+
+func transferEvent(block int64, txHash string, logIndex int, to, amount string) chain.Event {
+	return chain.Event{
+		BlockNumber: block,
+		BlockHash:   fmt.Sprintf("0x%064x", block),
+		TxHash:      txHash,
+		LogIndex:    logIndex,
+		Topics:      to,
+		Data:        []byte(amount),
+	}
+}
+
+func newReplayFixture(t *testing.T) (*gorm.DB, *Scanner, *Processor) {
+	db := testdb.Open(t, "processed_blocks", "onchain_events", "address_pools", "deposits", "outbox_events")
+	c := &replayChain{
+		safe: 10,
+		events: []chain.Event{
+			// 批量打款：同一交易两笔转入同一地址
+			transferEvent(3, "0xbatch", 0, TEST_DEPOSITOR, "100"),
+			transferEvent(3, "0xbatch", 1, TEST_DEPOSITOR, "250"),
+			transferEvent(7, "0xsingle", 4, TEST_DEPOSITOR, "5"),
+		},
+	}
+	chain.Register(c)
+	if err := db.Create(&model.AddressPool{Chain: TEST_CHAIN, Address: TEST_DEPOSITOR}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, NewScanner(c, db), NewProcessor(db)
+}
+
+// scanAndProcess 扫到安全高度并处理全部待处理事件
+func scanAndProcess(t *testing.T, s *Scanner, p *Processor) {
+	t.Helper()
+	ctx := context.Background()
+	if err := s.stepOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	evs, err := p.fetchPendingEvents(ctx, BATCH_PROCESS_SIZE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range evs {
+		if err := p.processEvent(ctx, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type replayCounts struct {
+	events, deposits, outbox, pending int64
+}
+
+func countRows(t *testing.T, db *gorm.DB) replayCounts {
+	t.Helper()
+	var c replayCounts
+	for _, q := range []struct {
+		model interface{}
+		where string
+		n     *int64
+	}{
+		{&model.OnchainEvent{}, "chain = '" + TEST_CHAIN + "'", &c.events},
+		{&model.Deposit{}, "chain = '" + TEST_CHAIN + "'", &c.deposits},
+		{&model.OutboxEvent{}, "1 = 1", &c.outbox},
+		{&model.OnchainEvent{}, "chain = '" + TEST_CHAIN + "' AND processed = false", &c.pending},
+	} {
+		if err := db.Model(q.model).Where(q.where).Count(q.n).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func TestBatchPayoutCreditsEveryLog(t *testing.T) {
+	db, s, p := newReplayFixture(t)
+	scanAndProcess(t, s, p)
+
+	var deps []model.Deposit
+	if err := db.Where("chain = ?", TEST_CHAIN).Order("tx_hash, log_index").Find(&deps).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		tx       string
+		logIndex int
+		amount   string
+	}{{"0xbatch", 0, "100"}, {"0xbatch", 1, "250"}, {"0xsingle", 4, "5"}}
+	if len(deps) != len(want) {
+		t.Fatalf("got %d deposits, want %d", len(deps), len(want))
+	}
+	for i, w := range want {
+		if deps[i].TxHash != w.tx || deps[i].LogIndex != w.logIndex || deps[i].Amount != w.amount {
+			t.Errorf("deposit %d = %s/%d/%s, want %s/%d/%s", i, deps[i].TxHash, deps[i].LogIndex, deps[i].Amount, w.tx, w.logIndex, w.amount)
+		}
+	}
+	// 每笔充值 seen / confirmed / credited 三个事件
+	if c := countRows(t, db); c.outbox != 9 || c.pending != 0 {
+		t.Fatalf("counts = %+v", c)
+	}
+}
+
+func TestReplaySameRangeIsNoop(t *testing.T) {
+	db, s, p := newReplayFixture(t)
+	scanAndProcess(t, s, p)
+	before := countRows(t, db)
+
+	// 回滚到起点后重扫同一区间：事件重新标记为待处理，再次入库与入账都不应产生新记录
+	if err := s.rollbackToBlock(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if c := countRows(t, db); c.pending != before.events {
+		t.Fatalf("after rollback pending = %d, want %d", c.pending, before.events)
+	}
+	scanAndProcess(t, s, p)
+
+	after := countRows(t, db)
+	if after != before {
+		t.Fatalf("replay changed rows: before %+v, after %+v", before, after)
+	}
+}
+
+func TestPersistEventsSkipsStoredEvents(t *testing.T) {
+	db, s, _ := newReplayFixture(t)
+	ctx := context.Background()
+	first := []chain.Event{transferEvent(3, "0xbatch", 0, TEST_DEPOSITOR, "100")}
+	if err := s.persistEvents(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	// 同一批次中已入库的事件不再导致整批失败
+	batch := append(first, transferEvent(3, "0xbatch", 1, TEST_DEPOSITOR, "250"))
+	if err := s.persistEvents(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	if c := countRows(t, db); c.events != 2 {
+		t.Fatalf("events = %d, want 2", c.events)
+	}
+}
+
+func TestProcessEventTwiceCreditsOnce(t *testing.T) {
+	db, s, p := newReplayFixture(t)
+	ctx := context.Background()
+	if err := s.stepOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	evs, err := p.fetchPendingEvents(ctx, BATCH_PROCESS_SIZE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range evs {
+		if err := p.processEvent(ctx, ev); err != nil {
+			t.Fatal(err)
+		}
+		// 模拟处理成功后事件又被标记为待处理（如回滚检测）
+		if err := db.Model(&model.OnchainEvent{}).Where("id = ?", ev.ID).Update("processed", false).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := p.processEvent(ctx, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c := countRows(t, db); c.deposits != 3 || c.outbox != 9 {
+		t.Fatalf("counts = %+v", c)
+	}
+}
+
+func TestDepositMatchesPoolAddressInAnyCase(t *testing.T) {
+	db, s, p := newReplayFixture(t)
+	c := s.chain.(*replayChain)
+	// 链上解析出的地址可能是校验和格式，地址池中为规范的小写形式
+	c.events = append(c.events, transferEvent(8, "0xmixed", 0, "0x"+strings.ToUpper(TEST_DEPOSITOR[2:]), "7"))
+	scanAndProcess(t, s, p)
+
+	var dep model.Deposit
+	if err := db.Where("chain = ? AND tx_hash = ?", TEST_CHAIN, "0xmixed").First(&dep).Error; err != nil {
+		t.Fatal(err)
+	}
+	if dep.ToAddress != TEST_DEPOSITOR {
+		t.Fatalf("to_address = %s, want %s", dep.ToAddress, TEST_DEPOSITOR)
+	}
+}
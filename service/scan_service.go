@@ -2,26 +2,17 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"github.com/crypto_custody/chain"
 	model "github.com/crypto_custody/model"
-	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log"
-	"math/big"
-	"os"
 	"sync"
 	"time"
 )
 
 // Configuration (tweakable)
 const (
-	RPC_URL           = "https://mainnet.infura.io/v3/YOUR_KEY" // <- 替换
-	DB_DSN            = "host=localhost user=postgres password=postgres dbname=custody sslmode=disable"
-	CHAIN             = "ethereum"
-	CONFIRMATIONS     = uint64(12)
 	INITIAL_STEP      = uint64(200)
 	MIN_STEP          = uint64(10)
 	MAX_STEP          = uint64(2000)
@@ -29,10 +20,15 @@ const (
 	FAILURE_THRESHOLD = 1
 	POLL_INTERVAL     = 3 * time.Second
 	REORG_CHECK_DEPTH = 100 // on startup check last N blocks for reorg
+	EVENT_BATCH_SIZE  = 500
 )
 
+// eventKey onchain_events 的唯一键，同一事件重复扫描时跳过
+var eventKey = []clause.Column{{Name: "chain"}, {Name: "tx_hash"}, {Name: "log_index"}}
+
+// Scanner 通用扫块器，链相关逻辑全部委托给 chain.Chain
 type Scanner struct {
-	client       *ethclient.Client
+	chain        chain.Chain
 	db           *gorm.DB
 	step         uint64
 	successCount int
@@ -40,29 +36,18 @@ type Scanner struct {
 	mu           sync.Mutex
 }
 
-func NewScanner(rpc string, dsn string) (*Scanner, error) {
-	client, err := ethclient.Dial(rpc)
-	if err != nil {
-		return nil, err
-	}
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		return nil, err
-	}
-	if err := model.AutoMigrate(db); err != nil {
-		return nil, err
-	}
+func NewScanner(c chain.Chain, db *gorm.DB) *Scanner {
 	return &Scanner{
-		client: client,
-		db:     db,
-		step:   INITIAL_STEP,
-	}, nil
+		chain: c,
+		db:    db,
+		step:  INITIAL_STEP,
+	}
 }
 
 // helper: get last processed block from DB
 func (s *Scanner) lastProcessedBlock(ctx context.Context) (int64, error) {
 	var pb model.ProcessedBlock
-	if err := s.db.WithContext(ctx).Order("block_number desc").Limit(1).First(&pb).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("chain = ?", s.chain.Name()).Order("block_number desc").Limit(1).First(&pb).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return 0, nil
 		}
@@ -73,39 +58,60 @@ func (s *Scanner) lastProcessedBlock(ctx context.Context) (int64, error) {
 
 func (s *Scanner) persistProcessedBlock(ctx context.Context, block int64, hash string) error {
 	pb := model.ProcessedBlock{
-		Chain:       CHAIN,
+		Chain:       s.chain.Name(),
 		BlockNumber: block,
 		BlockHash:   hash,
 	}
-	return s.db.WithContext(ctx).Clauses().Create(&pb).Error
+	// 重扫同一区块时更新区块哈希
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}, {Name: "block_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"block_hash"}),
+	}).Create(&pb).Error
 }
 
-// store logs into DB (onchain_events)
-func (s *Scanner) persistLogs(ctx context.Context, logs []types.Log) error {
-	// batch insert in transaction
-	tx := s.db.WithContext(ctx).Begin()
-	for _, l := range logs {
-		topicsJSON, _ := json.Marshal(l.Topics)
-		ev := model.OnchainEvent{
-			Chain:       CHAIN,
-			BlockNumber: int64(l.BlockNumber),
-			BlockHash:   l.BlockHash.Hex(),
-			TxHash:      l.TxHash.Hex(),
-			LogIndex:    int(l.Index),
-			Address:     l.Address.Hex(),
-			Topics:      string(topicsJSON),
-			Data:        l.Data,
-			Processed:   false,
-		}
-		// Use Create with On Conflict DO NOTHING semantics: GORM upsert requires more setup;
-		// For simplicity we attempt Create and ignore unique errors
-		if err := tx.Create(&ev).Error; err != nil {
-			// check unique constraint error (skip duplicates)
-			tx.Rollback()
-			return err
+// watchedAddresses returns a filter over this chain's address pool; pool addresses
+// are stored normalized, candidates are normalized before the lookup
+func (s *Scanner) watchedAddresses(ctx context.Context) (chain.AddressFilter, error) {
+	var addrs []string
+	if err := s.db.WithContext(ctx).Model(&model.AddressPool{}).
+		Where("chain = ?", s.chain.Name()).
+		Pluck("address", &addrs).Error; err != nil {
+		return nil, err
+	}
+	set := make(map[string]struct{}, len(addrs))
+	for _, a := range addrs {
+		set[a] = struct{}{}
+	}
+	return func(addr string) bool {
+		normalized, err := s.chain.NormalizeAddress(addr)
+		if err != nil {
+			return false
 		}
+		_, ok := set[normalized]
+		return ok
+	}, nil
+}
+
+// store events into DB (onchain_events); events already stored are skipped so
+// replaying a range (restart, reorg rollback) neither fails nor duplicates rows
+func (s *Scanner) persistEvents(ctx context.Context, events []chain.Event) error {
+	evs := make([]model.OnchainEvent, 0, len(events))
+	for _, e := range events {
+		evs = append(evs, model.OnchainEvent{
+			Chain:       s.chain.Name(),
+			BlockNumber: e.BlockNumber,
+			BlockHash:   e.BlockHash,
+			TxHash:      e.TxHash,
+			LogIndex:    e.LogIndex,
+			Address:     e.Address,
+			Topics:      e.Topics,
+			Data:        e.Data,
+			Processed:   false,
+		})
 	}
-	return tx.Commit().Error
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: eventKey, DoNothing: true}).
+		CreateInBatches(&evs, EVENT_BATCH_SIZE).Error
 }
 
 // reorg detection on startup: compare last N processed blocks with chain
@@ -113,7 +119,7 @@ func (s *Scanner) detectAndHandleReorg(ctx context.Context) error {
 	// load last REORG_CHECK_DEPTH processed blocks
 	var pbs []model.ProcessedBlock
 	if err := s.db.WithContext(ctx).
-		Where("chain = ?", CHAIN).
+		Where("chain = ?", s.chain.Name()).
 		Order("block_number desc").
 		Limit(REORG_CHECK_DEPTH).
 		Find(&pbs).Error; err != nil {
@@ -124,16 +130,15 @@ func (s *Scanner) detectAndHandleReorg(ctx context.Context) error {
 	}
 	// iterate from newest to oldest and compare
 	for _, pb := range pbs {
-		num := big.NewInt(pb.BlockNumber)
-		header, err := s.client.HeaderByNumber(ctx, num)
+		hash, err := s.chain.BlockHash(ctx, uint64(pb.BlockNumber))
 		if err != nil {
 			// if RPC cannot find header (e.g. node pruned) skip
 			continue
 		}
-		if header.Hash().Hex() != pb.BlockHash {
+		if hash != pb.BlockHash {
 			// reorg detected: rollback DB entries > header.Number
 			log.Printf("reorg detected at block %d: dbHash=%s chainHash=%s. rolling back above %d",
-				pb.BlockNumber, pb.BlockHash, header.Hash().Hex(), pb.BlockNumber-1)
+				pb.BlockNumber, pb.BlockHash, hash, pb.BlockNumber-1)
 			// delete processed_blocks > pb.BlockNumber-1 and mark events unprocessed
 			if err := s.rollbackToBlock(ctx, pb.BlockNumber-1); err != nil {
 				return err
@@ -146,12 +151,12 @@ func (s *Scanner) detectAndHandleReorg(ctx context.Context) error {
 
 func (s *Scanner) rollbackToBlock(ctx context.Context, blockNumber int64) error {
 	// delete processed blocks above blockNumber
-	if err := s.db.WithContext(ctx).Where("chain = ? AND block_number > ?", CHAIN, blockNumber).Delete(&model.ProcessedBlock{}).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("chain = ? AND block_number > ?", s.chain.Name(), blockNumber).Delete(&model.ProcessedBlock{}).Error; err != nil {
 		return err
 	}
 	// mark onchain_events above blockNumber as unprocessed (so processor will reprocess)
 	if err := s.db.WithContext(ctx).Model(&model.OnchainEvent{}).
-		Where("chain = ? AND block_number > ?", CHAIN, blockNumber).
+		Where("chain = ? AND block_number > ?", s.chain.Name(), blockNumber).
 		Updates(map[string]interface{}{"processed": false}).Error; err != nil {
 		return err
 	}
@@ -203,17 +208,15 @@ func (s *Scanner) adjustStepOnFailure() {
 }
 
 func (s *Scanner) stepOnce(ctx context.Context) error {
-	// get latest
-	header, err := s.client.HeaderByNumber(ctx, nil)
+	// highest block that already has enough confirmations
+	safe, err := s.chain.SafeHeight(ctx)
 	if err != nil {
 		s.adjustStepOnFailure()
 		return err
 	}
-	latest := header.Number.Uint64()
-	if latest <= CONFIRMATIONS {
+	if safe == 0 {
 		return nil
 	}
-	safe := latest - CONFIRMATIONS
 
 	last, err := s.lastProcessedBlock(ctx)
 	if err != nil {
@@ -233,34 +236,33 @@ func (s *Scanner) stepOnce(ctx context.Context) error {
 	end := minUint64(start+step-1, safe)
 	log.Printf("scan range %d -> %d (safe=%d, step=%d)", start, end, safe, step)
 
-	q := ethereum.FilterQuery{
-		FromBlock: big.NewInt(int64(start)),
-		ToBlock:   big.NewInt(int64(end)),
-		// Addresses: []common.Address{...}, // optional: restrict to tokens/contracts you care about
+	watched, err := s.watchedAddresses(ctx)
+	if err != nil {
+		s.adjustStepOnFailure()
+		return err
 	}
-
-	logs, err := s.client.FilterLogs(ctx, q)
+	events, err := s.chain.ScanRange(ctx, start, end, watched)
 	if err != nil {
-		log.Printf("FilterLogs error: %v", err)
+		log.Printf("ScanRange error: %v", err)
 		s.adjustStepOnFailure()
 		return err
 	}
 
-	// persist logs
-	if len(logs) > 0 {
-		if err := s.persistLogs(ctx, logs); err != nil {
+	// persist events
+	if len(events) > 0 {
+		if err := s.persistEvents(ctx, events); err != nil {
 			s.adjustStepOnFailure()
 			return err
 		}
 	}
 
 	// persist processed_blocks entry for 'end'
-	h, err := s.client.HeaderByNumber(ctx, big.NewInt(int64(end)))
+	hash, err := s.chain.BlockHash(ctx, end)
 	if err != nil {
 		// still consider success but skip persisting
 		log.Printf("warning: cannot fetch header for %d: %v", end, err)
 	} else {
-		if err := s.persistProcessedBlock(ctx, int64(end), h.Hash().Hex()); err != nil {
+		if err := s.persistProcessedBlock(ctx, int64(end), hash); err != nil {
 			s.adjustStepOnFailure()
 			return err
 		}
@@ -288,20 +290,3 @@ func (s *Scanner) Run(ctx context.Context) {
 		}
 	}
 }
-
-func main() {
-	rpc := os.Getenv("RPC_URL")
-	if rpc == "" {
-		rpc = RPC_URL
-	}
-	dsn := os.Getenv("DATABASE_DSN")
-	if dsn == "" {
-		dsn = DB_DSN
-	}
-	scanner, err := NewScanner(rpc, dsn)
-	if err != nil {
-		log.Fatalf("new scanner err: %v", err)
-	}
-	ctx := context.Background()
-	scanner.Run(ctx)
-}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/chain/evm"
+	"github.com/crypto_custody/chain/solana"
+	"github.com/crypto_custody/model"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gorm.io/gorm"
+)
+
+// ==========================
+// 充值地址归集
+// ==========================
+
+// SWEEP_BROADCAST_TIMEOUT 广播后超过该时间仍未上链的归集按链处理：交易会过期的链视为失败，下一轮重新归集；
+// 按 nonce 排序的链以相同 nonce 替换，见 resolveStale
+const SWEEP_BROADCAST_TIMEOUT = 30 * time.Minute
+
+// KeySource 按链和 BIP44 索引提供充值地址私钥（SignTx 所需的原始字节）
+type KeySource interface {
+	DepositKey(chainName string, index uint32) ([]byte, error)
+}
+
+// SeedKeySource 从 BIP39 种子派生充值地址私钥
+type SeedKeySource struct {
+	seed []byte
+}
+
+func NewSeedKeySource(seed []byte) *SeedKeySource {
+	return &SeedKeySource{seed: seed}
+}
+
+func (s *SeedKeySource) DepositKey(chainName string, index uint32) ([]byte, error) {
+	c, err := chain.Get(chainName)
+	if err != nil {
+		return nil, err
+	}
+	switch c.CoinType() {
+	case evm.COIN_TYPE:
+		key, err := evm.DeriveKey(s.seed, index)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.FromECDSA(key), nil
+	case solana.COIN_TYPE:
+		key, err := solana.DeriveKey(s.seed, index)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(ed25519.PrivateKey(key)), nil
+	default:
+		return nil, fmt.Errorf("no key derivation for chain %s", chainName)
+	}
+}
+
+// SweepRule 单个币种的归集规则
+type SweepRule struct {
+	Chain         string
+	Token         *string  // nil 表示原生币
+	Currency      string   // 币种，如 "ETH"、"USDT-ERC20"
+	FeeCurrency   string   // 手续费币种（链原生币），用于记账
+	FeeDecimals   int      // 原生币精度，手续费记账时换算
+	HotAddress    string   // 归集目标热钱包
+	Threshold     *big.Int // 余额达到该值才归集
+	Confirmations uint64
+	// TokenPerNative 1 个原生币最小单位折合多少代币最小单位，用于判断代币归集是否为粉尘；
+	// 为 nil 时代币只按 Threshold 判断
+	TokenPerNative *big.Rat
+}
+
+func (r SweepRule) isNative() bool { return r.Token == nil }
+
+type Sweeper struct {
+	db    *gorm.DB
+	keys  KeySource
+	rules []SweepRule
+}
+
+func NewSweeper(db *gorm.DB, keys KeySource, rules []SweepRule) *Sweeper {
+	return &Sweeper{db: db, keys: keys, rules: rules}
+}
+
+// inFlight 该地址该币种是否已有未完成的归集
+func (s *Sweeper) inFlight(ctx context.Context, rule SweepRule, addr string) (bool, error) {
+	q := s.db.WithContext(ctx).Model(&model.Sweep{}).
+		Where("chain = ? AND from_address = ? AND status IN ?", rule.Chain, addr,
+			[]string{model.SWEEP_STATUS_PENDING, model.SWEEP_STATUS_BROADCASTED, model.SWEEP_STATUS_REPLACED})
+	if rule.isNative() {
+		q = q.Where("token IS NULL")
+	} else {
+		q = q.Where("token = ?", *rule.Token)
+	}
+	var count int64
+	err := q.Count(&count).Error
+	return count > 0, err
+}
+
+// isDust 手续费不低于归集金额时跳过
+func (r SweepRule) isDust(amount, fee *big.Int) bool {
+	if r.isNative() {
+		return fee.Cmp(amount) >= 0
+	}
+	if r.TokenPerNative == nil {
+		return false
+	}
+	feeInToken := new(big.Rat).Mul(new(big.Rat).SetInt(fee), r.TokenPerNative)
+	return feeInToken.Cmp(new(big.Rat).SetInt(amount)) >= 0
+}
+
+// SweepOnce 扫描全部规则下的充值地址，余额超过阈值的发起归集
+func (s *Sweeper) SweepOnce(ctx context.Context) error {
+	for _, rule := range s.rules {
+		c, err := chain.Get(rule.Chain)
+		if err != nil {
+			return err
+		}
+		var pool []model.AddressPool
+		if err := s.db.WithContext(ctx).Where("chain = ? AND used = ?", rule.Chain, true).Find(&pool).Error; err != nil {
+			return err
+		}
+		for _, p := range pool {
+			if _, err := s.sweepAddress(ctx, c, rule, p); err != nil {
+				log.Printf("sweep %s %s from %s err: %v", rule.Chain, rule.Currency, p.Address, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SweepAddress 归集单个地址，未满足归集条件时返回 nil
+func (s *Sweeper) SweepAddress(ctx context.Context, rule SweepRule, p model.AddressPool) (*model.Sweep, error) {
+	c, err := chain.Get(rule.Chain)
+	if err != nil {
+		return nil, err
+	}
+	return s.sweepAddress(ctx, c, rule, p)
+}
+
+// sweepable 构造归集交易，返回转账金额与手续费；已有未完成归集、余额未达阈值或为粉尘时 utx 为 nil
+func (s *Sweeper) sweepable(ctx context.Context, c chain.Chain, rule SweepRule, addr string) (utx *chain.UnsignedTx, amount, fee *big.Int, err error) {
+	builder, ok := c.(chain.SweepBuilder)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("chain %s does not support sweeping", c.Name())
+	}
+	busy, err := s.inFlight(ctx, rule, addr)
+	if err != nil || busy {
+		return nil, nil, nil, err
+	}
+	balance, err := c.Balance(ctx, addr, rule.Token)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if balance.Sign() == 0 || balance.Cmp(rule.Threshold) < 0 {
+		return nil, nil, nil, nil
+	}
+
+	// 交易只构造一次，转账金额与手续费取自同一 gas 价格；原生币归集时手续费从余额中扣除
+	utx, amount, fee, err = builder.BuildSweepTx(ctx, chain.TransferRequest{From: addr, To: rule.HotAddress, Token: rule.Token, Amount: balance})
+	if errors.Is(err, chain.ErrFeeExceedsAmount) {
+		log.Printf("skip dust %s on %s: %v", rule.Currency, addr, err)
+		return nil, nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if rule.isDust(balance, fee) {
+		log.Printf("skip dust %s on %s: balance=%s fee=%s", rule.Currency, addr, balance, fee)
+		return nil, nil, nil, nil
+	}
+	return utx, amount, fee, nil
+}
+
+func (s *Sweeper) sweepAddress(ctx context.Context, c chain.Chain, rule SweepRule, p model.AddressPool) (*model.Sweep, error) {
+	utx, amount, fee, err := s.sweepable(ctx, c, rule, p.Address)
+	if err != nil || utx == nil {
+		return nil, err
+	}
+	if !rule.isNative() {
+		// 代币归集的手续费由地址上的原生币支付，不足时等待补充 gas
+		native, err := c.Balance(ctx, p.Address, nil)
+		if err != nil {
+			return nil, err
+		}
+		if native.Cmp(fee) < 0 {
+			log.Printf("awaiting gas for %s on %s: have=%s need=%s", rule.Currency, p.Address, native, fee)
+			return nil, nil
+		}
+	}
+
+	sweep := model.Sweep{
+		Chain:       rule.Chain,
+		Token:       rule.Token,
+		FromAddress: p.Address,
+		ToAddress:   rule.HotAddress,
+		Amount:      amount.String(),
+		Fee:         fee.String(),
+	}
+	if replacer, ok := c.(chain.NonceReplacer); ok {
+		nonce, err := replacer.TxNonce(utx)
+		if err != nil {
+			return nil, err
+		}
+		sweep.Nonce = &nonce
+	}
+	if err := s.broadcast(ctx, c, &sweep, utx, p.HDIndex, nil); err != nil {
+		return nil, err
+	}
+	log.Printf("sweep #%d %s %s from %s tx=%s", sweep.ID, amount, rule.Currency, p.Address, *sweep.TxHash)
+	return &sweep, nil
+}
+
+// broadcast 签名并广播归集交易，归集记录在广播前落库；replaces 非 nil 时同一事务中将其置为 replaced。
+// 有 nonce 的交易先记录哈希再广播，广播出错时交易可能已被节点接收，保持 broadcasted，
+// 由 ConfirmOnce 按回执与 nonce 处理；其他交易广播出错时置为 failed
+func (s *Sweeper) broadcast(ctx context.Context, c chain.Chain, sweep *model.Sweep, utx *chain.UnsignedTx, index uint32, replaces *model.Sweep) error {
+	key, err := s.keys.DepositKey(c.Name(), index)
+	if err != nil {
+		return err
+	}
+	signed, err := c.SignTx(utx, key)
+	if err != nil {
+		return err
+	}
+	hasher, tracked := c.(chain.TxHasher)
+	tracked = tracked && sweep.Nonce != nil
+	sweep.Status = model.SWEEP_STATUS_PENDING
+	if tracked {
+		txHash, err := hasher.SignedTxHash(signed)
+		if err != nil {
+			return err
+		}
+		sweep.Status = model.SWEEP_STATUS_BROADCASTED
+		sweep.TxHash = &txHash
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sweep).Error; err != nil {
+			return err
+		}
+		if replaces == nil {
+			return nil
+		}
+		res := tx.Model(replaces).Where("status = ?", model.SWEEP_STATUS_BROADCASTED).Update("status", model.SWEEP_STATUS_REPLACED)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("sweep #%d is no longer broadcasted", replaces.ID)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	txHash, err := c.Broadcast(ctx, signed)
+	if err != nil {
+		fields := map[string]interface{}{"error": err.Error()}
+		if !tracked {
+			fields["status"] = model.SWEEP_STATUS_FAILED
+		}
+		s.db.WithContext(ctx).Model(sweep).Updates(fields)
+		return err
+	}
+	if tracked {
+		return nil
+	}
+	sweep.Status = model.SWEEP_STATUS_BROADCASTED
+	sweep.TxHash = &txHash
+	return s.db.WithContext(ctx).Model(sweep).Updates(map[string]interface{}{
+		"status":  model.SWEEP_STATUS_BROADCASTED,
+		"tx_hash": txHash,
+	}).Error
+}
+
+func (s *Sweeper) ruleFor(sweep model.Sweep) (SweepRule, bool) {
+	for _, r := range s.rules {
+		if r.Chain != sweep.Chain {
+			continue
+		}
+		if (r.Token == nil && sweep.Token == nil) || (r.Token != nil && sweep.Token != nil && *r.Token == *sweep.Token) {
+			return r, true
+		}
+	}
+	return SweepRule{}, false
+}
+
+// ConfirmOnce 检查已广播归集的确认数，确认后记录手续费流水
+func (s *Sweeper) ConfirmOnce(ctx context.Context) error {
+	var sweeps []model.Sweep
+	if err := s.db.WithContext(ctx).Where("status IN ?", awaitingSweepStatuses).Order("id").Find(&sweeps).Error; err != nil {
+		return err
+	}
+	for _, sw := range sweeps {
+		rule, ok := s.ruleFor(sw)
+		if !ok {
+			continue
+		}
+		c, err := chain.Get(sw.Chain)
+		if err != nil {
+			return err
+		}
+		confirmations, err := c.Confirmations(ctx, *sw.TxHash)
+		if errors.Is(err, chain.ErrTxFailed) {
+			// 交易回滚或已过期，资金仍在充值地址，下一轮重新归集
+			s.fail(ctx, sw, err.Error())
+			continue
+		}
+		if err != nil {
+			log.Printf("sweep #%d confirmations err: %v", sw.ID, err)
+			continue
+		}
+		if confirmations == 0 {
+			if time.Since(sw.UpdatedAt) > SWEEP_BROADCAST_TIMEOUT {
+				if err := s.resolveStale(ctx, c, rule, sw); err != nil {
+					log.Printf("sweep #%d stale err: %v", sw.ID, err)
+				}
+			}
+			continue
+		}
+		if confirmations < rule.Confirmations {
+			continue
+		}
+		if err := s.confirm(ctx, c, rule, sw); err != nil {
+			log.Printf("sweep #%d confirm err: %v", sw.ID, err)
+		}
+	}
+	return nil
+}
+
+// awaitingSweepStatuses 已广播、等待上链的归集
+var awaitingSweepStatuses = []string{model.SWEEP_STATUS_BROADCASTED, model.SWEEP_STATUS_REPLACED}
+
+func (s *Sweeper) fail(ctx context.Context, sw model.Sweep, reason string) {
+	s.db.WithContext(ctx).Model(&sw).Where("status IN ?", awaitingSweepStatuses).
+		Updates(map[string]interface{}{"status": model.SWEEP_STATUS_FAILED, "error": reason})
+}
+
+// resolveStale 处理广播后超过 SWEEP_BROADCAST_TIMEOUT 仍未上链的归集。
+// 交易有有效期的链（Solana blockhash）此时交易已不会上链，置为 failed，下一轮重新归集；
+// 按 nonce 排序的链（EVM）交易仍可能上链：nonce 已被其他交易占用时置为 failed，否则以相同 nonce 替换
+func (s *Sweeper) resolveStale(ctx context.Context, c chain.Chain, rule SweepRule, sw model.Sweep) error {
+	replacer, ok := c.(chain.NonceReplacer)
+	if !ok || sw.Nonce == nil {
+		s.fail(ctx, sw, fmt.Sprintf("not on chain %s after broadcast", SWEEP_BROADCAST_TIMEOUT))
+		return nil
+	}
+	// 先读 nonce 再查回执：nonce 已被占用而本交易仍无回执，说明同 nonce 的另一笔交易已上链，本交易不会再上链
+	mined, err := replacer.MinedNonce(ctx, sw.FromAddress)
+	if err != nil {
+		return err
+	}
+	if mined > *sw.Nonce {
+		confirmations, err := c.Confirmations(ctx, *sw.TxHash)
+		if err != nil || confirmations > 0 {
+			return err
+		}
+		s.fail(ctx, sw, fmt.Sprintf("nonce %d used by another transaction", *sw.Nonce))
+		return nil
+	}
+	if sw.Status == model.SWEEP_STATUS_REPLACED {
+		return nil // 只替换最新的一笔
+	}
+
+	var p model.AddressPool
+	if err := s.db.WithContext(ctx).Where("chain = ? AND address = ?", sw.Chain, sw.FromAddress).First(&p).Error; err != nil {
+		return err
+	}
+	balance, err := c.Balance(ctx, sw.FromAddress, sw.Token)
+	if err != nil {
+		return err
+	}
+	prevFee, ok := new(big.Int).SetString(sw.Fee, 10)
+	if !ok {
+		return fmt.Errorf("invalid fee %q", sw.Fee)
+	}
+	utx, amount, fee, err := replacer.ReplaceSweepTx(ctx, chain.TransferRequest{From: sw.FromAddress, To: sw.ToAddress, Token: sw.Token, Amount: balance}, *sw.Nonce, prevFee)
+	if err != nil {
+		return err
+	}
+	replacement := model.Sweep{
+		Chain:       sw.Chain,
+		Token:       sw.Token,
+		FromAddress: sw.FromAddress,
+		ToAddress:   sw.ToAddress,
+		Amount:      amount.String(),
+		Fee:         fee.String(),
+		Nonce:       sw.Nonce,
+	}
+	if err := s.broadcast(ctx, c, &replacement, utx, p.HDIndex, &sw); err != nil {
+		return err
+	}
+	log.Printf("sweep #%d replaced by #%d %s %s nonce=%d tx=%s", sw.ID, replacement.ID, amount, rule.Currency, *sw.Nonce, *replacement.TxHash)
+	return nil
+}
+
+// confirm 归集确认与手续费记账在同一事务，手续费按链上实际消耗记账；同 nonce 的其他归集不会再上链，置为 failed
+func (s *Sweeper) confirm(ctx context.Context, c chain.Chain, rule SweepRule, sw model.Sweep) error {
+	fee, _ := new(big.Int).SetString(sw.Fee, 10)
+	if r, ok := c.(chain.FeeReader); ok {
+		actual, err := r.TxFee(ctx, *sw.TxHash)
+		if err != nil {
+			return fmt.Errorf("read fee of %s: %w", *sw.TxHash, err)
+		}
+		fee = actual
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&sw).Where("status IN ?", awaitingSweepStatuses).
+			Updates(map[string]interface{}{"status": model.SWEEP_STATUS_CONFIRMED, "fee": fee.String()})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if sw.Nonce != nil {
+			siblings := func() *gorm.DB {
+				return tx.Model(&model.Sweep{}).
+					Where("chain = ? AND from_address = ? AND nonce = ? AND id <> ? AND status IN ?", sw.Chain, sw.FromAddress, *sw.Nonce, sw.ID, awaitingSweepStatuses)
+			}
+			// 补 gas 记录改为关联实际上链的一笔
+			if err := tx.Model(&model.GasTopUp{}).Where("sweep_id IN (?)", siblings().Select("id")).
+				Update("sweep_id", sw.ID).Error; err != nil {
+				return err
+			}
+			if err := siblings().Updates(map[string]interface{}{
+				"status": model.SWEEP_STATUS_FAILED,
+				"error":  fmt.Sprintf("nonce %d used by sweep #%d", *sw.Nonce, sw.ID),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&model.WalletDeposit{
+			UserID:   0, // 平台账户
+			Currency: rule.FeeCurrency,
+			RefID:    uint64(sw.ID),
+			Type:     3, // 手续费
+			Amount:   toDecimal(fee, rule.FeeDecimals),
+			Status:   1,
+		}).Error
+	})
+}
+
+// toDecimal 最小单位换算为带精度的数值
+func toDecimal(amount *big.Int, decimals int) float64 {
+	if amount == nil {
+		return 0
+	}
+	f, _ := new(big.Rat).SetFrac(amount, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)).Float64()
+	return f
+}
+
+// Run 定期归集并检查确认
+func (s *Sweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.ConfirmOnce(ctx); err != nil {
+			log.Printf("sweep confirm err: %v", err)
+		}
+		if err := s.SweepOnce(ctx); err != nil {
+			log.Printf("sweep err: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
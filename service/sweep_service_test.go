@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+const (
+	TEST_SWEEP_CHAIN = "sweeptest"
+	TEST_SWEEP_GAS   = 10 // 每笔归集的 gas 上限
+)
+
+// sweepTx 假链的交易内容，签名结果即为其 JSON
+type sweepTx struct {
+	From   string
+	Amount int64
+	Nonce  uint64
+	Fee    int64
+}
+
+// nonceChain 按 nonce 排序交易的假链：余额按 "地址" 或 "代币:地址" 查表，pending 为各地址下一个待用 nonce，
+// mined 为已上链 nonce，confirmations 为各交易的确认数，gasPrice 为当前建议 gas 价格
+type nonceChain struct {
+	chain.Chain
+	balances      map[string]int64
+	pending       map[string]uint64
+	mined         map[string]uint64
+	confirmations map[string]uint64
+	gasPrice      int64
+	sent          []string
+}
+
+func (c *nonceChain) Name() string { return TEST_SWEEP_CHAIN }
+
+func (c *nonceChain) Balance(ctx context.Context, addr string, token *string) (*big.Int, error) {
+	if token != nil {
+		addr = *token + ":" + addr
+	}
+	return big.NewInt(c.balances[addr]), nil
+}
+
+func (c *nonceChain) EstimateFee(ctx context.Context, req chain.TransferRequest) (*big.Int, error) {
+	return big.NewInt(c.gasPrice * TEST_SWEEP_GAS), nil
+}
+
+func (c *nonceChain) BuildTx(ctx context.Context, req chain.TransferRequest) (*chain.UnsignedTx, error) {
+	payload, err := json.Marshal(sweepTx{From: req.From, Amount: req.Amount.Int64(), Nonce: c.pending[req.From], Fee: c.gasPrice * TEST_SWEEP_GAS})
+	if err != nil {
+		return nil, err
+	}
+	return &chain.UnsignedTx{Chain: c.Name(), From: req.From, Payload: payload}, nil
+}
+
+// build 原生币归集转出余额减去手续费，代币归集转出全部余额
+func (c *nonceChain) build(req chain.TransferRequest, nonce uint64, gasPrice int64) (*chain.UnsignedTx, *big.Int, *big.Int, error) {
+	fee := gasPrice * TEST_SWEEP_GAS
+	amount := req.Amount.Int64()
+	if req.Token == nil {
+		amount -= fee
+	}
+	if amount <= 0 {
+		return nil, nil, nil, chain.ErrFeeExceedsAmount
+	}
+	payload, err := json.Marshal(sweepTx{From: req.From, Amount: amount, Nonce: nonce, Fee: fee})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return &chain.UnsignedTx{Chain: c.Name(), From: req.From, Payload: payload}, big.NewInt(amount), big.NewInt(fee), nil
+}
+
+func (c *nonceChain) BuildSweepTx(ctx context.Context, req chain.TransferRequest) (*chain.UnsignedTx, *big.Int, *big.Int, error) {
+	return c.build(req, c.pending[req.From], c.gasPrice)
+}
+
+func (c *nonceChain) ReplaceSweepTx(ctx context.Context, req chain.TransferRequest, nonce uint64, prevFee *big.Int) (*chain.UnsignedTx, *big.Int, *big.Int, error) {
+	price := prevFee.Int64()*2/TEST_SWEEP_GAS + 1
+	if price < c.gasPrice {
+		price = c.gasPrice
+	}
+	return c.build(req, nonce, price)
+}
+
+func (c *nonceChain) TxNonce(utx *chain.UnsignedTx) (uint64, error) {
+	var tx sweepTx
+	err := json.Unmarshal(utx.Payload, &tx)
+	return tx.Nonce, err
+}
+
+func (c *nonceChain) MinedNonce(ctx context.Context, addr string) (uint64, error) {
+	return c.mined[addr], nil
+}
+
+func (c *nonceChain) SignTx(utx *chain.UnsignedTx, privateKey []byte) ([]byte, error) {
+	return utx.Payload, nil
+}
+
+func (c *nonceChain) SignedTxHash(signed []byte) (string, error) {
+	var tx sweepTx
+	if err := json.Unmarshal(signed, &tx); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tx-%d-%d", tx.Nonce, tx.Fee), nil
+}
+
+func (c *nonceChain) Broadcast(ctx context.Context, signed []byte) (string, error) {
+	hash, err := c.SignedTxHash(signed)
+	if err != nil {
+		return "", err
+	}
+	c.sent = append(c.sent, hash)
+	return hash, nil
+}
+
+func (c *nonceChain) Confirmations(ctx context.Context, txHash string) (uint64, error) {
+	return c.confirmations[txHash], nil
+}
+
+type staticKeys struct{}
+
+func (staticKeys) DepositKey(chainName string, index uint32) ([]byte, error) { return []byte{1}, nil }
+
+func sweepRows(t *testing.T, db *gorm.DB) []model.Sweep {
+	t.Helper()
+	var sweeps []model.Sweep
+	if err := db.Order("id").Find(&sweeps).Error; err != nil {
+		t.Fatal(err)
+	}
+	return sweeps
+}
+
+func ageSweeps(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.Model(&model.Sweep{}).Where("1 = 1").UpdateColumn("updated_at", time.Now().Add(-SWEEP_BROADCAST_TIMEOUT-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 超时未上链的归集以相同 nonce 替换而不是另发新交易；原交易之后上链时替换交易置为 failed，
+// nonce 被占用而本交易无回执时置为 failed，下一轮重新归集
+func TestSweepReplacedByNonce(t *testing.T) {
+	db := testdb.Open(t, "sweeps", "address_pools", "gas_top_ups", "wallet_deposits")
+	ctx := context.Background()
+	c := &nonceChain{
+		balances:      map[string]int64{TEST_DEPOSIT_ADDRESS: 1000},
+		pending:       map[string]uint64{},
+		mined:         map[string]uint64{},
+		confirmations: map[string]uint64{},
+		gasPrice:      1,
+	}
+	chain.Register(c)
+	createRows(t, db, &model.AddressPool{Chain: TEST_SWEEP_CHAIN, Address: TEST_DEPOSIT_ADDRESS, Used: true})
+	s := NewSweeper(db, staticKeys{}, []SweepRule{{
+		Chain: TEST_SWEEP_CHAIN, Currency: "STEST", FeeCurrency: "STEST", HotAddress: TEST_HOT_WALLET,
+		Threshold: big.NewInt(1), Confirmations: 1,
+	}})
+	step := func(sweep bool) {
+		t.Helper()
+		if err := s.ConfirmOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if !sweep {
+			return
+		}
+		if err := s.SweepOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(want ...string) {
+		t.Helper()
+		var got []string
+		for _, sw := range sweepRows(t, db) {
+			got = append(got, fmt.Sprintf("%s:%s:%d", *sw.TxHash, sw.Status, *sw.Nonce))
+		}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Fatalf("sweeps = %v, want %v", got, want)
+		}
+	}
+
+	step(true)
+	check("tx-0-10:broadcasted:0")
+	step(true)
+	check("tx-0-10:broadcasted:0")
+
+	// 超时未上链：以相同 nonce、更高手续费替换，不重新归集
+	ageSweeps(t, db)
+	step(true)
+	check("tx-0-10:replaced:0", "tx-0-30:broadcasted:0")
+
+	// 原交易最终上链
+	c.mined[TEST_DEPOSIT_ADDRESS] = 1
+	c.pending[TEST_DEPOSIT_ADDRESS] = 1
+	c.confirmations["tx-0-10"] = 1
+	c.balances[TEST_DEPOSIT_ADDRESS] = 0
+	step(true)
+	check("tx-0-10:confirmed:0", "tx-0-30:failed:0")
+	if len(c.sent) != 2 {
+		t.Fatalf("sent = %v, want the sweep and one replacement", c.sent)
+	}
+
+	// 新充值归集后 nonce 被其他交易占用且本交易无回执：置为 failed，下一轮按新 nonce 重新归集
+	c.balances[TEST_DEPOSIT_ADDRESS] = 500
+	step(true)
+	c.mined[TEST_DEPOSIT_ADDRESS] = 2
+	c.pending[TEST_DEPOSIT_ADDRESS] = 2
+	ageSweeps(t, db)
+	step(true)
+	check("tx-0-10:confirmed:0", "tx-0-30:failed:0", "tx-1-10:failed:1", "tx-2-10:broadcasted:2")
+}
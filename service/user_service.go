@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/crypto_custody/auth"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/outbox"
+	"gorm.io/gorm"
+)
+
+var ErrUserNotFound = errors.New("用户不存在")
+
+// LoginResult 登录结果：开启二次验证的用户只拿到登录挑战，凭验证码换取令牌
+type LoginResult struct {
+	UserID            uint64     `json:"userId"`
+	Token             *auth.Pair `json:"token,omitempty"`
+	TwoFactorRequired bool       `json:"twoFactorRequired,omitempty"`
+	Challenge         string     `json:"challenge,omitempty"`
+}
+
+// UserService 注册、登录与会话
+type UserService struct {
+	db        *gorm.DB
+	tokens    *auth.Tokens
+	twoFactor *auth.TwoFactor
+}
+
+func NewUserService(db *gorm.DB, tokens *auth.Tokens, twoFactor *auth.TwoFactor) *UserService {
+	return &UserService{db: db, tokens: tokens, twoFactor: twoFactor}
+}
+
+// Register 注册用户，用户与 UserRegistered 事件同事务写入
+func (s *UserService) Register(ctx context.Context, email, phone, password string) (*model.User, error) {
+	if err := auth.ValidatePassword(password); err != nil {
+		return nil, err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user := model.User{Email: email, Phone: phone, Password: hash}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(tx, outbox.TOPIC_USER_REGISTERED, strconv.FormatUint(user.ID, 10), outbox.UserRegistered{
+			UserID: user.ID,
+			Email:  user.Email,
+			Phone:  user.Phone,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login 邮箱或手机号加密码登录
+func (s *UserService) Login(ctx context.Context, emailOrPhone, password, userAgent, ip string) (*LoginResult, error) {
+	var user model.User
+	if err := s.db.WithContext(ctx).Where("email=? OR phone=?", emailOrPhone, emailOrPhone).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return nil, auth.CheckMissingUser(password)
+	}
+
+	needRehash, err := auth.CheckPassword(user.Password, password)
+	if err != nil {
+		return nil, err
+	}
+	// 历史明文密码在首次登录成功时改为哈希存储
+	if needRehash {
+		if hash, err := auth.HashPassword(password); err == nil {
+			if err := s.db.WithContext(ctx).Model(&user).Update("password", hash).Error; err != nil {
+				log.Printf("rehash password for user %d err: %v", user.ID, err)
+			}
+		}
+	}
+
+	enabled, err := s.twoFactor.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		challenge, err := s.tokens.Challenge(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{UserID: user.ID, TwoFactorRequired: true, Challenge: challenge}, nil
+	}
+
+	pair, err := s.tokens.Issue(ctx, user.ID, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{UserID: user.ID, Token: pair}, nil
+}
+
+// LoginTwoFactor 登录第二步：校验登录挑战与 TOTP 验证码（或恢复码）
+func (s *UserService) LoginTwoFactor(ctx context.Context, challenge, code, userAgent, ip string) (*LoginResult, error) {
+	userID, err := s.tokens.ParseChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactor.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	pair, err := s.tokens.Issue(ctx, userID, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{UserID: userID, Token: pair}, nil
+}
+
+// Refresh 用刷新令牌换取新的令牌，旧刷新令牌立即作废
+func (s *UserService) Refresh(ctx context.Context, refreshToken string) (*auth.Pair, error) {
+	return s.tokens.Refresh(ctx, refreshToken)
+}
+
+// Logout 注销当前会话；all 为 true 时注销该用户全部会话
+func (s *UserService) Logout(ctx context.Context, userID uint64, sessionID string, all bool) error {
+	if all {
+		return s.tokens.RevokeAll(ctx, userID)
+	}
+	return s.tokens.Revoke(ctx, userID, sessionID)
+}
+
+// Get 查询用户
+func (s *UserService) Get(ctx context.Context, userID uint64) (*model.User, error) {
+	var user model.User
+	if err := s.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// EnrollTwoFactor 生成 TOTP 密钥，账户名取邮箱或手机号
+func (s *UserService) EnrollTwoFactor(ctx context.Context, userID uint64) (secret, uri string, err error) {
+	user, err := s.Get(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+	return s.twoFactor.Enroll(ctx, user.ID, account)
+}
+
+// ConfirmTwoFactor 用第一个验证码确认开启，返回恢复码（只显示一次）
+func (s *UserService) ConfirmTwoFactor(ctx context.Context, userID uint64, code string) ([]string, error) {
+	return s.twoFactor.Confirm(ctx, userID, code)
+}
+
+// DisableTwoFactor 关闭二次验证，同时注销全部会话
+func (s *UserService) DisableTwoFactor(ctx context.Context, userID uint64, code string) error {
+	if err := s.twoFactor.Disable(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.tokens.RevokeAll(ctx, userID)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID uint64, code string) ([]string, error) {
+	return s.twoFactor.RegenerateRecoveryCodes(ctx, userID, code)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"github.com/crypto_custody/address"
 	"github.com/crypto_custody/model"
 	"github.com/crypto_custody/repository"
 )
@@ -11,17 +12,32 @@ type WalletService struct {
 	depositRepo     *repository.DepositRepository
 	withdrawRepo    *repository.WithdrawRepository
 	transactionRepo *repository.TransactionRepository
+	addrChecker     *address.Checker
+	addressBook     *AddressBookService
+	gate            WithdrawalGate
+	tiers           KYCTierLookup
+	limits          TierLimits
 }
 
 func NewWalletService(addr *repository.AddressRepository,
 	dep *repository.DepositRepository,
 	withd *repository.WithdrawRepository,
-	tx *repository.TransactionRepository) *WalletService {
+	tx *repository.TransactionRepository,
+	checker *address.Checker,
+	book *AddressBookService,
+	gate WithdrawalGate,
+	tiers KYCTierLookup,
+	limits TierLimits) *WalletService {
 	return &WalletService{
 		addressRepo:     addr,
 		depositRepo:     dep,
 		withdrawRepo:    withd,
 		transactionRepo: tx,
+		addrChecker:     checker,
+		addressBook:     book,
+		gate:            gate,
+		tiers:           tiers,
+		limits:          limits,
 	}
 }
 
@@ -31,20 +47,50 @@ func (s *WalletService) GetDepositAddress(userID uint64, currency string) (*mode
 }
 
 // 提交提现请求
-func (s *WalletService) RequestWithdraw(userID uint64, currency, address string, amount float64) (*model.WalletWithdraw, error) {
+func (s *WalletService) RequestWithdraw(ctx context.Context, userID uint64, currency, addr string, amount float64) (*model.WalletWithdraw, error) {
+	// 对账差额超限时该币种暂停提现
+	if s.gate != nil {
+		if err := s.gate.CheckWithdrawalAllowed(ctx, currency); err != nil {
+			return nil, err
+		}
+	}
+	// 目标地址校验：格式/校验和/网络、零地址、自有地址、合约地址
+	if err := s.addrChecker.Check(ctx, currency, addr); err != nil {
+		return nil, err
+	}
+	addr, err := address.NormalizeForCurrency(currency, addr)
+	if err != nil {
+		return nil, err
+	}
+	// 白名单模式：只能提现到地址簿中已确认且过了锁定期的地址
+	if err := s.addressBook.CheckWithdrawAddress(ctx, userID, currency, addr); err != nil {
+		return nil, err
+	}
 	withdraw := &model.WalletWithdraw{
 		UserID:   userID,
 		Currency: currency,
-		Address:  address,
+		Address:  addr,
 		Amount:   amount,
 		Status:   0, // 待处理
 	}
-	if err := s.withdrawRepo.Create(withdraw); err != nil {
+	// KYC 等级日/月限额，与写入在同一事务中
+	err = s.withdrawRepo.Transaction(ctx, func(repo *repository.WithdrawRepository) error {
+		if err := s.checkWithdrawLimit(ctx, repo, userID, currency, amount); err != nil {
+			return err
+		}
+		return repo.Create(withdraw)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return withdraw, nil
 }
 
+// 地址簿
+func (s *WalletService) AddressBook() *AddressBookService {
+	return s.addressBook
+}
+
 // 查询充值记录
 func (s *WalletService) GetDepositHistory(ctx context.Context, userId uint64, currency string, page, size int) ([]*model.WalletTransaction, int64, error) {
 	return s.depositRepo.ListDeposits(ctx, userId, currency, page, size)
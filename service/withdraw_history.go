@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+// WithdrawalHistory 基于提现表实现风控所需的历史查询，被拒绝 / 失败的提现不计入
+type WithdrawalHistory struct {
+	db *gorm.DB
+}
+
+func NewWithdrawalHistory(db *gorm.DB) *WithdrawalHistory {
+	return &WithdrawalHistory{db: db}
+}
+
+var countedWithdrawalStatuses = []string{"pending", "approving", "approved", "signing", "broadcasting", "unconfirmed", "success"}
+
+type withdrawalTotals struct {
+	Count int64
+	Total string
+}
+
+func (h *WithdrawalHistory) totals(q *gorm.DB) (int64, *big.Int, error) {
+	var t withdrawalTotals
+	if err := q.Model(&model.Withdrawal{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(CAST(amount AS NUMERIC)), 0)::TEXT AS total").
+		Where("status IN ?", countedWithdrawalStatuses).
+		Scan(&t).Error; err != nil {
+		return 0, nil, err
+	}
+	total, ok := new(big.Int).SetString(t.Total, 10)
+	if !ok {
+		return 0, nil, errors.New("invalid withdrawal total: " + t.Total)
+	}
+	return t.Count, total, nil
+}
+
+func (h *WithdrawalHistory) UserWithdrawals(ctx context.Context, userID uint64, currency string, since time.Time) (int64, *big.Int, error) {
+	return h.totals(h.db.WithContext(ctx).Where("user_id = ? AND currency = ? AND created_at >= ?", userID, currency, since))
+}
+
+func (h *WithdrawalHistory) GlobalWithdrawals(ctx context.Context, currency string, since time.Time) (int64, *big.Int, error) {
+	return h.totals(h.db.WithContext(ctx).Where("currency = ? AND created_at >= ?", currency, since))
+}
+
+func (h *WithdrawalHistory) FirstWithdrawalTo(ctx context.Context, userID uint64, to string) (time.Time, bool, error) {
+	var w model.Withdrawal
+	err := h.db.WithContext(ctx).
+		Where("user_id = ? AND address = ? AND status IN ?", userID, to, countedWithdrawalStatuses).
+		Order("created_at asc").
+		First(&w).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return w.CreatedAt, true, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/crypto_custody/repository"
+)
+
+// ==========================
+// KYC 等级提现限额
+// ==========================
+// 每个等级按币种配置日、月提现限额（主单位），按 UTC 自然日、自然月累计未失败的提现。
+// 未配置的等级或币种不允许提现。检查与写入提现申请在同一事务中，并锁定用户行。
+
+// UNLIMITED 不限额
+const UNLIMITED = -1
+
+var ErrWithdrawLimitExceeded = errors.New("超出 KYC 等级提现限额")
+
+// KYCTierLookup 查询用户当前 KYC 等级，由 kyc.Service 实现
+type KYCTierLookup interface {
+	Tier(ctx context.Context, userID uint64) (string, error)
+}
+
+// TierLimit 单个币种的限额，UNLIMITED 表示不限
+type TierLimit struct {
+	Daily   float64
+	Monthly float64
+}
+
+// TierLimits 等级 -> 币种 -> 限额
+type TierLimits map[string]map[string]TierLimit
+
+// LimitExceededError 说明触发的是哪个限额及剩余额度
+type LimitExceededError struct {
+	Tier      string    `json:"tier"`
+	Currency  string    `json:"currency"`
+	Period    string    `json:"period"` // daily / monthly
+	Limit     float64   `json:"limit"`
+	Used      float64   `json:"used"`
+	Remaining float64   `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+func (e *LimitExceededError) Error() string {
+	period := "日"
+	if e.Period == "monthly" {
+		period = "月"
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return fmt.Sprintf("%s: %s 等级 %s %s限额 %s，已用 %s，剩余 %s，%s 重置",
+		ErrWithdrawLimitExceeded, e.Tier, e.Currency, period, f(e.Limit), f(e.Used), f(e.Remaining),
+		e.ResetAt.Format(time.RFC3339))
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrWithdrawLimitExceeded
+}
+
+// checkWithdrawLimit 本次提现加上本日/本月已提现金额不能超过等级限额，金额按十进制精确比较。
+// 在 repo 的事务中锁定用户行，调用方在同一事务中写入提现申请，同一用户的并发申请不能合计超限
+func (s *WalletService) checkWithdrawLimit(ctx context.Context, repo *repository.WithdrawRepository, userID uint64, currency string, amount float64) error {
+	if s.tiers == nil {
+		return nil
+	}
+	tier, err := s.tiers.Tier(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := repo.LockUser(ctx, userID); err != nil {
+		return err
+	}
+	// 未配置即限额为 0
+	limit := s.limits[tier][currency]
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	periods := []struct {
+		name    string
+		limit   float64
+		since   time.Time
+		resetAt time.Time
+	}{
+		{"daily", limit.Daily, dayStart, dayStart.AddDate(0, 0, 1)},
+		{"monthly", limit.Monthly, monthStart, monthStart.AddDate(0, 1, 0)},
+	}
+	for _, p := range periods {
+		if p.limit == UNLIMITED {
+			continue
+		}
+		used, err := repo.SumSince(ctx, userID, currency, p.since)
+		if err != nil {
+			return err
+		}
+		allowed := decimal(p.limit)
+		if new(big.Rat).Add(used, decimal(amount)).Cmp(allowed) > 0 {
+			remaining := new(big.Rat).Sub(allowed, used)
+			if remaining.Sign() < 0 {
+				remaining.SetInt64(0)
+			}
+			usedF, _ := used.Float64()
+			remainingF, _ := remaining.Float64()
+			return &LimitExceededError{
+				Tier:      tier,
+				Currency:  currency,
+				Period:    p.name,
+				Limit:     p.limit,
+				Used:      usedF,
+				Remaining: remainingF,
+				ResetAt:   p.resetAt,
+			}
+		}
+	}
+	return nil
+}
+
+// decimal 金额的十进制精确值：取 float64 的最短十进制表示，0.1 即 1/10
+func decimal(f float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	return r
+}
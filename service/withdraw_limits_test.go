@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/repository"
+)
+
+// tierMap 按用户查 KYC 等级，未登记的用户为 "none"（未配置限额）
+type tierMap struct {
+	mu    sync.Mutex
+	tiers map[uint64]string
+}
+
+func (m *tierMap) Tier(ctx context.Context, userID uint64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if tier, ok := m.tiers[userID]; ok {
+		return tier, nil
+	}
+	return "none", nil
+}
+
+func (m *tierMap) set(userID uint64, tier string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tiers[userID] = tier
+}
+
+func newLimitFixture(t *testing.T, users ...uint64) (*WalletService, *tierMap) {
+	t.Helper()
+	db := testdb.Open(t, "users", "wallet_withdraws", "withdraw_settings", "address_book_entries")
+	address.RegisterChain(TEST_WITHDRAW_CHAIN, address.ChainEthereum)
+	address.RegisterCurrency(TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_CHAIN)
+	for _, id := range users {
+		if err := db.Create(&model.User{ID: id, Email: fmt.Sprintf("user%d@example.com", id)}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	tiers := &tierMap{tiers: map[uint64]string{}}
+	limits := TierLimits{
+		"basic":    {TEST_WITHDRAW_CURRENCY: {Daily: 0.3, Monthly: 10}},
+		"monthly":  {TEST_WITHDRAW_CURRENCY: {Daily: 10, Monthly: 1}},
+		"advanced": {TEST_WITHDRAW_CURRENCY: {Daily: UNLIMITED, Monthly: UNLIMITED}},
+	}
+	checker := address.NewChecker(repository.NewAddressRepository(db))
+	wallet := NewWalletService(repository.NewAddressRepository(db), repository.NewDepositRepository(db),
+		repository.NewWithdrawRepository(db), repository.NewTransactionRepository(db), checker,
+		NewAddressBookService(repository.NewAddressBookRepository(db), checker, nil, 0), nil, tiers, limits)
+	return wallet, tiers
+}
+
+// 日、月限额按十进制精确累计（0.1 + 0.2 恰好等于 0.3），超限时说明是哪个周期及剩余额度
+func TestWithdrawLimitPeriods(t *testing.T) {
+	wallet, tiers := newLimitFixture(t, 1, 2)
+	ctx := context.Background()
+	tiers.set(1, "basic")
+	tiers.set(2, "monthly")
+
+	tests := []struct {
+		user      uint64
+		amount    float64
+		period    string // 为空表示通过
+		remaining float64
+	}{
+		{1, 0.1, "", 0},
+		{1, 0.2, "", 0},
+		{1, 0.1, "daily", 0},
+		{2, 0.6, "", 0},
+		{2, 0.6, "monthly", 0.4},
+		{2, 0.4, "", 0},
+	}
+	for i, tt := range tests {
+		_, err := wallet.RequestWithdraw(ctx, tt.user, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, tt.amount)
+		if tt.period == "" {
+			if err != nil {
+				t.Fatalf("#%d user %d amount %v: %v", i, tt.user, tt.amount, err)
+			}
+			continue
+		}
+		var limitErr *LimitExceededError
+		if !errors.As(err, &limitErr) || !errors.Is(err, ErrWithdrawLimitExceeded) {
+			t.Fatalf("#%d user %d amount %v err = %v, want %s limit exceeded", i, tt.user, tt.amount, err, tt.period)
+		}
+		if limitErr.Period != tt.period || limitErr.Remaining != tt.remaining {
+			t.Fatalf("#%d period = %s remaining = %v, want %s and %v", i, limitErr.Period, limitErr.Remaining, tt.period, tt.remaining)
+		}
+	}
+}
+
+// 未配置限额的等级不能提现，KYC 升级到不限额等级后可以
+func TestWithdrawLimitKYCTier(t *testing.T) {
+	wallet, tiers := newLimitFixture(t, 1)
+	ctx := context.Background()
+
+	if _, err := wallet.RequestWithdraw(ctx, 1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, 0.01); !errors.Is(err, ErrWithdrawLimitExceeded) {
+		t.Fatalf("unconfigured tier err = %v, want %v", err, ErrWithdrawLimitExceeded)
+	}
+	tiers.set(1, "advanced")
+	for i := 0; i < 2; i++ {
+		if _, err := wallet.RequestWithdraw(ctx, 1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, 1_000_000); err != nil {
+			t.Fatalf("unlimited tier request %d: %v", i, err)
+		}
+	}
+}
+
+// 同一用户的并发申请串行检查，合计不超过日限额
+func TestWithdrawLimitConcurrent(t *testing.T) {
+	wallet, tiers := newLimitFixture(t, 1)
+	tiers.set(1, "basic")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var accepted, exceeded int
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := wallet.RequestWithdraw(context.Background(), 1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, 0.1)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				accepted++
+			case errors.Is(err, ErrWithdrawLimitExceeded):
+				exceeded++
+			default:
+				t.Errorf("request: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 3 || exceeded != 7 {
+		t.Fatalf("accepted %d, exceeded %d, want 3 and 7 under a 0.3 daily limit", accepted, exceeded)
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"gorm.io/gorm"
+)
+
+// ==========================
+// 提现申请处理
+// ==========================
+// 钱包接口写入的提现申请（wallet_withdraw，主单位金额）按币种精度换算为最小单位后逐笔转入提现流程。
+// 对账暂停或数据库错误时申请保持待处理，下次重试；地址无效、币种未配置、金额精度超出时申请置为失败。
+
+// RequestWorker 提现申请转入提现流程的后台任务
+type RequestWorker struct {
+	db          *gorm.DB
+	withdrawals *WithdrawalService
+	decimals    map[string]int // 币种 -> 精度，包含所有链上的币种
+}
+
+func NewRequestWorker(db *gorm.DB, withdrawals *WithdrawalService, decimals map[string]int) *RequestWorker {
+	return &RequestWorker{db: db, withdrawals: withdrawals, decimals: decimals}
+}
+
+// toBaseUnits 主单位金额换算为最小单位，超出币种精度的金额返回错误
+func toBaseUnits(amount float64, decimals int) (*big.Int, error) {
+	v, ok := new(big.Rat).SetString(strconv.FormatFloat(amount, 'f', -1, 64))
+	if !ok || v.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount %v", amount)
+	}
+	v.Mul(v, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if !v.IsInt() {
+		return nil, fmt.Errorf("amount %v exceeds %d decimals", amount, decimals)
+	}
+	return v.Num(), nil
+}
+
+// fail 申请未能转入提现流程时置为失败；已关联提现记录的申请状态由提现流程回写，不在这里修改
+func (r *RequestWorker) fail(ctx context.Context, req *model.WalletWithdraw, reason error) error {
+	log.Printf("withdraw request #%d failed: %v", req.ID, reason)
+	return r.db.WithContext(ctx).Model(&model.WalletWithdraw{}).
+		Where("id = ? AND status = 0 AND withdrawal_id IS NULL", req.ID).
+		Update("status", 4).Error
+}
+
+// ProcessOnce 处理一批待处理的提现申请
+func (r *RequestWorker) ProcessOnce(ctx context.Context) error {
+	var list []model.WalletWithdraw
+	if err := r.db.WithContext(ctx).
+		Where("status = 0 AND withdrawal_id IS NULL").
+		Order("id asc").Limit(BATCH_SEND_SIZE).
+		Find(&list).Error; err != nil {
+		return err
+	}
+	for i := range list {
+		req := &list[i]
+		decimals, ok := r.decimals[req.Currency]
+		if !ok {
+			if err := r.fail(ctx, req, fmt.Errorf("currency %s is not withdrawable", req.Currency)); err != nil {
+				return err
+			}
+			continue
+		}
+		amount, err := toBaseUnits(req.Amount, decimals)
+		if err != nil {
+			if err := r.fail(ctx, req, err); err != nil {
+				return err
+			}
+			continue
+		}
+		err = r.withdrawals.ProcessRequest(ctx, req, amount)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrInvalidWithdrawAddress), errors.Is(err, ErrCurrencyNotWithdrawable):
+			if err := r.fail(ctx, req, err); err != nil {
+				return err
+			}
+		case errors.Is(err, ErrWithdrawalsBlocked):
+			// 对账暂停，申请保持待处理
+		default:
+			// 提现记录已创建时（如风控拒绝、签名失败）状态已回写，其余错误下次重试
+			log.Printf("withdraw request #%d err: %v", req.ID, err)
+		}
+	}
+	return nil
+}
+
+// Run 定期处理提现申请
+func (r *RequestWorker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.ProcessOnce(ctx); err != nil {
+				log.Printf("process withdraw requests err: %v", err)
+			}
+		}
+	}
+}
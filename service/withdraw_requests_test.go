@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/approval"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/internal/testdb"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/repository"
+	"github.com/crypto_custody/risk"
+	"gorm.io/gorm"
+)
+
+const (
+	TEST_WITHDRAW_CHAIN    = "withdrawtest"
+	TEST_WITHDRAW_CURRENCY = "WTEST"
+	TEST_HOT_WALLET        = "0x00000000000000000000000000000000000000a1"
+)
+
+// sendChain 记录广播的交易；BuildTx 的 Payload 为 "to:amount"，交易哈希为 "tx-" 加签名结果。
+// buildErr / broadcastErr 非 nil 时构造 / 广播失败（广播失败前交易仍被记录），confirmations 为各交易的确认数
+type sendChain struct {
+	chain.Chain
+	name          string // 为空时为 TEST_WITHDRAW_CHAIN
+	mu            sync.Mutex
+	sent          []string
+	buildErr      error
+	broadcastErr  error
+	confirmations map[string]uint64
+}
+
+func (c *sendChain) Name() string {
+	if c.name != "" {
+		return c.name
+	}
+	return TEST_WITHDRAW_CHAIN
+}
+
+func (c *sendChain) ValidateAddress(addr string) error { return nil }
+func (c *sendChain) NormalizeAddress(addr string) (string, error) {
+	return address.NormalizeEVM(addr)
+}
+
+func (c *sendChain) BuildTx(ctx context.Context, req chain.TransferRequest) (*chain.UnsignedTx, error) {
+	if c.buildErr != nil {
+		return nil, c.buildErr
+	}
+	return &chain.UnsignedTx{Chain: c.Name(), From: req.From, Payload: []byte(fmt.Sprintf("%s:%s", req.To, req.Amount))}, nil
+}
+
+func (c *sendChain) SignTx(utx *chain.UnsignedTx, privateKey []byte) ([]byte, error) {
+	return utx.Payload, nil
+}
+
+func (c *sendChain) SignedTxHash(signed []byte) (string, error) {
+	return "tx-" + string(signed), nil
+}
+
+func (c *sendChain) Broadcast(ctx context.Context, signed []byte) (string, error) {
+	c.mu.Lock()
+	c.sent = append(c.sent, string(signed))
+	c.mu.Unlock()
+	if c.broadcastErr != nil {
+		return "", c.broadcastErr
+	}
+	return c.SignedTxHash(signed)
+}
+
+func (c *sendChain) Confirmations(ctx context.Context, txHash string) (uint64, error) {
+	return c.confirmations[txHash], nil
+}
+
+// newWithdrawFixture 无审批档位、无风控规则、不限额的提现链路，从钱包接口申请到签名广播
+func newWithdrawFixture(t *testing.T) (*gorm.DB, *WalletService, *RequestWorker, *sendChain) {
+	t.Helper()
+	db := testdb.Open(t, "users", "wallet_withdraws", "withdrawals", "risk_decisions", "withdrawal_approvals",
+		"withdraw_settings", "address_book_entries", "outbox_events")
+	address.RegisterChain(TEST_WITHDRAW_CHAIN, address.ChainEthereum)
+	address.RegisterCurrency(TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_CHAIN)
+	// 提现流程锁定用户行
+	if err := db.Create(&model.User{ID: 1, Email: "user1@example.com"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	c := &sendChain{}
+	approvals := approval.NewService(db, approval.Policy{}, []byte("trail-key"))
+	sign, err := NewSignService(db, c, TEST_HOT_WALLET, "01", approvals, map[string]*string{TEST_WITHDRAW_CURRENCY: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := address.NewChecker(repository.NewAddressRepository(db))
+	withdrawals, err := NewWithdrawalService(db, []*SignService{sign}, checker, risk.NewEngine(), approvals, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := NewWalletService(repository.NewAddressRepository(db), repository.NewDepositRepository(db),
+		repository.NewWithdrawRepository(db), repository.NewTransactionRepository(db), checker,
+		NewAddressBookService(repository.NewAddressBookRepository(db), checker, nil, 0), nil, nil, nil)
+	worker := NewRequestWorker(db, withdrawals, map[string]int{TEST_WITHDRAW_CURRENCY: 18})
+	return db, wallet, worker, c
+}
+
+// 校验和格式的 EVM 地址申请后以规范（全小写）形式保存，后台处理时不能因缺少校验和而失败
+func TestEVMRequestThroughWorker(t *testing.T) {
+	db, wallet, worker, c := newWithdrawFixture(t)
+	ctx := context.Background()
+	const to = "0x52908400098527886E0F7030069857D2E4169EE7"
+
+	req, err := wallet.RequestWithdraw(ctx, 1, TEST_WITHDRAW_CURRENCY, to, 1.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Address != "0x52908400098527886e0f7030069857d2e4169ee7" {
+		t.Fatalf("stored address %s, want canonical form", req.Address)
+	}
+	if err := worker.ProcessOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored model.WalletWithdraw
+	if err := db.First(&stored, req.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != requestStatuses["success"] || stored.WithdrawalID == nil {
+		t.Fatalf("request status = %d withdrawal = %v, want sent", stored.Status, stored.WithdrawalID)
+	}
+	want := req.Address + ":" + new(big.Int).Mul(big.NewInt(15), new(big.Int).Exp(big.NewInt(10), big.NewInt(17), nil)).String()
+	if len(c.sent) != 1 || c.sent[0] != want {
+		t.Fatalf("sent %v, want [%s]", c.sent, want)
+	}
+}
+
+// 用户输入仍要求校验和：全小写、校验和错误的地址在申请时拒绝
+func TestRequestWithdrawRejectsUnchecksummedInput(t *testing.T) {
+	_, wallet, _, _ := newWithdrawFixture(t)
+	for _, to := range []string{
+		"0x52908400098527886e0f7030069857d2e4169ee7",
+		"0x52908400098527886E0F7030069857D2E4169Ee7",
+	} {
+		if _, err := wallet.RequestWithdraw(context.Background(), 1, TEST_WITHDRAW_CURRENCY, to, 1); err == nil {
+			t.Fatalf("RequestWithdraw(%s) accepted", to)
+		}
+	}
+}
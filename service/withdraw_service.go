@@ -2,178 +2,674 @@ package service
 
 import (
 	"context"
-	"crypto/ecdsa"
-	_ "encoding/hex"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"gorm.io/driver/postgres"
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/approval"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/outbox"
+	"github.com/crypto_custody/repository"
+	"github.com/crypto_custody/risk"
 	"gorm.io/gorm"
 )
 
-// ==========================
-// 数据库模型
-// ==========================
-type Withdrawal struct {
-	ID        uint   `gorm:"primaryKey"`
-	UserID    uint   `gorm:"index"`
-	Address   string `gorm:"size:64"`
-	Amount    string
-	Status    string `gorm:"size:20"` // pending / success / failed
-	CreatedAt time.Time
-	UpdatedAt time.Time
-}
-
 // ==========================
 // 签名服务
 // ==========================
+// 交易构造、签名、广播都交给链适配器，这里只持有热钱包私钥
 type SignService struct {
-	privateKey *ecdsa.PrivateKey
-	client     *ethclient.Client
+	db         *gorm.DB
+	chain      chain.Chain
+	hasher     chain.TxHasher
+	from       string
+	privateKey []byte
+	verifier   approval.Verifier
+	tokens     map[string]*string // 币种 -> 代币合约 / mint，原生币为 nil
 }
 
-func NewSignService(rpcURL string, privateKeyHex string) (*SignService, error) {
-	client, err := ethclient.Dial(rpcURL)
+// ErrSignRefused 提现与记录不符、被风控拒绝、未完成审批或审批链被篡改，重试不会改变结果
+var ErrSignRefused = errors.New("拒绝签名")
+
+// NewSignService tokens 为本链可提现的币种，未登记的币种拒绝签名；
+// 链适配器须能由已签名交易计算哈希，以便广播前记录
+func NewSignService(db *gorm.DB, c chain.Chain, from string, privateKeyHex string, verifier approval.Verifier, tokens map[string]*string) (*SignService, error) {
+	hasher, ok := c.(chain.TxHasher)
+	if !ok {
+		return nil, fmt.Errorf("链 %s 不支持计算交易哈希", c.Name())
+	}
+	privateKey, err := hex.DecodeString(strings.TrimPrefix(privateKeyHex, "0x"))
 	if err != nil {
 		return nil, err
 	}
-
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
+	if err := c.ValidateAddress(from); err != nil {
 		return nil, err
 	}
-
-	return &SignService{privateKey: privateKey, client: client}, nil
+	return &SignService{db: db, chain: c, hasher: hasher, from: from, privateKey: privateKey, verifier: verifier, tokens: tokens}, nil
 }
 
-func (s *SignService) SignAndSendTx(to common.Address, amount *big.Int) (string, error) {
-	fromAddress := crypto.PubkeyToAddress(s.privateKey.PublicKey)
+// Chain 签名服务所属的链
+func (s *SignService) Chain() chain.Chain {
+	return s.chain
+}
 
-	nonce, err := s.client.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		return "", err
+// SignTx 签名前核对提现记录与风控结论，并按实际发送的地址和金额校验审批链，返回已签名交易与交易哈希，不广播；
+// 参数与记录不符、被风控拒绝、未完成审批或审批链被篡改时返回 ErrSignRefused
+func (s *SignService) SignTx(ctx context.Context, withdrawalID uint, currency, to string, amount *big.Int) ([]byte, string, error) {
+	token, ok := s.tokens[currency]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: 币种 %s 不在 %s 链上", ErrSignRefused, currency, s.chain.Name())
 	}
-
-	gasPrice, err := s.client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return "", err
+	var withdrawal model.Withdrawal
+	if err := s.db.WithContext(ctx).First(&withdrawal, withdrawalID).Error; err != nil {
+		return nil, "", fmt.Errorf("查询提现 %d: %w", withdrawalID, err)
+	}
+	if withdrawal.Currency != currency || withdrawal.Address != to || withdrawal.Amount != amount.String() {
+		return nil, "", fmt.Errorf("%w: 提现 %d 的币种、地址或金额与记录不符", ErrSignRefused, withdrawalID)
+	}
+	var decision model.RiskDecision
+	if err := s.db.WithContext(ctx).Where("withdrawal_id = ?", withdrawalID).Order("id desc").First(&decision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", fmt.Errorf("%w: 提现 %d 没有风控结论", ErrSignRefused, withdrawalID)
+		}
+		return nil, "", fmt.Errorf("查询提现 %d 风控结论: %w", withdrawalID, err)
+	}
+	if decision.Decision == string(risk.ActionReject) {
+		return nil, "", fmt.Errorf("%w: 提现 %d 已被风控拒绝", ErrSignRefused, withdrawalID)
+	}
+	if err := s.verifier.VerifyApproved(ctx, approval.Subject{
+		ID:         withdrawalID,
+		Currency:   currency,
+		Address:    to,
+		Amount:     amount,
+		RiskReview: decision.Decision == string(risk.ActionReview),
+	}); err != nil {
+		if errors.Is(err, approval.ErrNotApproved) || errors.Is(err, approval.ErrTrailTampered) || errors.Is(err, approval.ErrSubjectMismatch) {
+			return nil, "", fmt.Errorf("%w: 审批校验失败: %w", ErrSignRefused, err)
+		}
+		return nil, "", fmt.Errorf("审批校验失败: %w", err)
 	}
 
-	// 构造交易
-	tx := types.NewTransaction(nonce, to, amount, uint64(21000), gasPrice, nil)
-
-	chainID, err := s.client.NetworkID(context.Background())
+	// 构造交易，代币提现转出对应合约 / mint
+	utx, err := s.chain.BuildTx(ctx, chain.TransferRequest{From: s.from, To: to, Token: token, Amount: amount})
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
 	// 签名
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), s.privateKey)
+	signed, err := s.chain.SignTx(utx, s.privateKey)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
-
-	// 广播交易
-	err = s.client.SendTransaction(context.Background(), signedTx)
+	txHash, err := s.hasher.SignedTxHash(signed)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
+	return signed, txHash, nil
+}
 
-	return signedTx.Hash().Hex(), nil
+// Currencies 本链可提现的币种
+func (s *SignService) Currencies() []string {
+	currencies := make([]string, 0, len(s.tokens))
+	for currency := range s.tokens {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// Broadcast 广播已签名交易，返回交易哈希
+func (s *SignService) Broadcast(ctx context.Context, signed []byte) (string, error) {
+	return s.chain.Broadcast(ctx, signed)
 }
 
 // ==========================
 // 提现服务
 // ==========================
+const (
+	READY_RETRY_DELAY   = time.Minute
+	STALE_SIGNING_DELAY = 10 * time.Minute // 签名中断（进程退出、节点错误）后重新签名前的等待时间
+	BATCH_SEND_SIZE     = 50
+)
+
+var (
+	ErrInvalidWithdrawAddress  = errors.New("无效的提现地址")
+	ErrCurrencyNotWithdrawable = errors.New("币种未配置提现链")
+)
+
 type WithdrawalService struct {
 	db          *gorm.DB
-	signService *SignService
+	signers     map[string]*SignService // 币种 -> 所在链的签名服务
+	addrChecker *address.Checker
+	riskEngine  *risk.Engine
+	approvals   *approval.Service
+	gate        WithdrawalGate // 对账熔断，nil 表示不检查
+}
+
+// NewWithdrawalService signServices 为各提现链的签名服务，提现按币种路由到所在链；同一币种出现在多条链上时返回错误
+func NewWithdrawalService(db *gorm.DB, signServices []*SignService, addrChecker *address.Checker, riskEngine *risk.Engine, approvals *approval.Service, gate WithdrawalGate) (*WithdrawalService, error) {
+	signers := map[string]*SignService{}
+	for _, s := range signServices {
+		for _, currency := range s.Currencies() {
+			if other, ok := signers[currency]; ok {
+				return nil, fmt.Errorf("币种 %s 同时配置在 %s 和 %s 链上", currency, other.Chain().Name(), s.Chain().Name())
+			}
+			signers[currency] = s
+		}
+	}
+	return &WithdrawalService{
+		db:          db,
+		signers:     signers,
+		addrChecker: addrChecker,
+		riskEngine:  riskEngine,
+		approvals:   approvals,
+		gate:        gate,
+	}, nil
+}
+
+// currencies 可提现的币种（有序）
+func (w *WithdrawalService) currencies() []string {
+	currencies := make([]string, 0, len(w.signers))
+	for currency := range w.signers {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
 }
 
-func NewWithdrawalService(db *gorm.DB, signService *SignService) *WithdrawalService {
-	return &WithdrawalService{db: db, signService: signService}
+// publishStatus 在事务 tx 中写入提现状态变更事件
+func publishStatus(tx *gorm.DB, withdrawal *model.Withdrawal, txHash string) error {
+	return outbox.Publish(tx, outbox.TOPIC_WITHDRAWAL_STATUS_CHANGED, strconv.FormatUint(uint64(withdrawal.ID), 10), outbox.WithdrawalStatusChanged{
+		WithdrawalID: withdrawal.ID,
+		UserID:       withdrawal.UserID,
+		Currency:     withdrawal.Currency,
+		Address:      withdrawal.Address,
+		Amount:       withdrawal.Amount,
+		Status:       withdrawal.Status,
+		TxHash:       txHash,
+	})
 }
 
-// 提现处理
-func (w *WithdrawalService) ProcessWithdrawal(userID uint, to string, amount *big.Int) error {
-	// === Step 1: 校验地址合法性 ===
-	if !common.IsHexAddress(to) {
-		return fmt.Errorf("无效的提现地址: %s", to)
+// setStatus 更新提现状态，状态变更事件同事务写入发件箱
+func (w *WithdrawalService) setStatus(tx *gorm.DB, withdrawal *model.Withdrawal, status, txHash string) error {
+	if err := tx.Model(withdrawal).Update("Status", status).Error; err != nil {
+		return err
+	}
+	withdrawal.Status = status
+	if err := syncRequest(tx, withdrawal, txHash); err != nil {
+		return err
+	}
+	return publishStatus(tx, withdrawal, txHash)
+}
+
+func (w *WithdrawalService) checkGate(ctx context.Context, currency string) error {
+	if w.gate == nil {
+		return nil
+	}
+	return w.gate.CheckWithdrawalAllowed(ctx, currency)
+}
+
+// requestStatuses 提现状态对应的申请状态（wallet_withdraw.status），未列出的状态申请保持待处理
+var requestStatuses = map[string]int8{
+	"signing":      1,
+	"broadcasting": 1,
+	"unconfirmed":  1,
+	"success":      2,
+	"rejected":     4,
+	"expired":      4,
+	"failed":       4,
+}
+
+// syncRequest 在事务 tx 中将提现状态回写到关联的提现申请
+func syncRequest(tx *gorm.DB, withdrawal *model.Withdrawal, txHash string) error {
+	status, ok := requestStatuses[withdrawal.Status]
+	if !ok {
+		return nil
+	}
+	updates := map[string]interface{}{"status": status}
+	if txHash != "" {
+		updates["tx_id"] = txHash
+	}
+	return tx.Model(&model.WalletWithdraw{}).Where("withdrawal_id = ?", withdrawal.ID).Updates(updates).Error
+}
+
+// 提现处理，to 为用户输入的地址
+func (w *WithdrawalService) ProcessWithdrawal(userID uint, currency, to string, amount *big.Int) error {
+	ctx := context.Background()
+	// 校验和、网络、零地址只能在用户原始输入上校验
+	if err := w.addrChecker.Check(ctx, currency, to); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidWithdrawAddress, err)
+	}
+	to, err := address.NormalizeForCurrency(currency, to)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidWithdrawAddress, err)
+	}
+	return w.submit(ctx, userID, currency, to, amount, nil)
+}
+
+// ProcessRequest 将钱包接口提交的提现申请转入提现流程（地址校验、风控、审批、签名），amount 为最小单位；
+// 申请地址已在 RequestWithdraw 中按原始输入校验，并以规范形式保存。
+// 提现记录与申请在同一事务中关联，之后的状态变更同步回写申请；未能创建提现记录时返回错误，申请保持待处理
+func (w *WithdrawalService) ProcessRequest(ctx context.Context, req *model.WalletWithdraw, amount *big.Int) error {
+	return w.submit(ctx, uint(req.UserID), req.Currency, req.Address, amount, func(tx *gorm.DB, withdrawal *model.Withdrawal) error {
+		res := tx.Model(&model.WalletWithdraw{}).
+			Where("id = ? AND withdrawal_id IS NULL", req.ID).
+			Update("withdrawal_id", withdrawal.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("提现申请 %d 已在处理", req.ID)
+		}
+		return syncRequest(tx, withdrawal, "")
+	})
+}
+
+// submit 提现流程，to 为已通过 Check 的规范地址；link 非 nil 时在创建提现记录的事务中调用，用于关联提现申请
+func (w *WithdrawalService) submit(ctx context.Context, userID uint, currency, to string, amount *big.Int, link func(tx *gorm.DB, withdrawal *model.Withdrawal) error) error {
+	if err := w.checkGate(ctx, currency); err != nil {
+		return err
+	}
+	if _, ok := w.signers[currency]; !ok {
+		return fmt.Errorf("%w: %s", ErrCurrencyNotWithdrawable, currency)
+	}
+
+	// === Step 1: 重新检查自有地址、合约地址；风控历史与提现记录按规范地址比较 ===
+	if err := w.addrChecker.CheckStored(ctx, currency, to); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidWithdrawAddress, err)
 	}
 
 	// === Step 2: 校验余额 (这里简化为假设通过) ===
 	// 实际应该从账本表 / redis 中扣减余额
 	// 如果余额不足，直接 return error
 
-	// === Step 3: 风控检查 (简化版，只是黑名单校验) ===
-	blacklist := []string{"0x1111111111111111111111111111111111111111"}
-	for _, bad := range blacklist {
-		if to == bad {
-			return fmt.Errorf("提现地址在黑名单: %s", to)
+	// === Step 3 / 4: 风控检查，创建提现记录，风控结论、审批单同事务落库 ===
+	// 锁定用户行，同一用户的提现串行评估并落库，并发提现不能绕过频率 / 限额规则；
+	// 规则的历史查询在拿到锁之后执行，能看到前一笔已提交的提现
+	withdrawal := model.Withdrawal{
+		UserID:   userID,
+		Currency: currency,
+		Address:  to,
+		Amount:   amount.String(),
+		Status:   "pending",
+	}
+	var decision risk.Decision
+	var pending *model.WithdrawalApproval
+	if err := w.db.Transaction(func(tx *gorm.DB) error {
+		if err := repository.NewWithdrawRepository(tx).LockUser(ctx, uint64(userID)); err != nil {
+			return err
+		}
+		decision = w.riskEngine.Evaluate(ctx, risk.Request{
+			UserID:   uint64(userID),
+			Currency: currency,
+			To:       to,
+			Amount:   amount,
+		})
+		if decision.Action == risk.ActionReject {
+			withdrawal.Status = "rejected"
+		}
+		if err := tx.Create(&withdrawal).Error; err != nil {
+			return err
+		}
+		if err := publishStatus(tx, &withdrawal, ""); err != nil {
+			return err
+		}
+		if link != nil {
+			if err := link(tx, &withdrawal); err != nil {
+				return err
+			}
 		}
+		reasons, _ := json.Marshal(decision.Reasons())
+		if err := tx.Create(&model.RiskDecision{
+			WithdrawalID: withdrawal.ID,
+			Decision:     string(decision.Action),
+			Reasons:      string(reasons),
+		}).Error; err != nil {
+			return err
+		}
+		if decision.Action == risk.ActionReject {
+			return nil
+		}
+		// 超过审批阈值或风控要求人工审核时创建审批单
+		var err error
+		pending, err = w.approvals.WithTx(tx).Submit(ctx, approval.Subject{
+			ID:         withdrawal.ID,
+			Currency:   currency,
+			Address:    to,
+			Amount:     amount,
+			RiskReview: decision.Action == risk.ActionReview,
+		})
+		if err != nil || pending == nil {
+			return err
+		}
+		return w.setStatus(tx, &withdrawal, "approving", "")
+	}); err != nil {
+		return err
+	}
+
+	if decision.Action == risk.ActionReject {
+		return fmt.Errorf("提现被风控拒绝: %s", decision)
+	}
+	if pending != nil {
+		fmt.Printf("提现等待审批，提现ID=%d，需要 %d 人审批（%s），风控审核（%s），风控=%s\n", withdrawal.ID, pending.Required, pending.Roles, pending.ReviewRoles, decision)
+		return nil
 	}
+	return w.execute(ctx, &withdrawal)
+}
+
+// readyWithdrawalStatuses 待发送的提现：无需审批的 pending 与审批通过的 approved
+var readyWithdrawalStatuses = []string{"pending", "approved"}
+
+// inflightWithdrawalStatuses 已记录交易哈希、资金可能已离开热钱包的提现：
+// broadcasting 已记录哈希、广播结果未回写；unconfirmed 广播出错，交易可能已被节点接收，需按哈希对账
+var inflightWithdrawalStatuses = []string{"broadcasting", "unconfirmed"}
 
-	// === Step 4: 创建提现记录 ===
-	withdrawal := Withdrawal{
-		UserID:  userID,
-		Address: to,
-		Amount:  amount.String(),
-		Status:  "pending",
+// claim 将待发送的提现置为 signing，并发处理同一笔提现时只有一方成功，避免重复签名；
+// 停留在 signing 超过 STALE_SIGNING_DELAY 的提现尚未广播（广播前先置为 broadcasting），可重新认领
+func (w *WithdrawalService) claim(ctx context.Context, withdrawal *model.Withdrawal) (bool, error) {
+	claimed := false
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Withdrawal{}).
+			Where("id = ? AND (status IN ? OR (status = ? AND updated_at < ?))",
+				withdrawal.ID, readyWithdrawalStatuses, "signing", time.Now().Add(-STALE_SIGNING_DELAY)).
+			Update("status", "signing")
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		claimed = true
+		if withdrawal.Status == "signing" {
+			return nil
+		}
+		withdrawal.Status = "signing"
+		if err := syncRequest(tx, withdrawal, ""); err != nil {
+			return err
+		}
+		return publishStatus(tx, withdrawal, "")
+	})
+	return claimed, err
+}
+
+// markBroadcasting 广播前记录交易哈希并置为 broadcasting；提现已不在 signing（被重新认领的一方抢先）时返回 false，不得广播
+func (w *WithdrawalService) markBroadcasting(ctx context.Context, withdrawal *model.Withdrawal, txHash string) (bool, error) {
+	recorded := false
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Withdrawal{}).
+			Where("id = ? AND status = ?", withdrawal.ID, "signing").
+			Updates(map[string]interface{}{"status": "broadcasting", "tx_hash": txHash})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		recorded = true
+		withdrawal.Status = "broadcasting"
+		withdrawal.TxHash = txHash
+		if err := syncRequest(tx, withdrawal, txHash); err != nil {
+			return err
+		}
+		return publishStatus(tx, withdrawal, txHash)
+	})
+	return recorded, err
+}
+
+// execute 签名并广播，签名服务会先校验审批链；币种被对账暂停时保持原状态，由 RunSender 在解除后重试。
+// 签名被拒绝（ErrSignRefused）时提现失败；其他广播前的错误保持 signing，超过 STALE_SIGNING_DELAY 后重新签名；
+// 广播出错时交易可能已被节点接收，置为 unconfirmed，由 ResolveInflightOnce 按已记录的哈希确认；交易已过期未上链时回到 signing
+func (w *WithdrawalService) execute(ctx context.Context, withdrawal *model.Withdrawal) error {
+	if err := w.checkGate(ctx, withdrawal.Currency); err != nil {
+		return err
+	}
+	amount, ok := new(big.Int).SetString(withdrawal.Amount, 10)
+	if !ok {
+		return fmt.Errorf("提现金额错误: %s", withdrawal.Amount)
 	}
-	if err := w.db.Create(&withdrawal).Error; err != nil {
+	claimed, err := w.claim(ctx, withdrawal)
+	if err != nil || !claimed {
 		return err
 	}
 
-	// === Step 5: 调用签名服务广播交易 ===
-	txHash, err := w.signService.SignAndSendTx(common.HexToAddress(to), amount)
+	// === Step 5: 按币种所在链签名，广播前记录交易哈希 ===
+	signer, ok := w.signers[withdrawal.Currency]
+	var signed []byte
+	var txHash string
+	if ok {
+		signed, txHash, err = signer.SignTx(ctx, withdrawal.ID, withdrawal.Currency, withdrawal.Address, amount)
+	} else {
+		err = fmt.Errorf("%w: %w: %s", ErrSignRefused, ErrCurrencyNotWithdrawable, withdrawal.Currency)
+	}
+	if errors.Is(err, ErrSignRefused) {
+		if uerr := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return w.setStatus(tx, withdrawal, "failed", "")
+		}); uerr != nil {
+			log.Printf("mark withdrawal #%d failed err: %v", withdrawal.ID, uerr)
+		}
+		return err
+	}
 	if err != nil {
-		w.db.Model(&withdrawal).Update("Status", "failed")
-		return fmt.Errorf("交易发送失败: %v", err)
+		return fmt.Errorf("交易签名失败，稍后重试: %w", err)
+	}
+	recorded, err := w.markBroadcasting(ctx, withdrawal, txHash)
+	if err != nil || !recorded {
+		return err
 	}
 
-	// === Step 6: 更新提现记录 ===
-	w.db.Model(&withdrawal).Updates(map[string]interface{}{
-		"Status": "success",
-	})
+	// === Step 6: 广播 ===
+	if _, err := signer.Broadcast(ctx, signed); err != nil {
+		if errors.Is(err, chain.ErrTxExpired) {
+			if uerr := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				return w.resetSigning(tx, withdrawal)
+			}); uerr != nil {
+				log.Printf("reset withdrawal #%d err: %v", withdrawal.ID, uerr)
+			}
+			return fmt.Errorf("交易已过期未上链，稍后重新签名: %w", err)
+		}
+		if uerr := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return w.setStatus(tx, withdrawal, "unconfirmed", txHash)
+		}); uerr != nil {
+			log.Printf("mark withdrawal #%d unconfirmed err: %v", withdrawal.ID, uerr)
+		}
+		return fmt.Errorf("交易广播失败，待按哈希 %s 对账: %w", txHash, err)
+	}
+
+	// === Step 7: 更新提现记录；失败时保持 broadcasting，由 ResolveInflightOnce 确认后更新 ===
+	if err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return w.setStatus(tx, withdrawal, "success", txHash)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("提现成功，用户ID=%d，交易哈希=%s\n", withdrawal.UserID, txHash)
+	return nil
+}
+
+// resetSigning 交易已过期未上链、资金未转移：清除交易哈希回到 signing，超过 STALE_SIGNING_DELAY 后重新签名
+func (w *WithdrawalService) resetSigning(tx *gorm.DB, withdrawal *model.Withdrawal) error {
+	if err := tx.Model(withdrawal).Update("tx_hash", "").Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.WalletWithdraw{}).Where("withdrawal_id = ?", withdrawal.ID).Update("tx_id", "").Error; err != nil {
+		return err
+	}
+	withdrawal.TxHash = ""
+	return w.setStatus(tx, withdrawal, "signing", "")
+}
 
-	fmt.Printf("提现成功，用户ID=%d，交易哈希=%s\n", userID, txHash)
+// SendReadyOnce 发送因对账暂停等原因滞留的待发送提现；刚创建或刚审批通过的提现由请求方直接发送，
+// 超过 READY_RETRY_DELAY 仍未发送的才由这里接手，签名中断超过 STALE_SIGNING_DELAY 的重新签名
+func (w *WithdrawalService) SendReadyOnce(ctx context.Context) error {
+	now := time.Now()
+	var list []model.Withdrawal
+	if err := w.db.WithContext(ctx).
+		Where("(status IN ? AND updated_at < ?) OR (status = ? AND updated_at < ?)",
+			readyWithdrawalStatuses, now.Add(-READY_RETRY_DELAY), "signing", now.Add(-STALE_SIGNING_DELAY)).
+		Order("id asc").Limit(BATCH_SEND_SIZE).
+		Find(&list).Error; err != nil {
+		return err
+	}
+	for i := range list {
+		if err := w.execute(ctx, &list[i]); err != nil && !errors.Is(err, ErrWithdrawalsBlocked) {
+			log.Printf("send withdrawal #%d err: %v", list[i].ID, err)
+		}
+	}
 	return nil
 }
 
+// ResolveInflightOnce 按记录的交易哈希确认 broadcasting / unconfirmed 的提现：已上链置为 success，执行失败置为 failed，
+// 已过期未上链的回到 signing 重新签名；仍未上链的保持原状态，交易被丢弃时需人工对账
+func (w *WithdrawalService) ResolveInflightOnce(ctx context.Context) error {
+	var list []model.Withdrawal
+	if err := w.db.WithContext(ctx).
+		Where("status IN ? AND tx_hash <> '' AND currency IN ? AND updated_at < ?",
+			inflightWithdrawalStatuses, w.currencies(), time.Now().Add(-READY_RETRY_DELAY)).
+		Order("id asc").Limit(BATCH_SEND_SIZE).
+		Find(&list).Error; err != nil {
+		return err
+	}
+	for i := range list {
+		withdrawal := &list[i]
+		status := "success"
+		confs, err := w.signers[withdrawal.Currency].Chain().Confirmations(ctx, withdrawal.TxHash)
+		switch {
+		case errors.Is(err, chain.ErrTxExpired):
+			status = "signing"
+		case errors.Is(err, chain.ErrTxFailed):
+			status = "failed"
+		case err != nil:
+			log.Printf("check withdrawal #%d tx %s err: %v", withdrawal.ID, withdrawal.TxHash, err)
+			continue
+		case confs == 0:
+			continue
+		}
+		txHash := withdrawal.TxHash
+		if err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if status == "signing" {
+				return w.resetSigning(tx, withdrawal)
+			}
+			return w.setStatus(tx, withdrawal, status, txHash)
+		}); err != nil {
+			return err
+		}
+		log.Printf("withdrawal #%d tx %s resolved as %s", withdrawal.ID, txHash, status)
+	}
+	return nil
+}
+
+// RunSender 定期发送待发送的提现，并确认广播结果未回写的提现
+func (w *WithdrawalService) RunSender(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.SendReadyOnce(ctx); err != nil {
+				log.Printf("send ready withdrawals err: %v", err)
+			}
+			if err := w.ResolveInflightOnce(ctx); err != nil {
+				log.Printf("resolve inflight withdrawals err: %v", err)
+			}
+		}
+	}
+}
+
 // ==========================
-// Main 示例
+// 审批
 // ==========================
-func main() {
-	// === 数据库连接 ===
-	dsn := "host=localhost port=5432 user=postgres password=123456 dbname=wallet sslmode=disable"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatal("连接数据库失败:", err)
-	}
 
-	// 自动建表
-	if err := db.AutoMigrate(&Withdrawal{}); err != nil {
-		log.Fatal("迁移失败:", err)
+func (w *WithdrawalService) approvingWithdrawal(ctx context.Context, withdrawalID uint) (*model.Withdrawal, error) {
+	var withdrawal model.Withdrawal
+	if err := w.db.WithContext(ctx).First(&withdrawal, withdrawalID).Error; err != nil {
+		return nil, err
 	}
+	if withdrawal.Status != "approving" {
+		return nil, fmt.Errorf("提现 %d 当前状态为 %s，不在审批中", withdrawalID, withdrawal.Status)
+	}
+	return &withdrawal, nil
+}
 
-	// === 初始化服务 ===
-	signService, err := NewSignService("https://mainnet.infura.io/v3/YOUR_PROJECT_ID", "你的私钥HEX")
+// ApproveWithdrawal 操作员审批；审批人数达到要求后置为 approved 并立即签名广播，
+// 币种被对账暂停时保持 approved，解除后由 RunSender 发送
+func (w *WithdrawalService) ApproveWithdrawal(ctx context.Context, withdrawalID uint, operatorID uint64, roles []string, comment string) (*model.WithdrawalApproval, error) {
+	withdrawal, err := w.approvingWithdrawal(ctx, withdrawalID)
 	if err != nil {
-		log.Fatal("初始化签名服务失败:", err)
+		return nil, err
+	}
+	a, err := w.approvals.Approve(ctx, withdrawalID, operatorID, roles, comment)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status != approval.StatusApproved {
+		return a, nil
 	}
-	withdrawService := NewWithdrawalService(db, signService)
+	if err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return w.setStatus(tx, withdrawal, "approved", "")
+	}); err != nil {
+		return a, err
+	}
+	return a, w.execute(ctx, withdrawal)
+}
 
-	// === 模拟提现 ===
-	amount := big.NewInt(10000000000000000) // 0.01 ETH
-	err = withdrawService.ProcessWithdrawal(1, "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", amount)
+// RejectWithdrawal 操作员拒绝，提现直接结束
+func (w *WithdrawalService) RejectWithdrawal(ctx context.Context, withdrawalID uint, operatorID uint64, roles []string, comment string) (*model.WithdrawalApproval, error) {
+	withdrawal, err := w.approvingWithdrawal(ctx, withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+	a, err := w.approvals.Reject(ctx, withdrawalID, operatorID, roles, comment)
 	if err != nil {
-		log.Fatal("提现失败:", err)
+		return nil, err
+	}
+	return a, w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return w.setStatus(tx, withdrawal, "rejected", "")
+	})
+}
+
+// ApprovalTrail 查询审批单与审批记录
+func (w *WithdrawalService) ApprovalTrail(ctx context.Context, withdrawalID uint) (*model.WithdrawalApproval, []model.ApprovalAction, error) {
+	return w.approvals.Trail(ctx, withdrawalID)
+}
+
+// ExpireStaleApprovals 过期审批单对应的提现置为 expired
+func (w *WithdrawalService) ExpireStaleApprovals(ctx context.Context) error {
+	ids, err := w.approvals.ExpireStale(ctx)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	log.Printf("expired %d stale withdrawal approvals: %v", len(ids), ids)
+	return w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var list []model.Withdrawal
+		if err := tx.Where("id IN ? AND status = ?", ids, "approving").Find(&list).Error; err != nil {
+			return err
+		}
+		for i := range list {
+			if err := w.setStatus(tx, &list[i], "expired", ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RunApprovalExpiry 定期清理过期审批单
+func (w *WithdrawalService) RunApprovalExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.ExpireStaleApprovals(ctx); err != nil {
+				log.Printf("expire approvals err: %v", err)
+			}
+		}
 	}
 }
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/address"
+	"github.com/crypto_custody/approval"
+	"github.com/crypto_custody/chain"
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/risk"
+	"gorm.io/gorm"
+)
+
+const TEST_WITHDRAW_TO = "0x52908400098527886E0F7030069857D2E4169EE7"
+
+// lastWithdrawal 最近创建的提现记录
+func lastWithdrawal(t *testing.T, db *gorm.DB) model.Withdrawal {
+	t.Helper()
+	var w model.Withdrawal
+	if err := db.Order("id desc").First(&w).Error; err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// age 将提现的更新时间提前 d，模拟等待重试
+func age(t *testing.T, db *gorm.DB, id uint, d time.Duration) {
+	t.Helper()
+	if err := db.Model(&model.Withdrawal{}).Where("id = ?", id).UpdateColumn("updated_at", time.Now().Add(-d)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 广播前出错（交易未发出）的提现保持 signing，签名中断超时后由 RunSender 重新签名发送
+func TestPreBroadcastErrorIsRetried(t *testing.T) {
+	db, _, worker, c := newWithdrawFixture(t)
+	ctx := context.Background()
+	c.buildErr = errors.New("node unavailable")
+
+	if err := worker.withdrawals.ProcessWithdrawal(1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, big.NewInt(100)); err == nil {
+		t.Fatal("ProcessWithdrawal succeeded with failing node")
+	}
+	w := lastWithdrawal(t, db)
+	if w.Status != "signing" || w.TxHash != "" || len(c.sent) != 0 {
+		t.Fatalf("status = %s tx = %q sent = %v, want signing without a transaction", w.Status, w.TxHash, c.sent)
+	}
+
+	// 未超过 STALE_SIGNING_DELAY 时不重新签名
+	c.buildErr = nil
+	if err := worker.withdrawals.SendReadyOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 0 {
+		t.Fatalf("resent before STALE_SIGNING_DELAY: %v", c.sent)
+	}
+	age(t, db, w.ID, STALE_SIGNING_DELAY+time.Minute)
+	if err := worker.withdrawals.SendReadyOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	w = lastWithdrawal(t, db)
+	if w.Status != "success" || len(c.sent) != 1 || w.TxHash != "tx-"+c.sent[0] {
+		t.Fatalf("status = %s tx = %q sent = %v, want one successful send", w.Status, w.TxHash, c.sent)
+	}
+}
+
+// 广播出错时交易可能已被节点接收：不置为 failed，保留广播前记录的哈希，确认上链后置为 success
+func TestBroadcastErrorNeedsReconciliation(t *testing.T) {
+	db, _, worker, c := newWithdrawFixture(t)
+	ctx := context.Background()
+	c.broadcastErr = errors.New("i/o timeout")
+
+	if err := worker.withdrawals.ProcessWithdrawal(1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, big.NewInt(100)); err == nil {
+		t.Fatal("ProcessWithdrawal succeeded with failing broadcast")
+	}
+	w := lastWithdrawal(t, db)
+	if w.Status != "unconfirmed" || len(c.sent) != 1 || w.TxHash != "tx-"+c.sent[0] {
+		t.Fatalf("status = %s tx = %q sent = %v, want unconfirmed with recorded hash", w.Status, w.TxHash, c.sent)
+	}
+
+	// 不会重新签名广播
+	age(t, db, w.ID, STALE_SIGNING_DELAY+time.Minute)
+	if err := worker.withdrawals.SendReadyOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := worker.withdrawals.ResolveInflightOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w = lastWithdrawal(t, db); w.Status != "unconfirmed" || len(c.sent) != 1 {
+		t.Fatalf("status = %s sent = %v before confirmation, want unconfirmed and no resend", w.Status, c.sent)
+	}
+
+	c.confirmations = map[string]uint64{w.TxHash: 1}
+	if err := worker.withdrawals.ResolveInflightOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w = lastWithdrawal(t, db); w.Status != "success" {
+		t.Fatalf("status = %s after confirmation, want success", w.Status)
+	}
+}
+
+// 交易已过期未上链时资金未转移：清除哈希回到 signing，之后重新签名发送
+func TestExpiredBroadcastIsResigned(t *testing.T) {
+	db, _, worker, c := newWithdrawFixture(t)
+	ctx := context.Background()
+	c.broadcastErr = fmt.Errorf("%w: blockhash not found", chain.ErrTxExpired)
+
+	if err := worker.withdrawals.ProcessWithdrawal(1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, big.NewInt(100)); !errors.Is(err, chain.ErrTxExpired) {
+		t.Fatalf("ProcessWithdrawal err = %v, want %v", err, chain.ErrTxExpired)
+	}
+	w := lastWithdrawal(t, db)
+	if w.Status != "signing" || w.TxHash != "" {
+		t.Fatalf("status = %s tx = %q, want signing without hash", w.Status, w.TxHash)
+	}
+
+	c.broadcastErr = nil
+	age(t, db, w.ID, STALE_SIGNING_DELAY+time.Minute)
+	if err := worker.withdrawals.SendReadyOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w = lastWithdrawal(t, db); w.Status != "success" || len(c.sent) != 2 {
+		t.Fatalf("status = %s sent = %v, want success after resend", w.Status, c.sent)
+	}
+}
+
+// 提现按币种路由到所在链的签名服务
+func TestWithdrawalRoutedByCurrency(t *testing.T) {
+	db, _, worker, c := newWithdrawFixture(t)
+	const otherChain, otherCurrency = "withdrawtest2", "WTEST2"
+	address.RegisterChain(otherChain, address.ChainEthereum)
+	address.RegisterCurrency(otherCurrency, otherChain)
+
+	other := &sendChain{name: otherChain}
+	approvals := approval.NewService(db, approval.Policy{}, []byte("trail-key"))
+	first, err := NewSignService(db, c, TEST_HOT_WALLET, "01", approvals, map[string]*string{TEST_WITHDRAW_CURRENCY: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewSignService(db, other, TEST_HOT_WALLET, "01", approvals, map[string]*string{otherCurrency: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdrawals, err := NewWithdrawalService(db, []*SignService{first, second}, worker.withdrawals.addrChecker, risk.NewEngine(), approvals, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := withdrawals.ProcessWithdrawal(1, otherCurrency, TEST_WITHDRAW_TO, big.NewInt(7)); err != nil {
+		t.Fatal(err)
+	}
+	if err := withdrawals.ProcessWithdrawal(1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, big.NewInt(8)); err != nil {
+		t.Fatal(err)
+	}
+	if len(other.sent) != 1 || !strings.HasSuffix(other.sent[0], ":7") || len(c.sent) != 1 || !strings.HasSuffix(c.sent[0], ":8") {
+		t.Fatalf("sent on %s = %v, on %s = %v", otherChain, other.sent, TEST_WITHDRAW_CHAIN, c.sent)
+	}
+	// 币种已登记在链上，但该链没有签名服务
+	address.RegisterCurrency("WTEST3", otherChain)
+	if err := withdrawals.ProcessWithdrawal(1, "WTEST3", TEST_WITHDRAW_TO, big.NewInt(1)); !errors.Is(err, ErrCurrencyNotWithdrawable) {
+		t.Fatalf("currency without signer err = %v, want %v", err, ErrCurrencyNotWithdrawable)
+	}
+}
+
+func TestNewWithdrawalServiceRejectsDuplicateCurrency(t *testing.T) {
+	a, err := NewSignService(nil, &sendChain{name: "a"}, TEST_HOT_WALLET, "01", nil, map[string]*string{TEST_WITHDRAW_CURRENCY: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewSignService(nil, &sendChain{name: "b"}, TEST_HOT_WALLET, "01", nil, map[string]*string{TEST_WITHDRAW_CURRENCY: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWithdrawalService(nil, []*SignService{a, b}, nil, nil, nil, nil); err == nil {
+		t.Fatal("currency on two chains accepted")
+	}
+}
+
+// 同一用户的并发提现在用户行锁下串行评估风控，合计不超过频率限制
+func TestUserVelocitySerialized(t *testing.T) {
+	db, _, worker, c := newWithdrawFixture(t)
+	approvals := approval.NewService(db, approval.Policy{}, []byte("trail-key"))
+	sign, err := NewSignService(db, c, TEST_HOT_WALLET, "01", approvals, map[string]*string{TEST_WITHDRAW_CURRENCY: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := risk.NewEngine(risk.NewUserVelocityRule(risk.Limit{Window: time.Hour, MaxCount: 2}, NewWithdrawalHistory(db), risk.ActionReject))
+	withdrawals, err := NewWithdrawalService(db, []*SignService{sign}, worker.withdrawals.addrChecker, engine, approvals, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			withdrawals.ProcessWithdrawal(1, TEST_WITHDRAW_CURRENCY, TEST_WITHDRAW_TO, big.NewInt(1))
+		}()
+	}
+	wg.Wait()
+
+	var statuses []string
+	if err := db.Model(&model.Withdrawal{}).Pluck("status", &statuses).Error; err != nil {
+		t.Fatal(err)
+	}
+	var passed int
+	for _, status := range statuses {
+		if status != "rejected" {
+			passed++
+		}
+	}
+	if len(statuses) != 8 || passed != 2 {
+		t.Fatalf("statuses = %v, want 2 of 8 withdrawals past the velocity limit", statuses)
+	}
+}
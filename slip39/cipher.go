@@ -0,0 +1,56 @@
+package slip39
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// 主密钥加密：4 轮 Feistel 网络，轮函数为 PBKDF2-HMAC-SHA256
+
+const (
+	BASE_ITERATION_COUNT = 10000
+	ROUND_COUNT          = 4
+)
+
+func salt(identifier uint16, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+	s := []byte(CUSTOMIZATION_STRING)
+	return binary.BigEndian.AppendUint16(s, identifier)
+}
+
+func roundFunction(i int, passphrase []byte, exponent int, salt, r []byte) []byte {
+	password := append([]byte{byte(i)}, passphrase...)
+	return pbkdf2.Key(password, append(append([]byte(nil), salt...), r...),
+		(BASE_ITERATION_COUNT<<exponent)/ROUND_COUNT, len(r), sha256.New)
+}
+
+func feistel(data, passphrase []byte, exponent int, identifier uint16, extendable, decrypt bool) []byte {
+	half := len(data) / 2
+	l := append([]byte(nil), data[:half]...)
+	r := append([]byte(nil), data[half:]...)
+	s := salt(identifier, extendable)
+	for n := 0; n < ROUND_COUNT; n++ {
+		i := n
+		if decrypt {
+			i = ROUND_COUNT - 1 - n
+		}
+		f := roundFunction(i, passphrase, exponent, s, r)
+		for j := range l {
+			l[j] ^= f[j]
+		}
+		l, r = r, l
+	}
+	return append(r, l...)
+}
+
+func encrypt(masterSecret, passphrase []byte, exponent int, identifier uint16, extendable bool) []byte {
+	return feistel(masterSecret, passphrase, exponent, identifier, extendable, false)
+}
+
+func decrypt(encrypted, passphrase []byte, exponent int, identifier uint16, extendable bool) []byte {
+	return feistel(encrypted, passphrase, exponent, identifier, extendable, true)
+}
@@ -0,0 +1,152 @@
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// ==========================
+// GF(256) 上的 Shamir 秘密分享
+// ==========================
+// 域多项式为 Rijndael 多项式 x^8 + x^4 + x^3 + x + 1，生成元为 3。
+// x=255 处为秘密，x=254 处为摘要分片（4 字节 HMAC 摘要 + 随机数），用于恢复时校验。
+
+const (
+	SECRET_INDEX        = 255
+	DIGEST_INDEX        = 254
+	DIGEST_LENGTH_BYTES = 4
+)
+
+var (
+	expTable [255]byte
+	logTable [256]int
+)
+
+func init() {
+	poly := 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(poly)
+		logTable[poly] = i
+		// 乘以生成元 3
+		poly = (poly << 1) ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11B
+		}
+	}
+}
+
+type point struct {
+	x     byte
+	value []byte
+}
+
+// interpolate 拉格朗日插值求 x 处的值
+func interpolate(points []point, x byte) ([]byte, error) {
+	if len(points) == 0 {
+		return nil, errors.New("no shares to interpolate")
+	}
+	seen := map[byte]bool{}
+	for _, p := range points {
+		if seen[p.x] {
+			return nil, errors.New("share indices must be unique")
+		}
+		if len(p.value) != len(points[0].value) {
+			return nil, errors.New("all shares must have the same length")
+		}
+		seen[p.x] = true
+	}
+	for _, p := range points {
+		if p.x == x {
+			return append([]byte(nil), p.value...), nil
+		}
+	}
+
+	logProd := 0
+	for _, p := range points {
+		logProd += logTable[p.x^x]
+	}
+	result := make([]byte, len(points[0].value))
+	for _, p := range points {
+		basis := logProd - logTable[p.x^x]
+		for _, o := range points {
+			if o.x != p.x {
+				basis -= logTable[p.x^o.x]
+			}
+		}
+		basis = ((basis % 255) + 255) % 255
+		for i, v := range p.value {
+			if v != 0 {
+				result[i] ^= expTable[(logTable[v]+basis)%255]
+			}
+		}
+	}
+	return result, nil
+}
+
+func digest(randomPart, secret []byte) []byte {
+	m := hmac.New(sha256.New, randomPart)
+	m.Write(secret)
+	return m.Sum(nil)[:DIGEST_LENGTH_BYTES]
+}
+
+// splitSecret 将 secret 分为 count 份，任意 threshold 份可恢复
+func splitSecret(threshold, count int, secret []byte) ([]point, error) {
+	if threshold < 1 || threshold > count || count > MAX_SHARE_COUNT {
+		return nil, fmt.Errorf("invalid threshold %d of %d", threshold, count)
+	}
+	if threshold == 1 {
+		points := make([]point, count)
+		for i := range points {
+			points[i] = point{byte(i), append([]byte(nil), secret...)}
+		}
+		return points, nil
+	}
+
+	randomCount := threshold - 2
+	points := make([]point, 0, count)
+	for i := 0; i < randomCount; i++ {
+		v := make([]byte, len(secret))
+		if _, err := rand.Read(v); err != nil {
+			return nil, err
+		}
+		points = append(points, point{byte(i), v})
+	}
+	randomPart := make([]byte, len(secret)-DIGEST_LENGTH_BYTES)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, err
+	}
+	base := append(append([]point(nil), points...),
+		point{DIGEST_INDEX, append(digest(randomPart, secret), randomPart...)},
+		point{SECRET_INDEX, secret},
+	)
+	for i := randomCount; i < count; i++ {
+		v, err := interpolate(base, byte(i))
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point{byte(i), v})
+	}
+	return points, nil
+}
+
+// recoverSecret 恢复秘密并校验摘要
+func recoverSecret(threshold int, points []point) ([]byte, error) {
+	if threshold == 1 {
+		return points[0].value, nil
+	}
+	secret, err := interpolate(points, SECRET_INDEX)
+	if err != nil {
+		return nil, err
+	}
+	d, err := interpolate(points, DIGEST_INDEX)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(d[:DIGEST_LENGTH_BYTES], digest(d[DIGEST_LENGTH_BYTES:], secret)) {
+		return nil, ErrDigest
+	}
+	return secret, nil
+}
@@ -0,0 +1,42 @@
+package slip39
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadAndCombine 逐行读取分片助记词，每读入一份即尝试恢复，达到阈值后返回主密钥。
+// 格式错误的分片会提示后跳过；输入结束时仍不足阈值返回 ErrInsufficientShares
+func ReadAndCombine(in io.Reader, prompt io.Writer, passphrase []byte) ([]byte, error) {
+	r := bufio.NewReader(in)
+	var shares []*Share
+	for {
+		fmt.Fprintf(prompt, "share %d: ", len(shares)+1)
+		line, err := r.ReadString('\n')
+		if strings.TrimSpace(line) != "" {
+			s, perr := ParseShare(line)
+			if perr != nil {
+				fmt.Fprintf(prompt, "rejected: %v\n", perr)
+			} else {
+				shares = append(shares, s)
+				secret, cerr := CombineShares(shares, passphrase)
+				switch {
+				case cerr == nil:
+					return secret, nil
+				case !errors.Is(cerr, ErrInsufficientShares):
+					return nil, cerr
+				}
+				fmt.Fprintf(prompt, "accepted (group %d, member %d); %v\n", s.GroupIndex+1, s.MemberIndex+1, cerr)
+			}
+		}
+		if err == io.EOF {
+			return nil, ErrInsufficientShares
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package slip39
+
+// RS1024 校验和：GF(1024) 上的 Reed-Solomon 码，3 个字（30 位）
+
+const CHECKSUM_LENGTH_WORDS = 3
+
+var rs1024Gen = [10]uint32{
+	0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009,
+	0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120,
+}
+
+func rs1024Polymod(values []int) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xFFFFF)<<10 ^ uint32(v)
+		for i := 0; i < 10; i++ {
+			if (b>>i)&1 != 0 {
+				chk ^= rs1024Gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func customizationValues(customization string, data []int) []int {
+	values := make([]int, 0, len(customization)+len(data)+CHECKSUM_LENGTH_WORDS)
+	for _, c := range []byte(customization) {
+		values = append(values, int(c))
+	}
+	return append(values, data...)
+}
+
+func rs1024CreateChecksum(customization string, data []int) []int {
+	values := append(customizationValues(customization, data), 0, 0, 0)
+	polymod := rs1024Polymod(values) ^ 1
+	out := make([]int, CHECKSUM_LENGTH_WORDS)
+	for i := 0; i < CHECKSUM_LENGTH_WORDS; i++ {
+		out[i] = int(polymod>>(10*(CHECKSUM_LENGTH_WORDS-1-i))) & 1023
+	}
+	return out
+}
+
+func rs1024VerifyChecksum(customization string, data []int) bool {
+	return rs1024Polymod(customizationValues(customization, data)) == 1
+}
@@ -0,0 +1,131 @@
+package slip39
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ==========================
+// 分片助记词编码
+// ==========================
+// 位布局：identifier(15) | extendable(1) | iteration exponent(4) | group index(4) |
+// group threshold-1(4) | group count-1(4) | member index(4) | member threshold-1(4) |
+// 左侧补零对齐到 10 位的分片值 | RS1024 校验和(30)
+
+const (
+	RADIX_BITS           = 10
+	ID_LENGTH_BITS       = 15
+	ITERATION_EXP_BITS   = 4
+	HEADER_LENGTH_WORDS  = 4
+	MIN_STRENGTH_BITS    = 128
+	MAX_SHARE_COUNT      = 16
+	MIN_MNEMONIC_WORDS   = HEADER_LENGTH_WORDS + (MIN_STRENGTH_BITS+RADIX_BITS-1)/RADIX_BITS + CHECKSUM_LENGTH_WORDS
+	CUSTOMIZATION_STRING = "shamir"
+	// extendable 分片使用的自定义串
+	CUSTOMIZATION_STRING_EXTENDABLE = "shamir_extendable"
+)
+
+// Share 一个分片
+type Share struct {
+	Identifier        uint16
+	Extendable        bool
+	IterationExponent int
+	GroupIndex        int
+	GroupThreshold    int
+	GroupCount        int
+	MemberIndex       int
+	MemberThreshold   int
+	Value             []byte
+}
+
+func (s *Share) customization() string {
+	if s.Extendable {
+		return CUSTOMIZATION_STRING_EXTENDABLE
+	}
+	return CUSTOMIZATION_STRING
+}
+
+// Mnemonic 编码为单词序列
+func (s *Share) Mnemonic() string {
+	ext := 0
+	if s.Extendable {
+		ext = 1
+	}
+	header := uint64(s.Identifier)<<25 | uint64(ext)<<24 | uint64(s.IterationExponent)<<20 |
+		uint64(s.GroupIndex)<<16 | uint64(s.GroupThreshold-1)<<12 | uint64(s.GroupCount-1)<<8 |
+		uint64(s.MemberIndex)<<4 | uint64(s.MemberThreshold-1)
+	data := make([]int, 0, HEADER_LENGTH_WORDS+len(s.Value))
+	for i := HEADER_LENGTH_WORDS - 1; i >= 0; i-- {
+		data = append(data, int(header>>(RADIX_BITS*i))&1023)
+	}
+
+	valueWords := (len(s.Value)*8 + RADIX_BITS - 1) / RADIX_BITS
+	v := new(big.Int).SetBytes(s.Value)
+	mask := big.NewInt(1023)
+	for i := valueWords - 1; i >= 0; i-- {
+		w := new(big.Int).Rsh(v, uint(RADIX_BITS*i))
+		data = append(data, int(w.And(w, mask).Int64()))
+	}
+	data = append(data, rs1024CreateChecksum(s.customization(), data)...)
+
+	words := make([]string, len(data))
+	for i, idx := range data {
+		words[i] = wordlist[idx]
+	}
+	return strings.Join(words, " ")
+}
+
+// ParseShare 解析并校验分片助记词
+func ParseShare(mnemonic string) (*Share, error) {
+	fields := strings.Fields(strings.ToLower(mnemonic))
+	if len(fields) < MIN_MNEMONIC_WORDS {
+		return nil, fmt.Errorf("%w: must be at least %d words", ErrInvalidMnemonic, MIN_MNEMONIC_WORDS)
+	}
+	data := make([]int, len(fields))
+	for i, w := range fields {
+		idx, ok := wordIndex[w]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %q", ErrInvalidMnemonic, w)
+		}
+		data[i] = idx
+	}
+
+	paddingBits := (RADIX_BITS * (len(data) - HEADER_LENGTH_WORDS - CHECKSUM_LENGTH_WORDS)) % 16
+	if paddingBits > 8 {
+		return nil, fmt.Errorf("%w: invalid length", ErrInvalidMnemonic)
+	}
+
+	var header uint64
+	for _, idx := range data[:HEADER_LENGTH_WORDS] {
+		header = header<<RADIX_BITS | uint64(idx)
+	}
+	s := &Share{
+		Identifier:        uint16(header >> 25),
+		Extendable:        (header>>24)&1 == 1,
+		IterationExponent: int(header>>20) & 0xF,
+		GroupIndex:        int(header>>16) & 0xF,
+		GroupThreshold:    int(header>>12)&0xF + 1,
+		GroupCount:        int(header>>8)&0xF + 1,
+		MemberIndex:       int(header>>4) & 0xF,
+		MemberThreshold:   int(header)&0xF + 1,
+	}
+	if !rs1024VerifyChecksum(s.customization(), data) {
+		return nil, ErrChecksum
+	}
+	if s.GroupCount < s.GroupThreshold {
+		return nil, fmt.Errorf("%w: group threshold exceeds group count", ErrInvalidMnemonic)
+	}
+
+	valueData := data[HEADER_LENGTH_WORDS : len(data)-CHECKSUM_LENGTH_WORDS]
+	v := new(big.Int)
+	for _, idx := range valueData {
+		v.Lsh(v, RADIX_BITS).Or(v, big.NewInt(int64(idx)))
+	}
+	valueBytes := (RADIX_BITS*len(valueData) - paddingBits) / 8
+	if v.BitLen() > valueBytes*8 {
+		return nil, fmt.Errorf("%w: invalid padding", ErrInvalidMnemonic)
+	}
+	s.Value = v.FillBytes(make([]byte, valueBytes))
+	return s, nil
+}
@@ -0,0 +1,146 @@
+// Package slip39 实现 SLIP-0039 Shamir 分片备份：
+// 主密钥经口令加密后按两级（组 / 组内成员）阈值拆分为助记词分片，
+// 与 Trezor 等支持 SLIP-39 的钱包互通。
+package slip39
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidMnemonic    = errors.New("invalid share mnemonic")
+	ErrChecksum           = errors.New("share checksum mismatch")
+	ErrDigest             = errors.New("share digest mismatch")
+	ErrMismatchedShares   = errors.New("shares belong to different secrets")
+	ErrInsufficientShares = errors.New("insufficient shares")
+)
+
+// Group 组内阈值
+type Group struct {
+	Threshold int
+	Count     int
+}
+
+// Split 将主密钥拆分为分片助记词，返回值按组排列。
+// masterSecret 至少 16 字节且为偶数长度；exponent 控制 PBKDF2 迭代次数（10000 << exponent）
+func Split(masterSecret, passphrase []byte, groupThreshold int, groups []Group, exponent int) ([][]string, error) {
+	if len(masterSecret)*8 < MIN_STRENGTH_BITS || len(masterSecret)%2 != 0 {
+		return nil, fmt.Errorf("master secret must be at least %d bits and an even number of bytes", MIN_STRENGTH_BITS)
+	}
+	if groupThreshold < 1 || groupThreshold > len(groups) || len(groups) > MAX_SHARE_COUNT {
+		return nil, fmt.Errorf("invalid group threshold %d of %d", groupThreshold, len(groups))
+	}
+	if exponent < 0 || exponent >= 1<<ITERATION_EXP_BITS {
+		return nil, fmt.Errorf("iteration exponent out of range: %d", exponent)
+	}
+	for _, g := range groups {
+		if g.Threshold == 1 && g.Count > 1 {
+			return nil, errors.New("a group with threshold 1 must have exactly one member")
+		}
+	}
+	for _, c := range passphrase {
+		if c < 32 || c > 126 {
+			return nil, errors.New("passphrase must contain only printable ASCII characters")
+		}
+	}
+
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, err
+	}
+	identifier := binary.BigEndian.Uint16(idBytes[:]) & (1<<ID_LENGTH_BITS - 1)
+	encrypted := encrypt(masterSecret, passphrase, exponent, identifier, false)
+
+	groupPoints, err := splitSecret(groupThreshold, len(groups), encrypted)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]string, len(groups))
+	for gi, g := range groups {
+		members, err := splitSecret(g.Threshold, g.Count, groupPoints[gi].value)
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %w", gi+1, err)
+		}
+		for _, m := range members {
+			s := Share{
+				Identifier:        identifier,
+				IterationExponent: exponent,
+				GroupIndex:        gi,
+				GroupThreshold:    groupThreshold,
+				GroupCount:        len(groups),
+				MemberIndex:       int(m.x),
+				MemberThreshold:   g.Threshold,
+				Value:             m.value,
+			}
+			out[gi] = append(out[gi], s.Mnemonic())
+		}
+	}
+	return out, nil
+}
+
+// Combine 用足够的分片恢复主密钥；分片不足阈值时返回 ErrInsufficientShares
+func Combine(mnemonics []string, passphrase []byte) ([]byte, error) {
+	shares := make([]*Share, 0, len(mnemonics))
+	for _, m := range mnemonics {
+		s, err := ParseShare(m)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, s)
+	}
+	return CombineShares(shares, passphrase)
+}
+
+// CombineShares 同 Combine，输入为已解析的分片
+func CombineShares(shares []*Share, passphrase []byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrInsufficientShares
+	}
+	first := shares[0]
+	groups := map[int][]*Share{}
+	for _, s := range shares {
+		if s.Identifier != first.Identifier || s.Extendable != first.Extendable ||
+			s.IterationExponent != first.IterationExponent || s.GroupThreshold != first.GroupThreshold ||
+			s.GroupCount != first.GroupCount || len(s.Value) != len(first.Value) {
+			return nil, ErrMismatchedShares
+		}
+		groups[s.GroupIndex] = append(groups[s.GroupIndex], s)
+	}
+
+	var groupPoints []point
+	for gi, members := range groups {
+		threshold := members[0].MemberThreshold
+		seen := map[int]bool{}
+		points := make([]point, 0, len(members))
+		for _, m := range members {
+			if m.MemberThreshold != threshold {
+				return nil, ErrMismatchedShares
+			}
+			if seen[m.MemberIndex] {
+				continue // 重复输入同一分片
+			}
+			seen[m.MemberIndex] = true
+			points = append(points, point{byte(m.MemberIndex), m.Value})
+		}
+		if len(points) < threshold {
+			continue
+		}
+		value, err := recoverSecret(threshold, points[:threshold])
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %w", gi+1, err)
+		}
+		groupPoints = append(groupPoints, point{byte(gi), value})
+	}
+	if len(groupPoints) < first.GroupThreshold {
+		return nil, fmt.Errorf("%w: %d of %d groups complete", ErrInsufficientShares, len(groupPoints), first.GroupThreshold)
+	}
+
+	encrypted, err := recoverSecret(first.GroupThreshold, groupPoints[:first.GroupThreshold])
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(encrypted, passphrase, first.IterationExponent, first.Identifier, first.Extendable), nil
+}
@@ -0,0 +1,176 @@
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// 官方测试向量（SLIP-0039 vectors.json），口令均为 TREZOR
+const VECTOR_PASSPHRASE = "TREZOR"
+
+func TestCombineVectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		mnemonics []string
+		secret    string
+	}{
+		{
+			name:      "128 bits, no sharing",
+			mnemonics: []string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"},
+			secret:    "bb54aac4b89dc868ba37d9cc21b2cece",
+		},
+		{
+			name: "128 bits, 2 of 3 members",
+			mnemonics: []string{
+				"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+				"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+			},
+			secret: "b43ceb7e57a0ea8766221624d01b0864",
+		},
+		{
+			name:      "256 bits, no sharing",
+			mnemonics: []string{"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck"},
+			secret:    "989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Combine(tt.mnemonics, []byte(VECTOR_PASSPHRASE))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != tt.secret {
+				t.Fatalf("secret = %x, want %s", got, tt.secret)
+			}
+		})
+	}
+}
+
+func TestCombineInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		mnemonics []string
+		err       error
+	}{
+		{
+			name:      "invalid checksum",
+			mnemonics: []string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney"},
+			err:       ErrChecksum,
+		},
+		{
+			name:      "unknown word",
+			mnemonics: []string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboardx"},
+			err:       ErrInvalidMnemonic,
+		},
+		{
+			name:      "too short",
+			mnemonics: []string{"duckling enlarge academic academic agency result"},
+			err:       ErrInvalidMnemonic,
+		},
+		{
+			name:      "1 of 2 required members",
+			mnemonics: []string{"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed"},
+			err:       ErrInsufficientShares,
+		},
+		{
+			name: "shares of different secrets",
+			mnemonics: []string{
+				"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+				"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard",
+			},
+			err: ErrMismatchedShares,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Combine(tt.mnemonics, []byte(VECTOR_PASSPHRASE))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret16, _ := hex.DecodeString("bb54aac4b89dc868ba37d9cc21b2cece")
+	secret32, _ := hex.DecodeString("989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92")
+	tests := []struct {
+		name           string
+		secret         []byte
+		groupThreshold int
+		groups         []Group
+		pick           [][]int // 每组选取的成员下标
+	}{
+		{"single share", secret16, 1, []Group{{1, 1}}, [][]int{{0}}},
+		{"3 of 5", secret32, 1, []Group{{3, 5}}, [][]int{{4, 0, 2}}},
+		{"2 of 3 groups", secret16, 2, []Group{{2, 3}, {1, 1}, {3, 5}}, [][]int{{2, 1}, nil, {0, 3, 4}}},
+		{"1 of 2 groups", secret32, 1, []Group{{2, 2}, {1, 1}}, [][]int{nil, {0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := Split(tt.secret, []byte(VECTOR_PASSPHRASE), tt.groupThreshold, tt.groups, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(shares) != len(tt.groups) {
+				t.Fatalf("got %d groups, want %d", len(shares), len(tt.groups))
+			}
+			var picked []string
+			for g, members := range tt.pick {
+				if len(shares[g]) != tt.groups[g].Count {
+					t.Fatalf("group %d has %d shares, want %d", g, len(shares[g]), tt.groups[g].Count)
+				}
+				for _, m := range members {
+					picked = append(picked, shares[g][m])
+				}
+			}
+			got, err := Combine(picked, []byte(VECTOR_PASSPHRASE))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.secret) {
+				t.Fatalf("secret = %x, want %x", got, tt.secret)
+			}
+
+			// 口令错误时得到另一个主密钥，而不是报错
+			other, err := Combine(picked, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(other, tt.secret) {
+				t.Fatal("wrong passphrase recovered the same secret")
+			}
+
+			// 去掉一个分片后不足阈值
+			if _, err := Combine(picked[1:], []byte(VECTOR_PASSPHRASE)); err == nil {
+				t.Fatal("combined with too few shares")
+			}
+		})
+	}
+}
+
+func TestSplitRejectsInvalidParameters(t *testing.T) {
+	secret, _ := hex.DecodeString("bb54aac4b89dc868ba37d9cc21b2cece")
+	tests := []struct {
+		name           string
+		secret         []byte
+		passphrase     string
+		groupThreshold int
+		groups         []Group
+	}{
+		{"short secret", secret[:14], "", 1, []Group{{1, 1}}},
+		{"odd length", append(secret, 0), "", 1, []Group{{1, 1}}},
+		{"group threshold above count", secret, "", 2, []Group{{1, 1}}},
+		{"threshold 1 with several members", secret, "", 1, []Group{{1, 3}}},
+		{"non-ascii passphrase", secret, "密码", 1, []Group{{1, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Split(tt.secret, []byte(tt.passphrase), tt.groupThreshold, tt.groups, 0); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
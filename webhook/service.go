@@ -0,0 +1,348 @@
+// Package webhook 商户回调：订阅充值、提现事件，签名后 POST 到商户地址，失败按指数退避重试并保留投递记录。
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/outbox"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	SIGNATURE_HEADER = "X-Webhook-Signature" // sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+	TIMESTAMP_HEADER = "X-Webhook-Timestamp"
+	EVENT_HEADER     = "X-Webhook-Event"
+	ID_HEADER        = "X-Webhook-Id"
+
+	MAX_ATTEMPTS    = 12
+	MIN_BACKOFF     = 30 * time.Second
+	MAX_BACKOFF     = 6 * time.Hour
+	DELIVERY_BATCH  = 100
+	REQUEST_TIMEOUT = 10 * time.Second
+)
+
+// EVENTS 可订阅的事件
+var EVENTS = []string{
+	outbox.TOPIC_DEPOSIT_SEEN,
+	outbox.TOPIC_DEPOSIT_CONFIRMED,
+	outbox.TOPIC_DEPOSIT_CREDITED,
+	outbox.TOPIC_WITHDRAWAL_STATUS_CHANGED,
+}
+
+var (
+	ErrSubscriptionNotFound = errors.New("回调订阅不存在")
+	ErrDeliveryNotFound     = errors.New("回调投递记录不存在")
+	ErrInvalidURL           = errors.New("回调地址必须是 https URL")
+	ErrInvalidEvent         = errors.New("不支持的回调事件")
+)
+
+type Service struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, client: &http.Client{Timeout: REQUEST_TIMEOUT}}
+}
+
+// ==========================
+// 订阅
+// ==========================
+
+func validEvent(e string) bool {
+	if e == "*" {
+		return true
+	}
+	for _, known := range EVENTS {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe 创建订阅并生成签名密钥，密钥只在此处返回一次
+func (s *Service) Subscribe(ctx context.Context, userID uint64, rawURL string, events []string) (*model.WebhookSubscription, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, "", ErrInvalidURL
+	}
+	if len(events) == 0 {
+		events = []string{"*"}
+	}
+	for _, e := range events {
+		if !validEvent(e) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidEvent, e)
+		}
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	sub := model.WebhookSubscription{
+		UserID: userID,
+		URL:    rawURL,
+		Secret: hex.EncodeToString(buf),
+		Events: strings.Join(events, ","),
+		Active: true,
+	}
+	if err := s.db.WithContext(ctx).Create(&sub).Error; err != nil {
+		return nil, "", err
+	}
+	return &sub, sub.Secret, nil
+}
+
+func (s *Service) List(ctx context.Context, userID uint64) ([]model.WebhookSubscription, error) {
+	var list []model.WebhookSubscription
+	return list, s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&list).Error
+}
+
+func (s *Service) Delete(ctx context.Context, userID uint64, id uint) error {
+	res := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&model.WebhookSubscription{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func subscribed(sub model.WebhookSubscription, event string) bool {
+	for _, e := range strings.Split(sub.Events, ",") {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ==========================
+// 事件 -> 投递
+// ==========================
+
+// eventUser 事件所属用户；无主充值等不属于任何用户的事件返回 false
+func eventUser(msg outbox.Message) (uint64, bool, error) {
+	switch msg.Topic {
+	case outbox.TOPIC_DEPOSIT_SEEN, outbox.TOPIC_DEPOSIT_CONFIRMED, outbox.TOPIC_DEPOSIT_CREDITED:
+		var ev outbox.DepositEvent
+		if err := json.Unmarshal(msg.Payload, &ev); err != nil {
+			return 0, false, err
+		}
+		if ev.UserID == nil {
+			return 0, false, nil
+		}
+		return uint64(*ev.UserID), true, nil
+	case outbox.TOPIC_WITHDRAWAL_STATUS_CHANGED:
+		var ev outbox.WithdrawalStatusChanged
+		if err := json.Unmarshal(msg.Payload, &ev); err != nil {
+			return 0, false, err
+		}
+		return uint64(ev.UserID), true, nil
+	}
+	return 0, false, nil
+}
+
+// HandleEvent 作为 outbox 订阅者，为匹配的订阅生成投递记录；重复投递的事件按 (订阅, 事件 ID) 去重
+func (s *Service) HandleEvent(ctx context.Context, msg outbox.Message) error {
+	userID, ok, err := eventUser(msg)
+	if err != nil || !ok {
+		return err
+	}
+	var subs []model.WebhookSubscription
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND active = ?", userID, true).Find(&subs).Error; err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"id":        msg.ID,
+		"event":     msg.Topic,
+		"createdAt": msg.CreatedAt,
+		"data":      msg.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if !subscribed(sub, msg.Topic) {
+			continue
+		}
+		if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        msg.ID,
+			Event:          msg.Topic,
+			Payload:        body,
+			Status:         model.WEBHOOK_DELIVERY_PENDING,
+			NextAttemptAt:  time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ==========================
+// 投递
+// ==========================
+
+// Sign 回调签名，商户用同样的方式校验
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func backoff(attempts int) time.Duration {
+	d := MIN_BACKOFF
+	for i := 1; i < attempts && d < MAX_BACKOFF; i++ {
+		d *= 2
+	}
+	if d > MAX_BACKOFF {
+		d = MAX_BACKOFF
+	}
+	return d
+}
+
+func (s *Service) post(ctx context.Context, sub model.WebhookSubscription, d model.WebhookDelivery) (int, error) {
+	ts := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ID_HEADER, d.EventID)
+	req.Header.Set(EVENT_HEADER, d.Event)
+	req.Header.Set(TIMESTAMP_HEADER, strconv.FormatInt(ts, 10))
+	req.Header.Set(SIGNATURE_HEADER, Sign(sub.Secret, ts, d.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// DeliverOnce 投递一批到期的回调
+func (s *Service) DeliverOnce(ctx context.Context) error {
+	var list []model.WebhookDelivery
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.WEBHOOK_DELIVERY_PENDING, time.Now()).
+		Order("id").Limit(DELIVERY_BATCH).Find(&list).Error; err != nil {
+		return err
+	}
+	for _, d := range list {
+		var sub model.WebhookSubscription
+		if err := s.db.WithContext(ctx).First(&sub, d.SubscriptionID).Error; err != nil || !sub.Active {
+			s.db.WithContext(ctx).Model(&d).Updates(map[string]interface{}{
+				"status":     model.WEBHOOK_DELIVERY_FAILED,
+				"last_error": "subscription removed or inactive",
+			})
+			continue
+		}
+		code, err := s.post(ctx, sub, d)
+		attempts := d.Attempts + 1
+		updates := map[string]interface{}{"attempts": attempts, "response_code": code}
+		switch {
+		case err == nil:
+			now := time.Now()
+			updates["status"] = model.WEBHOOK_DELIVERY_SUCCEEDED
+			updates["delivered_at"] = &now
+			updates["last_error"] = ""
+		case attempts >= MAX_ATTEMPTS:
+			updates["status"] = model.WEBHOOK_DELIVERY_FAILED
+			updates["last_error"] = err.Error()
+		default:
+			updates["next_attempt_at"] = time.Now().Add(backoff(attempts))
+			updates["last_error"] = err.Error()
+		}
+		if err != nil {
+			log.Printf("webhook delivery #%d to %s attempt %d err: %v", d.ID, sub.URL, attempts, err)
+		}
+		if err := s.db.WithContext(ctx).Model(&d).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deliveries 订阅的投递记录
+func (s *Service) Deliveries(ctx context.Context, userID uint64, subscriptionID uint, page, size int) ([]model.WebhookDelivery, int64, error) {
+	var sub model.WebhookSubscription
+	if err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", subscriptionID, userID).First(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrSubscriptionNotFound
+		}
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	var list []model.WebhookDelivery
+	var total int64
+	q := s.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("subscription_id = ?", sub.ID)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := q.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&list).Error
+	return list, total, err
+}
+
+// Redeliver 手动重新投递，重置重试次数
+func (s *Service) Redeliver(ctx context.Context, userID uint64, deliveryID uint) error {
+	res := s.db.WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("id = ? AND subscription_id IN (?)", deliveryID,
+			s.db.Model(&model.WebhookSubscription{}).Select("id").Where("user_id = ?", userID)).
+		Updates(map[string]interface{}{
+			"status":          model.WEBHOOK_DELIVERY_PENDING,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// Run 定期投递
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.DeliverOnce(ctx); err != nil {
+			log.Printf("webhook deliver err: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
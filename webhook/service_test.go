@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/crypto_custody/model"
+	"github.com/crypto_custody/outbox"
+)
+
+// 商户按文档自行校验签名的方式
+func merchantVerify(secret, timestamp, signature string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	return hmac.Equal([]byte(signature), []byte("sha256="+hex.EncodeToString(mac.Sum(nil))))
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"depositId":1}`)
+	sig := Sign("secret", 1700000000, body)
+	if !merchantVerify("secret", "1700000000", sig, body) {
+		t.Fatalf("signature %s does not verify", sig)
+	}
+	tests := []struct {
+		name   string
+		secret string
+		ts     int64
+		body   []byte
+	}{
+		{"secret", "other", 1700000000, body},
+		{"timestamp", "secret", 1700000001, body},
+		{"body", "secret", 1700000000, []byte(`{"depositId":2}`)},
+	}
+	for _, tt := range tests {
+		if Sign(tt.secret, tt.ts, tt.body) == sig {
+			t.Fatalf("changing %s kept the same signature", tt.name)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, MIN_BACKOFF},
+		{1, MIN_BACKOFF},
+		{2, 2 * MIN_BACKOFF},
+		{5, 16 * MIN_BACKOFF},
+		{MAX_ATTEMPTS, MAX_BACKOFF},
+		{100, MAX_BACKOFF},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Fatalf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribed(t *testing.T) {
+	tests := []struct {
+		events string
+		event  string
+		want   bool
+	}{
+		{"*", outbox.TOPIC_DEPOSIT_SEEN, true},
+		{outbox.TOPIC_DEPOSIT_CREDITED, outbox.TOPIC_DEPOSIT_CREDITED, true},
+		{outbox.TOPIC_DEPOSIT_SEEN + "," + outbox.TOPIC_WITHDRAWAL_STATUS_CHANGED, outbox.TOPIC_WITHDRAWAL_STATUS_CHANGED, true},
+		{outbox.TOPIC_DEPOSIT_SEEN, outbox.TOPIC_DEPOSIT_CREDITED, false},
+	}
+	for _, tt := range tests {
+		if got := subscribed(model.WebhookSubscription{Events: tt.events}, tt.event); got != tt.want {
+			t.Fatalf("subscribed(%q, %q) = %v, want %v", tt.events, tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestEventUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     outbox.Message
+		userID  uint64
+		ok      bool
+		wantErr bool
+	}{
+		{"deposit", outbox.Message{Topic: outbox.TOPIC_DEPOSIT_CREDITED, Payload: []byte(`{"userId":42}`)}, 42, true, false},
+		// 无主充值不推送
+		{"unowned deposit", outbox.Message{Topic: outbox.TOPIC_DEPOSIT_SEEN, Payload: []byte(`{"userId":null}`)}, 0, false, false},
+		{"withdrawal", outbox.Message{Topic: outbox.TOPIC_WITHDRAWAL_STATUS_CHANGED, Payload: []byte(`{"userId":7}`)}, 7, true, false},
+		{"unknown topic", outbox.Message{Topic: "other", Payload: []byte(`{"userId":7}`)}, 0, false, false},
+		{"bad payload", outbox.Message{Topic: outbox.TOPIC_DEPOSIT_CONFIRMED, Payload: []byte(`[`)}, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, ok, err := eventUser(tt.msg)
+			if (err != nil) != tt.wantErr || ok != tt.ok || userID != tt.userID {
+				t.Fatalf("eventUser = (%d, %v, %v), want (%d, %v, err=%v)", userID, ok, err, tt.userID, tt.ok, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPost(t *testing.T) {
+	const secret = "s3cret"
+	payload := []byte(`{"withdrawalId":3,"status":"broadcasted"}`)
+	status := http.StatusOK
+	var gotErr string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts := r.Header.Get(TIMESTAMP_HEADER)
+		if _, err := strconv.ParseInt(ts, 10, 64); err != nil {
+			gotErr = "bad timestamp header"
+		} else if !merchantVerify(secret, ts, r.Header.Get(SIGNATURE_HEADER), body) {
+			gotErr = "signature does not verify"
+		} else if r.Header.Get(ID_HEADER) != "evt-1" || r.Header.Get(EVENT_HEADER) != outbox.TOPIC_WITHDRAWAL_STATUS_CHANGED {
+			gotErr = "missing event headers"
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	s := &Service{client: srv.Client()}
+	sub := model.WebhookSubscription{URL: srv.URL, Secret: secret}
+	d := model.WebhookDelivery{EventID: "evt-1", Event: outbox.TOPIC_WITHDRAWAL_STATUS_CHANGED, Payload: payload}
+	code, err := s.post(context.Background(), sub, d)
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("post = (%d, %v)", code, err)
+	}
+	if gotErr != "" {
+		t.Fatal(gotErr)
+	}
+
+	// 非 2xx 视为失败，保留状态码
+	status = http.StatusInternalServerError
+	if code, err := s.post(context.Background(), sub, d); err == nil || code != http.StatusInternalServerError {
+		t.Fatalf("post = (%d, %v), want failure with 500", code, err)
+	}
+}